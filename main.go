@@ -1,18 +1,27 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/ProtocolONE/rbac"
 	"github.com/casbin/redis-adapter"
 	"github.com/kelseyhightower/envconfig"
 	"go.uber.org/zap"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"qilin-api/pkg/api"
 	"qilin-api/pkg/conf"
 	"qilin-api/pkg/orm"
 	"qilin-api/pkg/sys"
+	"syscall"
+	"time"
 )
 
+// shutdownTimeout limits waiting for requests in progress on shutdown
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	logger, _ := zap.NewProduction()
 	zap.ReplaceGlobals(logger)
@@ -45,6 +54,14 @@ func main() {
 		}
 	}()
 
+	// Server is started by default, `resync` command publishes catalog to event bus and exits
+	if len(os.Args) > 1 && os.Args[1] == "resync" {
+		if err := runResync(db, os.Args[2:]); err != nil {
+			logger.Fatal("Failed to resync catalog", zap.Error(err))
+		}
+		return
+	}
+
 	mailer := sys.NewMailer(config.Mailer)
 
 	notifier, err := sys.NewNotifier(config.Notifier.ApiKey, config.Notifier.Host)
@@ -67,8 +84,12 @@ func main() {
 		Notifier:         notifier,
 		CentrifugoSecret: config.Notifier.Secret,
 		Enforcer:         enf,
+		RoleSyncInterval: config.Enforcer.SyncInterval,
 		EventBus:         &config.EventBus,
 		Imaginary:        &config.Imaginary,
+		KeyBatch:         &config.KeyBatch,
+		PreOrder:         &config.PreOrder,
+		License:          &config.License,
 	}
 
 	server, err := api.NewServer(&serverOptions)
@@ -76,9 +97,26 @@ func main() {
 		logger.Fatal("Failed to create server", zap.Error(err))
 	}
 
+	// Server is stopped on signal, running resyncs are stopped and may be resumed later
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Error("Failed to shutdown server", zap.Error(err))
+		}
+	}()
+
 	logger.Info("Starting up server")
 	err = server.Start()
-	if err != nil {
+	if err != http.ErrServerClosed {
 		logger.Fatal("Failed to start server", zap.Error(err))
 	}
+	<-stopped
 }
@@ -99,6 +99,8 @@ func (suite *AccessRightsTestSuite) TearDownTest() {
 func (s *AccessRightsTestSuite) InitRoutes() error {
 	s.T().Helper()
 
+	routes := rbac_echo.NewRoutes(s.echo)
+
 	userService, err := orm.NewUserService(s.db, nil)
 	if err != nil {
 		return err
@@ -108,7 +110,7 @@ func (s *AccessRightsTestSuite) InitRoutes() error {
 	if err != nil {
 		return err
 	}
-	if _, err := InitMediaRouter(s.Router, mediaService); err != nil {
+	if _, err := InitMediaRouter(s.Router, routes, mediaService); err != nil {
 		return err
 	}
 
@@ -116,7 +118,7 @@ func (s *AccessRightsTestSuite) InitRoutes() error {
 	if err != nil {
 		return err
 	}
-	if _, err := InitRatingsRouter(s.Router, ratingService); err != nil {
+	if _, err := InitRatingsRouter(s.Router, routes, ratingService); err != nil {
 		return err
 	}
 
@@ -124,7 +126,7 @@ func (s *AccessRightsTestSuite) InitRoutes() error {
 	if err != nil {
 		return err
 	}
-	if _, err := InitDiscountsRouter(s.Router, discountService); err != nil {
+	if _, err := InitDiscountsRouter(s.Router, routes, discountService); err != nil {
 		return err
 	}
 
@@ -137,7 +139,7 @@ func (s *AccessRightsTestSuite) InitRoutes() error {
 		return err
 	}
 
-	if _, err := InitClientOnboardingRouter(s.Router, clientOnboarding, notificationServ); err != nil {
+	if _, err := InitClientOnboardingRouter(s.Router, routes, clientOnboarding, notificationServ); err != nil {
 		return err
 	}
 
@@ -147,7 +149,7 @@ func (s *AccessRightsTestSuite) InitRoutes() error {
 	}
 
 	priceService := orm.NewPriceService(s.db)
-	if _, err := InitPriceRouter(s.Router, priceService, gameService); err != nil {
+	if _, err := InitPriceRouter(s.Router, routes, priceService, gameService); err != nil {
 		return err
 	}
 
@@ -176,28 +178,28 @@ func (s *AccessRightsTestSuite) InitRoutes() error {
 		return err
 	}
 
-	if _, err := InitClientMembershipRouter(s.Router, membershipService); err != nil {
+	if _, err := InitClientMembershipRouter(s.Router, routes, membershipService); err != nil {
 		return err
 	}
 
-	if _, err := InitGameRoutes(s.Router, gameService, userService, mock.NewEventBus()); err != nil {
+	if _, err := InitGameRoutes(s.Router, routes, gameService, userService, mock.NewEventBus()); err != nil {
 		return err
 	}
 
-	if _, err := InitPackageRouter(s.Router, packageService, productService, priceService); err != nil {
+	if _, err := InitPackageRouter(s.Router, routes, packageService, productService, priceService); err != nil {
 		return err
 	}
 
-	if _, err := InitBundleRouter(s.Router, bundleService); err != nil {
+	if _, err := InitBundleRouter(s.Router, routes, bundleService); err != nil {
 		return err
 	}
 
-	if err := InitVendorRoutes(s.Router, vendorService, userService); err != nil {
+	if err := InitVendorRoutes(s.Router, routes, vendorService, userService); err != nil {
 		return err
 	}
 
 	adminService, err := orm.NewAdminOnboardingService(s.db, mock.NewMembershipService(), orm.NewOwnerProvider(s.db))
-	if _, err := InitAdminOnboardingRouter(s.AdminRouter, adminService, nil); err != nil {
+	if _, err := InitAdminOnboardingRouter(s.AdminRouter, routes, adminService, nil); err != nil {
 		return err
 	}
 
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/model/utils"
+	"qilin-api/pkg/orm"
+	"time"
+)
+
+type (
+	AchievementDTO struct {
+		ID           string                `json:"id"`
+		Name         utils.LocalizedString `json:"name" validate:"required"`
+		Description  utils.LocalizedString `json:"description"`
+		LockedIcon   string                `json:"lockedIcon" validate:"omitempty,url"`
+		UnlockedIcon string                `json:"unlockedIcon" validate:"omitempty,url"`
+		Hidden       bool                  `json:"hidden"`
+		Order        int                   `json:"order"`
+		Points       int                   `json:"points" validate:"min=0"`
+		Created      string                `json:"created"`
+		Updated      string                `json:"updated"`
+	}
+
+	AchievementRouter struct {
+		service  model.AchievementService
+		eventBus model.EventBus
+	}
+)
+
+func InitAchievementRouter(group *echo.Group, routes *rbac_echo.Routes, service model.AchievementService, eventBus model.EventBus) (*AchievementRouter, error) {
+	if service == nil {
+		return nil, errors.New("Achievement service must be provided")
+	}
+
+	router := AchievementRouter{
+		service:  service,
+		eventBus: eventBus,
+	}
+
+	r := routes.Group(group, "/games/:gameId", &router, []string{"gameId", model.GameType, model.VendorDomain})
+	r.GET("/achievements", router.getList, nil)
+	r.POST("/achievements", router.create, nil)
+	r.GET("/achievements/:achievementId", router.get, nil)
+	r.PUT("/achievements/:achievementId", router.update, nil)
+	r.DELETE("/achievements/:achievementId", router.delete, nil)
+
+	return &router, nil
+}
+
+func (router *AchievementRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return GetOwnerForGame(ctx)
+}
+
+func (router *AchievementRouter) getList(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	achievements, err := router.service.GetList(gameId)
+	if err != nil {
+		return err
+	}
+
+	result := make([]AchievementDTO, 0, len(achievements))
+	for i := range achievements {
+		result = append(result, mapAchievementDTO(&achievements[i]))
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *AchievementRouter) get(ctx echo.Context) error {
+	gameId, achievementId, err := getAchievementParams(ctx)
+	if err != nil {
+		return err
+	}
+
+	achievement, err := router.service.Get(gameId, achievementId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapAchievementDTO(achievement))
+}
+
+func (router *AchievementRouter) create(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	dto, err := bindAchievementDTO(ctx)
+	if err != nil {
+		return err
+	}
+
+	achievement, err := router.service.Create(gameId, mapAchievement(dto))
+	if err != nil {
+		return err
+	}
+
+	if err := router.publish(gameId); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, mapAchievementDTO(achievement))
+}
+
+func (router *AchievementRouter) update(ctx echo.Context) error {
+	gameId, achievementId, err := getAchievementParams(ctx)
+	if err != nil {
+		return err
+	}
+
+	dto, err := bindAchievementDTO(ctx)
+	if err != nil {
+		return err
+	}
+
+	achievement := mapAchievement(dto)
+	achievement.ID = achievementId
+	achievement, err = router.service.Update(gameId, achievement)
+	if err != nil {
+		return err
+	}
+
+	if err := router.publish(gameId); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapAchievementDTO(achievement))
+}
+
+func (router *AchievementRouter) delete(ctx echo.Context) error {
+	gameId, achievementId, err := getAchievementParams(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := router.service.Delete(gameId, achievementId); err != nil {
+		return err
+	}
+
+	if err := router.publish(gameId); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+// publish sends achievements to production, they are exposed only if `achievementOnProd` flag of game is set
+func (router *AchievementRouter) publish(gameId uuid.UUID) error {
+	if err := router.eventBus.PublishAchievementsChanges(gameId); err != nil {
+		return orm.NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Can't publish achievements changes"))
+	}
+	return nil
+}
+
+func getAchievementParams(ctx echo.Context) (gameId uuid.UUID, achievementId uuid.UUID, err error) {
+	gameId, err = uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return gameId, achievementId, orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	achievementId, err = uuid.FromString(ctx.Param("achievementId"))
+	if err != nil {
+		return gameId, achievementId, orm.NewServiceError(http.StatusBadRequest, "Invalid achievement Id")
+	}
+
+	return gameId, achievementId, nil
+}
+
+func bindAchievementDTO(ctx echo.Context) (*AchievementDTO, error) {
+	dto := &AchievementDTO{}
+	if err := ctx.Bind(dto); err != nil {
+		return nil, orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	if errs := ctx.Validate(dto); errs != nil {
+		return nil, orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	return dto, nil
+}
+
+func mapAchievement(dto *AchievementDTO) *model.Achievement {
+	return &model.Achievement{
+		Name:         dto.Name,
+		Description:  dto.Description,
+		LockedIcon:   dto.LockedIcon,
+		UnlockedIcon: dto.UnlockedIcon,
+		Hidden:       dto.Hidden,
+		Order:        dto.Order,
+		Points:       dto.Points,
+	}
+}
+
+func mapAchievementDTO(achievement *model.Achievement) AchievementDTO {
+	return AchievementDTO{
+		ID:           achievement.ID.String(),
+		Name:         achievement.Name,
+		Description:  achievement.Description,
+		LockedIcon:   achievement.LockedIcon,
+		UnlockedIcon: achievement.UnlockedIcon,
+		Hidden:       achievement.Hidden,
+		Order:        achievement.Order,
+		Points:       achievement.Points,
+		Created:      achievement.CreatedAt.Format(time.RFC3339),
+		Updated:      achievement.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"strconv"
+)
+
+type (
+	AdminGameReviewRouter struct {
+		service      model.GameReviewService
+		publications model.PublicationService
+	}
+
+	reviewCommentRequest struct {
+		Field   string `json:"field" validate:"required"`
+		Message string `json:"message" validate:"required"`
+	}
+
+	// gameReviewDetailsDTO contains submitted data and its changes relative to the latest published version
+	gameReviewDetailsDTO struct {
+		gameReviewDTO
+		Game     storeGameDTO           `json:"game"`
+		Packages model.SnapshotPackages `json:"packages"`
+		Changes  []snapshotChangeDTO    `json:"changes"`
+	}
+)
+
+// InitAdminGameReviewRouter is initialization method for review of games by admins
+func InitAdminGameReviewRouter(adminGroup *echo.Group, routes *rbac_echo.Routes, service model.GameReviewService, publications model.PublicationService) (*AdminGameReviewRouter, error) {
+	router := AdminGameReviewRouter{service: service, publications: publications}
+
+	r := routes.Group(adminGroup, "/games", &router, []string{"*", model.AdminGameReviewsType, model.VendorDomain})
+	r.GET("/reviews", router.getQueue, nil)
+	r.GET("/:gameId/review", router.get, nil)
+	r.POST("/:gameId/review/comments", router.addComment, nil)
+	r.PUT("/:gameId/review/status", router.changeStatus, nil)
+
+	return &router, nil
+}
+
+func (router *AdminGameReviewRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return "*", nil
+}
+
+func (router *AdminGameReviewRouter) getQueue(ctx echo.Context) error {
+	offset := 0
+	limit := 20
+
+	if offsetParam := ctx.QueryParam("offset"); offsetParam != "" {
+		num, err := strconv.Atoi(offsetParam)
+		if err != nil {
+			return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad offset"))
+		}
+		offset = num
+	}
+
+	if limitParam := ctx.QueryParam("limit"); limitParam != "" {
+		num, err := strconv.Atoi(limitParam)
+		if err != nil {
+			return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad limit"))
+		}
+		limit = num
+	}
+
+	status, err := model.ReviewStatusFromString(ctx.QueryParam("status"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad status"))
+	}
+
+	reviews, count, err := router.service.GetQueue(status, offset, limit)
+	if err != nil {
+		return err
+	}
+
+	result := []gameReviewDTO{}
+	for i := range reviews {
+		result = append(result, mapGameReviewDto(&reviews[i]))
+	}
+
+	ctx.Response().Header().Add("X-Items-Count", fmt.Sprintf("%d", count))
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *AdminGameReviewRouter) get(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	review, err := router.service.Get(gameId)
+	if err != nil {
+		return err
+	}
+
+	// Game without publications is compared with empty snapshot, so all fields are new
+	published, err := router.publications.Get(gameId, 0)
+	if serviceErr, ok := err.(*orm.ServiceError); ok && serviceErr.Code == http.StatusNotFound {
+		published = &model.GameSnapshot{}
+	} else if err != nil {
+		return err
+	}
+
+	changes, err := model.DiffSnapshots(published, review.GetSnapshot())
+	if err != nil {
+		return orm.NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Compare game with published version"))
+	}
+
+	result := gameReviewDetailsDTO{
+		gameReviewDTO: mapGameReviewDto(review),
+		Game:          mapStoreGameDto(&review.Game, review.CreatedAt),
+		Packages:      review.Packages,
+		Changes:       []snapshotChangeDTO{},
+	}
+	for _, change := range changes {
+		result.Changes = append(result.Changes, snapshotChangeDTO{Path: change.Path, Old: change.Old, New: change.New})
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *AdminGameReviewRouter) addComment(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	request := new(reviewCommentRequest)
+	if err := ctx.Bind(request); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	if errs := ctx.Validate(request); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	review, err := router.service.AddComment(userId, gameId, request.Field, request.Message)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapGameReviewDto(review))
+}
+
+func (router *AdminGameReviewRouter) changeStatus(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	request := new(ChangeStatusRequest)
+	if err := ctx.Bind(request); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	if errs := ctx.Validate(request); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	status, err := model.ReviewStatusFromString(request.Status)
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad status"))
+	}
+
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	review, err := router.service.ChangeStatus(userId, gameId, status, request.Message)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapGameReviewDto(review))
+}
@@ -56,7 +56,7 @@ type ShortDocumentsInfoDTO struct {
 	Status    string `json:"status"`
 }
 
-func InitAdminOnboardingRouter(group *echo.Group, service *orm.AdminOnboardingService, notificationService model.NotificationService) (*OnboardingAdminRouter, error) {
+func InitAdminOnboardingRouter(group *echo.Group, routes *rbac_echo.Routes, service *orm.AdminOnboardingService, notificationService model.NotificationService) (*OnboardingAdminRouter, error) {
 	router := OnboardingAdminRouter{
 		service:             service,
 		notificationService: notificationService,
@@ -64,7 +64,7 @@ func InitAdminOnboardingRouter(group *echo.Group, service *orm.AdminOnboardingSe
 
 	common := []string{"*", model.AdminDocumentsType, model.VendorDomain}
 
-	r := rbac_echo.Group(group, "/vendors", &router, common)
+	r := routes.Group(group, "/vendors", &router, common)
 	r.GET("/reviews", router.getReviews, nil)
 	r.GET("/:vendorId/documents", router.getDocument, nil)
 	r.PUT("/:vendorId/documents/status", router.changeStatus, nil)
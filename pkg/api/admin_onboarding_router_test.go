@@ -13,6 +13,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"qilin-api/pkg/api/mock"
+	"qilin-api/pkg/api/rbac_echo"
 	"qilin-api/pkg/model"
 	bto "qilin-api/pkg/model/game"
 	"qilin-api/pkg/orm"
@@ -74,7 +75,7 @@ func (suite *OnboardingAdminRouterTestSuite) SetupTest() {
 	should.Nil(err)
 	notService, err := orm.NewNotificationService(db, notifier, config.Notifier.Secret)
 	should.Nil(err)
-	router, err := InitAdminOnboardingRouter(e.Group("/api/v1"), service, notService)
+	router, err := InitAdminOnboardingRouter(e.Group("/api/v1"), rbac_echo.NewRoutes(e), service, notService)
 	should.Nil(err)
 	v := validator.New()
 	assert.NoError(suite.T(), utils.RegisterCustomValidations(v))
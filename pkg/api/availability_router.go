@@ -0,0 +1,81 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"strings"
+)
+
+type (
+	AvailabilityRouter struct {
+		service model.AvailabilityService
+	}
+
+	availabilityDTO struct {
+		ID           uuid.UUID   `json:"id"`
+		Country      string      `json:"country"`
+		Available    bool        `json:"available"`
+		RestrictedBy []uuid.UUID `json:"restrictedBy"`
+	}
+)
+
+// InitAvailabilityRouter is initialization method for regional availability of packages and bundles
+func InitAvailabilityRouter(group *echo.Group, routes *rbac_echo.Routes, service model.AvailabilityService) (*AvailabilityRouter, error) {
+	router := AvailabilityRouter{service: service}
+
+	packageGroup := routes.Group(group, "/packages", &router, []string{"packageId", model.PackageType, model.VendorDomain})
+	packageGroup.GET("/:packageId/availability", router.getPackageAvailability, nil)
+
+	bundleGroup := routes.Group(group, "/bundles", &router, []string{"bundleId", model.RoleBundle, model.VendorDomain})
+	bundleGroup.GET("/:bundleId/availability", router.getBundleAvailability, nil)
+
+	return &router, nil
+}
+
+func (router *AvailabilityRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	if strings.Contains(ctx.Path(), "/bundles/:bundleId") {
+		return GetOwnerForBundle(ctx)
+	}
+	return GetOwnerForPackage(ctx)
+}
+
+func (router *AvailabilityRouter) getPackageAvailability(ctx echo.Context) error {
+	packageId, err := uuid.FromString(ctx.Param("packageId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	availability, err := router.service.GetPackageAvailability(packageId, strings.ToUpper(ctx.QueryParam("country")))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapAvailabilityDto(availability))
+}
+
+func (router *AvailabilityRouter) getBundleAvailability(ctx echo.Context) error {
+	bundleId, err := uuid.FromString(ctx.Param("bundleId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	availability, err := router.service.GetBundleAvailability(bundleId, strings.ToUpper(ctx.QueryParam("country")))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapAvailabilityDto(availability))
+}
+
+func mapAvailabilityDto(availability *model.Availability) availabilityDTO {
+	return availabilityDTO{
+		ID:           availability.ID,
+		Country:      availability.Country,
+		Available:    availability.Available,
+		RestrictedBy: availability.RestrictedBy,
+	}
+}
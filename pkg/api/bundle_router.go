@@ -34,6 +34,7 @@ type (
 
 	bundleRegionalRestrinctionsDTO struct {
 		AllowedCountries []string `json:"allowedCountries"`
+		DeniedCountries  []string `json:"deniedCountries"`
 	}
 
 	storeBundleDTO struct {
@@ -58,6 +59,42 @@ type (
 		Discount         uint                  `json:"discount"`
 		Price            priceDTO              `json:"price"`
 	}
+
+	lootboxItemDTO struct {
+		PackageID uuid.UUID `json:"packageId" validate:"required"`
+		Weight    uint      `json:"weight" validate:"required,min=1"`
+		Rarity    string    `json:"rarity"`
+		// Probability is disclosed chance to get the package, read only
+		Probability float64     `json:"probability"`
+		Package     *packageDTO `json:"package,omitempty" validate:"-"`
+	}
+
+	createLootboxDTO struct {
+		Name     string           `json:"name" validate:"required"`
+		Currency string           `json:"currency" validate:"required"`
+		Items    []lootboxItemDTO `json:"items" validate:"required,dive"`
+	}
+
+	lootboxDTO struct {
+		ID                    uuid.UUID                      `json:"id"`
+		CreatedAt             time.Time                      `json:"createdAt"`
+		Sku                   string                         `json:"sku" validate:"required"`
+		Name                  utils.LocalizedString          `json:"name" validate:"dive,required"`
+		IsEnabled             bool                           `json:"isEnabled"`
+		Price                 priceDTO                       `json:"price"`
+		RegionalRestrinctions bundleRegionalRestrinctionsDTO `json:"regionalRestrinctions" validate:"required,dive"`
+		Items                 []lootboxItemDTO               `json:"items" validate:"required,dive"`
+	}
+
+	lootboxItemListDTO struct {
+		ID        uuid.UUID             `json:"id"`
+		CreatedAt time.Time             `json:"createdAt"`
+		Sku       string                `json:"sku"`
+		Name      utils.LocalizedString `json:"name"`
+		IsEnabled bool                  `json:"isEnabled"`
+		Price     priceDTO              `json:"price"`
+		Items     int                   `json:"items"`
+	}
 )
 
 func mapStoreBundleDto(bundle *model.StoreBundle) (dto *storeBundleDTO, err error) {
@@ -74,6 +111,7 @@ func mapStoreBundleDto(bundle *model.StoreBundle) (dto *storeBundleDTO, err erro
 		},
 		RegionalRestrinctions: bundleRegionalRestrinctionsDTO{
 			AllowedCountries: bundle.AllowedCountries,
+			DeniedCountries:  countriesOrEmpty(bundle.DeniedCountries),
 		},
 	}
 	for _, p := range bundle.Packages {
@@ -105,7 +143,8 @@ func mapStoreBundleItemDto(bundle *model.StoreBundle) (*storeBundleItemDTO, erro
 
 func mapStoreBundleModel(dto *storeBundleDTO) (bundle *model.StoreBundle, err error) {
 
-	if !pkg_utils.ValidateCountryList(dto.RegionalRestrinctions.AllowedCountries) {
+	if !pkg_utils.ValidateCountryList(dto.RegionalRestrinctions.AllowedCountries) ||
+		!pkg_utils.ValidateCountryList(dto.RegionalRestrinctions.DeniedCountries) {
 		return nil, orm.NewServiceError(http.StatusUnprocessableEntity, "Invalid countries")
 	}
 
@@ -118,19 +157,101 @@ func mapStoreBundleModel(dto *storeBundleDTO) (bundle *model.StoreBundle, err er
 		Discount:         dto.DiscountPolicy.Discount,
 		DiscountBuyOpt:   model.NewBuyOption(dto.DiscountPolicy.BuyOption),
 		AllowedCountries: dto.RegionalRestrinctions.AllowedCountries,
+		DeniedCountries:  countriesOrEmpty(dto.RegionalRestrinctions.DeniedCountries),
+	}, nil
+}
+
+func mapLootboxDto(bundle *model.LootboxBundle) (dto *lootboxDTO, err error) {
+	dto = &lootboxDTO{
+		ID:        bundle.ID,
+		CreatedAt: bundle.CreatedAt,
+		Sku:       bundle.Sku,
+		Name:      bundle.Name,
+		IsEnabled: bundle.IsEnabled,
+		Price:     priceDTO{bundle.Currency, bundle.Price},
+		RegionalRestrinctions: bundleRegionalRestrinctionsDTO{
+			AllowedCountries: bundle.AllowedCountries,
+			DeniedCountries:  countriesOrEmpty(bundle.DeniedCountries),
+		},
+		Items: []lootboxItemDTO{},
+	}
+	drops := bundle.GetDrops()
+	for i, item := range bundle.Items {
+		itemDto := lootboxItemDTO{
+			PackageID:   item.PackageID,
+			Weight:      item.Weight,
+			Rarity:      item.Rarity,
+			Probability: drops[i].Probability,
+		}
+		if item.Package != nil {
+			itemDto.Package, err = mapPackageDto(item.Package)
+			if err != nil {
+				return nil, err
+			}
+		}
+		dto.Items = append(dto.Items, itemDto)
+	}
+	return dto, nil
+}
+
+func mapLootboxItemListDto(bundle *model.LootboxBundle) *lootboxItemListDTO {
+	return &lootboxItemListDTO{
+		ID:        bundle.ID,
+		CreatedAt: bundle.CreatedAt,
+		Sku:       bundle.Sku,
+		Name:      bundle.Name,
+		IsEnabled: bundle.IsEnabled,
+		Price:     priceDTO{bundle.Currency, bundle.Price},
+		Items:     len(bundle.Items),
+	}
+}
+
+func mapLootboxItems(items []lootboxItemDTO) []model.LootboxItem {
+	result := make([]model.LootboxItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, model.LootboxItem{
+			PackageID: item.PackageID,
+			Weight:    item.Weight,
+			Rarity:    item.Rarity,
+		})
+	}
+	return result
+}
+
+func mapLootboxModel(dto *lootboxDTO) (bundle *model.LootboxBundle, err error) {
+
+	if !pkg_utils.ValidateCountryList(dto.RegionalRestrinctions.AllowedCountries) ||
+		!pkg_utils.ValidateCountryList(dto.RegionalRestrinctions.DeniedCountries) {
+		return nil, orm.NewServiceError(http.StatusUnprocessableEntity, "Invalid countries")
+	}
+
+	return &model.LootboxBundle{
+		Model:            model.Model{ID: dto.ID},
+		Sku:              dto.Sku,
+		Name:             dto.Name,
+		IsEnabled:        dto.IsEnabled,
+		Price:            dto.Price.Price,
+		Currency:         dto.Price.Currency,
+		AllowedCountries: dto.RegionalRestrinctions.AllowedCountries,
+		DeniedCountries:  countriesOrEmpty(dto.RegionalRestrinctions.DeniedCountries),
+		Items:            mapLootboxItems(dto.Items),
 	}, nil
 }
 
-func InitBundleRouter(group *echo.Group, service model.BundleService) (router *BundleRouter, err error) {
+func InitBundleRouter(group *echo.Group, routes *rbac_echo.Routes, service model.BundleService) (router *BundleRouter, err error) {
 	router = &BundleRouter{service}
 
-	vendorRouter := rbac_echo.Group(group, "/vendors/:vendorId", router, []string{"*", model.RoleBundleList, model.VendorDomain})
+	vendorRouter := routes.Group(group, "/vendors/:vendorId", router, []string{"*", model.RoleBundleList, model.VendorDomain})
 	vendorRouter.POST("/bundles/store", router.CreateStore, nil)
 	vendorRouter.GET("/bundles/store", router.GetStoreList, nil)
+	vendorRouter.POST("/bundles/lootbox", router.CreateLootbox, nil)
+	vendorRouter.GET("/bundles/lootbox", router.GetLootboxList, nil)
 
-	bundleGroup := rbac_echo.Group(group, "/bundles", router, []string{"bundleId", model.RoleBundle, model.VendorDomain})
+	bundleGroup := routes.Group(group, "/bundles", router, []string{"bundleId", model.RoleBundle, model.VendorDomain})
 	bundleGroup.GET("/:bundleId/store", router.GetStore, nil)
 	bundleGroup.PUT("/:bundleId/store", router.UpdateStore, nil)
+	bundleGroup.GET("/:bundleId/lootbox", router.GetLootbox, nil)
+	bundleGroup.PUT("/:bundleId/lootbox", router.UpdateLootbox, nil)
 	bundleGroup.DELETE("/:bundleId", router.Delete, nil)
 	bundleGroup.POST("/:bundleId/packages", router.AddPackages, nil)
 	bundleGroup.DELETE("/:bundleId/packages", router.RemovePackages, nil)
@@ -213,7 +334,8 @@ func (router *BundleRouter) GetStoreList(ctx echo.Context) (err error) {
 	}
 	query := ctx.QueryParam("query")
 	sort := ctx.QueryParam("sort")
-	total, bundles, err := router.service.GetStoreList(userId, vendorId, query, sort, offset, limit, filterFunc)
+	country := strings.ToUpper(ctx.QueryParam("country"))
+	total, bundles, err := router.service.GetStoreList(userId, vendorId, query, sort, country, offset, limit, filterFunc)
 	if err != nil {
 		return err
 	}
@@ -295,6 +417,156 @@ func (router *BundleRouter) Delete(ctx echo.Context) (err error) {
 	return ctx.NoContent(http.StatusOK)
 }
 
+func (router *BundleRouter) CreateLootbox(ctx echo.Context) (err error) {
+	vendorId, err := uuid.FromString(ctx.Param("vendorId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid vendor Id")
+	}
+
+	params := createLootboxDTO{}
+	err = ctx.Bind(&params)
+	if err != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, "Wrong parameters in body")
+	}
+
+	if errs := ctx.Validate(params); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+	items := mapLootboxItems(params.Items)
+	qilinCtx := ctx.(rbac_echo.AppContext)
+	err = router.checkRBAC(userId, &qilinCtx, lootboxPackageIds(items))
+	if err != nil {
+		return err
+	}
+
+	bundle, err := router.service.CreateLootbox(vendorId, userId, params.Name, params.Currency, items)
+	if err != nil {
+		return err
+	}
+	dto, err := mapLootboxDto(bundle.(*model.LootboxBundle))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, dto)
+}
+
+func (router *BundleRouter) GetLootboxList(ctx echo.Context) (err error) {
+	vendorId, err := uuid.FromString(ctx.Param("vendorId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid vendor Id")
+	}
+	offset, err := strconv.Atoi(ctx.QueryParam("offset"))
+	if err != nil {
+		offset = 0
+	}
+	limit, err := strconv.Atoi(ctx.QueryParam("limit"))
+	if err != nil {
+		limit = 20
+	}
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+	qilinCtx := ctx.(rbac_echo.AppContext)
+	filterFunc := func(bundleId uuid.UUID) (grant bool, err error) {
+		owner, err := qilinCtx.GetOwnerForBundle(bundleId)
+		if err != nil {
+			return
+		}
+		if qilinCtx.CheckPermissions(userId, model.VendorDomain, model.RoleBundle, bundleId.String(), owner, "read") != nil {
+			return false, nil
+		}
+		return true, nil
+	}
+	country := strings.ToUpper(ctx.QueryParam("country"))
+	total, bundles, err := router.service.GetLootboxList(userId, vendorId, ctx.QueryParam("query"), ctx.QueryParam("sort"), country, offset, limit, filterFunc)
+	if err != nil {
+		return err
+	}
+	dto := []*lootboxItemListDTO{}
+	for _, bundle := range bundles {
+		dto = append(dto, mapLootboxItemListDto(bundle.(*model.LootboxBundle)))
+	}
+	ctx.Response().Header().Add("X-Items-Count", fmt.Sprintf("%d", total))
+	return ctx.JSON(http.StatusOK, dto)
+}
+
+func (router *BundleRouter) GetLootbox(ctx echo.Context) (err error) {
+	bundleId, err := uuid.FromString(ctx.Param("bundleId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid bundle Id")
+	}
+
+	bundle, err := router.service.Get(bundleId)
+	if err != nil {
+		return err
+	}
+
+	lootbox, ok := bundle.(*model.LootboxBundle)
+	if !ok {
+		return orm.NewServiceError(http.StatusBadRequest, "Bundle isn't lootbox")
+	}
+	dto, err := mapLootboxDto(lootbox)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dto)
+}
+
+func (router *BundleRouter) UpdateLootbox(ctx echo.Context) (err error) {
+	bundleId, err := uuid.FromString(ctx.Param("bundleId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid bundle Id")
+	}
+	lootboxDto := &lootboxDTO{}
+	err = ctx.Bind(lootboxDto)
+	if err != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errors.Wrap(err, "Wrong lootbox in body").Error())
+	}
+	if errs := ctx.Validate(lootboxDto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	lootbox, err := mapLootboxModel(lootboxDto)
+	if err != nil {
+		return err
+	}
+	lootbox.ID = bundleId
+
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+	qilinCtx := ctx.(rbac_echo.AppContext)
+	err = router.checkRBAC(userId, &qilinCtx, lootboxPackageIds(lootbox.Items))
+	if err != nil {
+		return err
+	}
+
+	result, err := router.service.UpdateLootbox(lootbox)
+	if err != nil {
+		return err
+	}
+	dto, err := mapLootboxDto(result.(*model.LootboxBundle))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dto)
+}
+
+func lootboxPackageIds(items []model.LootboxItem) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.PackageID)
+	}
+	return ids
+}
+
 func (router *BundleRouter) checkRBAC(userId string, qilinCtx *rbac_echo.AppContext, packagesIds []uuid.UUID) error {
 	for _, packageId := range packagesIds {
 		owner, err := qilinCtx.GetOwnerForPackage(packageId)
@@ -47,7 +47,8 @@ var (
     "buyOption": "whole"
   },
   "regionalRestrinctions": {
-    "allowedCountries": []
+    "allowedCountries": [],
+    "deniedCountries": []
   },
   "packages": [
     {
@@ -77,7 +78,8 @@ var (
         "buyOption": "whole"
       },
       "regionalRestrinctions": {
-        "allowedCountries": []
+        "allowedCountries": [],
+        "deniedCountries": []
       },
       "commercial": {
         "common": {
@@ -105,7 +107,8 @@ var (
     "buyOption": "part"
   },
   "regionalRestrinctions": {
-    "allowedCountries": []
+    "allowedCountries": [],
+    "deniedCountries": []
   },
   "packages": [
     {
@@ -135,7 +138,8 @@ var (
         "buyOption": "whole"
       },
       "regionalRestrinctions": {
-        "allowedCountries": []
+        "allowedCountries": [],
+        "deniedCountries": []
       },
       "commercial": {
         "common": {
@@ -324,7 +328,7 @@ func (suite *BundleRouterTestSuite) SetupTest() {
 	service, err := orm.NewBundleService(db, packageService, gameService)
 	require.Nil(suite.T(), err)
 
-	_, err = InitBundleRouter(echoObj.Group("/api/v1"), service)
+	_, err = InitBundleRouter(echoObj.Group("/api/v1"), rbac_echo.NewRoutes(echoObj), service)
 	require.Nil(suite.T(), err, "Unable to init router")
 
 	suite.db = db
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	CatalogResyncRouter struct {
+		service model.CatalogResyncService
+		// stop is closed on shutdown of server, running resyncs are stopped and may be resumed later
+		stop    <-chan struct{}
+		running sync.WaitGroup
+	}
+
+	catalogResyncRequest struct {
+		Entities     []string   `json:"entities"`
+		VendorID     *uuid.UUID `json:"vendorId"`
+		UpdatedSince *time.Time `json:"updatedSince"`
+		Rate         int        `json:"rate" validate:"min=0"`
+	}
+
+	catalogResyncDTO struct {
+		ID           uuid.UUID  `json:"id"`
+		CreatedAt    time.Time  `json:"createdAt"`
+		CreatorID    string     `json:"creatorId"`
+		Entities     []string   `json:"entities"`
+		VendorID     *uuid.UUID `json:"vendorId,omitempty"`
+		UpdatedSince *time.Time `json:"updatedSince,omitempty"`
+		Rate         int        `json:"rate"`
+		Status       string     `json:"status"`
+		Entity       string     `json:"entity"`
+		Cursor       uuid.UUID  `json:"cursor"`
+		Published    int        `json:"published"`
+		LastError    string     `json:"lastError,omitempty"`
+		UpdatedAt    time.Time  `json:"updatedAt"`
+		FinishedAt   *time.Time `json:"finishedAt,omitempty"`
+	}
+)
+
+// InitCatalogResyncRouter is initialization method for publishing of the whole catalog to event bus
+func InitCatalogResyncRouter(adminGroup *echo.Group, routes *rbac_echo.Routes, service model.CatalogResyncService, stop <-chan struct{}) (*CatalogResyncRouter, error) {
+	router := CatalogResyncRouter{service: service, stop: stop}
+
+	r := routes.Group(adminGroup, "/catalog/resyncs", &router, []string{"*", model.AdminCatalogType, model.VendorDomain})
+	r.GET("", router.getList, nil)
+	r.POST("", router.create, nil)
+	r.GET("/:resyncId", router.get, nil)
+	r.POST("/:resyncId/resume", router.resume, nil)
+	r.DELETE("/:resyncId", router.cancel, nil)
+
+	return &router, nil
+}
+
+func (router *CatalogResyncRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return "*", nil
+}
+
+func (router *CatalogResyncRouter) getList(ctx echo.Context) error {
+	offset := 0
+	limit := 20
+
+	if offsetParam := ctx.QueryParam("offset"); offsetParam != "" {
+		num, err := strconv.Atoi(offsetParam)
+		if err != nil {
+			return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad offset"))
+		}
+		offset = num
+	}
+
+	if limitParam := ctx.QueryParam("limit"); limitParam != "" {
+		num, err := strconv.Atoi(limitParam)
+		if err != nil {
+			return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad limit"))
+		}
+		limit = num
+	}
+
+	list, count, err := router.service.GetList(offset, limit)
+	if err != nil {
+		return err
+	}
+
+	result := []catalogResyncDTO{}
+	for i := range list {
+		result = append(result, mapCatalogResyncDto(&list[i]))
+	}
+
+	ctx.Response().Header().Add("X-Items-Count", fmt.Sprintf("%d", count))
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *CatalogResyncRouter) create(ctx echo.Context) error {
+	request := &catalogResyncRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	if errs := ctx.Validate(request); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	resync, err := router.service.Create(userId, model.ResyncFilter{
+		Entities:     request.Entities,
+		VendorID:     request.VendorID,
+		UpdatedSince: request.UpdatedSince,
+		Rate:         request.Rate,
+	})
+	if err != nil {
+		return err
+	}
+
+	router.start(resync.ID)
+
+	return ctx.JSON(http.StatusAccepted, mapCatalogResyncDto(resync))
+}
+
+func (router *CatalogResyncRouter) get(ctx echo.Context) error {
+	resyncId, err := uuid.FromString(ctx.Param("resyncId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	resync, err := router.service.Get(resyncId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapCatalogResyncDto(resync))
+}
+
+func (router *CatalogResyncRouter) resume(ctx echo.Context) error {
+	resyncId, err := uuid.FromString(ctx.Param("resyncId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	resync, err := router.service.Resume(resyncId)
+	if err != nil {
+		return err
+	}
+
+	router.start(resync.ID)
+
+	return ctx.JSON(http.StatusAccepted, mapCatalogResyncDto(resync))
+}
+
+func (router *CatalogResyncRouter) cancel(ctx echo.Context) error {
+	resyncId, err := uuid.FromString(ctx.Param("resyncId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	resync, err := router.service.Cancel(resyncId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapCatalogResyncDto(resync))
+}
+
+// start publishes catalog in background until resync is finished or server is stopped, progress of resync is
+// available by its id
+func (router *CatalogResyncRouter) start(resyncId uuid.UUID) {
+	router.running.Add(1)
+	go func() {
+		defer router.running.Done()
+		if err := router.service.Run(resyncId, router.stop); err != nil {
+			zap.L().Error("Catalog resync failed", zap.String("resync", resyncId.String()), zap.Error(err))
+		}
+	}()
+}
+
+// Wait blocks until running resyncs are finished, it's used on shutdown to let stopped resyncs save progress
+func (router *CatalogResyncRouter) Wait() {
+	router.running.Wait()
+}
+
+func mapCatalogResyncDto(resync *model.CatalogResync) catalogResyncDTO {
+	return catalogResyncDTO{
+		ID:           resync.ID,
+		CreatedAt:    resync.CreatedAt,
+		CreatorID:    resync.CreatorID,
+		Entities:     resync.Entities,
+		VendorID:     resync.VendorID,
+		UpdatedSince: resync.UpdatedSince,
+		Rate:         resync.Rate,
+		Status:       resync.Status.ToString(),
+		Entity:       resync.Entity,
+		Cursor:       resync.Cursor,
+		Published:    resync.Published,
+		LastError:    resync.LastError,
+		UpdatedAt:    resync.UpdatedAt,
+		FinishedAt:   resync.FinishedAt,
+	}
+}
@@ -35,12 +35,12 @@ type DiscountCreated struct {
 	ID uuid.UUID `json:"id"`
 }
 
-func InitDiscountsRouter(group *echo.Group, service *orm.DiscountService) (*DiscountsRouter, error) {
+func InitDiscountsRouter(group *echo.Group, routes *rbac_echo.Routes, service *orm.DiscountService) (*DiscountsRouter, error) {
 	router := DiscountsRouter{
 		service: service,
 	}
 
-	r := rbac_echo.Group(group, "/games/:gameId", &router, []string{"gameId", model.GameType, model.VendorDomain})
+	r := routes.Group(group, "/games/:gameId", &router, []string{"gameId", model.GameType, model.VendorDomain})
 	r.GET("/discounts", router.get, nil)
 	r.POST("/discounts", router.post, nil)
 	r.PUT("/discounts/:discountId", router.put, nil)
@@ -5,6 +5,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"qilin-api/pkg/api/rbac_echo"
 	"qilin-api/pkg/model"
 	"qilin-api/pkg/orm"
 	"qilin-api/pkg/test"
@@ -66,7 +67,7 @@ func (suite *DiscountRouterTestSuite) SetupTest() {
 
 	e := echo.New()
 	service, err := orm.NewDiscountService(db)
-	router, err := InitDiscountsRouter(e.Group("/api/v1"), service)
+	router, err := InitDiscountsRouter(e.Group("/api/v1"), rbac_echo.NewRoutes(e), service)
 
 	e.Validator = &QilinValidator{validator: validator.New()}
 
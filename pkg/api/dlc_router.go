@@ -0,0 +1,206 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/model/utils"
+	"qilin-api/pkg/orm"
+	"time"
+)
+
+type (
+	DlcMediaDTO struct {
+		Image       utils.LocalizedString      `json:"image"`
+		Cover       utils.LocalizedString      `json:"cover"`
+		Screenshots utils.LocalizedStringArray `json:"screenshots"`
+	}
+
+	DlcDTO struct {
+		ID               string                `json:"id"`
+		GameID           string                `json:"gameId"`
+		Name             utils.LocalizedString `json:"name" validate:"required"`
+		Media            DlcMediaDTO           `json:"media"`
+		DefaultPackageID string                `json:"defaultPackageId"`
+		Created          string                `json:"created"`
+		Updated          string                `json:"updated"`
+	}
+
+	DlcRouter struct {
+		service model.DlcService
+	}
+)
+
+func InitDlcRouter(group *echo.Group, routes *rbac_echo.Routes, service model.DlcService) (*DlcRouter, error) {
+	if service == nil {
+		return nil, errors.New("Dlc service must be provided")
+	}
+
+	router := DlcRouter{service: service}
+
+	r := routes.Group(group, "/games/:gameId", &router, []string{"gameId", model.GameType, model.VendorDomain})
+	r.GET("/dlcs", router.getList, nil)
+	r.POST("/dlcs", router.create, nil)
+	r.GET("/dlcs/:dlcId", router.get, nil)
+	r.PUT("/dlcs/:dlcId", router.update, nil)
+	r.DELETE("/dlcs/:dlcId", router.delete, nil)
+
+	return &router, nil
+}
+
+func (router *DlcRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return GetOwnerForGame(ctx)
+}
+
+func (router *DlcRouter) getList(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	dlcs, err := router.service.GetList(gameId)
+	if err != nil {
+		return err
+	}
+
+	result := make([]DlcDTO, 0, len(dlcs))
+	for i := range dlcs {
+		result = append(result, mapDlcDTO(&dlcs[i]))
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *DlcRouter) get(ctx echo.Context) error {
+	gameId, dlcId, err := getDlcParams(ctx)
+	if err != nil {
+		return err
+	}
+
+	dlc, err := router.service.Get(gameId, dlcId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapDlcDTO(dlc))
+}
+
+func (router *DlcRouter) create(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	dto, err := bindDlcDTO(ctx)
+	if err != nil {
+		return err
+	}
+
+	dlc, err := router.service.Create(userId, gameId, mapDlc(dto))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, mapDlcDTO(dlc))
+}
+
+func (router *DlcRouter) update(ctx echo.Context) error {
+	gameId, dlcId, err := getDlcParams(ctx)
+	if err != nil {
+		return err
+	}
+
+	dto, err := bindDlcDTO(ctx)
+	if err != nil {
+		return err
+	}
+
+	dlc := mapDlc(dto)
+	dlc.ID = dlcId
+	dlc, err = router.service.Update(gameId, dlc)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapDlcDTO(dlc))
+}
+
+func (router *DlcRouter) delete(ctx echo.Context) error {
+	gameId, dlcId, err := getDlcParams(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := router.service.Delete(gameId, dlcId); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func getDlcParams(ctx echo.Context) (gameId uuid.UUID, dlcId uuid.UUID, err error) {
+	gameId, err = uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return gameId, dlcId, orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	dlcId, err = uuid.FromString(ctx.Param("dlcId"))
+	if err != nil {
+		return gameId, dlcId, orm.NewServiceError(http.StatusBadRequest, "Invalid dlc Id")
+	}
+
+	return gameId, dlcId, nil
+}
+
+func bindDlcDTO(ctx echo.Context) (*DlcDTO, error) {
+	dto := &DlcDTO{}
+	if err := ctx.Bind(dto); err != nil {
+		return nil, orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	if errs := ctx.Validate(dto); errs != nil {
+		return nil, orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	for _, loc := range []*utils.LocalizedString{&dto.Media.Image, &dto.Media.Cover} {
+		if err := utils.ValidateUrls(loc); err != nil {
+			return nil, orm.NewServiceError(http.StatusUnprocessableEntity, err)
+		}
+	}
+
+	return dto, nil
+}
+
+func mapDlc(dto *DlcDTO) *model.Dlc {
+	return &model.Dlc{
+		Name:        dto.Name,
+		Image:       dto.Media.Image,
+		ImageCover:  dto.Media.Cover,
+		Screenshots: dto.Media.Screenshots,
+	}
+}
+
+func mapDlcDTO(dlc *model.Dlc) DlcDTO {
+	return DlcDTO{
+		ID:     dlc.ID.String(),
+		GameID: dlc.GameID.String(),
+		Name:   dlc.Name,
+		Media: DlcMediaDTO{
+			Image:       dlc.Image,
+			Cover:       dlc.ImageCover,
+			Screenshots: dlc.Screenshots,
+		},
+		DefaultPackageID: dlc.DefaultPackageID.String(),
+		Created:          dlc.CreatedAt.Format(time.RFC3339),
+		Updated:          dlc.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"time"
+)
+
+type (
+	GameReviewRouter struct {
+		service model.GameReviewService
+	}
+
+	reviewCommentDTO struct {
+		Field     string    `json:"field"`
+		Message   string    `json:"message"`
+		AuthorID  string    `json:"authorId"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+
+	gameReviewDTO struct {
+		ID           uuid.UUID          `json:"id"`
+		GameID       uuid.UUID          `json:"gameId"`
+		VendorID     uuid.UUID          `json:"vendorId"`
+		Title        string             `json:"title"`
+		Status       string             `json:"status"`
+		SubmitterID  string             `json:"submitterId"`
+		ReviewerID   string             `json:"reviewerId,omitempty"`
+		Message      string             `json:"message,omitempty"`
+		Version      *int               `json:"version,omitempty"`
+		RestoredFrom *int               `json:"restoredFrom,omitempty"`
+		Comments     []reviewCommentDTO `json:"comments"`
+		CreatedAt    time.Time          `json:"createdAt"`
+		UpdatedAt    time.Time          `json:"updatedAt"`
+	}
+)
+
+// InitGameReviewRouter is initialization method for vendor side of game review before publication
+func InitGameReviewRouter(group *echo.Group, routes *rbac_echo.Routes, service model.GameReviewService) (*GameReviewRouter, error) {
+	router := GameReviewRouter{service: service}
+
+	r := routes.Group(group, "/games", &router, []string{"gameId", model.GameType, model.VendorDomain})
+	r.GET("/:gameId/reviews", router.get, nil)
+	r.POST("/:gameId/reviews", router.sendToReview, []string{"gameId", model.PublishGame, model.VendorDomain})
+	r.DELETE("/:gameId/reviews", router.revoke, []string{"gameId", model.PublishGame, model.VendorDomain})
+	r.POST("/:gameId/publications/:version/rollback", router.rollback, []string{"gameId", model.PublishGame, model.VendorDomain})
+
+	return &router, nil
+}
+
+func (router *GameReviewRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return GetOwnerForGame(ctx)
+}
+
+func (router *GameReviewRouter) get(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	review, err := router.service.Get(gameId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapGameReviewDto(review))
+}
+
+func (router *GameReviewRouter) sendToReview(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	review, err := router.service.SendToReview(userId, gameId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapGameReviewDto(review))
+}
+
+func (router *GameReviewRouter) revoke(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	review, err := router.service.Revoke(userId, gameId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapGameReviewDto(review))
+}
+
+// rollback restores published version of game and sends it to review, it's published on approval
+func (router *GameReviewRouter) rollback(ctx echo.Context) error {
+	gameId, version, err := getPublicationParams(ctx)
+	if err != nil {
+		return err
+	}
+
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	review, err := router.service.Rollback(userId, gameId, version)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapGameReviewDto(review))
+}
+
+func mapGameReviewDto(review *model.GameReview) gameReviewDTO {
+	comments := []reviewCommentDTO{}
+	for _, comment := range review.Comments {
+		comments = append(comments, reviewCommentDTO{
+			Field:     comment.Field,
+			Message:   comment.Message,
+			AuthorID:  comment.AuthorID,
+			CreatedAt: comment.CreatedAt,
+		})
+	}
+
+	return gameReviewDTO{
+		ID:           review.ID,
+		GameID:       review.GameID,
+		VendorID:     review.VendorID,
+		Title:        review.Game.Title,
+		Status:       review.Status.ToString(),
+		SubmitterID:  review.SubmitterID,
+		ReviewerID:   review.ReviewerID,
+		Message:      review.Message,
+		Version:      review.Version,
+		RestoredFrom: review.RestoredFrom,
+		Comments:     comments,
+		CreatedAt:    review.CreatedAt,
+		UpdatedAt:    review.UpdatedAt,
+	}
+}
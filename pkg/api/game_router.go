@@ -6,7 +6,6 @@ import (
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/pkg/errors"
 	"github.com/satori/go.uuid"
-	"go.uber.org/zap"
 	"gopkg.in/russross/blackfriday.v2"
 	"net/http"
 	"qilin-api/pkg/api/context"
@@ -270,7 +269,7 @@ func mapGameInfoBTO(game *UpdateGameDTO) *model.Game {
 	}
 }
 
-func InitGameRoutes(router *echo.Group, service model.GameService, userService model.UserService, bus model.EventBus) (*GameRouter, error) {
+func InitGameRoutes(router *echo.Group, routes *rbac_echo.Routes, service model.GameService, userService model.UserService, bus model.EventBus) (*GameRouter, error) {
 	if service == nil {
 		return nil, errors.New("service must be provided")
 	}
@@ -289,17 +288,16 @@ func InitGameRoutes(router *echo.Group, service model.GameService, userService m
 		eventBus:    bus,
 	}
 
-	r := rbac_echo.Group(router, "/vendors/:vendorId", &Router, []string{"*", model.VendorGameType, model.VendorDomain})
+	r := routes.Group(router, "/vendors/:vendorId", &Router, []string{"*", model.VendorGameType, model.VendorDomain})
 	r.GET("/games", Router.GetList, nil)
 	r.POST("/games", Router.Create, nil)
 
-	gameGroup := rbac_echo.Group(router, "/games", &Router, []string{"gameId", model.GameType, model.VendorDomain})
+	gameGroup := routes.Group(router, "/games", &Router, []string{"gameId", model.GameType, model.VendorDomain})
 	gameGroup.GET("/:gameId", Router.GetInfo, nil)
 	gameGroup.DELETE("/:gameId", Router.Delete, nil)
 	gameGroup.PUT("/:gameId", Router.UpdateInfo, nil)
 	gameGroup.GET("/:gameId/descriptions", Router.GetDescr, nil)
 	gameGroup.PUT("/:gameId/descriptions", Router.UpdateDescr, nil)
-	gameGroup.POST("/:gameId/publications", Router.PublishGame, []string{"gameId", model.PublishGame, model.VendorDomain})
 	gameGroup.GET("/:gameId/packages", Router.GetPackages, nil)
 
 	router.GET("/genre", Router.GetGenres) // TODO: Remove after some time
@@ -322,19 +320,6 @@ func (api *GameRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
 	return GetOwnerForGame(ctx)
 }
 
-func (api *GameRouter) PublishGame(ctx echo.Context) error {
-	gameId, err := uuid.FromString(ctx.Param("gameId"))
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid Id")
-	}
-
-	if err := api.eventBus.PublishGameChanges(gameId); err != nil {
-		return orm.NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Can't publish game changes"))
-	}
-
-	return ctx.NoContent(http.StatusOK)
-}
-
 func (api *GameRouter) GetList(ctx echo.Context) error {
 	vendorId, err := uuid.FromString(ctx.Param("vendorId"))
 	if err != nil {
@@ -452,11 +437,6 @@ func (api *GameRouter) Delete(ctx echo.Context) error {
 		return err
 	}
 
-	err = api.eventBus.PublishGameDelete(gameId)
-	if err != nil {
-		zap.L().Error("Error during publishing game changes.", zap.Error(err))
-	}
-
 	return ctx.JSON(http.StatusOK, "OK")
 }
 
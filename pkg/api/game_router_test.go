@@ -90,7 +90,7 @@ func (suite *GamesRouterTestSuite) SetupTest() {
 
 	groupApi := echoObj.Group("/api/v1")
 	userService, err := orm.NewUserService(db, nil)
-	router, err := InitGameRoutes(groupApi, service, userService, mock.NewEventBus())
+	router, err := InitGameRoutes(groupApi, rbac_echo.NewRoutes(echoObj), service, userService, mock.NewEventBus())
 	if err != nil {
 		suite.FailNow("Init routes fail", "%v", err)
 	}
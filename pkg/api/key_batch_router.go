@@ -0,0 +1,240 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"time"
+)
+
+const KeyBatchSignatureHeader = "X-Signature"
+
+type (
+	CreateKeyBatchDTO struct {
+		PartnerLabel string `json:"partnerLabel" validate:"required"`
+		Count        int    `json:"count" validate:"required,min=1"`
+	}
+
+	KeyBatchDTO struct {
+		ID           string `json:"id"`
+		PartnerLabel string `json:"partnerLabel"`
+		Count        int    `json:"count"`
+		CreatedBy    string `json:"createdBy"`
+		Created      string `json:"created"`
+	}
+)
+
+type keyBatchRouter struct {
+	keyPackageService model.KeyPackageService
+	keyBatchService   model.KeyBatchService
+	secret            []byte
+}
+
+func InitKeyBatchRouter(router *echo.Group, routes *rbac_echo.Routes, keyPackageService model.KeyPackageService, keyBatchService model.KeyBatchService, secret string) (*keyBatchRouter, error) {
+	if keyBatchService == nil {
+		return nil, errors.New("Key Batch service must be provided")
+	}
+
+	if secret == "" {
+		return nil, errors.New("Key Batch secret must be provided")
+	}
+
+	batchRouter := keyBatchRouter{
+		keyPackageService: keyPackageService,
+		keyBatchService:   keyBatchService,
+		secret:            []byte(secret),
+	}
+
+	r := routes.Group(router, "/packages/:packageId/keypackages/:keyPackageId", &batchRouter, []string{"*", model.PackageType, model.VendorDomain})
+	r.GET("/batches", batchRouter.GetList, nil)
+	r.POST("/batches", batchRouter.Create, nil)
+	r.GET("/batches/:batchId", batchRouter.Get, nil)
+	r.GET("/batches/:batchId/download", batchRouter.Download, nil)
+
+	return &batchRouter, nil
+}
+
+func (router *keyBatchRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return GetOwnerForPackage(ctx)
+}
+
+func (router *keyBatchRouter) Create(ctx echo.Context) error {
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
+	if err != nil {
+		return err
+	}
+
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	dto := &CreateKeyBatchDTO{}
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	batch, err := router.keyBatchService.Create(keyPackageId, userId, dto.PartnerLabel, dto.Count)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, mapKeyBatch(batch))
+}
+
+func (router *keyBatchRouter) GetList(ctx echo.Context) error {
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
+	if err != nil {
+		return err
+	}
+
+	batches, err := router.keyBatchService.List(keyPackageId)
+	if err != nil {
+		return err
+	}
+
+	result := make([]KeyBatchDTO, 0, len(batches))
+	for i := range batches {
+		result = append(result, mapKeyBatch(&batches[i]))
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *keyBatchRouter) Get(ctx echo.Context) error {
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
+	if err != nil {
+		return err
+	}
+
+	batchId, err := uuid.FromString(ctx.Param("batchId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "batchId is wrong")
+	}
+
+	batch, err := router.keyBatchService.Get(keyPackageId, batchId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapKeyBatch(batch))
+}
+
+// Download returns keys of batch as CSV file signed with HMAC-SHA256. Signature is returned in `X-Signature` header,
+// for `format=zip` archive contains `keys.csv` and `keys.csv.sig` files.
+func (router *keyBatchRouter) Download(ctx echo.Context) error {
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
+	if err != nil {
+		return err
+	}
+
+	batchId, err := uuid.FromString(ctx.Param("batchId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "batchId is wrong")
+	}
+
+	format := ctx.QueryParam("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "zip" {
+		return orm.NewServiceErrorf(http.StatusBadRequest, "Unknown format `%s`", format)
+	}
+
+	batch, err := router.keyBatchService.Get(keyPackageId, batchId)
+	if err != nil {
+		return err
+	}
+
+	keys, err := router.keyBatchService.GetKeys(keyPackageId, batchId)
+	if err != nil {
+		return err
+	}
+
+	data, err := writeKeyBatchCsv(batch, keys)
+	if err != nil {
+		return orm.NewServiceError(http.StatusInternalServerError, err)
+	}
+	signature := signKeyBatch(router.secret, data)
+
+	fileName := fmt.Sprintf("keys-%s", batch.ID)
+	ctx.Response().Header().Set(KeyBatchSignatureHeader, signature)
+
+	if format == "csv" {
+		ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"%s.csv\"", fileName))
+		return ctx.Blob(http.StatusOK, "text/csv", data)
+	}
+
+	archive := &bytes.Buffer{}
+	writer := zip.NewWriter(archive)
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"keys.csv", data},
+		{"keys.csv.sig", []byte(signature)},
+	}
+	for _, file := range files {
+		w, err := writer.Create(file.name)
+		if err != nil {
+			return orm.NewServiceError(http.StatusInternalServerError, err)
+		}
+		if _, err := w.Write(file.data); err != nil {
+			return orm.NewServiceError(http.StatusInternalServerError, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return orm.NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"%s.zip\"", fileName))
+	return ctx.Blob(http.StatusOK, "application/zip", archive.Bytes())
+}
+
+func writeKeyBatchCsv(batch *model.KeyBatch, keys []model.Key) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	writer := csv.NewWriter(buffer)
+	if err := writer.Write([]string{"batchId", "partnerLabel", "activationCode"}); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if err := writer.Write([]string{batch.ID.String(), batch.PartnerLabel, key.ActivationCode}); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+
+	return buffer.Bytes(), writer.Error()
+}
+
+// signKeyBatch returns hex encoded HMAC-SHA256 of data
+func signKeyBatch(secret, data []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func mapKeyBatch(batch *model.KeyBatch) KeyBatchDTO {
+	return KeyBatchDTO{
+		ID:           batch.ID.String(),
+		PartnerLabel: batch.PartnerLabel,
+		Count:        batch.Count,
+		CreatedBy:    batch.CreatedBy,
+		Created:      batch.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"github.com/stretchr/testify/require"
+	"qilin-api/pkg/model"
+	"testing"
+)
+
+func Test_KeyBatchSigning(t *testing.T) {
+	shouldBe := require.New(t)
+	batch := &model.KeyBatch{PartnerLabel: "Partner"}
+	keys := []model.Key{{ActivationCode: "AAAA-BBBB"}, {ActivationCode: "CCCC-DDDD"}}
+
+	data, err := writeKeyBatchCsv(batch, keys)
+	shouldBe.Nil(err)
+	shouldBe.Equal("batchId,partnerLabel,activationCode\n"+
+		batch.ID.String()+",Partner,AAAA-BBBB\n"+
+		batch.ID.String()+",Partner,CCCC-DDDD\n", string(data))
+
+	signature := signKeyBatch([]byte("secret"), data)
+	shouldBe.Len(signature, 64)
+	shouldBe.Equal(signature, signKeyBatch([]byte("secret"), data))
+	shouldBe.NotEqual(signature, signKeyBatch([]byte("another"), data))
+}
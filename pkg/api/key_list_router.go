@@ -2,40 +2,78 @@ package api
 
 import (
 	"bufio"
+	"encoding/csv"
+	"fmt"
 	"github.com/labstack/echo/v4"
-	uuid "github.com/satori/go.uuid"
+	"github.com/pkg/errors"
 	"io"
 	"net/http"
+	"qilin-api/pkg/api/context"
 	"qilin-api/pkg/api/rbac_echo"
 	"qilin-api/pkg/model"
 	"qilin-api/pkg/orm"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type AddKeyListDTO struct {
 	Keys []string `json:"keys" validate:"required"`
 }
 
+type KeyImportReportDTO struct {
+	Accepted   []KeyImportLineDTO `json:"accepted"`
+	Duplicates []KeyImportLineDTO `json:"duplicates"`
+	Rejected   []KeyImportLineDTO `json:"rejected"`
+}
+
+type KeyImportLineDTO struct {
+	Line   int    `json:"line"`
+	Code   string `json:"code"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type RevokeKeysDTO struct {
+	Keys   []string `json:"keys" validate:"required,min=1"`
+	Reason string   `json:"reason" validate:"required"`
+}
+
+type RevokeKeysResultDTO struct {
+	Revoked int `json:"revoked"`
+}
+
+type KeyListItemDTO struct {
+	ID             string `json:"id"`
+	ActivationCode string `json:"activationCode"`
+	Created        string `json:"created"`
+	RedeemTime     string `json:"redeemTime,omitempty"`
+	RevokeTime     string `json:"revokeTime,omitempty"`
+}
+
 type KeyListRouter struct {
-	keyListService model.KeyListService
+	keyPackageService model.KeyPackageService
+	keyListService    model.KeyListService
 }
 
-func InitKeyListRouter(router *echo.Group, keyListService model.KeyListService) (*KeyListRouter, error){
+func InitKeyListRouter(router *echo.Group, routes *rbac_echo.Routes, keyPackageService model.KeyPackageService, keyListService model.KeyListService) (*KeyListRouter, error) {
 	keyRouter := KeyListRouter{
-		keyListService: keyListService,
+		keyPackageService: keyPackageService,
+		keyListService:    keyListService,
 	}
-	r := rbac_echo.Group(router, "/packages/:packageId/keypackages/:keyPackageId", &keyRouter, []string{"*", model.PackageType, model.VendorDomain})
+	r := routes.Group(router, "/packages/:packageId/keypackages/:keyPackageId", &keyRouter, []string{"*", model.PackageType, model.VendorDomain})
+	r.GET("/keys", keyRouter.GetKeys, nil)
 	r.POST("/keys", keyRouter.AddKeys, nil)
+	r.GET("/keys/export", keyRouter.ExportKeys, nil)
+	r.POST("/keys/revoke", keyRouter.RevokeKeys, nil)
 	r.POST("/file", keyRouter.AddFileKeys, nil)
 
 	return &keyRouter, nil
 }
 
 func (router *KeyListRouter) AddFileKeys(ctx echo.Context) error {
-	keyPackageIdParam := ctx.Param("keyPackageId")
-	keyPackageId, err := uuid.FromString(keyPackageIdParam)
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
 	if err != nil {
-		return orm.NewServiceError(http.StatusBadRequest, "keyPackageId is wrong")
+		return err
 	}
 
 	file, err := ctx.FormFile("keys")
@@ -49,33 +87,31 @@ func (router *KeyListRouter) AddFileKeys(ctx echo.Context) error {
 	}
 	defer src.Close()
 
-	reader := bufio.NewReader(src)
-	var line string
-	var codes []string
-	shouldBreak := false
-	for shouldBreak == false {
-		line, err = reader.ReadString('\n')
-		if err != nil {
-			if err != io.EOF {
-				return orm.NewServiceError(http.StatusBadRequest, err)
-			}
-			shouldBreak = true
+	format := ctx.FormValue("format")
+	if format == "" {
+		format = "txt"
+		if strings.HasSuffix(strings.ToLower(file.Filename), ".csv") {
+			format = "csv"
 		}
-		line = strings.Trim(line, "\t\n")
-		codes = append(codes, line)
 	}
-	if err := router.keyListService.AddKeys(keyPackageId, codes); err != nil {
+
+	lines, err := readKeyLines(src, format)
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	report, err := router.keyListService.ImportKeys(keyPackageId, lines)
+	if err != nil {
 		return err
 	}
 
-	return ctx.NoContent(http.StatusOK)
+	return ctx.JSON(http.StatusOK, mapKeyImportReport(report))
 }
 
 func (router *KeyListRouter) AddKeys(ctx echo.Context) error {
-	keyPackageIdParam := ctx.Param("keyPackageId")
-	keyPackageId, err := uuid.FromString(keyPackageIdParam)
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
 	if err != nil {
-		return orm.NewServiceError(http.StatusBadRequest, "keyPackageId is wrong")
+		return err
 	}
 
 	dto := &AddKeyListDTO{}
@@ -86,12 +122,230 @@ func (router *KeyListRouter) AddKeys(ctx echo.Context) error {
 		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
 	}
 
-	err = router.keyListService.AddKeys(keyPackageId, dto.Keys)
+	report, err := router.keyListService.ImportKeys(keyPackageId, dto.Keys)
 	if err != nil {
 		return err
 	}
 
-	return ctx.NoContent(http.StatusOK)
+	return ctx.JSON(http.StatusOK, mapKeyImportReport(report))
+}
+
+// readKeyLines reads one activation code per line. For CSV files code is taken from the first column
+// and header line is skipped. Empty lines are kept so line numbers in import report match the file.
+func readKeyLines(src io.Reader, format string) ([]string, error) {
+	switch format {
+	case "txt":
+		var lines []string
+		scanner := bufio.NewScanner(src)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return lines, nil
+	case "csv":
+		return readCsvKeyLines(src)
+	}
+	return nil, errors.Errorf("Unknown file format `%s`", format)
+}
+
+// readCsvKeyLines reads whole file with one csv reader, so quoted values may contain commas and line breaks.
+// Code of record is placed at the last line of the record, lines skipped by csv reader stay empty.
+func readCsvKeyLines(src io.Reader) ([]string, error) {
+	counter := &lineCounter{src: bufio.NewReader(src)}
+	reader := csv.NewReader(counter)
+	reader.FieldsPerRecord = -1
+
+	var lines []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "Bad CSV")
+		}
+
+		code := record[0]
+		if len(lines) == 0 && isKeyCsvHeader(code) {
+			code = ""
+		}
+		for len(lines) < counter.line-1 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, code)
+	}
+}
+
+// lineCounter passes source to csv reader by single lines and counts them. Reader stops right after the last
+// line of record, so after each read record `line` is number of its last line.
+type lineCounter struct {
+	src  *bufio.Reader
+	rest []byte
+	line int
+}
+
+func (counter *lineCounter) Read(p []byte) (int, error) {
+	if len(counter.rest) == 0 {
+		line, err := counter.src.ReadBytes('\n')
+		if len(line) == 0 {
+			return 0, err
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		counter.rest = line
+		counter.line++
+	}
+
+	n := copy(p, counter.rest)
+	counter.rest = counter.rest[n:]
+	return n, nil
+}
+
+func isKeyCsvHeader(column string) bool {
+	switch strings.ToLower(strings.TrimSpace(column)) {
+	case "key", "code", "activationcode", "activation_code":
+		return true
+	}
+	return false
+}
+
+func mapKeyImportReport(report *model.KeyImportReport) KeyImportReportDTO {
+	mapLines := func(lines []model.KeyImportLine) []KeyImportLineDTO {
+		result := make([]KeyImportLineDTO, 0, len(lines))
+		for _, line := range lines {
+			result = append(result, KeyImportLineDTO{Line: line.Line, Code: line.Code, Reason: line.Reason})
+		}
+		return result
+	}
+
+	return KeyImportReportDTO{
+		Accepted:   mapLines(report.Accepted),
+		Duplicates: mapLines(report.Duplicates),
+		Rejected:   mapLines(report.Rejected),
+	}
+}
+
+func (router *KeyListRouter) GetKeys(ctx echo.Context) error {
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
+	if err != nil {
+		return err
+	}
+
+	offset := 0
+	limit := 20
+
+	if offsetParam := ctx.QueryParam("offset"); offsetParam != "" {
+		if offset, err = strconv.Atoi(offsetParam); err != nil {
+			return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad offset"))
+		}
+	}
+
+	if limitParam := ctx.QueryParam("limit"); limitParam != "" {
+		if limit, err = strconv.Atoi(limitParam); err != nil || limit <= 0 {
+			return orm.NewServiceError(http.StatusBadRequest, "Bad limit")
+		}
+	}
+
+	state, err := model.KeyStateFromString(ctx.QueryParam("state"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	total, keys, err := router.keyListService.List(keyPackageId, state, offset, limit)
+	if err != nil {
+		return err
+	}
+
+	result := make([]KeyListItemDTO, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, mapKeyListItem(key))
+	}
+
+	ctx.Response().Header().Add("X-Items-Count", fmt.Sprintf("%d", total))
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// ExportKeys writes all keys of key package in specified state as CSV file
+func (router *KeyListRouter) ExportKeys(ctx echo.Context) error {
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
+	if err != nil {
+		return err
+	}
+
+	state, err := model.KeyStateFromString(ctx.QueryParam("state"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	_, keys, err := router.keyListService.List(keyPackageId, state, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	response := ctx.Response()
+	response.Header().Set(echo.HeaderContentType, "text/csv")
+	response.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"keys-%s.csv\"", keyPackageId))
+	response.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(response)
+	if err := writer.Write([]string{"id", "activationCode", "created", "redeemTime", "revokeTime"}); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		item := mapKeyListItem(key)
+		if err := writer.Write([]string{item.ID, item.ActivationCode, item.Created, item.RedeemTime, item.RevokeTime}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func (router *KeyListRouter) RevokeKeys(ctx echo.Context) error {
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
+	if err != nil {
+		return err
+	}
+
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	dto := &RevokeKeysDTO{}
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	revoked, err := router.keyListService.Revoke(keyPackageId, userId, dto.Keys, dto.Reason)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, RevokeKeysResultDTO{Revoked: revoked})
+}
+
+func mapKeyListItem(key model.Key) KeyListItemDTO {
+	item := KeyListItemDTO{
+		ID:             key.ID.String(),
+		ActivationCode: key.ActivationCode,
+		Created:        key.CreatedAt.Format(time.RFC3339),
+	}
+	if key.RedeemTime != nil {
+		item.RedeemTime = key.RedeemTime.Format(time.RFC3339)
+	}
+	if key.RevokeTime != nil {
+		item.RevokeTime = key.RevokeTime.Format(time.RFC3339)
+	}
+	return item
 }
 
 func (*KeyListRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
@@ -12,6 +12,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"qilin-api/pkg/api/rbac_echo"
 	"qilin-api/pkg/model"
 	"qilin-api/pkg/model/utils"
 	"qilin-api/pkg/orm"
@@ -96,8 +97,9 @@ func (suite *KeyListRouterTestSuite) SetupTest() {
 	e := echo.New()
 	e.Validator = &QilinValidator{validator: validator.New()}
 
+	keyPackageService := orm.NewKeyPackageService(db, orm.NewKeyStreamService(db), nil)
 	service := orm.NewKeyListService(db)
-	suite.router, err = InitKeyListRouter(e.Group("/api/v1"), service)
+	suite.router, err = InitKeyListRouter(e.Group("/api/v1"), rbac_echo.NewRoutes(e), keyPackageService, service)
 	suite.db = db
 	suite.echo = e
 	shouldBe.Nil(err)
@@ -157,9 +159,35 @@ func (suite *KeyListRouterTestSuite) TestAddKeysNotFound() {
 	shouldBe.Equal(404, err.(*orm.ServiceError).Code)
 }
 
+func (suite *KeyListRouterTestSuite) TestAddKeysToAnotherPackage() {
+	shouldBe := require.New(suite.T())
+
+	handlers := map[string]echo.HandlerFunc{
+		"AddKeys":     suite.router.AddKeys,
+		"AddFileKeys": suite.router.AddFileKeys,
+	}
+	for name, handler := range handlers {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"keys":["QWERTY","TESTSOMECODE"]}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := suite.echo.NewContext(req, rec)
+		c.SetPath("/api/v1/packages/:packageId/keypackages/:keyPackageId")
+		c.SetParamNames("packageId", "keyPackageId")
+		c.SetParamValues(suite.keyPackage.String(), suite.wrongKeyPackage.String())
+
+		err := handler(c)
+		shouldBe.NotNil(err, name)
+		shouldBe.Equal(404, err.(*orm.ServiceError).Code, name)
+	}
+
+	count := 0
+	shouldBe.Nil(suite.db.DB().Model(model.Key{}).Count(&count).Error)
+	shouldBe.Equal(0, count, "Keys must not be imported")
+}
+
 func (suite *KeyListRouterTestSuite) TestAddFile() {
 	shouldBe := require.New(suite.T())
-	values := map[string]io.Reader {
+	values := map[string]io.Reader{
 		"keys": strings.NewReader("QWERTY\nTEST\nOPIUY"),
 	}
 	var b bytes.Buffer
@@ -185,4 +213,50 @@ func (suite *KeyListRouterTestSuite) TestAddFile() {
 	err := suite.router.AddFileKeys(c)
 	shouldBe.Nil(err)
 	shouldBe.Equal(200, rec.Code)
-}
\ No newline at end of file
+}
+
+func (suite *KeyListRouterTestSuite) TestKeysOfAnotherPackage() {
+	shouldBe := require.New(suite.T())
+
+	handlers := map[string]echo.HandlerFunc{
+		"GetKeys":    suite.router.GetKeys,
+		"ExportKeys": suite.router.ExportKeys,
+		"RevokeKeys": suite.router.RevokeKeys,
+	}
+	for name, handler := range handlers {
+		req := httptest.NewRequest(http.MethodGet, "/", strings.NewReader(`{"keys":["QWERTY"],"reason":"leaked"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := suite.echo.NewContext(req, rec)
+		c.SetPath("/api/v1/packages/:packageId/keypackages/:keyPackageId")
+		c.SetParamNames("packageId", "keyPackageId")
+		c.SetParamValues(suite.keyPackage.String(), suite.wrongKeyPackage.String())
+
+		err := handler(c)
+		shouldBe.NotNil(err, name)
+		shouldBe.Equal(404, err.(*orm.ServiceError).Code, name)
+	}
+}
+
+func Test_ReadKeyLines(t *testing.T) {
+	shouldBe := require.New(t)
+
+	lines, err := readKeyLines(strings.NewReader("QWERTY\r\n\nTEST\n"), "txt")
+	shouldBe.Nil(err)
+	shouldBe.Equal([]string{"QWERTY", "", "TEST"}, lines)
+
+	lines, err = readKeyLines(strings.NewReader("code,comment\nQWERTY,first\n\n\"TEST\",second"), "csv")
+	shouldBe.Nil(err)
+	shouldBe.Equal([]string{"", "QWERTY", "", "TEST"}, lines)
+
+	// record with line break in quoted comment takes two lines, records may have different number of columns
+	lines, err = readKeyLines(strings.NewReader("QWERTY,\"first\nsecond\"\nTEST\n\n\"KEY,1\",a,b\n"), "csv")
+	shouldBe.Nil(err)
+	shouldBe.Equal([]string{"", "QWERTY", "TEST", "", "KEY,1"}, lines)
+
+	_, err = readKeyLines(strings.NewReader("QWERTY\n\"TEST"), "csv")
+	shouldBe.NotNil(err)
+
+	_, err = readKeyLines(strings.NewReader("QWERTY"), "xls")
+	shouldBe.NotNil(err)
+}
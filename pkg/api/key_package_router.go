@@ -13,11 +13,25 @@ import (
 
 type (
 	KeyPackageDTO struct {
-		ID      string `json:"id"`
-		Name    string `json:"name"`
-		Type    string `json:"type"`
-		Created string `json:"created"`
-		Updated string `json:"updated"`
+		ID                string            `json:"id"`
+		Name              string            `json:"name"`
+		Type              string            `json:"type"`
+		LowStockThreshold int               `json:"lowStockThreshold"`
+		CodePattern       string            `json:"codePattern"`
+		CodeFormat        *KeyCodeFormatDTO `json:"codeFormat,omitempty"`
+		ActivationPolicy  string            `json:"activationPolicy"`
+		LicenseDays       int               `json:"licenseDays"`
+		Created           string            `json:"created"`
+		Updated           string            `json:"updated"`
+	}
+
+	KeyCodeFormatDTO struct {
+		GroupCount  int    `json:"groupCount" validate:"required"`
+		GroupLength int    `json:"groupLength" validate:"required"`
+		Alphabet    string `json:"alphabet" validate:"required"`
+		Exclude     string `json:"exclude"`
+		Prefix      string `json:"prefix"`
+		Checksum    bool   `json:"checksum"`
 	}
 
 	CreateKeyPackageDTO struct {
@@ -26,7 +40,38 @@ type (
 	}
 
 	ChangeKeyPackageDTO struct {
-		Name string `json:"name" validate:"required"`
+		Name              string `json:"name" validate:"required"`
+		LowStockThreshold int    `json:"lowStockThreshold" validate:"min=0"`
+		CodePattern       string `json:"codePattern"`
+	}
+
+	ChangeActivationPolicyDTO struct {
+		ActivationPolicy string `json:"activationPolicy" validate:"required"`
+		LicenseDays      int    `json:"licenseDays" validate:"min=0"`
+	}
+
+	RedeemKeysDTO struct {
+		Count int `json:"count" validate:"required,min=1,max=1000"`
+	}
+
+	KeyPackageInventoryDTO struct {
+		Total       int                    `json:"total"`
+		Redeemed    int                    `json:"redeemed"`
+		Revoked     int                    `json:"revoked"`
+		Available   int                    `json:"available"`
+		Unlimited   bool                   `json:"unlimited"`
+		Redemptions []KeyRedemptionStatDTO `json:"redemptions"`
+	}
+
+	KeyRedemptionStatDTO struct {
+		Date  string `json:"date"`
+		Count int    `json:"count"`
+	}
+
+	KeyDTO struct {
+		ID             string `json:"id"`
+		ActivationCode string `json:"activationCode"`
+		RedeemTime     string `json:"redeemTime"`
 	}
 )
 
@@ -34,7 +79,7 @@ type keyPackageRouter struct {
 	keyPackageService model.KeyPackageService
 }
 
-func InitKeyPackageRouter(router *echo.Group, keyPackageService model.KeyPackageService) (*keyPackageRouter, error) {
+func InitKeyPackageRouter(router *echo.Group, routes *rbac_echo.Routes, keyPackageService model.KeyPackageService) (*keyPackageRouter, error) {
 	if keyPackageService == nil {
 		return nil, errors.New("Key Package service must be provided")
 	}
@@ -43,11 +88,16 @@ func InitKeyPackageRouter(router *echo.Group, keyPackageService model.KeyPackage
 		keyPackageService: keyPackageService,
 	}
 
-	r := rbac_echo.Group(router, "/packages/:packageId", &keyRouter, []string{"*", model.PackageType, model.VendorDomain})
+	r := routes.Group(router, "/packages/:packageId", &keyRouter, []string{"*", model.PackageType, model.VendorDomain})
 	r.GET("/keypackages", keyRouter.GetList, nil)
 	r.POST("/keypackages", keyRouter.Create, nil)
 	r.GET("/keypackages/:keyPackageId", keyRouter.Get, nil)
 	r.PUT("/keypackages/:keyPackageId", keyRouter.Change, nil)
+	r.PUT("/keypackages/:keyPackageId/format", keyRouter.ChangeCodeFormat, nil)
+	r.PUT("/keypackages/:keyPackageId/policy", keyRouter.ChangeActivationPolicy, nil)
+	r.GET("/keypackages/:keyPackageId/inventory", keyRouter.GetInventory, nil)
+	r.POST("/keypackages/:keyPackageId/redeem", keyRouter.Redeem, nil)
+	r.POST("/keypackages/:keyPackageId/redeem/batch", keyRouter.RedeemList, nil)
 
 	return &keyRouter, nil
 }
@@ -121,7 +171,58 @@ func (router *keyPackageRouter) Change(ctx echo.Context) (err error) {
 		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
 	}
 
-	keyPackage, err := router.keyPackageService.Update(packageId, dto.Name)
+	keyPackage, err := router.keyPackageService.Update(packageId, dto.Name, dto.LowStockThreshold, dto.CodePattern)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapKeyPackage(keyPackage))
+}
+
+func (router *keyPackageRouter) ChangeCodeFormat(ctx echo.Context) (err error) {
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
+	if err != nil {
+		return err
+	}
+
+	dto := &KeyCodeFormatDTO{}
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	keyPackage, err := router.keyPackageService.UpdateCodeFormat(keyPackageId, model.KeyCodeFormat{
+		GroupCount:  dto.GroupCount,
+		GroupLength: dto.GroupLength,
+		Alphabet:    dto.Alphabet,
+		Exclude:     dto.Exclude,
+		Prefix:      dto.Prefix,
+		Checksum:    dto.Checksum,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapKeyPackage(keyPackage))
+}
+
+func (router *keyPackageRouter) ChangeActivationPolicy(ctx echo.Context) (err error) {
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
+	if err != nil {
+		return err
+	}
+
+	dto := &ChangeActivationPolicyDTO{}
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	keyPackage, err := router.keyPackageService.UpdateActivationPolicy(keyPackageId, dto.ActivationPolicy, dto.LicenseDays)
 	if err != nil {
 		return err
 	}
@@ -129,6 +230,121 @@ func (router *keyPackageRouter) Change(ctx echo.Context) (err error) {
 	return ctx.JSON(http.StatusOK, mapKeyPackage(keyPackage))
 }
 
+func (router *keyPackageRouter) GetInventory(ctx echo.Context) (err error) {
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
+	if err != nil {
+		return err
+	}
+
+	to := time.Now().UTC()
+	if param := ctx.QueryParam("to"); param != "" {
+		to, err = time.Parse(time.RFC3339, param)
+		if err != nil {
+			return orm.NewServiceError(http.StatusBadRequest, "Invalid `to` date")
+		}
+	}
+
+	from := to.AddDate(0, -1, 0)
+	if param := ctx.QueryParam("from"); param != "" {
+		from, err = time.Parse(time.RFC3339, param)
+		if err != nil {
+			return orm.NewServiceError(http.StatusBadRequest, "Invalid `from` date")
+		}
+	}
+
+	period := ctx.QueryParam("period")
+	if period == "" {
+		period = "day"
+	}
+
+	inventory, err := router.keyPackageService.GetInventory(keyPackageId, from, to, period)
+	if err != nil {
+		return err
+	}
+
+	result := KeyPackageInventoryDTO{
+		Total:       inventory.Total,
+		Redeemed:    inventory.Redeemed,
+		Revoked:     inventory.Revoked,
+		Available:   inventory.Available,
+		Unlimited:   inventory.Unlimited,
+		Redemptions: []KeyRedemptionStatDTO{},
+	}
+	for _, stat := range inventory.Redemptions {
+		result.Redemptions = append(result.Redemptions, KeyRedemptionStatDTO{
+			Date:  stat.Date.Format(time.RFC3339),
+			Count: stat.Count,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *keyPackageRouter) Redeem(ctx echo.Context) (err error) {
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
+	if err != nil {
+		return err
+	}
+
+	key, err := router.keyPackageService.Redeem(keyPackageId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapKey(key))
+}
+
+func (router *keyPackageRouter) RedeemList(ctx echo.Context) (err error) {
+	keyPackageId, err := getOwnKeyPackageId(ctx, router.keyPackageService)
+	if err != nil {
+		return err
+	}
+
+	dto := &RedeemKeysDTO{}
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	keys, err := router.keyPackageService.RedeemList(keyPackageId, dto.Count)
+	if err != nil {
+		return err
+	}
+
+	result := []KeyDTO{}
+	for _, key := range keys {
+		result = append(result, mapKey(&key))
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// getOwnKeyPackageId returns key package id from path and checks that key package belongs to package from path
+func getOwnKeyPackageId(ctx echo.Context, keyPackageService model.KeyPackageService) (uuid.UUID, error) {
+	packageId, err := getPackageId(ctx)
+	if err != nil {
+		return uuid.Nil, orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	keyPackageId, err := getKeyPackageId(ctx)
+	if err != nil {
+		return uuid.Nil, orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	keyPackage, err := keyPackageService.Get(keyPackageId)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if keyPackage.PackageID != packageId {
+		return uuid.Nil, orm.NewServiceErrorf(http.StatusNotFound, "Key package `%s` not found in package `%s`", keyPackageId, packageId)
+	}
+
+	return keyPackageId, nil
+}
+
 func getPackageId(ctx echo.Context) (uuid.UUID, error) {
 	packageIdStr := ctx.Param("packageId")
 	return uuid.FromString(packageIdStr)
@@ -144,11 +360,40 @@ func (*keyPackageRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
 }
 
 func mapKeyPackage(keyPackage *model.KeyPackage) KeyPackageDTO {
-	return KeyPackageDTO{
-		Type:    keyPackage.KeyStreamType.String(),
-		Name:    keyPackage.Name,
-		ID:      keyPackage.ID.String(),
-		Created: keyPackage.CreatedAt.Format(time.RFC3339),
-		Updated: keyPackage.UpdatedAt.Format(time.RFC3339),
+	dto := KeyPackageDTO{
+		Type:              keyPackage.KeyStreamType.String(),
+		Name:              keyPackage.Name,
+		ID:                keyPackage.ID.String(),
+		LowStockThreshold: keyPackage.LowStockThreshold,
+		CodePattern:       keyPackage.CodePattern,
+		ActivationPolicy:  keyPackage.ActivationPolicy,
+		LicenseDays:       keyPackage.LicenseDays,
+		Created:           keyPackage.CreatedAt.Format(time.RFC3339),
+		Updated:           keyPackage.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if keyPackage.KeyStreamType == model.PlatformKeysStream {
+		format := keyPackage.CodeFormat.OrDefault()
+		dto.CodeFormat = &KeyCodeFormatDTO{
+			GroupCount:  format.GroupCount,
+			GroupLength: format.GroupLength,
+			Alphabet:    format.Alphabet,
+			Exclude:     format.Exclude,
+			Prefix:      format.Prefix,
+			Checksum:    format.Checksum,
+		}
+	}
+
+	return dto
+}
+
+func mapKey(key *model.Key) KeyDTO {
+	dto := KeyDTO{
+		ID:             key.ID.String(),
+		ActivationCode: key.ActivationCode,
+	}
+	if key.RedeemTime != nil {
+		dto.RedeemTime = key.RedeemTime.Format(time.RFC3339)
 	}
+	return dto
 }
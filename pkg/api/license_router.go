@@ -0,0 +1,294 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"strconv"
+	"time"
+)
+
+type (
+	ActivateKeyDTO struct {
+		ActivationCode  string `json:"activationCode" validate:"required"`
+		OrderID         string `json:"orderId"`
+		ExternalContext string `json:"externalContext"`
+	}
+
+	ValidateKeyDTO struct {
+		ActivationCode string `json:"activationCode" validate:"required"`
+	}
+
+	KeyValidationDTO struct {
+		Valid     bool   `json:"valid"`
+		Reason    string `json:"reason,omitempty"`
+		PackageID string `json:"packageId,omitempty"`
+	}
+
+	RevokeLicenseDTO struct {
+		Reason string `json:"reason" validate:"required"`
+	}
+
+	ExtendLicenseDTO struct {
+		EndDate time.Time `json:"endDate" validate:"required"`
+	}
+
+	OwnershipDTO struct {
+		Owned   bool        `json:"owned"`
+		License *LicenseDTO `json:"license,omitempty"`
+	}
+
+	LicenseDTO struct {
+		ID               string `json:"id"`
+		PackageID        string `json:"packageId"`
+		UserID           string `json:"userId"`
+		OrderID          string `json:"orderId"`
+		ExternalContext  string `json:"externalContext"`
+		ActivationCode   string `json:"activationCode"`
+		ActivationPolicy string `json:"activationPolicy"`
+		StartDate        string `json:"startDate"`
+		EndDate          string `json:"endDate,omitempty"`
+		RevokeTime       string `json:"revokeTime,omitempty"`
+		RevokeReason     string `json:"revokeReason,omitempty"`
+		Active           bool   `json:"active"`
+	}
+)
+
+type licenseRouter struct {
+	service model.LicenseService
+}
+
+// InitLicenseRouter registers activation and ownership routes available for any authorized user (store, launcher)
+// and license management routes for vendors. Activation and validation of codes are limited to `activationLimit`
+// requests of user per `activationWindow`, so codes can't be brute forced.
+func InitLicenseRouter(router *echo.Group, routes *rbac_echo.Routes, service model.LicenseService, activationLimit int, activationWindow time.Duration) (*licenseRouter, error) {
+	if service == nil {
+		return nil, errors.New("License service must be provided")
+	}
+
+	licenseRouter := licenseRouter{service: service}
+
+	limiter := newUserRateLimiter(activationLimit, activationWindow)
+	router.POST("/keys/activate", licenseRouter.Activate, limiter.Middleware)
+	router.POST("/keys/validate", licenseRouter.Validate, limiter.Middleware)
+	router.GET("/licenses/check", licenseRouter.CheckOwnership)
+
+	r := routes.Group(router, "/packages/:packageId", &licenseRouter, []string{"*", model.PackageType, model.VendorDomain})
+	r.GET("/licenses", licenseRouter.GetList, nil)
+	r.POST("/licenses/:licenseId/revoke", licenseRouter.Revoke, nil)
+	r.POST("/licenses/:licenseId/extend", licenseRouter.Extend, nil)
+	r.POST("/licenses/:licenseId/renew", licenseRouter.Renew, nil)
+
+	return &licenseRouter, nil
+}
+
+func (router *licenseRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return GetOwnerForPackage(ctx)
+}
+
+func (router *licenseRouter) Activate(ctx echo.Context) error {
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	dto := &ActivateKeyDTO{}
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	license, err := router.service.Activate(userId, dto.ActivationCode, dto.OrderID, dto.ExternalContext)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, mapLicense(license))
+}
+
+func (router *licenseRouter) Validate(ctx echo.Context) error {
+	dto := &ValidateKeyDTO{}
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	validation, err := router.service.Validate(dto.ActivationCode)
+	if err != nil {
+		return err
+	}
+
+	result := KeyValidationDTO{Valid: validation.Valid, Reason: validation.Reason}
+	if validation.Valid {
+		result.PackageID = validation.PackageID.String()
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// CheckOwnership answers whether current user owns package from `packageId` query param right now
+func (router *licenseRouter) CheckOwnership(ctx echo.Context) error {
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	packageId, err := uuid.FromString(ctx.QueryParam("packageId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "packageId is wrong")
+	}
+
+	license, err := router.service.GetActive(userId, packageId)
+	if err != nil {
+		return err
+	}
+
+	result := OwnershipDTO{Owned: license != nil}
+	if license != nil {
+		dto := mapLicense(license)
+		result.License = &dto
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *licenseRouter) GetList(ctx echo.Context) error {
+	packageId, err := uuid.FromString(ctx.Param("packageId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "packageId is wrong")
+	}
+
+	offset := 0
+	limit := 20
+
+	if offsetParam := ctx.QueryParam("offset"); offsetParam != "" {
+		if offset, err = strconv.Atoi(offsetParam); err != nil {
+			return orm.NewServiceError(http.StatusBadRequest, "Bad offset")
+		}
+	}
+
+	if limitParam := ctx.QueryParam("limit"); limitParam != "" {
+		if limit, err = strconv.Atoi(limitParam); err != nil {
+			return orm.NewServiceError(http.StatusBadRequest, "Bad limit")
+		}
+	}
+
+	total, licenses, err := router.service.List(packageId, ctx.QueryParam("userId"), ctx.QueryParam("orderId"), offset, limit)
+	if err != nil {
+		return err
+	}
+
+	result := make([]LicenseDTO, 0, len(licenses))
+	for i := range licenses {
+		result = append(result, mapLicense(&licenses[i]))
+	}
+
+	ctx.Response().Header().Add("X-Items-Count", fmt.Sprintf("%d", total))
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *licenseRouter) Revoke(ctx echo.Context) error {
+	packageId, licenseId, err := getLicensePathIds(ctx)
+	if err != nil {
+		return err
+	}
+
+	dto := &RevokeLicenseDTO{}
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	license, err := router.service.Revoke(packageId, licenseId, dto.Reason)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapLicense(license))
+}
+
+func (router *licenseRouter) Extend(ctx echo.Context) error {
+	packageId, licenseId, err := getLicensePathIds(ctx)
+	if err != nil {
+		return err
+	}
+
+	dto := &ExtendLicenseDTO{}
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	license, err := router.service.Extend(packageId, licenseId, dto.EndDate)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapLicense(license))
+}
+
+// Renew is called on renewal payment of subscription
+func (router *licenseRouter) Renew(ctx echo.Context) error {
+	packageId, licenseId, err := getLicensePathIds(ctx)
+	if err != nil {
+		return err
+	}
+
+	license, err := router.service.Renew(packageId, licenseId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapLicense(license))
+}
+
+func getLicensePathIds(ctx echo.Context) (packageId uuid.UUID, licenseId uuid.UUID, err error) {
+	packageId, err = uuid.FromString(ctx.Param("packageId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, orm.NewServiceError(http.StatusBadRequest, "packageId is wrong")
+	}
+
+	licenseId, err = uuid.FromString(ctx.Param("licenseId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, orm.NewServiceError(http.StatusBadRequest, "licenseId is wrong")
+	}
+
+	return packageId, licenseId, nil
+}
+
+func mapLicense(license *model.License) LicenseDTO {
+	dto := LicenseDTO{
+		ID:               license.ID.String(),
+		PackageID:        license.PackageID.String(),
+		UserID:           license.UserID,
+		OrderID:          license.OrderID,
+		ExternalContext:  license.ExternalContext,
+		ActivationCode:   license.ActivationCode,
+		ActivationPolicy: license.ActivationPolicy,
+		StartDate:        license.StartDate.Format(time.RFC3339),
+		RevokeReason:     license.RevokeReason,
+		Active:           license.IsActive(time.Now()),
+	}
+	if license.EndDate != nil {
+		dto.EndDate = license.EndDate.Format(time.RFC3339)
+	}
+	if license.RevokeTime != nil {
+		dto.RevokeTime = license.RevokeTime.Format(time.RFC3339)
+	}
+	return dto
+}
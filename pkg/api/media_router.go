@@ -55,12 +55,12 @@ type (
 )
 
 //InitMediaRouter is initializing group method
-func InitMediaRouter(group *echo.Group, service model.MediaService) (*MediaRouter, error) {
+func InitMediaRouter(group *echo.Group, routes *rbac_echo.Routes, service model.MediaService) (*MediaRouter, error) {
 	mediaRouter := MediaRouter{
 		mediaService: service,
 	}
 
-	router := rbac_echo.Group(group, "/games/:gameId", &mediaRouter, []string{"gameId", model.GameType, model.VendorDomain})
+	router := routes.Group(group, "/games/:gameId", &mediaRouter, []string{"gameId", model.GameType, model.VendorDomain})
 	router.GET("/media", mediaRouter.get, nil)
 	router.PUT("/media", mediaRouter.put, nil)
 
@@ -47,13 +47,13 @@ type InviteCreatedDTO struct {
 	Url string `json:"url"`
 }
 
-func InitClientMembershipRouter(group *echo.Group, service model.MembershipService) (*MembershipRouter, error) {
+func InitClientMembershipRouter(group *echo.Group, routes *rbac_echo.Routes, service model.MembershipService) (*MembershipRouter, error) {
 	res := &MembershipRouter{
 		service: service,
 	}
 
 	permissions := []string{"*", model.RolesType, model.VendorDomain}
-	route := rbac_echo.Group(group, "/vendors/:vendorId", res, permissions)
+	route := routes.Group(group, "/vendors/:vendorId", res, permissions)
 
 	route.GET("/memberships", res.getUsers, nil)
 	route.GET("/memberships/:userId", res.getUser, nil)
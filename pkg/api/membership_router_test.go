@@ -14,6 +14,7 @@ import (
 	"net/http/httptest"
 	"qilin-api/pkg/api/context"
 	"qilin-api/pkg/api/mock"
+	"qilin-api/pkg/api/rbac_echo"
 	"qilin-api/pkg/model"
 	"qilin-api/pkg/orm"
 	"qilin-api/pkg/test"
@@ -92,7 +93,7 @@ func (suite *MembershipRouterTestSuite) SetupTest() {
 	shouldBe.Nil(service.Init())
 	enf.AddRole(rbac.Role{Role: "admin", User: adminId, Domain: "vendor", Owner: ownerId, RestrictedResourceId: []string{"*"}})
 
-	router, err := InitClientMembershipRouter(e.Group("/api/v1"), service)
+	router, err := InitClientMembershipRouter(e.Group("/api/v1"), rbac_echo.NewRoutes(e), service)
 	shouldBe.Nil(err)
 
 	suite.db = db
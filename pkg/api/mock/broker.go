@@ -0,0 +1,49 @@
+package mock
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/streadway/amqp"
+	"sync"
+)
+
+// BrokerMessage is message accepted by in-memory broker
+type BrokerMessage struct {
+	Topic   string
+	Body    []byte
+	Headers amqp.Table
+}
+
+// Broker is in-memory stand-in for rabbitmq.Broker, it fails all publications while Err is set
+type Broker struct {
+	mutex    sync.Mutex
+	messages []BrokerMessage
+	Err      error
+}
+
+func NewBroker() *Broker {
+	return &Broker{}
+}
+
+func (b *Broker) Publish(topic string, msg proto.Message, h amqp.Table) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.Err != nil {
+		return b.Err
+	}
+
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	b.messages = append(b.messages, BrokerMessage{Topic: topic, Body: body, Headers: h})
+	return nil
+}
+
+// Messages returns accepted messages in order of publication
+func (b *Broker) Messages() []BrokerMessage {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return append([]BrokerMessage{}, b.messages...)
+}
@@ -16,7 +16,7 @@ func (*bundleService) CreateStore(vendorId uuid.UUID, userId, name string, packa
 	return &model.StoreBundle{}, nil
 }
 
-func (*bundleService) GetStoreList(userId string, vendorId uuid.UUID, query, sort string, offset, limit int, filterFunc model.BundleListingFilter) (total int, result []model.Bundle, err error) {
+func (*bundleService) GetStoreList(userId string, vendorId uuid.UUID, query, sort, country string, offset, limit int, filterFunc model.BundleListingFilter) (total int, result []model.Bundle, err error) {
 	return 0, []model.Bundle{}, nil
 }
 
@@ -39,3 +39,15 @@ func (p *bundleService) AddPackages(bundleId uuid.UUID, packageIds []uuid.UUID)
 func (p *bundleService) RemovePackages(bundleId uuid.UUID, packages []uuid.UUID) (err error) {
 	return nil
 }
+
+func (*bundleService) CreateLootbox(vendorId uuid.UUID, userId, name, currency string, items []model.LootboxItem) (bundle model.Bundle, err error) {
+	return &model.LootboxBundle{}, nil
+}
+
+func (*bundleService) GetLootboxList(userId string, vendorId uuid.UUID, query, sort, country string, offset, limit int, filterFunc model.BundleListingFilter) (total int, result []model.Bundle, err error) {
+	return 0, []model.Bundle{}, nil
+}
+
+func (*bundleService) UpdateLootbox(bundle model.Bundle) (result model.Bundle, err error) {
+	return bundle, nil
+}
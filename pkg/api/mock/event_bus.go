@@ -1,8 +1,10 @@
 package mock
 
 import (
+	"github.com/jinzhu/gorm"
 	"github.com/satori/go.uuid"
 	"qilin-api/pkg/model"
+	"time"
 )
 
 type eventBus struct {
@@ -16,6 +18,22 @@ func (eventBus) PublishGameDelete(gameId uuid.UUID) error {
 	return nil
 }
 
+func (eventBus) PublishAchievementsChanges(gameId uuid.UUID) error {
+	return nil
+}
+
+func (eventBus) PublishPackageReleased(packageId uuid.UUID, releaseDate time.Time) error {
+	return nil
+}
+
+func (eventBus) Publish(payload model.EventPayload) error {
+	return nil
+}
+
+func (bus *eventBus) WithTransaction(tx *gorm.DB) model.EventBus {
+	return bus
+}
+
 func NewEventBus() model.EventBus {
 	return &eventBus{}
 }
@@ -21,3 +21,8 @@ func (p *productService) GetPackages(uuid.UUID) (packages []model.Package, err e
 	packages = []model.Package{}
 	return
 }
+
+func (p *productService) GetDlcGames(dlcIds []uuid.UUID) (games []uuid.UUID, err error) {
+	games = []uuid.UUID{}
+	return
+}
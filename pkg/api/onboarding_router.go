@@ -74,14 +74,14 @@ type (
 	}
 )
 
-func InitClientOnboardingRouter(group *echo.Group, service *orm.OnboardingService, notificationService model.NotificationService) (*OnboardingClientRouter, error) {
+func InitClientOnboardingRouter(group *echo.Group, routes *rbac_echo.Routes, service *orm.OnboardingService, notificationService model.NotificationService) (*OnboardingClientRouter, error) {
 	router := OnboardingClientRouter{
 		service:             service,
 		notificationService: notificationService,
 	}
 
 	common := []string{"*", model.DocumentsType, model.VendorDomain}
-	r := rbac_echo.Group(group, "/vendors/:vendorId", &router, common)
+	r := routes.Group(group, "/vendors/:vendorId", &router, common)
 
 	r.GET("/documents", router.getDocument, nil)
 	r.PUT("/documents", router.changeDocument, nil)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"qilin-api/pkg/api/rbac_echo"
 	"qilin-api/pkg/model"
 	"qilin-api/pkg/orm"
 	"qilin-api/pkg/sys"
@@ -70,7 +71,7 @@ func (suite *OnboardingClientRouterTestSuite) SetupTest() {
 	should.Nil(err)
 	notService, err := orm.NewNotificationService(db, notifier, config.Notifier.Secret)
 	should.Nil(err)
-	router, err := InitClientOnboardingRouter(e.Group("/api/v1"), service, notService)
+	router, err := InitClientOnboardingRouter(e.Group("/api/v1"), rbac_echo.NewRoutes(e), service, notService)
 	v := validator.New()
 	assert.NoError(suite.T(), utils.RegisterCustomValidations(v))
 	e.Validator = &QilinValidator{validator: v}
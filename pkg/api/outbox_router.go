@@ -0,0 +1,152 @@
+package api
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"strconv"
+	"time"
+)
+
+type (
+	OutboxRouter struct {
+		service model.OutboxService
+	}
+
+	outboxEventDTO struct {
+		ID            uuid.UUID  `json:"id"`
+		Topic         string     `json:"topic"`
+		AggregateID   uuid.UUID  `json:"aggregateId"`
+		Sequence      int64      `json:"sequence"`
+		CorrelationID uuid.UUID  `json:"correlationId"`
+		SchemaVersion int        `json:"schemaVersion"`
+		Encoding      string     `json:"encoding"`
+		Status        string     `json:"status"`
+		Attempts      int        `json:"attempts"`
+		NextAttemptAt time.Time  `json:"nextAttemptAt"`
+		LastError     string     `json:"lastError,omitempty"`
+		CreatedAt     time.Time  `json:"createdAt"`
+		DeliveredAt   *time.Time `json:"deliveredAt,omitempty"`
+	}
+
+	replayedEventsDTO struct {
+		Count int `json:"count"`
+	}
+)
+
+// InitOutboxRouter is initialization method for inspection and replay of outgoing events
+func InitOutboxRouter(adminGroup *echo.Group, routes *rbac_echo.Routes, service model.OutboxService) (*OutboxRouter, error) {
+	router := OutboxRouter{service: service}
+
+	r := routes.Group(adminGroup, "/events", &router, []string{"*", model.AdminEventsType, model.VendorDomain})
+	r.GET("", router.getEvents, nil)
+	r.GET("/:eventId", router.get, nil)
+	r.POST("/replay", router.replayFailed, nil)
+	r.POST("/:eventId/replay", router.replay, nil)
+
+	return &router, nil
+}
+
+func (router *OutboxRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return "*", nil
+}
+
+func (router *OutboxRouter) getEvents(ctx echo.Context) error {
+	offset := 0
+	limit := 20
+
+	if offsetParam := ctx.QueryParam("offset"); offsetParam != "" {
+		num, err := strconv.Atoi(offsetParam)
+		if err != nil {
+			return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad offset"))
+		}
+		offset = num
+	}
+
+	if limitParam := ctx.QueryParam("limit"); limitParam != "" {
+		num, err := strconv.Atoi(limitParam)
+		if err != nil {
+			return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad limit"))
+		}
+		limit = num
+	}
+
+	status, err := model.OutboxStatusFromString(ctx.QueryParam("status"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad status"))
+	}
+
+	events, count, err := router.service.GetEvents(status, ctx.QueryParam("topic"), offset, limit)
+	if err != nil {
+		return err
+	}
+
+	result := []outboxEventDTO{}
+	for i := range events {
+		result = append(result, mapOutboxEventDto(&events[i]))
+	}
+
+	ctx.Response().Header().Add("X-Items-Count", fmt.Sprintf("%d", count))
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *OutboxRouter) get(ctx echo.Context) error {
+	eventId, err := uuid.FromString(ctx.Param("eventId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	event, err := router.service.Get(eventId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapOutboxEventDto(event))
+}
+
+func (router *OutboxRouter) replay(ctx echo.Context) error {
+	eventId, err := uuid.FromString(ctx.Param("eventId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	event, err := router.service.Replay(eventId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapOutboxEventDto(event))
+}
+
+func (router *OutboxRouter) replayFailed(ctx echo.Context) error {
+	count, err := router.service.ReplayFailed(ctx.QueryParam("topic"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, replayedEventsDTO{Count: count})
+}
+
+func mapOutboxEventDto(event *model.OutboxEvent) outboxEventDTO {
+	return outboxEventDTO{
+		ID:            event.ID,
+		Topic:         event.Topic,
+		AggregateID:   event.AggregateID,
+		Sequence:      event.Sequence,
+		CorrelationID: event.CorrelationID,
+		SchemaVersion: event.SchemaVersion,
+		Encoding:      event.Encoding,
+		Status:        event.Status.ToString(),
+		Attempts:      event.Attempts,
+		NextAttemptAt: event.NextAttemptAt,
+		LastError:     event.LastError,
+		CreatedAt:     event.CreatedAt,
+		DeliveredAt:   event.DeliveredAt,
+	}
+}
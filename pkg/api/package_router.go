@@ -43,6 +43,7 @@ type (
 
 	packageRegionalRestrinctionsDTO struct {
 		AllowedCountries []string `json:"allowedCountries"`
+		DeniedCountries  []string `json:"deniedCountries"`
 	}
 
 	productDTO struct {
@@ -87,7 +88,7 @@ type (
 )
 
 func InitPackageRouter(
-	group *echo.Group,
+	group *echo.Group, routes *rbac_echo.Routes,
 	service model.PackageService,
 	productService model.ProductService,
 	priceService model.PriceService) (router *packageRouter, err error) {
@@ -97,11 +98,11 @@ func InitPackageRouter(
 		priceService:    priceService,
 	}
 
-	vendorRouter := rbac_echo.Group(group, "/vendors/:vendorId", router, []string{"*", model.PackageListType, model.VendorDomain})
+	vendorRouter := routes.Group(group, "/vendors/:vendorId", router, []string{"*", model.PackageListType, model.VendorDomain})
 	vendorRouter.GET("/packages", router.GetList, nil)
 	vendorRouter.POST("/packages", router.Create, nil)
 
-	packageGroup := rbac_echo.Group(group, "/packages", router, []string{"packageId", model.PackageType, model.VendorDomain})
+	packageGroup := routes.Group(group, "/packages", router, []string{"packageId", model.PackageType, model.VendorDomain})
 	packageGroup.GET("/:packageId", router.Get, nil)
 	packageGroup.PUT("/:packageId", router.Update, nil)
 	packageGroup.DELETE("/:packageId", router.Remove, nil)
@@ -164,6 +165,7 @@ func mapPackageDto(pkg *model.Package) (dto *packageDTO, err error) {
 		},
 		RegionalRestrinctions: packageRegionalRestrinctionsDTO{
 			AllowedCountries: pkg.AllowedCountries,
+			DeniedCountries:  countriesOrEmpty(pkg.DeniedCountries),
 		},
 	}
 	for _, p := range pkg.Products {
@@ -196,7 +198,8 @@ func mapPackageModel(dto *packageDTO) (pkg *model.Package, err error) {
 		return
 	}
 
-	if !pkg_utils.ValidateCountryList(dto.RegionalRestrinctions.AllowedCountries) {
+	if !pkg_utils.ValidateCountryList(dto.RegionalRestrinctions.AllowedCountries) ||
+		!pkg_utils.ValidateCountryList(dto.RegionalRestrinctions.DeniedCountries) {
 		return nil, orm.NewServiceError(http.StatusUnprocessableEntity, "Invalid countries")
 	}
 
@@ -213,15 +216,29 @@ func mapPackageModel(dto *packageDTO) (pkg *model.Package, err error) {
 		Discount:         dto.DiscountPolicy.Discount,
 		DiscountBuyOpt:   model.NewBuyOption(dto.DiscountPolicy.BuyOption),
 		AllowedCountries: dto.RegionalRestrinctions.AllowedCountries,
+		DeniedCountries:  countriesOrEmpty(dto.RegionalRestrinctions.DeniedCountries),
 	}, nil
 }
 
+// countriesOrEmpty replaces missing country list with empty one, so it's stored and shown as empty array
+func countriesOrEmpty(countries []string) []string {
+	if countries == nil {
+		return []string{}
+	}
+	return countries
+}
+
 func (router *packageRouter) checkRBAC(userId string, qilinCtx *rbac_echo.AppContext, productIds []uuid.UUID) error {
-	// Check permissions for Games
-	games, _, err := router.productsService.Specialization(productIds)
+	// Check permissions for Games, DLC are checked by games they belong to
+	games, dlcs, err := router.productsService.Specialization(productIds)
+	if err != nil {
+		return err
+	}
+	dlcGames, err := router.productsService.GetDlcGames(dlcs)
 	if err != nil {
 		return err
 	}
+	games = append(games, dlcGames...)
 	for _, gameId := range games {
 		owner, err := qilinCtx.GetOwnerForGame(gameId)
 		if err != nil {
@@ -231,7 +248,6 @@ func (router *packageRouter) checkRBAC(userId string, qilinCtx *rbac_echo.AppCon
 			return orm.NewServiceError(http.StatusForbidden, fmt.Sprintf("Access restricted for game `%s`", gameId.String()))
 		}
 	}
-	// TODO: do same for DLC
 	return nil
 }
 
@@ -372,7 +388,8 @@ func (router *packageRouter) GetList(ctx echo.Context) (err error) {
 	}
 	query := ctx.QueryParam("query")
 	sort := ctx.QueryParam("sort")
-	total, packages, err := router.service.GetList(userId, vendorId, query, sort, offset, limit, filterFunc)
+	country := strings.ToUpper(ctx.QueryParam("country"))
+	total, packages, err := router.service.GetList(userId, vendorId, query, sort, country, offset, limit, filterFunc)
 	if err != nil {
 		return err
 	}
@@ -395,6 +412,11 @@ func (router *packageRouter) Update(ctx echo.Context) (err error) {
 		return orm.NewServiceError(http.StatusBadRequest, "Invalid package Id")
 	}
 
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
 	existPkg, err := router.service.Get(packageId)
 	if err != nil {
 		return orm.NewServiceError(http.StatusNotFound, "Package not found")
@@ -415,7 +437,7 @@ func (router *packageRouter) Update(ctx echo.Context) (err error) {
 	if err != nil {
 		return orm.NewServiceError(http.StatusBadRequest, err)
 	}
-	if err := router.priceService.UpdateBase(packageId, &basePrice); err != nil {
+	if err := router.priceService.UpdateBase(userId, packageId, &basePrice); err != nil {
 		return err
 	}
 	for _, price := range basePrice.Prices {
@@ -425,7 +447,7 @@ func (router *packageRouter) Update(ctx echo.Context) (err error) {
 		if price.Vat < 0 {
 			price.Vat = 0
 		}
-		if err := router.priceService.Update(packageId, &price); err != nil {
+		if err := router.priceService.Update(userId, packageId, &price); err != nil {
 			return orm.NewServiceError(http.StatusBadRequest, err)
 		}
 	}
@@ -438,7 +460,7 @@ func (router *packageRouter) Update(ctx echo.Context) (err error) {
 			}
 		}
 		if !found {
-			if err := router.priceService.Delete(packageId, &existPrice); err != nil {
+			if err := router.priceService.Delete(userId, packageId, &existPrice); err != nil {
 				return orm.NewServiceError(http.StatusBadRequest, err)
 			}
 		}
@@ -59,7 +59,8 @@ var (
     "buyOption": "whole"
   },
   "regionalRestrinctions": {
-    "allowedCountries": []
+    "allowedCountries": [],
+    "deniedCountries": []
   },
   "commercial": {
     "common": {
@@ -100,7 +101,8 @@ var (
     "buyOption": "whole"
   },
   "regionalRestrinctions": {
-    "allowedCountries": []
+    "allowedCountries": [],
+    "deniedCountries": []
   },
   "commercial": {
     "common": {
@@ -141,7 +143,8 @@ var (
     "buyOption": "whole"
   },
   "regionalRestrinctions": {
-    "allowedCountries": []
+    "allowedCountries": [],
+    "deniedCountries": []
   },
   "commercial": {
     "common": {
@@ -285,7 +288,7 @@ func (suite *PackageRouterTestSuite) SetupTest() {
 
 	priceService := orm.NewPriceService(db)
 
-	_, err = InitPackageRouter(echoObj.Group("/api/v1"), service, productService, priceService)
+	_, err = InitPackageRouter(echoObj.Group("/api/v1"), rbac_echo.NewRoutes(echoObj), service, productService, priceService)
 	require.Nil(suite.T(), err)
 
 	suite.db = db
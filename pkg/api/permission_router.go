@@ -0,0 +1,166 @@
+package api
+
+import (
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"strings"
+)
+
+// maxPermissionChecks limits number of resources and routes checked by one request
+const maxPermissionChecks = 100
+
+type (
+	PermissionRouter struct {
+		routes *rbac_echo.Routes
+	}
+
+	resourcePermissionDTO struct {
+		Resource   string `json:"resource" validate:"required"`
+		ResourceID string `json:"resourceId"`
+		VendorID   string `json:"vendorId"`
+		Action     string `json:"action" validate:"required,oneof=read write any"`
+	}
+
+	routePermissionDTO struct {
+		Method string `json:"method" validate:"required"`
+		Path   string `json:"path" validate:"required"`
+	}
+
+	permissionCheckRequest struct {
+		Resources []resourcePermissionDTO `json:"resources" validate:"dive"`
+		Routes    []routePermissionDTO    `json:"routes" validate:"dive"`
+	}
+
+	resourcePermissionResultDTO struct {
+		resourcePermissionDTO
+		Allowed bool   `json:"allowed"`
+		Error   string `json:"error,omitempty"`
+	}
+
+	// routePermissionResultDTO reports route level permissions only, checks made by handlers aren't included
+	routePermissionResultDTO struct {
+		routePermissionDTO
+		RouteAllowed bool   `json:"routeAllowed"`
+		Error        string `json:"error,omitempty"`
+	}
+
+	permissionCheckResultDTO struct {
+		Resources []resourcePermissionResultDTO `json:"resources"`
+		Routes    []routePermissionResultDTO    `json:"routes"`
+	}
+)
+
+// InitPermissionRouter is initialization method for checking permissions of current user before calling of api
+func InitPermissionRouter(group *echo.Group, routes *rbac_echo.Routes) (*PermissionRouter, error) {
+	router := PermissionRouter{routes: routes}
+
+	group.POST("/permissions/check", router.check)
+
+	return &router, nil
+}
+
+func (router *PermissionRouter) check(ctx echo.Context) error {
+	request := &permissionCheckRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	if errs := ctx.Validate(request); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	if len(request.Resources)+len(request.Routes) > maxPermissionChecks {
+		return orm.NewServiceErrorf(http.StatusUnprocessableEntity, "At most %d resources and routes can be checked at once", maxPermissionChecks)
+	}
+
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	qilinCtx := ctx.(rbac_echo.AppContext)
+	result := permissionCheckResultDTO{
+		Resources: []resourcePermissionResultDTO{},
+		Routes:    []routePermissionResultDTO{},
+	}
+
+	for _, resource := range request.Resources {
+		allowed, reason := permissionResult(checkResource(qilinCtx, userId, resource))
+		result.Resources = append(result.Resources, resourcePermissionResultDTO{resourcePermissionDTO: resource, Allowed: allowed, Error: reason})
+	}
+
+	for _, route := range request.Routes {
+		allowed, reason := permissionResult(router.routes.Check(qilinCtx, route.Method, route.Path))
+		result.Routes = append(result.Routes, routePermissionResultDTO{routePermissionDTO: route, RouteAllowed: allowed, Error: reason})
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+// checkResource enforces action on resource with owner resolved like routers of the resource do
+func checkResource(ctx rbac_echo.AppContext, userId string, check resourcePermissionDTO) error {
+	resourceId := check.ResourceID
+	if resourceId == "" {
+		resourceId = "*"
+	}
+
+	owner, err := getResourceOwner(ctx, check.Resource, resourceId, check.VendorID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.CheckPermissions(userId, model.VendorDomain, check.Resource, resourceId, owner, check.Action)
+}
+
+func getResourceOwner(ctx rbac_echo.AppContext, resource string, resourceId string, vendorIdParam string) (string, error) {
+	if strings.HasPrefix(resource, "admin.") {
+		return "*", nil
+	}
+
+	if vendorIdParam != "" {
+		vendorId, err := uuid.FromString(vendorIdParam)
+		if err != nil {
+			return "", orm.NewServiceError(http.StatusBadRequest, errors.Wrapf(err, "Vendor id `%s` is incorrect", vendorIdParam))
+		}
+		return ctx.GetOwnerForVendor(vendorId)
+	}
+
+	id, err := uuid.FromString(resourceId)
+	if err != nil {
+		return "", orm.NewServiceErrorf(http.StatusBadRequest, "Vendor id or id of resource `%s` is required", resource)
+	}
+
+	switch resource {
+	case model.GameType, model.PublishGame:
+		return ctx.GetOwnerForGame(id)
+	case model.PackageType:
+		return ctx.GetOwnerForPackage(id)
+	case model.RoleBundle:
+		return ctx.GetOwnerForBundle(id)
+	}
+
+	return "", orm.NewServiceErrorf(http.StatusBadRequest, "Vendor id is required for resource `%s`", resource)
+}
+
+// permissionResult converts result of permission check to answer for client, forbidden request isn't error of check
+func permissionResult(err error) (bool, string) {
+	if err == nil {
+		return true, ""
+	}
+
+	if serviceErr, ok := err.(*orm.ServiceError); ok {
+		if serviceErr.Code == http.StatusForbidden {
+			return false, ""
+		}
+		return false, fmt.Sprint(serviceErr.Message)
+	}
+
+	return false, err.Error()
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/ProtocolONE/authone-jwt-verifier-golang"
+	"github.com/ProtocolONE/rbac"
+	"github.com/labstack/echo/v4"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gopkg.in/go-playground/validator.v9"
+	"net/http"
+	"net/http/httptest"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"strings"
+	"testing"
+)
+
+type PermissionRouterTestSuite struct {
+	suite.Suite
+	echo   *echo.Echo
+	gameId string
+	userId string
+}
+
+// permissionOwnerProvider knows the only game of vendor owner
+type permissionOwnerProvider struct {
+	gameId string
+}
+
+// permissionGameRouter protects games like game router does
+type permissionGameRouter struct{}
+
+func Test_PermissionRouter(t *testing.T) {
+	suite.Run(t, new(PermissionRouterTestSuite))
+}
+
+func (p *permissionOwnerProvider) GetOwnerForVendor(vendorId uuid.UUID) (string, error) {
+	return "", orm.NewServiceError(http.StatusNotFound, "Vendor not found")
+}
+
+func (p *permissionOwnerProvider) GetOwnerForGame(gameId uuid.UUID) (string, error) {
+	if gameId.String() != p.gameId {
+		return "", orm.NewServiceError(http.StatusNotFound, "Game not found")
+	}
+	return "owner", nil
+}
+
+func (p *permissionOwnerProvider) GetOwnerForPackage(packageId uuid.UUID) (string, error) {
+	return "", orm.NewServiceError(http.StatusNotFound, "Package not found")
+}
+
+func (p *permissionOwnerProvider) GetOwnerForBundle(bundleId uuid.UUID) (string, error) {
+	return "", orm.NewServiceError(http.StatusNotFound, "Bundle not found")
+}
+
+func (router *permissionGameRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return GetOwnerForGame(ctx)
+}
+
+func (suite *PermissionRouterTestSuite) SetupTest() {
+	shouldBe := require.New(suite.T())
+
+	suite.gameId = uuid.NewV4().String()
+	suite.userId = uuid.NewV4().String()
+
+	enf := rbac.NewEnforcer()
+	enf.AddPolicy(rbac.Policy{Role: model.Support, Domain: model.VendorDomain, ResourceType: model.GameType, ResourceId: "*", Action: "read", Effect: "allow"})
+	shouldBe.True(enf.AddRole(rbac.Role{Role: model.Support, User: suite.userId, Domain: model.VendorDomain, Owner: "owner", RestrictedResourceId: []string{"*"}}))
+
+	e := echo.New()
+	e.Validator = &QilinValidator{validator: validator.New()}
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		QilinErrorHandler(err, c, false)
+	}
+	e.Use(rbac_echo.NewAppContextMiddleware(&permissionOwnerProvider{gameId: suite.gameId}, enf))
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: suite.userId})
+			return next(c)
+		}
+	})
+
+	group := e.Group("/api/v1")
+	routes := rbac_echo.NewRoutes(e)
+	noContent := func(ctx echo.Context) error { return ctx.NoContent(http.StatusOK) }
+	games := routes.Group(group, "/games", &permissionGameRouter{}, []string{"gameId", model.GameType, model.VendorDomain})
+	games.GET("/:gameId", noContent, nil)
+	games.PUT("/:gameId", noContent, nil)
+
+	_, err := InitPermissionRouter(group, routes)
+	shouldBe.Nil(err)
+
+	suite.echo = e
+}
+
+func (suite *PermissionRouterTestSuite) check(body string) (int, *permissionCheckResultDTO) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/permissions/check", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	suite.echo.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		return rec.Code, nil
+	}
+
+	result := &permissionCheckResultDTO{}
+	require.Nil(suite.T(), json.Unmarshal(rec.Body.Bytes(), result))
+	return rec.Code, result
+}
+
+func (suite *PermissionRouterTestSuite) call(method, path string) int {
+	rec := httptest.NewRecorder()
+	suite.echo.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec.Code
+}
+
+func (suite *PermissionRouterTestSuite) TestResources() {
+	shouldBe := require.New(suite.T())
+
+	code, result := suite.check(`{"resources": [
+		{"resource": "games", "resourceId": "` + suite.gameId + `", "action": "read"},
+		{"resource": "games", "resourceId": "` + suite.gameId + `", "action": "any"},
+		{"resource": "games", "resourceId": "` + uuid.NewV4().String() + `", "action": "read"},
+		{"resource": "vendors.games", "action": "read"}
+	]}`)
+	shouldBe.Equal(http.StatusOK, code)
+	shouldBe.Len(result.Resources, 4)
+
+	shouldBe.True(result.Resources[0].Allowed)
+	shouldBe.False(result.Resources[1].Allowed, "Support can't change games")
+	shouldBe.Empty(result.Resources[1].Error)
+	shouldBe.False(result.Resources[2].Allowed)
+	shouldBe.NotEmpty(result.Resources[2].Error, "Unknown game must be reported")
+	shouldBe.False(result.Resources[3].Allowed)
+	shouldBe.NotEmpty(result.Resources[3].Error, "Owner can't be resolved without vendor")
+
+	code, _ = suite.check(`{"resources": [{"resource": "games", "action": "delete"}]}`)
+	shouldBe.Equal(http.StatusUnprocessableEntity, code)
+}
+
+func (suite *PermissionRouterTestSuite) TestRoutes() {
+	shouldBe := require.New(suite.T())
+
+	gamePath := "/api/v1/games/" + suite.gameId
+	code, result := suite.check(`{"routes": [
+		{"method": "GET", "path": "` + gamePath + `"},
+		{"method": "put", "path": "` + gamePath + `/"},
+		{"method": "GET", "path": "/api/v1/games/BAD_ID"},
+		{"method": "GET", "path": "/api/v1/unknown"}
+	]}`)
+	shouldBe.Equal(http.StatusOK, code)
+	shouldBe.Len(result.Routes, 4)
+
+	shouldBe.True(result.Routes[0].RouteAllowed)
+	shouldBe.Equal(http.StatusOK, suite.call(http.MethodGet, gamePath), "Check must agree with api")
+
+	shouldBe.False(result.Routes[1].RouteAllowed)
+	shouldBe.Empty(result.Routes[1].Error)
+	shouldBe.Equal(http.StatusForbidden, suite.call(http.MethodPut, gamePath), "Check must agree with api")
+
+	shouldBe.False(result.Routes[2].RouteAllowed)
+	shouldBe.NotEmpty(result.Routes[2].Error)
+
+	shouldBe.False(result.Routes[3].RouteAllowed)
+	shouldBe.NotEmpty(result.Routes[3].Error, "Unknown route must be reported")
+}
+
+func (suite *PermissionRouterTestSuite) TestRoutesOfAnotherServer() {
+	shouldBe := require.New(suite.T())
+
+	// the same route of another server is registered with permissions nobody has
+	another := echo.New()
+	games := rbac_echo.NewRoutes(another).Group(another.Group("/api/v1"), "/games", &permissionGameRouter{}, []string{"gameId", model.AdminGameReviewsType, model.VendorDomain})
+	games.GET("/:gameId", func(ctx echo.Context) error { return ctx.NoContent(http.StatusOK) }, nil)
+
+	code, result := suite.check(`{"routes": [{"method": "GET", "path": "/api/v1/games/` + suite.gameId + `"}]}`)
+	shouldBe.Equal(http.StatusOK, code)
+	shouldBe.True(result.Routes[0].RouteAllowed, "Routes must be checked by permissions of own server")
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/lib/pq"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"time"
+)
+
+type (
+	PreOrderRouter struct {
+		service model.PreOrderService
+	}
+
+	preOrderPriceDTO struct {
+		Currency string  `json:"currency" validate:"required"`
+		Price    float32 `json:"price" validate:"min=0"`
+	}
+
+	preOrderDTO struct {
+		Enabled       bool               `json:"enabled"`
+		DateStart     time.Time          `json:"dateStart"`
+		Prices        []preOrderPriceDTO `json:"prices" validate:"dive"`
+		BonusProducts []uuid.UUID        `json:"bonusProducts"`
+		// Read-only fields
+		ReleaseDate time.Time          `json:"releaseDate"`
+		ReleasedAt  *time.Time         `json:"releasedAt"`
+		State       model.PackageState `json:"state"`
+	}
+)
+
+// InitPreOrderRouter is initialization method for pre-order settings of packages
+func InitPreOrderRouter(group *echo.Group, routes *rbac_echo.Routes, service model.PreOrderService) (*PreOrderRouter, error) {
+	router := PreOrderRouter{service: service}
+
+	packageGroup := routes.Group(group, "/packages", &router, []string{"packageId", model.PackageType, model.VendorDomain})
+	packageGroup.GET("/:packageId/preorder", router.get, nil)
+	packageGroup.PUT("/:packageId/preorder", router.put, nil)
+
+	return &router, nil
+}
+
+func (router *PreOrderRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return GetOwnerForPackage(ctx)
+}
+
+func (router *PreOrderRouter) get(ctx echo.Context) error {
+	packageId, err := uuid.FromString(ctx.Param("packageId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	preOrder, err := router.service.Get(packageId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapPreOrderDto(preOrder))
+}
+
+func (router *PreOrderRouter) put(ctx echo.Context) error {
+	packageId, err := uuid.FromString(ctx.Param("packageId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	dto := new(preOrderDTO)
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	preOrder := &model.PreOrder{
+		PackageID:     packageId,
+		Enabled:       dto.Enabled,
+		DateStart:     dto.DateStart,
+		Prices:        model.PreOrderPrices{},
+		BonusProducts: pq.StringArray{},
+	}
+	for _, p := range dto.Prices {
+		preOrder.Prices = append(preOrder.Prices, model.PreOrderPrice{Currency: p.Currency, Price: p.Price})
+	}
+	for _, id := range dto.BonusProducts {
+		preOrder.BonusProducts = append(preOrder.BonusProducts, id.String())
+	}
+
+	result, err := router.service.Update(preOrder)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapPreOrderDto(result))
+}
+
+func mapPreOrderDto(preOrder *model.PreOrder) preOrderDTO {
+	dto := preOrderDTO{
+		Enabled:       preOrder.Enabled,
+		DateStart:     preOrder.DateStart,
+		Prices:        []preOrderPriceDTO{},
+		BonusProducts: []uuid.UUID{},
+		ReleaseDate:   preOrder.ReleaseDate,
+		ReleasedAt:    preOrder.ReleasedAt,
+		State:         preOrder.State(time.Now().UTC()),
+	}
+	for _, p := range preOrder.Prices {
+		dto.Prices = append(dto.Prices, preOrderPriceDTO{Currency: p.Currency, Price: p.Price})
+	}
+	for _, id := range preOrder.BonusProducts {
+		dto.BonusProducts = append(dto.BonusProducts, uuid.FromStringOrNil(id))
+	}
+	return dto
+}
@@ -5,12 +5,14 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/satori/go.uuid"
 	"net/http"
+	"qilin-api/pkg/api/context"
 	"qilin-api/pkg/api/rbac_echo"
 	"qilin-api/pkg/mapper"
 	"qilin-api/pkg/model"
 	"qilin-api/pkg/orm"
 	"qilin-api/pkg/utils"
 	"strings"
+	"time"
 )
 
 type (
@@ -40,20 +42,46 @@ type (
 		Currency        string `json:"currency" validate:"required"`
 		NotifyRateJumps bool   `json:"notifyRateJumps"`
 	}
+
+	priceChangeDTO struct {
+		Date     time.Time   `json:"date"`
+		UserID   string      `json:"userId"`
+		OldPrice *float32    `json:"oldPrice"`
+		NewPrice *float32    `json:"newPrice"`
+		OldVat   *int32      `json:"oldVat"`
+		NewVat   *int32      `json:"newVat"`
+		OldBase  model.JSONB `json:"oldBase,omitempty"`
+		NewBase  model.JSONB `json:"newBase,omitempty"`
+	}
+
+	priceTimelineDTO struct {
+		Currency string           `json:"currency"`
+		Current  *float32         `json:"current"`
+		Lowest   *float32         `json:"lowest30Days"`
+		Changes  []priceChangeDTO `json:"changes"`
+	}
+
+	priceHistoryDTO struct {
+		At          time.Time          `json:"at"`
+		Prices      []priceTimelineDTO `json:"prices"`
+		BaseChanges []priceChangeDTO   `json:"baseChanges"`
+	}
 )
 
 //InitPriceRouter is initialization method for group
-func InitPriceRouter(group *echo.Group, service model.PriceService, gameService model.GameService) (router *PriceRouter, err error) {
+func InitPriceRouter(group *echo.Group, routes *rbac_echo.Routes, service model.PriceService, gameService model.GameService) (router *PriceRouter, err error) {
 	priceRouter := PriceRouter{service, gameService}
 
-	packageGroup := rbac_echo.Group(group, "/packages", &priceRouter, []string{"packageId", model.PackageType, model.VendorDomain})
+	packageGroup := routes.Group(group, "/packages", &priceRouter, []string{"packageId", model.PackageType, model.VendorDomain})
 	packageGroup.GET("/:packageId/prices", priceRouter.getBase, nil)
+	packageGroup.GET("/:packageId/prices/history", priceRouter.getHistory, nil)
 	packageGroup.PUT("/:packageId/prices", priceRouter.putBase, nil)
 	packageGroup.PUT("/:packageId/prices/:currency", priceRouter.updatePrice, nil)
 	packageGroup.DELETE("/:packageId/prices/:currency", priceRouter.deletePrice, nil)
 
-	gameGroup := rbac_echo.Group(group, "/games", &priceRouter, []string{"gameId", model.GameType, model.VendorDomain})
+	gameGroup := routes.Group(group, "/games", &priceRouter, []string{"gameId", model.GameType, model.VendorDomain})
 	gameGroup.GET("/:gameId/prices", priceRouter.getBase, nil)
+	gameGroup.GET("/:gameId/prices/history", priceRouter.getHistory, nil)
 	gameGroup.PUT("/:gameId/prices", priceRouter.putBase, nil)
 	gameGroup.PUT("/:gameId/prices/:currency", priceRouter.updatePrice, nil)
 	gameGroup.DELETE("/:gameId/prices/:currency", priceRouter.deletePrice, nil)
@@ -140,7 +168,12 @@ func (router *PriceRouter) putBase(ctx echo.Context) error {
 		return orm.NewServiceError(http.StatusBadRequest, err)
 	}
 
-	if err := router.service.UpdateBase(id, &basePrice); err != nil {
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := router.service.UpdateBase(userId, id, &basePrice); err != nil {
 		return err
 	}
 
@@ -159,9 +192,14 @@ func (router *PriceRouter) deletePrice(ctx echo.Context) error {
 		return orm.NewServiceError(http.StatusBadRequest, fmt.Sprintf("Wrong currency %s", cur))
 	}
 
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
 	price := model.Price{Currency: cur}
 
-	if err := router.service.Delete(id, &price); err != nil {
+	if err := router.service.Delete(userId, id, &price); err != nil {
 		return err
 	}
 
@@ -205,9 +243,68 @@ func (router *PriceRouter) updatePrice(ctx echo.Context) error {
 		return orm.NewServiceError(http.StatusUnprocessableEntity, fmt.Sprintf("Wrong currency %s", cur))
 	}
 
-	if err := router.service.Update(id, &price); err != nil {
+	userId, err := context.GetAuthUserId(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := router.service.Update(userId, id, &price); err != nil {
 		return err
 	}
 
 	return ctx.String(http.StatusOK, "")
 }
+
+func (router *PriceRouter) getHistory(ctx echo.Context) error {
+	id, err := router.GetPackageID(&ctx)
+	if err != nil {
+		return err
+	}
+
+	cur := ctx.QueryParam("currency")
+	if cur != "" && utils.IsCurrency(cur) == false {
+		return orm.NewServiceError(http.StatusBadRequest, fmt.Sprintf("Wrong currency %s", cur))
+	}
+
+	at := time.Now().UTC()
+	if atParam := ctx.QueryParam("at"); atParam != "" {
+		at, err = time.Parse(time.RFC3339, atParam)
+		if err != nil {
+			return orm.NewServiceErrorf(http.StatusBadRequest, "Invalid time `%s`, RFC3339 expected", atParam)
+		}
+	}
+
+	history, err := router.service.GetHistory(id, cur, at)
+	if err != nil {
+		return err
+	}
+
+	result := priceHistoryDTO{At: history.At, Prices: []priceTimelineDTO{}, BaseChanges: mapPriceChangesDto(history.BaseChanges)}
+	for _, timeline := range history.Prices {
+		result.Prices = append(result.Prices, priceTimelineDTO{
+			Currency: timeline.Currency,
+			Current:  timeline.Current,
+			Lowest:   timeline.Lowest,
+			Changes:  mapPriceChangesDto(timeline.Changes),
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func mapPriceChangesDto(changes []model.PriceChange) []priceChangeDTO {
+	result := []priceChangeDTO{}
+	for _, change := range changes {
+		result = append(result, priceChangeDTO{
+			Date:     change.CreatedAt,
+			UserID:   change.UserID,
+			OldPrice: change.OldPrice,
+			NewPrice: change.NewPrice,
+			OldVat:   change.OldVat,
+			NewVat:   change.NewVat,
+			OldBase:  change.OldBase,
+			NewBase:  change.NewBase,
+		})
+	}
+	return result
+}
@@ -2,10 +2,13 @@ package api
 
 import (
 	"fmt"
+	"github.com/ProtocolONE/authone-jwt-verifier-golang"
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 	"net/http"
 	"net/http/httptest"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/api/rbac_echo"
 	"qilin-api/pkg/model"
 	"qilin-api/pkg/model/utils"
 	"qilin-api/pkg/orm"
@@ -101,7 +104,7 @@ func (suite *PriceRouterTestSuite) SetupTest() {
 
 	echoObj := echo.New()
 	service := orm.NewPriceService(db)
-	router, err := InitPriceRouter(echoObj.Group("/api/v1"), service, gameService)
+	router, err := InitPriceRouter(echoObj.Group("/api/v1"), rbac_echo.NewRoutes(echoObj), service, gameService)
 	require.Nil(suite.T(), err, "Unable to make price service")
 
 	echoObj.Validator = &QilinValidator{validator: validator.New()}
@@ -125,6 +128,7 @@ func (suite *PriceRouterTestSuite) TestGetBasePriceShouldReturnEmptyObject() {
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := suite.echo.NewContext(req, rec)
+	c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: userId})
 	c.SetPath("/api/v1/packages/:packageId/prices")
 	c.SetParamNames("packageId")
 	c.SetParamValues(packagePriceId)
@@ -141,6 +145,7 @@ func (suite *PriceRouterTestSuite) TestPutBasePriceShouldReturnOk() {
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := suite.echo.NewContext(req, rec)
+	c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: userId})
 	c.SetPath("/api/v1/packages/:packageId/prices")
 	c.SetParamNames("packageId")
 	c.SetParamValues(packagePriceId)
@@ -157,6 +162,7 @@ func (suite *PriceRouterTestSuite) TestPutPriceShouldReturnOk() {
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := suite.echo.NewContext(req, rec)
+	c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: userId})
 	c.SetPath("/api/v1/packages/:packageId/prices/:currency")
 	c.SetParamNames("packageId", "currency")
 	c.SetParamValues(packagePriceId, "USD")
@@ -173,6 +179,7 @@ func (suite *PriceRouterTestSuite) TestPutPriceShouldReturnBadRequest() {
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := suite.echo.NewContext(req, rec)
+	c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: userId})
 	c.SetPath("/api/v1/packages/:packageId/prices/:currency")
 	c.SetParamNames("packageId", "currency")
 	c.SetParamValues(packagePriceId, "USD")
@@ -186,6 +193,7 @@ func (suite *PriceRouterTestSuite) TestPutWithIncorrectCurrencyPriceShouldReturn
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := suite.echo.NewContext(req, rec)
+	c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: userId})
 	c.SetPath("/api/v1/packages/:packageId/prices/:currency")
 	c.SetParamNames("packageId", "currency")
 	c.SetParamValues(packagePriceId, "EUR")
@@ -199,6 +207,7 @@ func (suite *PriceRouterTestSuite) TestPutBadModelShouldReturn422() {
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := suite.echo.NewContext(req, rec)
+	c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: userId})
 	c.SetPath("/api/v1/packages/:packageId/prices")
 	c.SetParamNames("packageId")
 	c.SetParamValues(packagePriceId)
@@ -215,6 +224,7 @@ func (suite *PriceRouterTestSuite) TestPutWithUnknownIdModelShouldReturnNotFound
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := suite.echo.NewContext(req, rec)
+	c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: userId})
 	c.SetPath("/api/v1/packages/:packageId/prices")
 	c.SetParamNames("packageId") /// with 0000 tests not pass(
 	c.SetParamValues("00000000-0000-8000-0000-000000000000")
@@ -231,6 +241,7 @@ func (suite *PriceRouterTestSuite) TestPutWithBadIdModelShouldReturnBadRequest()
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := suite.echo.NewContext(req, rec)
+	c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: userId})
 	c.SetPath("/api/v1/packages/:packageId/prices")
 	c.SetParamNames("packageId")
 	c.SetParamValues("0000")
@@ -245,6 +256,7 @@ func (suite *PriceRouterTestSuite) TestDeleteUnknownCurrencyShouldReturnError()
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := suite.echo.NewContext(req, rec)
+	c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: userId})
 	c.SetPath("/api/v1/packages/:packageId/prices/:currency")
 	c.SetParamNames("packageId", "currency")
 	c.SetParamValues(packagePriceId, "XXX")
@@ -263,6 +275,7 @@ func (suite *PriceRouterTestSuite) TestPutBasePriceWihhUnknownCurrencyShouldRetu
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := suite.echo.NewContext(req, rec)
+	c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: userId})
 	c.SetPath("/api/v1/packages/:packageId/prices/")
 	c.SetParamNames("packageId")
 	c.SetParamValues(packagePriceId)
@@ -293,6 +306,7 @@ func (suite *PriceRouterTestSuite) TestPutBadObjectsShouldReturnError() {
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
 		c := suite.echo.NewContext(req, rec)
+		c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: userId})
 		c.SetPath("/api/v1/packages/:packageId/prices/:currency")
 		c.SetParamNames("packageId", "currency")
 		c.SetParamValues(packagePriceId, "USD")
@@ -312,6 +326,7 @@ func (suite *PriceRouterTestSuite) TestPutUnknownCurrencyShouldReturnError() {
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := suite.echo.NewContext(req, rec)
+	c.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: userId})
 	c.SetPath("/api/v1/packages/:packageId/prices/:currency")
 	c.SetParamNames("packageId", "currency")
 	c.SetParamValues(packagePriceId, "XXX")
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"strings"
+	"time"
+)
+
+type (
+	PricingRouter struct {
+		service    model.PricingService
+		taxService model.TaxService
+	}
+
+	priceTaxDTO struct {
+		Country   string  `json:"country"`
+		Name      string  `json:"name"`
+		Rate      float64 `json:"rate"`
+		Inclusive bool    `json:"inclusive"`
+		Net       float32 `json:"net"`
+		Tax       float32 `json:"tax"`
+		Gross     float32 `json:"gross"`
+	}
+
+	resolvedPriceDTO struct {
+		ID         uuid.UUID          `json:"id"`
+		Currency   string             `json:"currency"`
+		Price      float32            `json:"price"`
+		FinalPrice float32            `json:"finalPrice"`
+		Discount   float32            `json:"discount"`
+		DiscountID *uuid.UUID         `json:"discountId,omitempty"`
+		PreOrder   bool               `json:"preOrder"`
+		Tax        *priceTaxDTO       `json:"tax,omitempty"`
+		Packages   []resolvedPriceDTO `json:"packages,omitempty"`
+	}
+
+	pricePreviewDTO struct {
+		At time.Time `json:"at"`
+		resolvedPriceDTO
+	}
+)
+
+// InitPricingRouter is initialization method for price preview routes
+func InitPricingRouter(group *echo.Group, routes *rbac_echo.Routes, service model.PricingService, taxService model.TaxService) (*PricingRouter, error) {
+	router := PricingRouter{service: service, taxService: taxService}
+
+	packageGroup := routes.Group(group, "/packages", &router, []string{"packageId", model.PackageType, model.VendorDomain})
+	packageGroup.GET("/:packageId/prices/preview", router.previewPackage, nil)
+
+	bundleGroup := routes.Group(group, "/bundles", &router, []string{"bundleId", model.RoleBundle, model.VendorDomain})
+	bundleGroup.GET("/:bundleId/prices/preview", router.previewBundle, nil)
+
+	return &router, nil
+}
+
+func (router *PricingRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	if strings.Contains(ctx.Path(), "/bundles/:bundleId") {
+		return GetOwnerForBundle(ctx)
+	}
+	return GetOwnerForPackage(ctx)
+}
+
+func (router *PricingRouter) previewPackage(ctx echo.Context) error {
+	packageId, err := uuid.FromString(ctx.Param("packageId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	at, err := getPreviewTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	price, err := router.service.GetPackagePrice(packageId, ctx.QueryParam("currency"), at)
+	if err != nil {
+		return err
+	}
+
+	if err := router.applyTax(ctx, price); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, pricePreviewDTO{At: at, resolvedPriceDTO: mapResolvedPriceDto(price)})
+}
+
+func (router *PricingRouter) previewBundle(ctx echo.Context) error {
+	bundleId, err := uuid.FromString(ctx.Param("bundleId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	at, err := getPreviewTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	price, err := router.service.GetBundlePrice(bundleId, ctx.QueryParam("currency"), at)
+	if err != nil {
+		return err
+	}
+
+	if err := router.applyTax(ctx, price); err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, pricePreviewDTO{At: at, resolvedPriceDTO: mapResolvedPriceDto(price)})
+}
+
+// applyTax calculates tax for buyer country from `country` query param, tax is omitted if param is empty
+func (router *PricingRouter) applyTax(ctx echo.Context, price *model.ResolvedPrice) error {
+	country := strings.ToUpper(ctx.QueryParam("country"))
+	if country == "" {
+		return nil
+	}
+	return router.taxService.ApplyTax(price, country)
+}
+
+// getPreviewTime returns moment from `at` query param, current time is used if param is omitted
+func getPreviewTime(ctx echo.Context) (time.Time, error) {
+	atParam := ctx.QueryParam("at")
+	if atParam == "" {
+		return time.Now().UTC(), nil
+	}
+
+	at, err := time.Parse(time.RFC3339, atParam)
+	if err != nil {
+		return time.Time{}, orm.NewServiceErrorf(http.StatusBadRequest, "Invalid time `%s`, RFC3339 expected", atParam)
+	}
+
+	return at, nil
+}
+
+func mapResolvedPriceDto(price *model.ResolvedPrice) resolvedPriceDTO {
+	dto := resolvedPriceDTO{
+		ID:         price.ID,
+		Currency:   price.Currency,
+		Price:      price.Price,
+		FinalPrice: price.FinalPrice,
+		Discount:   price.Discount,
+		DiscountID: price.DiscountID,
+		PreOrder:   price.PreOrder,
+	}
+	if price.Tax != nil {
+		dto.Tax = &priceTaxDTO{
+			Country:   price.Tax.Country,
+			Name:      price.Tax.Name,
+			Rate:      price.Tax.Rate,
+			Inclusive: price.Tax.Inclusive,
+			Net:       price.Tax.Net,
+			Tax:       price.Tax.Tax,
+			Gross:     price.Tax.Gross,
+		}
+	}
+	for i := range price.Packages {
+		dto.Packages = append(dto.Packages, mapResolvedPriceDto(&price.Packages[i]))
+	}
+	return dto
+}
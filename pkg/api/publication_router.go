@@ -0,0 +1,153 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"strconv"
+	"time"
+)
+
+type (
+	PublicationRouter struct {
+		service model.PublicationService
+	}
+
+	gameSnapshotInfoDTO struct {
+		GameID       uuid.UUID `json:"gameId"`
+		Version      int       `json:"version"`
+		CreatedAt    time.Time `json:"createdAt"`
+		CreatorID    string    `json:"creatorId"`
+		RestoredFrom *int      `json:"restoredFrom,omitempty"`
+	}
+
+	gameSnapshotDTO struct {
+		gameSnapshotInfoDTO
+		Game     storeGameDTO           `json:"game"`
+		Packages model.SnapshotPackages `json:"packages"`
+	}
+
+	snapshotChangeDTO struct {
+		Path string      `json:"path"`
+		Old  interface{} `json:"old"`
+		New  interface{} `json:"new"`
+	}
+
+	snapshotDiffDTO struct {
+		Version int                 `json:"version"`
+		Changes []snapshotChangeDTO `json:"changes"`
+	}
+)
+
+// InitPublicationRouter is initialization method for published versions of games
+func InitPublicationRouter(group *echo.Group, routes *rbac_echo.Routes, service model.PublicationService) (*PublicationRouter, error) {
+	router := PublicationRouter{service: service}
+
+	r := routes.Group(group, "/games", &router, []string{"gameId", model.GameType, model.VendorDomain})
+	r.GET("/:gameId/publications", router.getList, nil)
+	r.GET("/:gameId/publications/diff", router.diff, nil)
+	r.GET("/:gameId/publications/:version", router.get, nil)
+
+	return &router, nil
+}
+
+func (router *PublicationRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return GetOwnerForGame(ctx)
+}
+
+func (router *PublicationRouter) getList(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	snapshots, err := router.service.GetList(gameId)
+	if err != nil {
+		return err
+	}
+
+	result := []gameSnapshotInfoDTO{}
+	for i := range snapshots {
+		result = append(result, mapGameSnapshotInfoDto(&snapshots[i]))
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *PublicationRouter) get(ctx echo.Context) error {
+	gameId, version, err := getPublicationParams(ctx)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := router.service.Get(gameId, version)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, gameSnapshotDTO{
+		gameSnapshotInfoDTO: mapGameSnapshotInfoDto(snapshot),
+		Game:                mapStoreGameDto(&snapshot.Game, snapshot.CreatedAt),
+		Packages:            snapshot.Packages,
+	})
+}
+
+// diff compares current data of game with version from `version` query param, the latest version is used if param is omitted
+func (router *PublicationRouter) diff(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	version := 0
+	if param := ctx.QueryParam("version"); param != "" {
+		version, err = strconv.Atoi(param)
+		if err != nil || version <= 0 {
+			return orm.NewServiceErrorf(http.StatusBadRequest, "Invalid version `%s`", param)
+		}
+	}
+
+	snapshot, err := router.service.Get(gameId, version)
+	if err != nil {
+		return err
+	}
+
+	changes, err := router.service.Diff(gameId, snapshot.Version)
+	if err != nil {
+		return err
+	}
+
+	result := snapshotDiffDTO{Version: snapshot.Version, Changes: []snapshotChangeDTO{}}
+	for _, change := range changes {
+		result.Changes = append(result.Changes, snapshotChangeDTO{Path: change.Path, Old: change.Old, New: change.New})
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func getPublicationParams(ctx echo.Context) (uuid.UUID, int, error) {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return uuid.Nil, 0, orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil || version <= 0 {
+		return uuid.Nil, 0, orm.NewServiceErrorf(http.StatusBadRequest, "Invalid version `%s`", ctx.Param("version"))
+	}
+
+	return gameId, version, nil
+}
+
+func mapGameSnapshotInfoDto(snapshot *model.GameSnapshot) gameSnapshotInfoDTO {
+	return gameSnapshotInfoDTO{
+		GameID:       snapshot.GameID,
+		Version:      snapshot.Version,
+		CreatedAt:    snapshot.CreatedAt,
+		CreatorID:    snapshot.CreatorID,
+		RestoredFrom: snapshot.RestoredFrom,
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/orm"
+	"sync"
+	"time"
+)
+
+// userRateLimiter allows at most `limit` requests of the same user per fixed time window
+type userRateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	started time.Time
+	counts  map[string]int
+}
+
+func newUserRateLimiter(limit int, window time.Duration) *userRateLimiter {
+	return &userRateLimiter{limit: limit, window: window, counts: map[string]int{}}
+}
+
+// allow counts request of user and reports whether it fits into limit of current window
+func (limiter *userRateLimiter) allow(userId string, now time.Time) bool {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	// counters of all users are dropped at once when window is over, so map doesn't grow with users
+	if now.Sub(limiter.started) >= limiter.window {
+		limiter.started = now
+		limiter.counts = map[string]int{}
+	}
+
+	if limiter.counts[userId] >= limiter.limit {
+		return false
+	}
+	limiter.counts[userId]++
+	return true
+}
+
+// Middleware rejects requests of authorized user with 429 when limit is exceeded, zero limit disables limiter
+func (limiter *userRateLimiter) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		if limiter.limit <= 0 {
+			return next(ctx)
+		}
+
+		userId, err := context.GetAuthUserId(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !limiter.allow(userId, time.Now()) {
+			return orm.NewServiceError(http.StatusTooManyRequests, "Too many requests, try again later")
+		}
+
+		return next(ctx)
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"github.com/ProtocolONE/authone-jwt-verifier-golang"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/orm"
+	"testing"
+	"time"
+)
+
+func TestUserRateLimiter(t *testing.T) {
+	should := require.New(t)
+	limiter := newUserRateLimiter(2, time.Minute)
+	now := time.Now()
+
+	should.True(limiter.allow("first", now))
+	should.True(limiter.allow("first", now))
+	should.False(limiter.allow("first", now), "Third request in window must be rejected")
+	should.True(limiter.allow("second", now), "Limit is counted per user")
+
+	should.True(limiter.allow("first", now.Add(time.Minute)), "Limit must be reset in next window")
+}
+
+func TestUserRateLimiterMiddleware(t *testing.T) {
+	should := require.New(t)
+	e := echo.New()
+	handler := newUserRateLimiter(1, time.Minute).Middleware(func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	call := func() error {
+		ctx := e.NewContext(httptest.NewRequest(http.MethodPost, "/api/v1/keys/validate", nil), httptest.NewRecorder())
+		ctx.Set(context.TokenKey, &jwtverifier.UserInfo{UserID: "user"})
+		return handler(ctx)
+	}
+
+	should.Nil(call())
+	err := call()
+	should.NotNil(err)
+	should.Equal(http.StatusTooManyRequests, err.(*orm.ServiceError).Code)
+}
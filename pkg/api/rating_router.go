@@ -35,12 +35,12 @@ type (
 )
 
 //InitRatingsRouter is initialization method for group
-func InitRatingsRouter(group *echo.Group, service *orm.RatingService) (*RatingsRouter, error) {
+func InitRatingsRouter(group *echo.Group, routes *rbac_echo.Routes, service *orm.RatingService) (*RatingsRouter, error) {
 	ratingRouter := RatingsRouter{
 		service: service,
 	}
 
-	r := rbac_echo.Group(group, "/games/:gameId", &ratingRouter, []string{"gameId", model.GameType, model.VendorDomain})
+	r := routes.Group(group, "/games/:gameId", &ratingRouter, []string{"gameId", model.GameType, model.VendorDomain})
 
 	r.GET("/ratings", ratingRouter.get, nil)
 	r.PUT("/ratings", ratingRouter.put, nil)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"qilin-api/pkg/api/rbac_echo"
 	"qilin-api/pkg/model"
 	"qilin-api/pkg/model/utils"
 	"qilin-api/pkg/orm"
@@ -74,7 +75,7 @@ func (suite *RatingRouterTestSuite) SetupTest() {
 
 	e := echo.New()
 	service, err := orm.NewRatingService(db)
-	router, err := InitRatingsRouter(e.Group("/api/v1"), service)
+	router, err := InitRatingsRouter(e.Group("/api/v1"), rbac_echo.NewRoutes(e), service)
 
 	validate := validator.New()
 	validate.RegisterStructValidation(RatingStructLevelValidation, RatingsDTO{})
@@ -50,50 +50,52 @@ func (c *AppContext) GetOwnerForBundle(uuid uuid.UUID) (string, error) {
 func CheckPermissions(group *RbacGroup, router Router) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			qilinCtx := c.(AppContext)
-
-			paths := group.paths
-			path := c.Path()
-			perm, ok := paths[path]
-			if !ok {
-				return orm.NewServiceErrorf(http.StatusForbidden, "Could not map `%s` in paths", path)
-			}
-
-			owner, err := router.GetOwner(qilinCtx)
-			if err != nil {
+			if err := checkPermissions(c.(AppContext), group, router); err != nil {
 				return err
 			}
 
-			userId, err := context.GetAuthUserId(c)
-			if err != nil {
-				return err
-			}
+			return next(c)
+		}
+	}
+}
 
-			resourceId := "*"
-			if perm[0] != "*" {
-				resourceId = c.Param(perm[0])
-			}
+// checkPermissions enforces permissions of route matched by context for authenticated user
+func checkPermissions(qilinCtx AppContext, group *RbacGroup, router Router) error {
+	paths := group.paths
+	path := qilinCtx.Path()
+	perm, ok := paths[path]
+	if !ok {
+		return orm.NewServiceErrorf(http.StatusForbidden, "Could not map `%s` in paths", path)
+	}
 
-			action := "any"
-			method := c.Request().Method
-			switch method {
-			case echo.GET:
-				action = "read"
-			case echo.PUT:
-			case echo.POST:
-			case echo.PATCH:
-			case echo.DELETE:
-				action = "write"
-			}
+	owner, err := router.GetOwner(qilinCtx)
+	if err != nil {
+		return err
+	}
 
-			err = qilinCtx.CheckPermissions(userId, perm[2], perm[1], resourceId, owner, action)
-			if err != nil {
-				return err
-			}
+	userId, err := context.GetAuthUserId(qilinCtx)
+	if err != nil {
+		return err
+	}
 
-			return next(c)
-		}
+	resourceId := "*"
+	if perm[0] != "*" {
+		resourceId = qilinCtx.Param(perm[0])
 	}
+
+	action := "any"
+	method := qilinCtx.Request().Method
+	switch method {
+	case echo.GET:
+		action = "read"
+	case echo.PUT:
+	case echo.POST:
+	case echo.PATCH:
+	case echo.DELETE:
+		action = "write"
+	}
+
+	return qilinCtx.CheckPermissions(userId, perm[2], perm[1], resourceId, owner, action)
 }
 
 func NewAppContextMiddleware(ownerProvider model.OwnerProvider, enf *rbac.Enforcer) echo.MiddlewareFunc {
@@ -0,0 +1,75 @@
+package rbac_echo
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/orm"
+	"strings"
+	"sync"
+)
+
+// Routes keeps rbac groups of one echo instance by method and path of their routes, so permissions of route can be
+// checked without calling it.
+type Routes struct {
+	sync.RWMutex
+	echo   *echo.Echo
+	groups map[string]*RbacGroup
+}
+
+func NewRoutes(e *echo.Echo) *Routes {
+	return &Routes{echo: e, groups: map[string]*RbacGroup{}}
+}
+
+// Group creates rbac group of routes registered in the registry
+func (r *Routes) Group(group *echo.Group, prefix string, router Router, permissions []string, middleware ...echo.MiddlewareFunc) *RbacGroup {
+	g := &RbacGroup{router: router, routes: r}
+	g.paths = map[string][]string{}
+	m := make([]echo.MiddlewareFunc, 0)
+	m = append(m, CheckPermissions(g, router))
+	m = append(m, middleware...)
+	g.group = group.Group(prefix, m...)
+	g.permissions = permissions
+	return g
+}
+
+func (r *Routes) register(route *echo.Route, group *RbacGroup) {
+	r.Lock()
+	defer r.Unlock()
+	r.groups[route.Method+" "+route.Path] = group
+}
+
+func (r *Routes) find(method, path string) (*RbacGroup, bool) {
+	r.RLock()
+	defer r.RUnlock()
+	group, ok := r.groups[method+" "+path]
+	return group, ok
+}
+
+// Check checks whether authenticated user of context is allowed to call method with path. Route is matched by
+// router of echo and checked by the same middleware as real request, nil is returned if request is allowed.
+// Only route level permissions are checked, checks made by handlers (e.g. permissions on products of package or
+// ownership of key package) depend on request and aren't covered.
+func (r *Routes) Check(ctx AppContext, method string, path string) error {
+	method = strings.ToUpper(method)
+	req, err := http.NewRequest(method, path, nil)
+	if err != nil {
+		return orm.NewServiceErrorf(http.StatusBadRequest, "Bad path `%s`", path)
+	}
+
+	routeCtx := r.echo.NewContext(req, nil)
+	routePath := req.URL.Path
+	if len(routePath) > 1 {
+		routePath = strings.TrimSuffix(routePath, "/")
+	}
+	r.echo.Router().Find(method, routePath, routeCtx)
+
+	group, ok := r.find(method, routeCtx.Path())
+	if !ok {
+		return orm.NewServiceErrorf(http.StatusNotFound, "Route `%s %s` not found", method, path)
+	}
+
+	routeCtx.Set(context.TokenKey, ctx.Get(context.TokenKey))
+
+	return checkPermissions(AppContext{Context: routeCtx, enf: ctx.enf, ownerProvider: ctx.ownerProvider}, group, group.router)
+}
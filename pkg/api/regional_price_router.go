@@ -0,0 +1,173 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/context"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"strings"
+	"time"
+)
+
+type (
+	RegionalPriceRouter struct {
+		service model.RegionalPriceService
+	}
+
+	roundingRuleDTO struct {
+		Step   float64 `json:"step" validate:"required,gt=0"`
+		Ending float64 `json:"ending" validate:"min=0"`
+	}
+
+	regionalPriceRequestDTO struct {
+		BasePrice             float32                    `json:"basePrice" validate:"min=0"`
+		Currencies            []string                   `json:"currencies"`
+		IgnorePurchasingPower bool                       `json:"ignorePurchasingPower"`
+		Rounding              map[string]roundingRuleDTO `json:"rounding" validate:"dive"`
+	}
+
+	regionalPriceDTO struct {
+		Currency  string   `json:"currency"`
+		Current   *float32 `json:"current"`
+		Suggested float32  `json:"suggested"`
+	}
+
+	regionalPricesDTO struct {
+		BaseCurrency string             `json:"baseCurrency"`
+		Applied      bool               `json:"applied"`
+		Prices       []regionalPriceDTO `json:"prices"`
+	}
+
+	currencyRateDTO struct {
+		Currency        string          `json:"currency"`
+		Rate            float64         `json:"rate" validate:"required,gt=0"`
+		PurchasingPower float64         `json:"purchasingPower" validate:"required,gt=0"`
+		Rounding        roundingRuleDTO `json:"rounding" validate:"required,dive"`
+		UpdatedAt       time.Time       `json:"updatedAt"`
+	}
+)
+
+// InitRegionalPriceRouter is initialization method for regional price recommendations and currency rates
+func InitRegionalPriceRouter(group *echo.Group, adminGroup *echo.Group, routes *rbac_echo.Routes, service model.RegionalPriceService) (*RegionalPriceRouter, error) {
+	router := RegionalPriceRouter{service: service}
+
+	packageGroup := routes.Group(group, "/packages", &router, []string{"packageId", model.PackageType, model.VendorDomain})
+	packageGroup.POST("/:packageId/prices/recommendations", router.recommend, nil)
+	packageGroup.POST("/:packageId/prices/recommendations/apply", router.apply, nil)
+
+	ratesGroup := routes.Group(adminGroup, "/currencies", &router, []string{"*", model.AdminCurrencyRatesType, model.VendorDomain})
+	ratesGroup.GET("/rates", router.getRates, nil)
+	ratesGroup.PUT("/rates/:currency", router.updateRate, nil)
+
+	return &router, nil
+}
+
+func (router *RegionalPriceRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	if strings.Contains(ctx.Path(), "/packages/:packageId") {
+		return GetOwnerForPackage(ctx)
+	}
+	return "*", nil
+}
+
+func (router *RegionalPriceRouter) recommend(ctx echo.Context) error {
+	return router.generate(ctx, false)
+}
+
+func (router *RegionalPriceRouter) apply(ctx echo.Context) error {
+	return router.generate(ctx, true)
+}
+
+func (router *RegionalPriceRouter) generate(ctx echo.Context, apply bool) error {
+	packageId, err := uuid.FromString(ctx.Param("packageId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	dto := new(regionalPriceRequestDTO)
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	request := &model.RegionalPriceRequest{
+		BasePrice:             dto.BasePrice,
+		Currencies:            dto.Currencies,
+		IgnorePurchasingPower: dto.IgnorePurchasingPower,
+		Rounding:              map[string]model.RoundingRule{},
+	}
+	for currency, rule := range dto.Rounding {
+		request.Rounding[currency] = model.RoundingRule{Step: rule.Step, Ending: rule.Ending}
+	}
+
+	var baseCurrency string
+	var prices []model.RegionalPrice
+	if apply {
+		userId, authErr := context.GetAuthUserId(ctx)
+		if authErr != nil {
+			return authErr
+		}
+		baseCurrency, prices, err = router.service.Apply(userId, packageId, request)
+	} else {
+		baseCurrency, prices, err = router.service.Recommend(packageId, request)
+	}
+	if err != nil {
+		return err
+	}
+
+	result := regionalPricesDTO{BaseCurrency: baseCurrency, Applied: apply, Prices: []regionalPriceDTO{}}
+	for _, p := range prices {
+		result.Prices = append(result.Prices, regionalPriceDTO{Currency: p.Currency, Current: p.Current, Suggested: p.Suggested})
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *RegionalPriceRouter) getRates(ctx echo.Context) error {
+	rates, err := router.service.GetRates()
+	if err != nil {
+		return err
+	}
+
+	result := []currencyRateDTO{}
+	for _, rate := range rates {
+		result = append(result, currencyRateDTO{
+			Currency:        rate.Currency,
+			Rate:            rate.Rate,
+			PurchasingPower: rate.PurchasingPower,
+			Rounding:        roundingRuleDTO{Step: rate.RoundingStep, Ending: rate.RoundingEnding},
+			UpdatedAt:       rate.UpdatedAt,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *RegionalPriceRouter) updateRate(ctx echo.Context) error {
+	dto := new(currencyRateDTO)
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	rate := &model.CurrencyRate{
+		Currency:        ctx.Param("currency"),
+		Rate:            dto.Rate,
+		PurchasingPower: dto.PurchasingPower,
+		RoundingStep:    dto.Rounding.Step,
+		RoundingEnding:  dto.Rounding.Ending,
+	}
+	if err := router.service.UpdateRate(rate); err != nil {
+		return err
+	}
+
+	return ctx.String(http.StatusOK, "")
+}
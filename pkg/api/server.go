@@ -1,8 +1,10 @@
 package api
 
 import (
+	"context"
 	"github.com/ProtocolONE/authone-jwt-verifier-golang"
 	jwt_middleware "github.com/ProtocolONE/authone-jwt-verifier-golang/middleware/echo"
+	"github.com/ProtocolONE/rabbitmq/pkg"
 	"github.com/ProtocolONE/rbac"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -15,6 +17,7 @@ import (
 	"qilin-api/pkg/sys"
 	"qilin-api/pkg/utils"
 	"strconv"
+	"time"
 )
 
 type ServerOptions struct {
@@ -25,8 +28,12 @@ type ServerOptions struct {
 	Notifier         sys.Notifier
 	CentrifugoSecret string
 	Enforcer         *rbac.Enforcer
+	RoleSyncInterval time.Duration
 	EventBus         *conf.EventBus
 	Imaginary        *conf.Imaginary
+	KeyBatch         *conf.KeyBatch
+	PreOrder         *conf.PreOrder
+	License          *conf.License
 }
 
 type Server struct {
@@ -36,11 +43,16 @@ type Server struct {
 	notifier         sys.Notifier
 	centrifugoSecret string
 	enforcer         *rbac.Enforcer
+	roleSyncInterval time.Duration
 	eventBusConfig   *conf.EventBus
+	// stop is closed on shutdown to stop background jobs
+	stop           chan struct{}
+	catalogResyncs *CatalogResyncRouter
 
 	Router      *echo.Group
 	AdminRouter *echo.Group
 	AuthRouter  *echo.Group
+	StoreRouter *echo.Group
 }
 
 type QilinValidator struct {
@@ -59,7 +71,9 @@ func NewServer(opts *ServerOptions) (*Server, error) {
 		notifier:         opts.Notifier,
 		centrifugoSecret: opts.CentrifugoSecret,
 		enforcer:         opts.Enforcer,
+		roleSyncInterval: opts.RoleSyncInterval,
 		eventBusConfig:   opts.EventBus,
+		stop:             make(chan struct{}),
 	}
 
 	server.echo.HideBanner = true
@@ -105,8 +119,10 @@ func NewServer(opts *ServerOptions) (*Server, error) {
 	server.AdminRouter.Use(jwt_middleware.AuthOneJwtWithConfig(jwtv))
 	server.Router.Use(jwt_middleware.AuthOneJwtWithConfig(jwtv))
 	server.AuthRouter = server.echo.Group("/auth-api")
+	// Store catalog is public and contains only published data
+	server.StoreRouter = server.echo.Group("/store/api/v1")
 
-	if err := server.setupRoutes(ownerProvider, opts.Mailer, jwtv, opts.Imaginary); err != nil {
+	if err := server.setupRoutes(ownerProvider, opts.Mailer, jwtv, opts.Imaginary, opts.KeyBatch, opts.PreOrder, opts.License); err != nil {
 		zap.L().Fatal("Fail to setup routes", zap.Error(err))
 	}
 
@@ -119,32 +135,66 @@ func (s *Server) Start() error {
 	return s.echo.Start(":" + strconv.Itoa(s.serverConfig.Port))
 }
 
+// Shutdown stops background jobs and http server, running resyncs save their progress before it returns
+func (s *Server) Shutdown(ctx context.Context) error {
+	close(s.stop)
+	err := s.echo.Shutdown(ctx)
+	s.catalogResyncs.Wait()
+	return err
+}
+
 func (s *Server) setupRoutes(
 	ownerProvider model.OwnerProvider,
 	mailer sys.Mailer,
 	verifier *jwtverifier.JwtVerifier,
-	imaginary *conf.Imaginary) error {
+	imaginary *conf.Imaginary,
+	keyBatch *conf.KeyBatch,
+	preOrder *conf.PreOrder,
+	license *conf.License) error {
 
-	eventBus, err := orm.NewEventBus(s.db.DB(), s.eventBusConfig.Connection)
+	eventBus := orm.NewEventBus(s.db.DB())
+	routes := qilin_middleware.NewRoutes(s.echo)
 
+	broker, err := rabbitmq.NewBroker(s.eventBusConfig.Connection)
+	if err != nil {
+		return err
+	}
+	dispatcher := orm.NewOutboxDispatcher(s.db, broker, s.eventBusConfig.MaxAttempts, s.eventBusConfig.RetryDelay)
+	if s.eventBusConfig.DispatchInterval > 0 {
+		go orm.RunOutboxDispatcher(dispatcher, s.eventBusConfig.DispatchInterval, s.stop)
+	}
+	if _, err := InitOutboxRouter(s.AdminRouter, routes, orm.NewOutboxService(s.db)); err != nil {
+		return err
+	}
+	catalogResyncs, err := InitCatalogResyncRouter(s.AdminRouter, routes, orm.NewCatalogResyncService(s.db), s.stop)
+	if err != nil {
+		return err
+	}
+	s.catalogResyncs = catalogResyncs
+
+	notificationService, err := orm.NewNotificationService(s.db, s.notifier, s.centrifugoSecret)
 	if err != nil {
 		return err
 	}
 
 	keyStreamService := orm.NewKeyStreamService(s.db)
-	keyPackageService := orm.NewKeyPackageService(s.db, keyStreamService)
+	keyPackageService := orm.NewKeyPackageService(s.db, keyStreamService, notificationService)
 
-	if _, err := InitKeyPackageRouter(s.Router, keyPackageService); err != nil {
+	if _, err := InitKeyPackageRouter(s.Router, routes, keyPackageService); err != nil {
 		return err
 	}
 
 	keyListService := orm.NewKeyListService(s.db)
-	if _, err := InitKeyListRouter(s.Router, keyListService); err != nil {
+	if _, err := InitKeyListRouter(s.Router, routes, keyPackageService, keyListService); err != nil {
 		return err
 	}
 
-	notificationService, err := orm.NewNotificationService(s.db, s.notifier, s.centrifugoSecret)
-	if err != nil {
+	if _, err := InitLicenseRouter(s.Router, routes, orm.NewLicenseService(s.db), license.ActivationLimit, license.ActivationWindow); err != nil {
+		return err
+	}
+
+	keyBatchService := orm.NewKeyBatchService(s.db)
+	if _, err := InitKeyBatchRouter(s.Router, routes, keyPackageService, keyBatchService, keyBatch.Secret); err != nil {
 		return err
 	}
 
@@ -160,7 +210,7 @@ func (s *Server) setupRoutes(
 	if err != nil {
 		return err
 	}
-	if _, err := InitMediaRouter(s.Router, mediaService); err != nil {
+	if _, err := InitMediaRouter(s.Router, routes, mediaService); err != nil {
 		return err
 	}
 
@@ -168,7 +218,7 @@ func (s *Server) setupRoutes(
 	if err != nil {
 		return err
 	}
-	if _, err := InitRatingsRouter(s.Router, ratingService); err != nil {
+	if _, err := InitRatingsRouter(s.Router, routes, ratingService); err != nil {
 		return err
 	}
 
@@ -176,7 +226,7 @@ func (s *Server) setupRoutes(
 	if err != nil {
 		return err
 	}
-	if _, err := InitDiscountsRouter(s.Router, discountService); err != nil {
+	if _, err := InitDiscountsRouter(s.Router, routes, discountService); err != nil {
 		return err
 	}
 
@@ -184,7 +234,7 @@ func (s *Server) setupRoutes(
 	if err != nil {
 		return err
 	}
-	if _, err := InitClientOnboardingRouter(s.Router, clientOnboarding, notificationService); err != nil {
+	if _, err := InitClientOnboardingRouter(s.Router, routes, clientOnboarding, notificationService); err != nil {
 		return err
 	}
 
@@ -193,7 +243,18 @@ func (s *Server) setupRoutes(
 		return err
 	}
 
-	if _, err := InitClientMembershipRouter(s.Router, membershipService); err != nil {
+	if _, err := InitClientMembershipRouter(s.Router, routes, membershipService); err != nil {
+		return err
+	}
+
+	vendorRoleService := orm.NewVendorRoleService(s.db, s.enforcer)
+	if err := vendorRoleService.Init(); err != nil {
+		return err
+	}
+	if s.roleSyncInterval > 0 {
+		go orm.RunVendorRoleSync(vendorRoleService, s.roleSyncInterval, s.stop)
+	}
+	if _, err := InitVendorRoleRouter(s.Router, routes, vendorRoleService); err != nil {
 		return err
 	}
 
@@ -201,7 +262,7 @@ func (s *Server) setupRoutes(
 	if err != nil {
 		return err
 	}
-	if _, err := InitAdminOnboardingRouter(s.AdminRouter, adminClientOnboarding, notificationService); err != nil {
+	if _, err := InitAdminOnboardingRouter(s.AdminRouter, routes, adminClientOnboarding, notificationService); err != nil {
 		return err
 	}
 
@@ -210,7 +271,10 @@ func (s *Server) setupRoutes(
 		return err
 	}
 	priceService := orm.NewPriceService(s.db)
-	if _, err := InitPriceRouter(s.Router, priceService, gameService); err != nil {
+	if _, err := InitPriceRouter(s.Router, routes, priceService, gameService); err != nil {
+		return err
+	}
+	if _, err := InitRegionalPriceRouter(s.Router, s.AdminRouter, routes, orm.NewRegionalPriceService(s.db, priceService)); err != nil {
 		return err
 	}
 	packageService, err := orm.NewPackageService(s.db, gameService)
@@ -225,10 +289,42 @@ func (s *Server) setupRoutes(
 	if err != nil {
 		return err
 	}
-	if _, err := InitPackageRouter(s.Router, packageService, productService, priceService); err != nil {
+	if _, err := InitPackageRouter(s.Router, routes, packageService, productService, priceService); err != nil {
 		return err
 	}
-	if _, err := InitBundleRouter(s.Router, bundleService); err != nil {
+	if _, err := InitBundleRouter(s.Router, routes, bundleService); err != nil {
+		return err
+	}
+	if _, err := InitAvailabilityRouter(s.Router, routes, orm.NewAvailabilityService(packageService, bundleService)); err != nil {
+		return err
+	}
+	preOrderService := orm.NewPreOrderService(s.db, eventBus)
+	if _, err := InitPreOrderRouter(s.Router, routes, preOrderService); err != nil {
+		return err
+	}
+	if preOrder != nil && preOrder.CheckInterval > 0 {
+		go orm.RunPreOrderReleaser(preOrderService, preOrder.CheckInterval, s.stop)
+	}
+	taxService := orm.NewTaxService(s.db)
+	if _, err := InitTaxRouter(s.AdminRouter, routes, taxService); err != nil {
+		return err
+	}
+	pricingService := orm.NewPricingService(s.db, packageService, bundleService)
+	if _, err := InitPricingRouter(s.Router, routes, pricingService, taxService); err != nil {
+		return err
+	}
+	if _, err := InitStoreRouter(s.StoreRouter, orm.NewStoreCatalogService(s.db, pricingService), taxService); err != nil {
+		return err
+	}
+	publicationService := orm.NewPublicationService(s.db)
+	if _, err := InitPublicationRouter(s.Router, routes, publicationService); err != nil {
+		return err
+	}
+	gameReviewService := orm.NewGameReviewService(s.db, publicationService, notificationService)
+	if _, err := InitGameReviewRouter(s.Router, routes, gameReviewService); err != nil {
+		return err
+	}
+	if _, err := InitAdminGameReviewRouter(s.AdminRouter, routes, gameReviewService, publicationService); err != nil {
 		return err
 	}
 
@@ -237,11 +333,23 @@ func (s *Server) setupRoutes(
 		return err
 	}
 
-	if _, err := InitGameRoutes(s.Router, gameService, userService, eventBus); err != nil {
+	if _, err := InitGameRoutes(s.Router, routes, gameService, userService, eventBus); err != nil {
+		return err
+	}
+
+	if _, err := InitDlcRouter(s.Router, routes, orm.NewDlcService(s.db)); err != nil {
+		return err
+	}
+
+	if _, err := InitAchievementRouter(s.Router, routes, orm.NewAchievementService(s.db), eventBus); err != nil {
+		return err
+	}
+
+	if err := InitVendorRoutes(s.Router, routes, vendorService, userService); err != nil {
 		return err
 	}
 
-	if err := InitVendorRoutes(s.Router, vendorService, userService); err != nil {
+	if _, err := InitPermissionRouter(s.Router, routes); err != nil {
 		return err
 	}
 
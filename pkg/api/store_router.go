@@ -0,0 +1,309 @@
+package api
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/model/game"
+	"qilin-api/pkg/model/utils"
+	"qilin-api/pkg/orm"
+	pkg_utils "qilin-api/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// storeCacheMaxAge is lifetime of store catalog responses in shared caches, seconds
+const storeCacheMaxAge = 300
+
+// storeMaxPageSize is the largest page of games returned by store catalog, larger limits are clamped
+const storeMaxPageSize = 100
+
+type (
+	//StoreRouter is read-only public catalog of published games
+	StoreRouter struct {
+		service    model.StoreCatalogService
+		taxService model.TaxService
+	}
+
+	storeMediaDTO struct {
+		CoverImage  utils.LocalizedString      `json:"coverImage"`
+		CoverVideo  utils.LocalizedString      `json:"coverVideo"`
+		Trailers    utils.LocalizedStringArray `json:"trailers"`
+		Screenshots utils.LocalizedStringArray `json:"screenshots"`
+		Store       model.JSONB                `json:"store"`
+		Capsule     model.JSONB                `json:"capsule"`
+	}
+
+	storeRatingsDTO struct {
+		PEGI model.JSONB `json:"PEGI"`
+		ESRB model.JSONB `json:"ESRB"`
+		BBFC model.JSONB `json:"BBFC"`
+		USK  model.JSONB `json:"USK"`
+		CERO model.JSONB `json:"CERO"`
+	}
+
+	storeShortGameDTO struct {
+		ID          uuid.UUID             `json:"id"`
+		Title       string                `json:"title"`
+		ReleaseDate time.Time             `json:"releaseDate"`
+		Tagline     utils.LocalizedString `json:"tagline"`
+		CoverImage  utils.LocalizedString `json:"coverImage"`
+		Platforms   []string              `json:"platforms"`
+		Languages   []string              `json:"languages"`
+		Genres      []int64               `json:"genres"`
+		Tags        []int64               `json:"tags"`
+		PublishedAt time.Time             `json:"publishedAt"`
+	}
+
+	storeGameDTO struct {
+		ID           uuid.UUID             `json:"id"`
+		Title        string                `json:"title"`
+		Developers   string                `json:"developers"`
+		Publishers   string                `json:"publishers"`
+		ReleaseDate  time.Time             `json:"releaseDate"`
+		Features     GameFeaturesDTO       `json:"features"`
+		Platforms    game.Platforms        `json:"platforms"`
+		Requirements game.GameRequirements `json:"requirements"`
+		Languages    game.GameLangs        `json:"languages"`
+		GenreMain    int64                 `json:"genreMain"`
+		Genres       []GameTagDTO          `json:"genres"`
+		Tags         []GameTagDTO          `json:"tags"`
+		Tagline      utils.LocalizedString `json:"tagline"`
+		Description  utils.LocalizedString `json:"description"`
+		Reviews      game.GameReviews      `json:"reviews"`
+		GameSite     string                `json:"gameSite"`
+		Socials      game.Socials          `json:"socials"`
+		Media        storeMediaDTO         `json:"media"`
+		Ratings      storeRatingsDTO       `json:"ratings"`
+		PublishedAt  time.Time             `json:"publishedAt"`
+	}
+
+	storeOfferDTO struct {
+		ID               uuid.UUID             `json:"id"`
+		Sku              string                `json:"sku"`
+		Name             utils.LocalizedString `json:"name"`
+		Image            utils.LocalizedString `json:"image,omitempty"`
+		Games            []uuid.UUID           `json:"games"`
+		Packages         []uuid.UUID           `json:"packages"`
+		AllowedCountries []string              `json:"allowedCountries"`
+		DeniedCountries  []string              `json:"deniedCountries"`
+		Prices           []resolvedPriceDTO    `json:"prices"`
+	}
+)
+
+// InitStoreRouter is initialization method for public store catalog, routes don't require authorization
+func InitStoreRouter(group *echo.Group, service model.StoreCatalogService, taxService model.TaxService) (*StoreRouter, error) {
+	router := StoreRouter{service: service, taxService: taxService}
+
+	group.GET("/games", router.getGames)
+	group.GET("/games/:gameId", router.getGame)
+	group.GET("/games/:gameId/packages", router.getPackages)
+	group.GET("/games/:gameId/bundles", router.getBundles)
+
+	return &router, nil
+}
+
+func (router *StoreRouter) getGames(ctx echo.Context) error {
+	filter := model.StoreGameFilter{
+		Platform: ctx.QueryParam("platform"),
+		Language: ctx.QueryParam("language"),
+		Offset:   0,
+		Limit:    20,
+	}
+	if offsetParam := ctx.QueryParam("offset"); offsetParam != "" {
+		offset, err := strconv.Atoi(offsetParam)
+		if err != nil || offset < 0 {
+			return orm.NewServiceErrorf(http.StatusBadRequest, "Invalid offset `%s`", offsetParam)
+		}
+		filter.Offset = offset
+	}
+	if limitParam := ctx.QueryParam("limit"); limitParam != "" {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil || limit <= 0 {
+			return orm.NewServiceErrorf(http.StatusBadRequest, "Invalid limit `%s`", limitParam)
+		}
+		filter.Limit = limit
+	}
+	if filter.Limit > storeMaxPageSize {
+		filter.Limit = storeMaxPageSize
+	}
+	if genre := ctx.QueryParam("genre"); genre != "" {
+		id, err := strconv.ParseInt(genre, 10, 64)
+		if err != nil {
+			return orm.NewServiceErrorf(http.StatusBadRequest, "Invalid genre `%s`", genre)
+		}
+		filter.Genre = id
+	}
+	if tag := ctx.QueryParam("tag"); tag != "" {
+		id, err := strconv.ParseInt(tag, 10, 64)
+		if err != nil {
+			return orm.NewServiceErrorf(http.StatusBadRequest, "Invalid tag `%s`", tag)
+		}
+		filter.Tag = id
+	}
+
+	total, games, err := router.service.GetGames(filter)
+	if err != nil {
+		return err
+	}
+
+	result := []storeShortGameDTO{}
+	for _, g := range games {
+		result = append(result, storeShortGameDTO{
+			ID:          g.GameID,
+			Title:       g.Game.Title,
+			ReleaseDate: g.Game.ReleaseDate,
+			Tagline:     g.Game.Tagline,
+			CoverImage:  g.Game.Media.CoverImage,
+			Platforms:   g.Platforms,
+			Languages:   g.Languages,
+			Genres:      g.Genres,
+			Tags:        g.Tags,
+			PublishedAt: g.PublishedAt,
+		})
+	}
+
+	ctx.Response().Header().Add("X-Items-Count", fmt.Sprintf("%d", total))
+	return storeJSON(ctx, result)
+}
+
+func (router *StoreRouter) getGame(ctx echo.Context) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	published, err := router.service.GetGame(gameId)
+	if err != nil {
+		return err
+	}
+
+	return storeJSON(ctx, mapStoreGameDto(&published.Game, published.PublishedAt))
+}
+
+func (router *StoreRouter) getPackages(ctx echo.Context) error {
+	return router.getOffers(ctx, router.service.GetPackages)
+}
+
+func (router *StoreRouter) getBundles(ctx echo.Context) error {
+	return router.getOffers(ctx, router.service.GetBundles)
+}
+
+// getOffers returns offers of game, offers unavailable in `country` are skipped and tax of the country is calculated
+func (router *StoreRouter) getOffers(ctx echo.Context, get func(uuid.UUID, string, time.Time) ([]model.StoreOffer, error)) error {
+	gameId, err := uuid.FromString(ctx.Param("gameId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, "Invalid Id")
+	}
+
+	country := strings.ToUpper(ctx.QueryParam("country"))
+	if country != "" && !pkg_utils.ValidateCountryList([]string{country}) {
+		return orm.NewServiceErrorf(http.StatusBadRequest, "Invalid country `%s`", country)
+	}
+
+	offers, err := get(gameId, ctx.QueryParam("currency"), time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	result := []storeOfferDTO{}
+	for _, offer := range offers {
+		if country != "" && !offer.IsAvailable(country) {
+			continue
+		}
+		dto := storeOfferDTO{
+			ID:               offer.ID,
+			Sku:              offer.Sku,
+			Name:             offer.Name,
+			Image:            offer.Image,
+			Games:            offer.Games,
+			Packages:         offer.Packages,
+			AllowedCountries: countriesOrEmpty(offer.Restrictions.AllowedCountries),
+			DeniedCountries:  countriesOrEmpty(offer.Restrictions.DeniedCountries),
+			Prices:           []resolvedPriceDTO{},
+		}
+		for i := range offer.Prices {
+			if country != "" {
+				if err := router.taxService.ApplyTax(&offer.Prices[i], country); err != nil {
+					return err
+				}
+			}
+			dto.Prices = append(dto.Prices, mapResolvedPriceDto(&offer.Prices[i]))
+		}
+		result = append(result, dto)
+	}
+
+	return storeJSON(ctx, result)
+}
+
+// storeJSON sends cacheable response, entity tag is hash of response body
+func storeJSON(ctx echo.Context, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return orm.NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	hash := sha1.Sum(body)
+	etag := `"` + hex.EncodeToString(hash[:]) + `"`
+
+	header := ctx.Response().Header()
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", storeCacheMaxAge))
+	header.Set("ETag", etag)
+
+	if ctx.Request().Header.Get("If-None-Match") == etag {
+		return ctx.NoContent(http.StatusNotModified)
+	}
+
+	return ctx.JSONBlob(http.StatusOK, body)
+}
+
+func mapStoreGameDto(doc *model.StoreGame, publishedAt time.Time) storeGameDTO {
+	dto := storeGameDTO{
+		ID:           doc.ID,
+		Title:        doc.Title,
+		Developers:   doc.Developers,
+		Publishers:   doc.Publishers,
+		ReleaseDate:  doc.ReleaseDate,
+		Features:     GameFeaturesDTO{Common: doc.FeaturesCommon, Controllers: doc.FeaturesCtrl},
+		Platforms:    doc.Platforms,
+		Requirements: doc.Requirements,
+		Languages:    doc.Languages,
+		GenreMain:    doc.GenreMain,
+		Genres:       []GameTagDTO{},
+		Tags:         []GameTagDTO{},
+		Tagline:      doc.Tagline,
+		Description:  doc.Description,
+		Reviews:      doc.Reviews,
+		GameSite:     doc.GameSite,
+		Socials:      doc.Socials,
+		Media: storeMediaDTO{
+			CoverImage:  doc.Media.CoverImage,
+			CoverVideo:  doc.Media.CoverVideo,
+			Trailers:    doc.Media.Trailers,
+			Screenshots: doc.Media.Screenshots,
+			Store:       doc.Media.Store,
+			Capsule:     doc.Media.Capsule,
+		},
+		Ratings: storeRatingsDTO{
+			PEGI: doc.Ratings.PEGI,
+			ESRB: doc.Ratings.ESRB,
+			BBFC: doc.Ratings.BBFC,
+			USK:  doc.Ratings.USK,
+			CERO: doc.Ratings.CERO,
+		},
+		PublishedAt: publishedAt,
+	}
+	for _, genre := range doc.Genres {
+		dto.Genres = append(dto.Genres, GameTagDTO{Id: genre.ID, Title: genre.Title})
+	}
+	for _, tag := range doc.Tags {
+		dto.Tags = append(dto.Tags, GameTagDTO{Id: tag.ID, Title: tag.Title})
+	}
+	return dto
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"strings"
+	"time"
+)
+
+type (
+	TaxRouter struct {
+		service model.TaxService
+	}
+
+	taxRateDTO struct {
+		Country   string    `json:"country"`
+		Name      string    `json:"name" validate:"required"`
+		Rate      float64   `json:"rate" validate:"min=0,lt=100"`
+		Inclusive bool      `json:"inclusive"`
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+)
+
+// InitTaxRouter is initialization method for tax rates of countries
+func InitTaxRouter(adminGroup *echo.Group, routes *rbac_echo.Routes, service model.TaxService) (*TaxRouter, error) {
+	router := TaxRouter{service: service}
+
+	taxGroup := routes.Group(adminGroup, "/taxes", &router, []string{"*", model.AdminTaxRatesType, model.VendorDomain})
+	taxGroup.GET("", router.getRates, nil)
+	taxGroup.PUT("/:country", router.updateRate, nil)
+
+	return &router, nil
+}
+
+func (router *TaxRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return "*", nil
+}
+
+func (router *TaxRouter) getRates(ctx echo.Context) error {
+	rates, err := router.service.GetRates()
+	if err != nil {
+		return err
+	}
+
+	result := []taxRateDTO{}
+	for _, rate := range rates {
+		result = append(result, taxRateDTO{
+			Country:   rate.Country,
+			Name:      rate.Name,
+			Rate:      rate.Rate,
+			Inclusive: rate.Inclusive,
+			UpdatedAt: rate.UpdatedAt,
+		})
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *TaxRouter) updateRate(ctx echo.Context) error {
+	dto := new(taxRateDTO)
+	if err := ctx.Bind(dto); err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	if errs := ctx.Validate(dto); errs != nil {
+		return orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	rate := &model.TaxRate{
+		Country:   strings.ToUpper(ctx.Param("country")),
+		Name:      dto.Name,
+		Rate:      dto.Rate,
+		Inclusive: dto.Inclusive,
+	}
+	if err := router.service.UpdateRate(rate); err != nil {
+		return err
+	}
+
+	return ctx.String(http.StatusOK, "")
+}
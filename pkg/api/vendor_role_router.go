@@ -0,0 +1,189 @@
+package api
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/api/rbac_echo"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"time"
+)
+
+type (
+	VendorRoleRouter struct {
+		service model.VendorRoleService
+	}
+
+	rolePermissionDTO struct {
+		Resource string `json:"resource" validate:"required"`
+		Action   string `json:"action" validate:"required,oneof=read write"`
+	}
+
+	vendorRoleRequest struct {
+		Name        string              `json:"name" validate:"required"`
+		Permissions []rolePermissionDTO `json:"permissions" validate:"required,min=1,dive"`
+	}
+
+	vendorRoleDTO struct {
+		ID          uuid.UUID           `json:"id"`
+		Name        string              `json:"name"`
+		Permissions []rolePermissionDTO `json:"permissions"`
+		CreatedAt   time.Time           `json:"createdAt"`
+		UpdatedAt   time.Time           `json:"updatedAt"`
+	}
+)
+
+// InitVendorRoleRouter is initialization method for custom roles of vendor
+func InitVendorRoleRouter(group *echo.Group, routes *rbac_echo.Routes, service model.VendorRoleService) (*VendorRoleRouter, error) {
+	router := VendorRoleRouter{service: service}
+
+	r := routes.Group(group, "/vendors/:vendorId/roles", &router, []string{"*", model.RolesType, model.VendorDomain})
+	r.GET("", router.getList, nil)
+	r.POST("", router.create, nil)
+	r.GET("/:roleId", router.get, nil)
+	r.PUT("/:roleId", router.update, nil)
+	r.DELETE("/:roleId", router.delete, nil)
+
+	return &router, nil
+}
+
+func (router *VendorRoleRouter) GetOwner(ctx rbac_echo.AppContext) (string, error) {
+	return GetOwnerForVendor(ctx)
+}
+
+func (router *VendorRoleRouter) getList(ctx echo.Context) error {
+	vendorId, err := uuid.FromString(ctx.Param("vendorId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad vendor id"))
+	}
+
+	roles, err := router.service.GetList(vendorId)
+	if err != nil {
+		return err
+	}
+
+	result := []vendorRoleDTO{}
+	for i := range roles {
+		result = append(result, mapVendorRoleDto(&roles[i]))
+	}
+
+	return ctx.JSON(http.StatusOK, result)
+}
+
+func (router *VendorRoleRouter) create(ctx echo.Context) error {
+	vendorId, err := uuid.FromString(ctx.Param("vendorId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad vendor id"))
+	}
+
+	request, err := bindVendorRoleRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	role, err := router.service.Create(vendorId, request.Name, mapRolePermissions(request.Permissions))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, mapVendorRoleDto(role))
+}
+
+func (router *VendorRoleRouter) get(ctx echo.Context) error {
+	vendorId, err := uuid.FromString(ctx.Param("vendorId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad vendor id"))
+	}
+
+	roleId, err := uuid.FromString(ctx.Param("roleId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad role id"))
+	}
+
+	role, err := router.service.Get(vendorId, roleId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapVendorRoleDto(role))
+}
+
+func (router *VendorRoleRouter) update(ctx echo.Context) error {
+	vendorId, err := uuid.FromString(ctx.Param("vendorId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad vendor id"))
+	}
+
+	roleId, err := uuid.FromString(ctx.Param("roleId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad role id"))
+	}
+
+	request, err := bindVendorRoleRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	role, err := router.service.Update(vendorId, roleId, request.Name, mapRolePermissions(request.Permissions))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mapVendorRoleDto(role))
+}
+
+func (router *VendorRoleRouter) delete(ctx echo.Context) error {
+	vendorId, err := uuid.FromString(ctx.Param("vendorId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad vendor id"))
+	}
+
+	roleId, err := uuid.FromString(ctx.Param("roleId"))
+	if err != nil {
+		return orm.NewServiceError(http.StatusBadRequest, errors.Wrap(err, "Bad role id"))
+	}
+
+	if err := router.service.Delete(vendorId, roleId); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+func bindVendorRoleRequest(ctx echo.Context) (*vendorRoleRequest, error) {
+	request := &vendorRoleRequest{}
+	if err := ctx.Bind(request); err != nil {
+		return nil, orm.NewServiceError(http.StatusBadRequest, err)
+	}
+
+	if errs := ctx.Validate(request); errs != nil {
+		return nil, orm.NewServiceError(http.StatusUnprocessableEntity, errs)
+	}
+
+	return request, nil
+}
+
+func mapRolePermissions(dto []rolePermissionDTO) model.RolePermissions {
+	permissions := model.RolePermissions{}
+	for _, permission := range dto {
+		permissions = append(permissions, model.RolePermission{Resource: permission.Resource, Action: permission.Action})
+	}
+	return permissions
+}
+
+func mapVendorRoleDto(role *model.VendorRole) vendorRoleDTO {
+	permissions := []rolePermissionDTO{}
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, rolePermissionDTO{Resource: permission.Resource, Action: permission.Action})
+	}
+
+	return vendorRoleDTO{
+		ID:          role.ID,
+		Name:        role.Name,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+	}
+}
@@ -28,13 +28,13 @@ type (
 	}
 )
 
-func InitVendorRoutes(group *echo.Group, service model.VendorService, userService model.UserService) error {
+func InitVendorRoutes(group *echo.Group, routes *rbac_echo.Routes, service model.VendorService, userService model.UserService) error {
 	vendorRouter := VendorRouter{
 		vendorService: service,
 		userService:   userService,
 	}
 
-	router := rbac_echo.Group(group, "/vendors", &vendorRouter, []string{"*", model.VendorType, model.VendorDomain})
+	router := routes.Group(group, "/vendors", &vendorRouter, []string{"*", model.VendorType, model.VendorDomain})
 	router.GET("/:vendorId", vendorRouter.get, nil)
 	router.PUT("/:vendorId", vendorRouter.update, nil)
 
@@ -1,5 +1,7 @@
 package conf
 
+import "time"
+
 // Config the application's configuration
 type Config struct {
 	Server    ServerConfig
@@ -10,15 +12,23 @@ type Config struct {
 	Enforcer  Enforcer
 	EventBus  EventBus
 	Imaginary Imaginary
+	KeyBatch  KeyBatch
+	PreOrder  PreOrder
+	License   License
 }
 
 type EventBus struct {
-	Connection string `envconfig:"CONNECTION" required:"true" default:"amqp://127.0.0.1:5672"`
+	Connection       string        `envconfig:"CONNECTION" required:"true" default:"amqp://127.0.0.1:5672"`
+	DispatchInterval time.Duration `envconfig:"DISPATCH_INTERVAL" required:"false" default:"5s"`
+	MaxAttempts      int           `envconfig:"MAX_ATTEMPTS" required:"false" default:"10"`
+	RetryDelay       time.Duration `envconfig:"RETRY_DELAY" required:"false" default:"10s"`
 }
 
 type Enforcer struct {
 	Host string `envconfig:"HOST" required:"false" default:"127.0.0.1"`
 	Port int    `envconfig:"PORT" required:"false" default:"6379"`
+	// SyncInterval is period of reloading policies of vendor roles changed by other instances
+	SyncInterval time.Duration `envconfig:"SYNC_INTERVAL" required:"false" default:"30s"`
 }
 
 // ServerConfig specifies all the parameters needed for http server
@@ -52,6 +62,22 @@ type Imaginary struct {
 	Secret string `envconfig:"SECRET" required:"false" default:"MTIzNDU2Nzg5"`
 }
 
+// KeyBatch specifies secret used for signing of downloaded key batches
+type KeyBatch struct {
+	Secret string `envconfig:"SECRET" required:"true"`
+}
+
+// PreOrder specifies how often packages with due release date are switched from pre-order to regular pricing
+type PreOrder struct {
+	CheckInterval time.Duration `envconfig:"CHECK_INTERVAL" required:"false" default:"1m"`
+}
+
+// License specifies how many activation codes each user may activate or validate per window, zero limit disables it
+type License struct {
+	ActivationLimit  int           `envconfig:"ACTIVATION_LIMIT" required:"false" default:"10"`
+	ActivationWindow time.Duration `envconfig:"ACTIVATION_WINDOW" required:"false" default:"1m"`
+}
+
 // Mailer specifies all the parameters needed for dump mail sender
 type Mailer struct {
 	Host               string `envconfig:"HOST" required:"false" default:"localhost"`
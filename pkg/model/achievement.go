@@ -2,17 +2,30 @@ package model
 
 import (
 	"github.com/satori/go.uuid"
+	"qilin-api/pkg/model/utils"
 )
 
 type (
+	// Achievement is goal of game which user can reach, achievements are shown on production only if
+	// `AchievementOnProd` flag of game is set
 	Achievement struct {
 		Model
-		Name      string
-		ProductID uuid.UUID
+		GameID       uuid.UUID             `gorm:"type:uuid; not null; index"`
+		Name         utils.LocalizedString `gorm:"type:jsonb; not null; default:'{}'"`
+		Description  utils.LocalizedString `gorm:"type:jsonb; not null; default:'{}'"`
+		LockedIcon   string
+		UnlockedIcon string
+		Hidden       bool `gorm:"not null; default:false"`
+		Order        int  `gorm:"not null; default:0"`
+		Points       int  `gorm:"not null; default:0"`
 	}
 
 	AchievementService interface {
-		Create(name string) (*Achievement, error)
-		Delete(achId uuid.UUID) error
+		// GetList returns achievements of game sorted by order
+		GetList(gameId uuid.UUID) ([]Achievement, error)
+		Get(gameId, achId uuid.UUID) (*Achievement, error)
+		Create(gameId uuid.UUID, achievement *Achievement) (*Achievement, error)
+		Update(gameId uuid.UUID, achievement *Achievement) (*Achievement, error)
+		Delete(gameId, achId uuid.UUID) error
 	}
 )
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"github.com/satori/go.uuid"
+)
+
+type (
+	// RegionalRestrictions limit countries where package or bundle can be seen and bought.
+	// Empty allow-list means that item is available in all countries, deny-list wins over allow-list.
+	RegionalRestrictions struct {
+		AllowedCountries []string
+		DeniedCountries  []string
+	}
+
+	// Availability of package or bundle for buyer from country
+	Availability struct {
+		ID        uuid.UUID
+		Country   string
+		Available bool
+		// RestrictedBy contains bundle and packages which are not available in country
+		RestrictedBy []uuid.UUID
+	}
+
+	AvailabilityService interface {
+		GetPackageAvailability(packageId uuid.UUID, country string) (*Availability, error)
+		// GetBundleAvailability checks restrictions of bundle and all its packages
+		GetBundleAvailability(bundleId uuid.UUID, country string) (*Availability, error)
+	}
+)
+
+// IsAvailable checks that buyer from country is allowed to see and buy item
+func (r RegionalRestrictions) IsAvailable(country string) bool {
+	for _, denied := range r.DeniedCountries {
+		if denied == country {
+			return false
+		}
+	}
+	if len(r.AllowedCountries) == 0 {
+		return true
+	}
+	for _, allowed := range r.AllowedCountries {
+		if allowed == country {
+			return true
+		}
+	}
+	return false
+}
+
+func (pkg *Package) GetRestrictions() RegionalRestrictions {
+	return RegionalRestrictions{AllowedCountries: pkg.AllowedCountries, DeniedCountries: pkg.DeniedCountries}
+}
+
+// ResolvePackageAvailability checks regional restrictions of package for country
+func ResolvePackageAvailability(pkg *Package, country string) *Availability {
+	result := &Availability{ID: pkg.ID, Country: country, Available: true, RestrictedBy: []uuid.UUID{}}
+	if !pkg.GetRestrictions().IsAvailable(country) {
+		result.Available = false
+		result.RestrictedBy = append(result.RestrictedBy, pkg.ID)
+	}
+	return result
+}
+
+// ResolveBundleAvailability checks regional restrictions of bundle and its packages for country,
+// bundle is available only if all its packages are available.
+func ResolveBundleAvailability(bundle Bundle, country string) (*Availability, error) {
+	result := &Availability{ID: bundle.GetID(), Country: country, Available: true, RestrictedBy: []uuid.UUID{}}
+	if !bundle.GetRestrictions().IsAvailable(country) {
+		result.Available = false
+		result.RestrictedBy = append(result.RestrictedBy, bundle.GetID())
+	}
+
+	packages, err := bundle.GetPackages()
+	if err != nil {
+		return nil, err
+	}
+	for i := range packages {
+		if !packages[i].GetRestrictions().IsAvailable(country) {
+			result.Available = false
+			result.RestrictedBy = append(result.RestrictedBy, packages[i].ID)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,57 @@
+package model_test
+
+import (
+	"github.com/satori/go.uuid"
+	"qilin-api/pkg/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RegionalRestrictions(t *testing.T) {
+	shouldBe := require.New(t)
+
+	shouldBe.True(model.RegionalRestrictions{}.IsAvailable("DE"))
+
+	allowed := model.RegionalRestrictions{AllowedCountries: []string{"DE", "FR"}}
+	shouldBe.True(allowed.IsAvailable("DE"))
+	shouldBe.False(allowed.IsAvailable("RU"))
+
+	denied := model.RegionalRestrictions{DeniedCountries: []string{"CN"}}
+	shouldBe.True(denied.IsAvailable("DE"))
+	shouldBe.False(denied.IsAvailable("CN"))
+
+	both := model.RegionalRestrictions{AllowedCountries: []string{"DE", "FR"}, DeniedCountries: []string{"FR"}}
+	shouldBe.True(both.IsAvailable("DE"))
+	shouldBe.False(both.IsAvailable("FR"))
+}
+
+func Test_ResolveBundleAvailability(t *testing.T) {
+	shouldBe := require.New(t)
+
+	pkgA := model.Package{Model: model.Model{ID: uuid.NewV4()}}
+	pkgB := model.Package{Model: model.Model{ID: uuid.NewV4()}, DeniedCountries: []string{"DE"}}
+	bundle := &model.StoreBundle{
+		Model:            model.Model{ID: uuid.NewV4()},
+		AllowedCountries: []string{"DE", "FR"},
+		Packages:         []model.Package{pkgA, pkgB},
+	}
+
+	availability, err := model.ResolveBundleAvailability(bundle, "FR")
+	shouldBe.Nil(err)
+	shouldBe.True(availability.Available)
+	shouldBe.Empty(availability.RestrictedBy)
+
+	availability, err = model.ResolveBundleAvailability(bundle, "DE")
+	shouldBe.Nil(err)
+	shouldBe.False(availability.Available)
+	shouldBe.Equal([]uuid.UUID{pkgB.ID}, availability.RestrictedBy)
+
+	availability, err = model.ResolveBundleAvailability(bundle, "US")
+	shouldBe.Nil(err)
+	shouldBe.False(availability.Available)
+	shouldBe.Equal([]uuid.UUID{bundle.ID}, availability.RestrictedBy)
+
+	shouldBe.True(model.ResolvePackageAvailability(&pkgA, "DE").Available)
+	shouldBe.False(model.ResolvePackageAvailability(&pkgB, "DE").Available)
+}
@@ -19,6 +19,7 @@ type (
 		GetName() *utils.LocalizedString
 		IsContains(productId uuid.UUID) (bool, error)
 		GetPrice() (currency string, price float32, discount float32, err error)
+		GetRestrictions() RegionalRestrictions
 		GetPackages() ([]Package, error)
 		GetGames() ([]*ProductGameImpl, error)
 		GetDlc() ([]Dlc, error)
@@ -41,9 +42,17 @@ type (
 
 	BundleService interface {
 		CreateStore(vendorId uuid.UUID, userId, name string, packages []uuid.UUID) (bundle Bundle, err error)
-		GetStoreList(userId string, vendorId uuid.UUID, query, sort string, offset, limit int, filterFunc BundleListingFilter) (total int, bundles []Bundle, err error)
+		// GetStoreList returns store bundles of vendor, bundles unavailable in country or having unavailable packages
+		// are skipped if country isn't empty
+		GetStoreList(userId string, vendorId uuid.UUID, query, sort, country string, offset, limit int, filterFunc BundleListingFilter) (total int, bundles []Bundle, err error)
 		UpdateStore(bundle Bundle) (result Bundle, err error)
 
+		CreateLootbox(vendorId uuid.UUID, userId, name, currency string, items []LootboxItem) (bundle Bundle, err error)
+		// GetLootboxList filters lootboxes by country like GetStoreList
+		GetLootboxList(userId string, vendorId uuid.UUID, query, sort, country string, offset, limit int, filterFunc BundleListingFilter) (total int, bundles []Bundle, err error)
+		// UpdateLootbox changes lootbox properties and replaces its drop table
+		UpdateLootbox(bundle Bundle) (result Bundle, err error)
+
 		Get(bundleId uuid.UUID) (bundle Bundle, err error)
 		Delete(bundleId uuid.UUID) (err error)
 		AddPackages(bundleId uuid.UUID, packages []uuid.UUID) (err error)
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/satori/go.uuid"
+	"time"
+)
+
+type ResyncStatus int8
+
+const (
+	ResyncRunning   ResyncStatus = 0 //`running`
+	ResyncCompleted ResyncStatus = 1 //`completed`
+	ResyncFailed    ResyncStatus = 2 //`failed`
+	ResyncCancelled ResyncStatus = 3 //`cancelled`
+
+	// Entities of catalog in order of resync
+	ResyncGames    = "games"
+	ResyncPackages = "packages"
+	ResyncBundles  = "bundles"
+
+	// DefaultResyncRate is number of entities published per second if rate isn't set
+	DefaultResyncRate = 50
+	MaxResyncRate     = 1000
+	// ResyncStaleTimeout is time after the last progress when running resync is considered as abandoned
+	ResyncStaleTimeout = time.Minute
+)
+
+type (
+	// CatalogResync is job publishing current state of catalog to event bus for rebuilding of consumers. Entities
+	// are walked in order of ids, cursor keeps id of the last published entity, so job is resumed from it.
+	CatalogResync struct {
+		ID           uuid.UUID      `gorm:"type:uuid; primary_key"`
+		CreatedAt    time.Time      `gorm:"default:now()"`
+		UpdatedAt    time.Time      `gorm:"default:now()"`
+		CreatorID    string         `gorm:"not null"`
+		Entities     pq.StringArray `gorm:"type:text[]; not null"`
+		VendorID     *uuid.UUID     `gorm:"type:uuid"`
+		UpdatedSince *time.Time
+		Rate         int          `gorm:"not null"`
+		Status       ResyncStatus `gorm:"not null; index"`
+		Entity       string       `gorm:"not null"`
+		Cursor       uuid.UUID    `gorm:"type:uuid; not null"`
+		Published    int          `gorm:"not null; default:0"`
+		LastError    string
+		FinishedAt   *time.Time
+	}
+
+	// ResyncFilter limits entities of catalog resync, empty entities mean games only
+	ResyncFilter struct {
+		Entities     []string
+		VendorID     *uuid.UUID
+		UpdatedSince *time.Time
+		// Rate is number of entities published per second
+		Rate int
+	}
+
+	CatalogResyncService interface {
+		Create(userId string, filter ResyncFilter) (*CatalogResync, error)
+		Get(id uuid.UUID) (*CatalogResync, error)
+		GetList(offset, limit int) ([]CatalogResync, int, error)
+		// Resume takes failed, cancelled or abandoned resync for running
+		Resume(id uuid.UUID) (*CatalogResync, error)
+		// Cancel stops running resync after the current batch
+		Cancel(id uuid.UUID) (*CatalogResync, error)
+		// Run publishes entities from cursor until resync is completed, cancelled or stop channel is closed
+		Run(id uuid.UUID, stop <-chan struct{}) error
+	}
+)
+
+func (CatalogResync) TableName() string {
+	return "catalog_resyncs"
+}
+
+// NewCatalogResync validates filter and makes resync in running status
+func NewCatalogResync(userId string, filter ResyncFilter) (*CatalogResync, error) {
+	entities := []string{}
+	for _, entity := range []string{ResyncGames, ResyncPackages, ResyncBundles} {
+		for _, requested := range filter.Entities {
+			if requested == entity {
+				entities = append(entities, entity)
+				break
+			}
+		}
+	}
+	if len(entities) != len(filter.Entities) {
+		return nil, errors.New(fmt.Sprintf("Unknown entities %v, only `%s`, `%s` and `%s` are supported", filter.Entities, ResyncGames, ResyncPackages, ResyncBundles))
+	}
+	if len(entities) == 0 {
+		entities = []string{ResyncGames}
+	}
+
+	if filter.Rate < 0 || filter.Rate > MaxResyncRate {
+		return nil, errors.New(fmt.Sprintf("Rate must be between 0 and %d", MaxResyncRate))
+	}
+	rate := filter.Rate
+	if rate == 0 {
+		rate = DefaultResyncRate
+	}
+
+	return &CatalogResync{
+		ID:           uuid.NewV4(),
+		CreatorID:    userId,
+		Entities:     entities,
+		VendorID:     filter.VendorID,
+		UpdatedSince: filter.UpdatedSince,
+		Rate:         rate,
+		Status:       ResyncRunning,
+		Entity:       entities[0],
+		Cursor:       uuid.Nil,
+	}, nil
+}
+
+// CanBeResumed reports whether resync isn't completed and nobody runs it at the moment t
+func (r *CatalogResync) CanBeResumed(t time.Time) bool {
+	switch r.Status {
+	case ResyncFailed, ResyncCancelled:
+		return true
+	case ResyncRunning:
+		return t.Sub(r.UpdatedAt) > ResyncStaleTimeout
+	}
+	return false
+}
+
+// NextEntity returns entity following the current one, empty string means that resync is done
+func (r *CatalogResync) NextEntity() string {
+	for i, entity := range r.Entities {
+		if entity == r.Entity && i+1 < len(r.Entities) {
+			return r.Entities[i+1]
+		}
+	}
+	return ""
+}
+
+func (status ResyncStatus) ToString() string {
+	switch status {
+	case ResyncRunning:
+		return "running"
+	case ResyncCompleted:
+		return "completed"
+	case ResyncFailed:
+		return "failed"
+	case ResyncCancelled:
+		return "cancelled"
+	}
+
+	return ""
+}
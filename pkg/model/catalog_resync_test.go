@@ -0,0 +1,60 @@
+package model_test
+
+import (
+	"qilin-api/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewCatalogResync(t *testing.T) {
+	shouldBe := require.New(t)
+
+	resync, err := model.NewCatalogResync("user", model.ResyncFilter{})
+	shouldBe.Nil(err)
+	shouldBe.Equal([]string{model.ResyncGames}, []string(resync.Entities), "Games are published by default")
+	shouldBe.Equal(model.DefaultResyncRate, resync.Rate)
+	shouldBe.Equal(model.ResyncRunning, resync.Status)
+	shouldBe.Equal(model.ResyncGames, resync.Entity)
+
+	resync, err = model.NewCatalogResync("user", model.ResyncFilter{Entities: []string{"bundles", "games"}, Rate: 10})
+	shouldBe.Nil(err)
+	shouldBe.Equal([]string{model.ResyncGames, model.ResyncBundles}, []string(resync.Entities), "Entities must be ordered")
+	shouldBe.Equal(10, resync.Rate)
+
+	_, err = model.NewCatalogResync("user", model.ResyncFilter{Entities: []string{"games", "dlc"}})
+	shouldBe.NotNil(err)
+
+	_, err = model.NewCatalogResync("user", model.ResyncFilter{Rate: model.MaxResyncRate + 1})
+	shouldBe.NotNil(err)
+}
+
+func Test_CatalogResyncNextEntity(t *testing.T) {
+	shouldBe := require.New(t)
+
+	resync, err := model.NewCatalogResync("user", model.ResyncFilter{Entities: []string{"games", "packages", "bundles"}})
+	shouldBe.Nil(err)
+
+	shouldBe.Equal(model.ResyncPackages, resync.NextEntity())
+	resync.Entity = resync.NextEntity()
+	shouldBe.Equal(model.ResyncBundles, resync.NextEntity())
+	resync.Entity = resync.NextEntity()
+	shouldBe.Equal("", resync.NextEntity())
+}
+
+func Test_CatalogResyncCanBeResumed(t *testing.T) {
+	shouldBe := require.New(t)
+
+	now := time.Now()
+	resync := &model.CatalogResync{Status: model.ResyncRunning, UpdatedAt: now}
+	shouldBe.False(resync.CanBeResumed(now), "Running resync must not be taken twice")
+	shouldBe.True(resync.CanBeResumed(now.Add(model.ResyncStaleTimeout+time.Second)), "Abandoned resync must be resumed")
+
+	resync.Status = model.ResyncCancelled
+	shouldBe.True(resync.CanBeResumed(now))
+	resync.Status = model.ResyncFailed
+	shouldBe.True(resync.CanBeResumed(now))
+	resync.Status = model.ResyncCompleted
+	shouldBe.False(resync.CanBeResumed(now))
+}
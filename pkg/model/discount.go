@@ -17,3 +17,13 @@ type Discount struct {
 	DateEnd     time.Time
 	GameID      uuid.UUID `gorm:"type:uuid;not null"`
 }
+
+// IsActive reports whether discount is applied at the moment t, end of discount window is exclusive
+func (d *Discount) IsActive(t time.Time) bool {
+	return !t.Before(d.DateStart) && t.Before(d.DateEnd)
+}
+
+// Overlaps reports whether windows of discounts have common moments
+func (d *Discount) Overlaps(other *Discount) bool {
+	return d.DateStart.Before(other.DateEnd) && other.DateStart.Before(d.DateEnd)
+}
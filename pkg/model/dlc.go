@@ -6,13 +6,32 @@ import (
 )
 
 type (
+	// Dlc is additional content of game, it's a product which can be sold in packages like game
 	Dlc struct {
 		Model
-		Image   JSONB `gorm:"type:jsonb"`
-		GameID  uuid.UUID
-		Game    Game
+		Name        utils.LocalizedString      `gorm:"type:jsonb; not null; default:'{}'"`
+		Image       utils.LocalizedString      `gorm:"type:jsonb; not null; default:'{}'"`
+		ImageCover  utils.LocalizedString      `gorm:"type:jsonb; not null; default:'{}'"`
+		Screenshots utils.LocalizedStringArray `gorm:"type:jsonb; not null; default:'{}'"`
+		GameID      uuid.UUID                  `gorm:"type:uuid; not null; index"`
+		VendorID    uuid.UUID                  `gorm:"type:uuid"`
+		CreatorID   string
+
+		DefaultPackageID uuid.UUID
+
 		Product ProductEntry `gorm:"polymorphic:Entry;"`
 	}
+
+	DlcService interface {
+		// Create makes DLC of game with default package containing it
+		Create(userId string, gameId uuid.UUID, dlc *Dlc) (*Dlc, error)
+		GetList(gameId uuid.UUID) ([]Dlc, error)
+		Get(gameId, dlcId uuid.UUID) (*Dlc, error)
+		// Update changes name and media of DLC
+		Update(gameId uuid.UUID, dlc *Dlc) (*Dlc, error)
+		Delete(gameId, dlcId uuid.UUID) error
+		GetProduct(dlcId uuid.UUID) (Product, error)
+	}
 )
 
 func (p *Dlc) GetID() uuid.UUID {
@@ -20,7 +39,7 @@ func (p *Dlc) GetID() uuid.UUID {
 }
 
 func (p *Dlc) GetName() string {
-	return p.Game.InternalName
+	return p.Name.EN
 }
 
 func (p *Dlc) GetType() ProductType {
@@ -28,10 +47,5 @@ func (p *Dlc) GetType() ProductType {
 }
 
 func (p *Dlc) GetImage() (res *utils.LocalizedString) {
-	res = &utils.LocalizedString{}
-	if p.Image == nil {
-		return
-	}
-	_ = p.Image.Scan(res)
-	return
+	return &p.Image
 }
@@ -0,0 +1,325 @@
+package model
+
+import (
+	"github.com/lib/pq"
+	"github.com/satori/go.uuid"
+	"qilin-api/pkg/model/utils"
+	"time"
+)
+
+// Topics of typed events
+const (
+	PackageCreatedTopic         = "package_created"
+	PackageUpdatedTopic         = "package_updated"
+	PackageProductsChangedTopic = "package_products_changed"
+	PackageRemovedTopic         = "package_removed"
+	PackageSyncedTopic          = "package_synced"
+
+	BundleCreatedTopic         = "bundle_created"
+	BundleUpdatedTopic         = "bundle_updated"
+	BundlePackagesChangedTopic = "bundle_packages_changed"
+	BundleDeletedTopic         = "bundle_deleted"
+	BundleSyncedTopic          = "bundle_synced"
+
+	BasePriceChangedTopic = "base_price_changed"
+	PriceChangedTopic     = "price_changed"
+	PriceRemovedTopic     = "price_removed"
+
+	DiscountCreatedTopic = "discount_created"
+	DiscountUpdatedTopic = "discount_updated"
+	DiscountRemovedTopic = "discount_removed"
+
+	VendorStatusChangedTopic = "vendor_status_changed"
+)
+
+type (
+	// EventPayload is typed content of event. Schema version is increased on each incompatible change of payload,
+	// so consumers are able to skip or migrate unknown versions.
+	EventPayload interface {
+		EventTopic() string
+		EventSchemaVersion() int
+		// EventAggregateID is id of entity which is changed by event, events of the same aggregate are numbered
+		EventAggregateID() uuid.UUID
+	}
+
+	// Event is JSON envelope of typed event. Sequence grows by one with each event of aggregate, events saved in
+	// the same transaction share correlation id.
+	Event struct {
+		ID            uuid.UUID    `json:"id"`
+		Topic         string       `json:"topic"`
+		SchemaVersion int          `json:"schemaVersion"`
+		AggregateID   uuid.UUID    `json:"aggregateId"`
+		Sequence      int64        `json:"sequence"`
+		CorrelationID uuid.UUID    `json:"correlationId"`
+		OccurredAt    time.Time    `json:"occurredAt"`
+		Payload       EventPayload `json:"payload"`
+	}
+
+	PackageEventState struct {
+		PackageID        uuid.UUID             `json:"packageId"`
+		VendorID         uuid.UUID             `json:"vendorId"`
+		Sku              string                `json:"sku"`
+		Name             utils.LocalizedString `json:"name"`
+		IsEnabled        bool                  `json:"isEnabled"`
+		IsUpgradeAllowed bool                  `json:"isUpgradeAllowed"`
+		DefaultProductID uuid.UUID             `json:"defaultProductId"`
+		Discount         uint                  `json:"discount"`
+		AllowedCountries pq.StringArray        `json:"allowedCountries"`
+		DeniedCountries  pq.StringArray        `json:"deniedCountries"`
+	}
+
+	PackageCreated struct {
+		PackageEventState
+		Products []uuid.UUID `json:"products"`
+	}
+
+	PackageUpdated struct {
+		PackageEventState
+	}
+
+	// PackageProductsChanged contains ordered list of products after change
+	PackageProductsChanged struct {
+		PackageID uuid.UUID   `json:"packageId"`
+		Added     []uuid.UUID `json:"added"`
+		Removed   []uuid.UUID `json:"removed"`
+		Products  []uuid.UUID `json:"products"`
+	}
+
+	PackageRemoved struct {
+		PackageID uuid.UUID `json:"packageId"`
+		VendorID  uuid.UUID `json:"vendorId"`
+	}
+
+	// PackageSynced contains full state of package, it's published by catalog resync
+	PackageSynced struct {
+		PackageEventState
+		Products []uuid.UUID `json:"products"`
+	}
+
+	BundleEventState struct {
+		BundleID         uuid.UUID             `json:"bundleId"`
+		Type             BundleType            `json:"type"`
+		VendorID         uuid.UUID             `json:"vendorId"`
+		Sku              string                `json:"sku"`
+		Name             utils.LocalizedString `json:"name"`
+		IsEnabled        bool                  `json:"isEnabled"`
+		IsUpgradeAllowed bool                  `json:"isUpgradeAllowed"`
+		Discount         uint                  `json:"discount"`
+		AllowedCountries pq.StringArray        `json:"allowedCountries"`
+		DeniedCountries  pq.StringArray        `json:"deniedCountries"`
+	}
+
+	BundleCreated struct {
+		BundleEventState
+		Packages []uuid.UUID `json:"packages"`
+	}
+
+	BundleUpdated struct {
+		BundleEventState
+	}
+
+	// BundlePackagesChanged contains ordered list of packages after change
+	BundlePackagesChanged struct {
+		BundleID uuid.UUID   `json:"bundleId"`
+		Added    []uuid.UUID `json:"added"`
+		Removed  []uuid.UUID `json:"removed"`
+		Packages []uuid.UUID `json:"packages"`
+	}
+
+	BundleDeleted struct {
+		BundleID uuid.UUID  `json:"bundleId"`
+		Type     BundleType `json:"type"`
+	}
+
+	// BundleSynced contains full state of store bundle, it's published by catalog resync
+	BundleSynced struct {
+		BundleEventState
+		Packages []uuid.UUID `json:"packages"`
+	}
+
+	BasePriceChanged struct {
+		PackageID uuid.UUID `json:"packageId"`
+		Common    JSONB     `json:"common"`
+		PreOrder  JSONB     `json:"preOrder"`
+	}
+
+	PriceChanged struct {
+		PackageID uuid.UUID `json:"packageId"`
+		Currency  string    `json:"currency"`
+		Price     float32   `json:"price"`
+		Vat       int32     `json:"vat"`
+	}
+
+	PriceRemoved struct {
+		PackageID uuid.UUID `json:"packageId"`
+		Currency  string    `json:"currency"`
+	}
+
+	DiscountEventState struct {
+		DiscountID  uuid.UUID `json:"discountId"`
+		GameID      uuid.UUID `json:"gameId"`
+		Title       JSONB     `json:"title"`
+		Description JSONB     `json:"description"`
+		Rate        float32   `json:"rate"`
+		DateStart   time.Time `json:"dateStart"`
+		DateEnd     time.Time `json:"dateEnd"`
+	}
+
+	DiscountCreated struct {
+		DiscountEventState
+	}
+
+	DiscountUpdated struct {
+		DiscountEventState
+	}
+
+	DiscountRemoved struct {
+		DiscountID uuid.UUID `json:"discountId"`
+		GameID     uuid.UUID `json:"gameId"`
+	}
+
+	VendorStatusChanged struct {
+		VendorID     uuid.UUID `json:"vendorId"`
+		Status       string    `json:"status"`
+		ReviewStatus string    `json:"reviewStatus"`
+	}
+)
+
+func NewPackageEventState(pkg *Package) PackageEventState {
+	return PackageEventState{
+		PackageID:        pkg.ID,
+		VendorID:         pkg.VendorID,
+		Sku:              pkg.Sku,
+		Name:             pkg.Name,
+		IsEnabled:        pkg.IsEnabled,
+		IsUpgradeAllowed: pkg.IsUpgradeAllowed,
+		DefaultProductID: pkg.DefaultProductID,
+		Discount:         pkg.Discount,
+		AllowedCountries: pkg.AllowedCountries,
+		DeniedCountries:  pkg.DeniedCountries,
+	}
+}
+
+func NewBundleEventState(bundle *StoreBundle) BundleEventState {
+	return BundleEventState{
+		BundleID:         bundle.ID,
+		Type:             BundleStore,
+		VendorID:         bundle.VendorID,
+		Sku:              bundle.Sku,
+		Name:             bundle.Name,
+		IsEnabled:        bundle.IsEnabled,
+		IsUpgradeAllowed: bundle.IsUpgradeAllowed,
+		Discount:         bundle.Discount,
+		AllowedCountries: bundle.AllowedCountries,
+		DeniedCountries:  bundle.DeniedCountries,
+	}
+}
+
+func NewLootboxEventState(bundle *LootboxBundle) BundleEventState {
+	return BundleEventState{
+		BundleID:         bundle.ID,
+		Type:             BundleLootbox,
+		VendorID:         bundle.VendorID,
+		Sku:              bundle.Sku,
+		Name:             bundle.Name,
+		IsEnabled:        bundle.IsEnabled,
+		AllowedCountries: bundle.AllowedCountries,
+		DeniedCountries:  bundle.DeniedCountries,
+	}
+}
+
+func NewDiscountEventState(discount *Discount) DiscountEventState {
+	return DiscountEventState{
+		DiscountID:  discount.ID,
+		GameID:      discount.GameID,
+		Title:       discount.Title,
+		Description: discount.Description,
+		Rate:        discount.Rate,
+		DateStart:   discount.DateStart,
+		DateEnd:     discount.DateEnd,
+	}
+}
+
+func (PackageCreated) EventTopic() string            { return PackageCreatedTopic }
+func (PackageCreated) EventSchemaVersion() int       { return 1 }
+func (e PackageCreated) EventAggregateID() uuid.UUID { return e.PackageID }
+
+func (PackageUpdated) EventTopic() string            { return PackageUpdatedTopic }
+func (PackageUpdated) EventSchemaVersion() int       { return 1 }
+func (e PackageUpdated) EventAggregateID() uuid.UUID { return e.PackageID }
+
+func (PackageProductsChanged) EventTopic() string            { return PackageProductsChangedTopic }
+func (PackageProductsChanged) EventSchemaVersion() int       { return 1 }
+func (e PackageProductsChanged) EventAggregateID() uuid.UUID { return e.PackageID }
+
+func (PackageRemoved) EventTopic() string            { return PackageRemovedTopic }
+func (PackageRemoved) EventSchemaVersion() int       { return 1 }
+func (e PackageRemoved) EventAggregateID() uuid.UUID { return e.PackageID }
+
+func (PackageSynced) EventTopic() string            { return PackageSyncedTopic }
+func (PackageSynced) EventSchemaVersion() int       { return 1 }
+func (e PackageSynced) EventAggregateID() uuid.UUID { return e.PackageID }
+
+func (BundleCreated) EventTopic() string            { return BundleCreatedTopic }
+func (BundleCreated) EventSchemaVersion() int       { return 1 }
+func (e BundleCreated) EventAggregateID() uuid.UUID { return e.BundleID }
+
+func (BundleUpdated) EventTopic() string            { return BundleUpdatedTopic }
+func (BundleUpdated) EventSchemaVersion() int       { return 1 }
+func (e BundleUpdated) EventAggregateID() uuid.UUID { return e.BundleID }
+
+func (BundlePackagesChanged) EventTopic() string            { return BundlePackagesChangedTopic }
+func (BundlePackagesChanged) EventSchemaVersion() int       { return 1 }
+func (e BundlePackagesChanged) EventAggregateID() uuid.UUID { return e.BundleID }
+
+func (BundleDeleted) EventTopic() string            { return BundleDeletedTopic }
+func (BundleDeleted) EventSchemaVersion() int       { return 1 }
+func (e BundleDeleted) EventAggregateID() uuid.UUID { return e.BundleID }
+
+func (BundleSynced) EventTopic() string            { return BundleSyncedTopic }
+func (BundleSynced) EventSchemaVersion() int       { return 1 }
+func (e BundleSynced) EventAggregateID() uuid.UUID { return e.BundleID }
+
+// Prices are part of package, so price events share sequence with package events
+func (BasePriceChanged) EventTopic() string            { return BasePriceChangedTopic }
+func (BasePriceChanged) EventSchemaVersion() int       { return 1 }
+func (e BasePriceChanged) EventAggregateID() uuid.UUID { return e.PackageID }
+
+func (PriceChanged) EventTopic() string            { return PriceChangedTopic }
+func (PriceChanged) EventSchemaVersion() int       { return 1 }
+func (e PriceChanged) EventAggregateID() uuid.UUID { return e.PackageID }
+
+func (PriceRemoved) EventTopic() string            { return PriceRemovedTopic }
+func (PriceRemoved) EventSchemaVersion() int       { return 1 }
+func (e PriceRemoved) EventAggregateID() uuid.UUID { return e.PackageID }
+
+// Discounts of game must not overlap, so they are ordered within the game
+func (DiscountCreated) EventTopic() string            { return DiscountCreatedTopic }
+func (DiscountCreated) EventSchemaVersion() int       { return 1 }
+func (e DiscountCreated) EventAggregateID() uuid.UUID { return e.GameID }
+
+func (DiscountUpdated) EventTopic() string            { return DiscountUpdatedTopic }
+func (DiscountUpdated) EventSchemaVersion() int       { return 1 }
+func (e DiscountUpdated) EventAggregateID() uuid.UUID { return e.GameID }
+
+func (DiscountRemoved) EventTopic() string            { return DiscountRemovedTopic }
+func (DiscountRemoved) EventSchemaVersion() int       { return 1 }
+func (e DiscountRemoved) EventAggregateID() uuid.UUID { return e.GameID }
+
+func (VendorStatusChanged) EventTopic() string            { return VendorStatusChangedTopic }
+func (VendorStatusChanged) EventSchemaVersion() int       { return 1 }
+func (e VendorStatusChanged) EventAggregateID() uuid.UUID { return e.VendorID }
+
+// NewEvent wraps payload into envelope
+func NewEvent(id uuid.UUID, sequence int64, correlationId uuid.UUID, t time.Time, payload EventPayload) *Event {
+	return &Event{
+		ID:            id,
+		Topic:         payload.EventTopic(),
+		SchemaVersion: payload.EventSchemaVersion(),
+		AggregateID:   payload.EventAggregateID(),
+		Sequence:      sequence,
+		CorrelationID: correlationId,
+		OccurredAt:    t,
+		Payload:       payload,
+	}
+}
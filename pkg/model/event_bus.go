@@ -1,8 +1,21 @@
 package model
 
-import "github.com/satori/go.uuid"
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/satori/go.uuid"
+	"time"
+)
 
+// EventBus saves events into outbox, they are delivered to broker by dispatcher
 type EventBus interface {
 	PublishGameChanges(gameId uuid.UUID) error
 	PublishGameDelete(gameId uuid.UUID) error
+	PublishAchievementsChanges(gameId uuid.UUID) error
+	// PublishPackageReleased notifies that package is switched from pre-order to regular pricing
+	PublishPackageReleased(packageId uuid.UUID, releaseDate time.Time) error
+	// Publish saves typed event of aggregate
+	Publish(payload EventPayload) error
+	// WithTransaction returns event bus saving events in transaction of domain change, so events are
+	// delivered only if transaction is committed
+	WithTransaction(tx *gorm.DB) EventBus
 }
@@ -0,0 +1,56 @@
+package model_test
+
+import (
+	"encoding/json"
+	"github.com/satori/go.uuid"
+	"qilin-api/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewEvent(t *testing.T) {
+	shouldBe := require.New(t)
+
+	pkg := &model.Package{Model: model.Model{ID: uuid.NewV4()}, VendorID: uuid.NewV4(), Sku: "sku"}
+	productId := uuid.NewV4()
+	correlationId := uuid.NewV4()
+	now := time.Now().UTC()
+
+	event := model.NewEvent(uuid.NewV4(), 3, correlationId, now, model.PackageCreated{
+		PackageEventState: model.NewPackageEventState(pkg),
+		Products:          []uuid.UUID{productId},
+	})
+	shouldBe.Equal(model.PackageCreatedTopic, event.Topic)
+	shouldBe.Equal(1, event.SchemaVersion)
+	shouldBe.Equal(pkg.ID, event.AggregateID)
+	shouldBe.Equal(int64(3), event.Sequence)
+	shouldBe.Equal(correlationId, event.CorrelationID)
+
+	data, err := json.Marshal(event)
+	shouldBe.Nil(err)
+
+	decoded := map[string]interface{}{}
+	shouldBe.Nil(json.Unmarshal(data, &decoded))
+	shouldBe.Equal("package_created", decoded["topic"])
+	shouldBe.Equal(float64(3), decoded["sequence"])
+	payload := decoded["payload"].(map[string]interface{})
+	shouldBe.Equal(pkg.ID.String(), payload["packageId"], "State of package must be embedded into payload")
+	shouldBe.Equal("sku", payload["sku"])
+	shouldBe.Equal([]interface{}{productId.String()}, payload["products"])
+}
+
+func Test_EventAggregates(t *testing.T) {
+	shouldBe := require.New(t)
+
+	packageId := uuid.NewV4()
+	gameId := uuid.NewV4()
+	vendorId := uuid.NewV4()
+
+	shouldBe.Equal(packageId, model.PriceChanged{PackageID: packageId}.EventAggregateID(), "Prices are part of package")
+	shouldBe.Equal(packageId, model.BasePriceChanged{PackageID: packageId}.EventAggregateID())
+	shouldBe.Equal(gameId, model.DiscountRemoved{DiscountID: uuid.NewV4(), GameID: gameId}.EventAggregateID(), "Discounts are part of game")
+	shouldBe.Equal(vendorId, model.VendorStatusChanged{VendorID: vendorId}.EventAggregateID())
+	shouldBe.Equal(model.BundleDeletedTopic, model.BundleDeleted{}.EventTopic())
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"github.com/satori/go.uuid"
+	"time"
+)
+
+type (
+	// ReviewComment is remark of admin about single field of submitted game, Field is path of field
+	// in snapshot of game, e.g. `game.title` or `packages.<id>.name.en`
+	ReviewComment struct {
+		Field     string    `json:"field"`
+		Message   string    `json:"message"`
+		AuthorID  string    `json:"authorId"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+
+	ReviewComments []ReviewComment
+
+	// GameReview is request of vendor to publish game. Review keeps copy of data submitted by vendor,
+	// approval publishes it only if vendor data of game wasn't changed after submission.
+	GameReview struct {
+		Model
+		GameID      uuid.UUID    `gorm:"type:uuid; not null; index"`
+		VendorID    uuid.UUID    `gorm:"type:uuid; not null"`
+		Status      ReviewStatus `gorm:"not null"`
+		SubmitterID string       `gorm:"not null"`
+		ReviewerID  string
+		// Message is the last message of admin sent with change of status
+		Message  string
+		Comments ReviewComments   `gorm:"type:jsonb; not null; default:'[]'"`
+		Game     StoreGame        `gorm:"type:jsonb; not null"`
+		Packages SnapshotPackages `gorm:"type:jsonb; not null; default:'[]'"`
+		// Version is published version of game, it's set on approval
+		Version *int
+		// RestoredFrom is published version which submitted data was restored from on rollback
+		RestoredFrom *int
+	}
+
+	GameReviewService interface {
+		// SendToReview submits current vendor data of game, game can't have more than one pending review
+		SendToReview(userId string, gameId uuid.UUID) (*GameReview, error)
+		// Revoke cancels pending review of game by vendor
+		Revoke(userId string, gameId uuid.UUID) (*GameReview, error)
+		// Get returns the latest review of game
+		Get(gameId uuid.UUID) (*GameReview, error)
+		// GetQueue returns reviews with status, pending reviews are returned for undefined status. Reviews
+		// are ordered from the oldest one.
+		GetQueue(status ReviewStatus, offset, limit int) ([]GameReview, int, error)
+		// AddComment adds comment of admin to field of pending review
+		AddComment(userId string, gameId uuid.UUID, field, message string) (*GameReview, error)
+		// ChangeStatus changes status of pending review by admin, approval publishes game
+		ChangeStatus(userId string, gameId uuid.UUID, status ReviewStatus, message string) (*GameReview, error)
+		// Rollback restores vendor data of game from published version and sends it to review, restored data
+		// is published on approval
+		Rollback(userId string, gameId uuid.UUID, version int) (*GameReview, error)
+	}
+)
+
+func (GameReview) TableName() string {
+	return "game_reviews"
+}
+
+// IsPending checks that review isn't finished yet
+func (r *GameReview) IsPending() bool {
+	return r.Status == ReviewNew || r.Status == ReviewChecking
+}
+
+// GetSnapshot returns submitted data of game as unsaved snapshot
+func (r *GameReview) GetSnapshot() *GameSnapshot {
+	return &GameSnapshot{GameID: r.GameID, Game: r.Game, Packages: r.Packages}
+}
+
+// NewGameReview creates pending review from draft snapshot of game
+func NewGameReview(userId string, vendorId uuid.UUID, draft *GameSnapshot) *GameReview {
+	return &GameReview{
+		Model:       Model{ID: uuid.NewV4()},
+		GameID:      draft.GameID,
+		VendorID:    vendorId,
+		Status:      ReviewNew,
+		SubmitterID: userId,
+		Comments:    ReviewComments{},
+		Game:        draft.Game,
+		Packages:    draft.Packages,
+	}
+}
+
+func (c ReviewComments) Value() (driver.Value, error) {
+	if c == nil {
+		return "[]", nil
+	}
+	j, err := json.Marshal(c)
+	return string(j), err
+}
+
+func (c *ReviewComments) Scan(src interface{}) error {
+	source, ok := src.([]byte)
+	if !ok {
+		return errors.New("Type assertion .([]byte) failed.")
+	}
+	return json.Unmarshal(source, c)
+}
@@ -0,0 +1,219 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"github.com/satori/go.uuid"
+	"qilin-api/pkg/model/utils"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// SnapshotPackage is state of package containing game at the moment of publication
+	SnapshotPackage struct {
+		ID               uuid.UUID             `json:"id"`
+		Sku              string                `json:"sku"`
+		Name             utils.LocalizedString `json:"name"`
+		Image            utils.LocalizedString `json:"image"`
+		ImageCover       utils.LocalizedString `json:"imageCover"`
+		ImageThumb       utils.LocalizedString `json:"imageThumb"`
+		IsEnabled        bool                  `json:"isEnabled"`
+		IsUpgradeAllowed bool                  `json:"isUpgradeAllowed"`
+		Discount         uint                  `json:"discount"`
+		DiscountBuyOpt   BuyOption             `json:"discountBuyOpt"`
+		AllowedCountries []string              `json:"allowedCountries"`
+		DeniedCountries  []string              `json:"deniedCountries"`
+		Products         []uuid.UUID           `json:"products"`
+		Games            []uuid.UUID           `json:"games"`
+	}
+
+	SnapshotPackages []SnapshotPackage
+
+	// GameSnapshot is immutable published version of game. Version numbers grow by one with each
+	// publication of game, rollback publishes copy of earlier snapshot as new version.
+	GameSnapshot struct {
+		ID        uuid.UUID `gorm:"type:uuid; primary_key; default:gen_random_uuid()"`
+		CreatedAt time.Time `gorm:"default:now()"`
+		GameID    uuid.UUID `gorm:"type:uuid; not null; unique_index:idx_game_snapshots_version"`
+		Version   int       `gorm:"not null; unique_index:idx_game_snapshots_version"`
+		CreatorID string
+		// RestoredFrom is version which snapshot was copied from on rollback
+		RestoredFrom *int
+		Game         StoreGame        `gorm:"type:jsonb; not null"`
+		Packages     SnapshotPackages `gorm:"type:jsonb; not null; default:'[]'"`
+	}
+
+	// SnapshotChange is difference of single field between snapshots, Old or New is nil when field is added or removed
+	SnapshotChange struct {
+		Path string
+		Old  interface{}
+		New  interface{}
+	}
+
+	PublicationService interface {
+		// Publish freezes current vendor data of game into new snapshot and makes it visible in store catalog
+		Publish(userId string, gameId uuid.UUID) (*GameSnapshot, error)
+		// GetList returns snapshots of game from the latest to the first one
+		GetList(gameId uuid.UUID) ([]GameSnapshot, error)
+		// Get returns snapshot of game, zero version means the latest snapshot
+		Get(gameId uuid.UUID, version int) (*GameSnapshot, error)
+		// Draft returns unsaved snapshot of current vendor data of game
+		Draft(gameId uuid.UUID) (*GameSnapshot, error)
+		// Diff compares current vendor data of game with snapshot, zero version means the latest snapshot
+		Diff(gameId uuid.UUID, version int) ([]SnapshotChange, error)
+		// PublishReviewed publishes vendor data of game only if it's unchanged since review was submitted, exactly
+		// the compared data is published. Snapshot of restored game refers to the version it was restored from.
+		PublishReviewed(userId string, review *GameReview) (*GameSnapshot, error)
+		// Restore writes vendor data of game from snapshot and returns restored draft, it isn't published
+		Restore(gameId uuid.UUID, version int) (*GameSnapshot, error)
+		// WithTransaction returns publication service working in transaction of caller
+		WithTransaction(tx *gorm.DB) PublicationService
+	}
+)
+
+func (GameSnapshot) TableName() string {
+	return "game_snapshots"
+}
+
+// GetPackage returns package from snapshot
+func (s *GameSnapshot) GetPackage(packageId uuid.UUID) (*SnapshotPackage, bool) {
+	for i := range s.Packages {
+		if s.Packages[i].ID == packageId {
+			return &s.Packages[i], true
+		}
+	}
+	return nil, false
+}
+
+// NewSnapshotPackage captures state of package, games are products of package with game type
+func NewSnapshotPackage(pkg *Package) SnapshotPackage {
+	result := SnapshotPackage{
+		ID:               pkg.ID,
+		Sku:              pkg.Sku,
+		Name:             pkg.Name,
+		Image:            pkg.Image,
+		ImageCover:       pkg.ImageCover,
+		ImageThumb:       pkg.ImageThumb,
+		IsEnabled:        pkg.IsEnabled,
+		IsUpgradeAllowed: pkg.IsUpgradeAllowed,
+		Discount:         pkg.Discount,
+		DiscountBuyOpt:   pkg.DiscountBuyOpt,
+		AllowedCountries: append([]string{}, pkg.AllowedCountries...),
+		DeniedCountries:  append([]string{}, pkg.DeniedCountries...),
+		Products:         []uuid.UUID{},
+		Games:            []uuid.UUID{},
+	}
+	for _, pr := range pkg.Products {
+		result.Products = append(result.Products, pr.GetID())
+		if pr.GetType() == ProductGame {
+			result.Games = append(result.Games, pr.GetID())
+		}
+	}
+	return result
+}
+
+func (p *SnapshotPackage) GetRestrictions() RegionalRestrictions {
+	return RegionalRestrictions{AllowedCountries: p.AllowedCountries, DeniedCountries: p.DeniedCountries}
+}
+
+// DiffSnapshots returns changes between snapshots sorted by path. Objects are compared field by field,
+// arrays are compared as a whole. Packages are matched by id, their paths are `packages.<id>.<field>`.
+func DiffSnapshots(old, new *GameSnapshot) ([]SnapshotChange, error) {
+	oldFields, err := flattenSnapshot(old)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := flattenSnapshot(new)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []SnapshotChange{}
+	for path, oldValue := range oldFields {
+		newValue, ok := newFields[path]
+		if !ok {
+			result = append(result, SnapshotChange{Path: path, Old: oldValue})
+		} else if !reflect.DeepEqual(oldValue, newValue) {
+			result = append(result, SnapshotChange{Path: path, Old: oldValue, New: newValue})
+		}
+	}
+	for path, newValue := range newFields {
+		if _, ok := oldFields[path]; !ok {
+			result = append(result, SnapshotChange{Path: path, New: newValue})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result, nil
+}
+
+// HasField checks that path points to field of snapshot or to object containing fields, e.g. `game.media`
+func (s *GameSnapshot) HasField(path string) (bool, error) {
+	fields, err := flattenSnapshot(s)
+	if err != nil {
+		return false, err
+	}
+	for field := range fields {
+		if field == path || strings.HasPrefix(field, path+".") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func flattenSnapshot(s *GameSnapshot) (map[string]interface{}, error) {
+	packages := map[string]SnapshotPackage{}
+	for _, pkg := range s.Packages {
+		packages[pkg.ID.String()] = pkg
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"game": s.Game, "packages": packages})
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{}
+	flattenValue("", doc, result)
+	return result, nil
+}
+
+func flattenValue(path string, value interface{}, result map[string]interface{}) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		result[path] = value
+		return
+	}
+	for key, field := range object {
+		fieldPath := key
+		if path != "" {
+			fieldPath = fmt.Sprintf("%s.%s", path, key)
+		}
+		flattenValue(fieldPath, field, result)
+	}
+}
+
+func (p SnapshotPackages) Value() (driver.Value, error) {
+	if p == nil {
+		return "[]", nil
+	}
+	j, err := json.Marshal(p)
+	return string(j), err
+}
+
+func (p *SnapshotPackages) Scan(src interface{}) error {
+	source, ok := src.([]byte)
+	if !ok {
+		return errors.New("Type assertion .([]byte) failed.")
+	}
+	return json.Unmarshal(source, p)
+}
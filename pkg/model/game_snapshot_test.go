@@ -0,0 +1,123 @@
+package model_test
+
+import (
+	"github.com/satori/go.uuid"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/model/utils"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DiffSnapshots(t *testing.T) {
+	shouldBe := require.New(t)
+
+	first := uuid.NewV4()
+	second := uuid.NewV4()
+	old := &model.GameSnapshot{
+		Game: model.StoreGame{
+			Title:       "Game",
+			Description: utils.LocalizedString{EN: "Old"},
+			Tags:        []model.GameTag{{ID: 1}},
+		},
+		Packages: model.SnapshotPackages{
+			{ID: first, IsEnabled: false},
+			{ID: second, IsEnabled: true},
+		},
+	}
+	new := &model.GameSnapshot{
+		Game: model.StoreGame{
+			Title:       "Game",
+			Description: utils.LocalizedString{EN: "New"},
+			Tags:        []model.GameTag{{ID: 1}, {ID: 2}},
+		},
+		Packages: model.SnapshotPackages{
+			{ID: first, IsEnabled: true},
+		},
+	}
+
+	changes, err := model.DiffSnapshots(old, old)
+	shouldBe.Nil(err)
+	shouldBe.Len(changes, 0)
+
+	changes, err = model.DiffSnapshots(old, new)
+	shouldBe.Nil(err)
+
+	paths := map[string]model.SnapshotChange{}
+	for _, change := range changes {
+		paths[change.Path] = change
+	}
+
+	shouldBe.Contains(paths, "game.description.en")
+	shouldBe.Equal("Old", paths["game.description.en"].Old)
+	shouldBe.Equal("New", paths["game.description.en"].New)
+
+	// Arrays are compared as a whole
+	shouldBe.Contains(paths, "game.tags")
+	shouldBe.NotContains(paths, "game.title")
+
+	shouldBe.Contains(paths, "packages."+first.String()+".isEnabled")
+	shouldBe.Equal(false, paths["packages."+first.String()+".isEnabled"].Old)
+	shouldBe.Equal(true, paths["packages."+first.String()+".isEnabled"].New)
+
+	// Removed package has no new values
+	removed := paths["packages."+second.String()+".isEnabled"]
+	shouldBe.Equal(true, removed.Old)
+	shouldBe.Nil(removed.New)
+
+	for i := 1; i < len(changes); i++ {
+		shouldBe.True(changes[i-1].Path < changes[i].Path)
+	}
+}
+
+func Test_NewSnapshotPackage(t *testing.T) {
+	shouldBe := require.New(t)
+
+	gameId := uuid.NewV4()
+	pkg := &model.Package{
+		Model:            model.Model{ID: uuid.NewV4()},
+		Name:             utils.LocalizedString{EN: "Package"},
+		IsEnabled:        true,
+		DeniedCountries:  []string{"CN"},
+		Products:         []model.Product{&model.ProductGameImpl{Game: model.Game{ID: gameId}}},
+		AllowedCountries: nil,
+	}
+
+	snapshot := model.NewSnapshotPackage(pkg)
+	shouldBe.Equal(pkg.ID, snapshot.ID)
+	shouldBe.Equal("Package", snapshot.Name.EN)
+	shouldBe.True(snapshot.IsEnabled)
+	shouldBe.Equal([]uuid.UUID{gameId}, snapshot.Products)
+	shouldBe.Equal([]uuid.UUID{gameId}, snapshot.Games)
+	shouldBe.NotNil(snapshot.AllowedCountries)
+	shouldBe.False(snapshot.GetRestrictions().IsAvailable("CN"))
+
+	gameSnapshot := &model.GameSnapshot{Packages: model.SnapshotPackages{snapshot}}
+	found, ok := gameSnapshot.GetPackage(pkg.ID)
+	shouldBe.True(ok)
+	shouldBe.Equal(snapshot.ID, found.ID)
+	_, ok = gameSnapshot.GetPackage(uuid.NewV4())
+	shouldBe.False(ok)
+}
+
+func Test_GameSnapshotHasField(t *testing.T) {
+	shouldBe := require.New(t)
+
+	packageId := uuid.NewV4()
+	snapshot := &model.GameSnapshot{
+		Game:     model.StoreGame{Title: "Game"},
+		Packages: model.SnapshotPackages{{ID: packageId}},
+	}
+
+	for _, field := range []string{"game.title", "game.description.en", "game.media", "packages." + packageId.String(), "packages." + packageId.String() + ".name.en"} {
+		exists, err := snapshot.HasField(field)
+		shouldBe.Nil(err)
+		shouldBe.True(exists, field)
+	}
+
+	for _, field := range []string{"", "game.tit", "game.unknown", "packages." + uuid.NewV4().String()} {
+		exists, err := snapshot.HasField(field)
+		shouldBe.Nil(err)
+		shouldBe.False(exists, field)
+	}
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"fmt"
 	uuid "github.com/satori/go.uuid"
 	"time"
 )
@@ -8,7 +9,45 @@ import (
 type Key struct {
 	Model
 
-	KeyStreamID    uuid.UUID
-	ActivationCode string `gorm:"index:activation_code;not null"`
+	KeyStreamID    uuid.UUID `gorm:"unique_index:key_stream_activation_code"`
+	ActivationCode string    `gorm:"index:activation_code;unique_index:key_stream_activation_code;not null"`
 	RedeemTime     *time.Time
+	// RevokeTime is set when key is voided (e.g. leaked), revoked keys are never redeemed
+	RevokeTime *time.Time
+	// ActivationTime is set when license is issued for the key
+	ActivationTime *time.Time
+	// BatchID is set for keys generated in advance. Such keys are issued, but not redeemed until activation
+	BatchID *uuid.UUID `gorm:"type:uuid;index"`
+}
+
+// KeyRevocation is audit record about who and why revoked the key
+type KeyRevocation struct {
+	Model
+
+	KeyID  uuid.UUID `gorm:"type:uuid;index"`
+	UserID string    `gorm:"not null"`
+	Reason string    `gorm:"not null"`
+}
+
+type KeyState int
+
+const (
+	KeyStateAll KeyState = iota
+	KeyStateRedeemed
+	KeyStateUnredeemed
+	KeyStateRevoked
+)
+
+func KeyStateFromString(state string) (KeyState, error) {
+	switch state {
+	case "":
+		return KeyStateAll, nil
+	case "redeemed":
+		return KeyStateRedeemed, nil
+	case "unredeemed":
+		return KeyStateUnredeemed, nil
+	case "revoked":
+		return KeyStateRevoked, nil
+	}
+	return KeyStateAll, fmt.Errorf("Unknown key state `%s`", state)
 }
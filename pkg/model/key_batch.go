@@ -0,0 +1,22 @@
+package model
+
+import uuid "github.com/satori/go.uuid"
+
+// KeyBatch is set of platform keys generated in advance for retail or partner distribution
+type KeyBatch struct {
+	Model
+
+	KeyPackageID uuid.UUID `gorm:"type:uuid;index"`
+	PartnerLabel string    `gorm:"not null"`
+	Count        int       `gorm:"not null"`
+	// CreatedBy is id of user generated the batch
+	CreatedBy string `gorm:"not null"`
+}
+
+type KeyBatchService interface {
+	Create(keyPackageId uuid.UUID, userId, partnerLabel string, count int) (*KeyBatch, error)
+	List(keyPackageId uuid.UUID) ([]KeyBatch, error)
+	// Get returns batch of key package, batches of other key packages are not found
+	Get(keyPackageId, batchId uuid.UUID) (*KeyBatch, error)
+	GetKeys(keyPackageId, batchId uuid.UUID) ([]Key, error)
+}
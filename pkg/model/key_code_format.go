@@ -0,0 +1,138 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	KeyCodeSeparator = "-"
+
+	maxKeyCodeGroups      = 10
+	maxKeyCodeGroupLength = 16
+	maxKeyCodePrefix      = 16
+	// minKeyCodeEntropy is minimal count of random bits in code, lower values leads to often collisions
+	minKeyCodeEntropy = 32
+)
+
+// KeyCodeFormat is template of activation codes generated by platform key stream.
+// Code looks like `PREFIX` + groups joined with `-`, checksum character is appended to the last group.
+// Example for default format: AAAA-BBBB-CCCC-DDDD
+type KeyCodeFormat struct {
+	GroupCount  int    `json:"groupCount"`
+	GroupLength int    `json:"groupLength"`
+	Alphabet    string `json:"alphabet"`
+	// Exclude contains ambiguous characters removed from alphabet, e.g. `0O1I`
+	Exclude  string `json:"exclude"`
+	Prefix   string `json:"prefix"`
+	Checksum bool   `json:"checksum"`
+}
+
+func DefaultKeyCodeFormat() KeyCodeFormat {
+	return KeyCodeFormat{
+		GroupCount:  4,
+		GroupLength: 4,
+		Alphabet:    "1234567890ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	}
+}
+
+// OrDefault returns default format for empty (not configured) format
+func (format KeyCodeFormat) OrDefault() KeyCodeFormat {
+	if format.GroupCount == 0 && format.GroupLength == 0 && format.Alphabet == "" {
+		return DefaultKeyCodeFormat()
+	}
+	return format
+}
+
+// Runes returns alphabet without excluded characters
+func (format KeyCodeFormat) Runes() []rune {
+	var result []rune
+	for _, r := range format.Alphabet {
+		if !strings.ContainsRune(format.Exclude, r) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+func (format KeyCodeFormat) Validate() error {
+	if format.GroupCount < 1 || format.GroupCount > maxKeyCodeGroups {
+		return fmt.Errorf("Group count must be between 1 and %d", maxKeyCodeGroups)
+	}
+
+	if format.GroupLength < 1 || format.GroupLength > maxKeyCodeGroupLength {
+		return fmt.Errorf("Group length must be between 1 and %d", maxKeyCodeGroupLength)
+	}
+
+	seen := make(map[rune]bool)
+	for _, r := range format.Alphabet {
+		if seen[r] {
+			return fmt.Errorf("Alphabet contains `%c` twice", r)
+		}
+		if unicode.IsSpace(r) || strings.ContainsRune(KeyCodeSeparator, r) {
+			return fmt.Errorf("Alphabet contains not allowed character `%c`", r)
+		}
+		seen[r] = true
+	}
+
+	runes := format.Runes()
+	if len(runes) < 2 {
+		return errors.New("Alphabet must contain at least two characters after exclusion")
+	}
+
+	if len([]rune(format.Prefix)) > maxKeyCodePrefix {
+		return fmt.Errorf("Prefix must be not longer than %d characters", maxKeyCodePrefix)
+	}
+	if strings.IndexFunc(format.Prefix, unicode.IsSpace) >= 0 {
+		return errors.New("Prefix must not contain spaces")
+	}
+
+	entropy := float64(format.GroupCount*format.GroupLength) * math.Log2(float64(len(runes)))
+	if entropy < minKeyCodeEntropy {
+		return fmt.Errorf("Format allows too few codes, increase groups or alphabet")
+	}
+
+	return nil
+}
+
+// ChecksumRune calculates Luhn mod N check character for payload, payload must consist of format runes
+func (format KeyCodeFormat) ChecksumRune(payload []rune) (rune, error) {
+	runes := format.Runes()
+	index := make(map[rune]int, len(runes))
+	for i, r := range runes {
+		index[r] = i
+	}
+
+	n := len(runes)
+	factor := 2
+	sum := 0
+	for i := len(payload) - 1; i >= 0; i-- {
+		codePoint, ok := index[payload[i]]
+		if !ok {
+			return 0, fmt.Errorf("Character `%c` is not in alphabet", payload[i])
+		}
+		addend := factor * codePoint
+		factor = 3 - factor
+		sum += addend/n + addend%n
+	}
+
+	return runes[(n-sum%n)%n], nil
+}
+
+func (format KeyCodeFormat) Value() (driver.Value, error) {
+	j, err := json.Marshal(format)
+	return string(j), err
+}
+
+func (format *KeyCodeFormat) Scan(src interface{}) error {
+	source, ok := src.([]byte)
+	if !ok {
+		return errors.New("Type assertion .([]byte) failed.")
+	}
+	return json.Unmarshal(source, format)
+}
@@ -0,0 +1,52 @@
+package model_test
+
+import (
+	"qilin-api/pkg/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_KeyCodeFormatValidate(t *testing.T) {
+	shouldBe := require.New(t)
+	shouldBe.Nil(model.DefaultKeyCodeFormat().Validate())
+	shouldBe.Equal(model.DefaultKeyCodeFormat(), model.KeyCodeFormat{}.OrDefault())
+
+	format := model.DefaultKeyCodeFormat()
+	format.GroupCount = 0
+	shouldBe.NotNil(format.Validate())
+
+	format = model.DefaultKeyCodeFormat()
+	format.Alphabet = "AAB"
+	shouldBe.NotNil(format.Validate())
+
+	format = model.DefaultKeyCodeFormat()
+	format.Alphabet = "AB-"
+	shouldBe.NotNil(format.Validate())
+
+	format = model.DefaultKeyCodeFormat()
+	format.Alphabet = "AB"
+	format.Exclude = "B"
+	shouldBe.NotNil(format.Validate())
+
+	format = model.DefaultKeyCodeFormat()
+	format.Prefix = "SOME PREFIX"
+	shouldBe.NotNil(format.Validate())
+
+	// 2^8 codes only
+	format = model.KeyCodeFormat{GroupCount: 2, GroupLength: 4, Alphabet: "AB"}
+	shouldBe.NotNil(format.Validate())
+}
+
+func Test_KeyCodeFormatChecksum(t *testing.T) {
+	shouldBe := require.New(t)
+	format := model.KeyCodeFormat{Alphabet: "0123456789"}
+
+	// Luhn mod 10 is classic Luhn algorithm
+	checksum, err := format.ChecksumRune([]rune("7992739871"))
+	shouldBe.Nil(err)
+	shouldBe.Equal('3', checksum)
+
+	_, err = format.ChecksumRune([]rune("79A"))
+	shouldBe.NotNil(err)
+}
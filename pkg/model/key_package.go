@@ -1,6 +1,9 @@
 package model
 
-import uuid "github.com/satori/go.uuid"
+import (
+	uuid "github.com/satori/go.uuid"
+	"time"
+)
 
 type KeyPackage struct {
 	Model
@@ -8,6 +11,46 @@ type KeyPackage struct {
 	KeyStreamID   uuid.UUID
 	PackageID     uuid.UUID
 	KeyStreamType KeyStreamType
+	// Vendor is notified when count of free keys drops below this value. Zero disables notification.
+	LowStockThreshold int `gorm:"not null;default:0"`
+	// CodePattern is optional regular expression every imported activation code must match
+	CodePattern string `gorm:"not null;default:''"`
+	// CodeFormat is template of codes generated by platform key stream, empty means default format
+	CodeFormat KeyCodeFormat `gorm:"type:jsonb; not null; default:'{}'"`
+	// ActivationPolicy of licenses issued for keys of key package
+	ActivationPolicy string `gorm:"not null;default:'perpetual'"`
+	// LicenseDays is duration of time-limited and subscription licenses
+	LicenseDays int `gorm:"not null;default:0"`
+}
+
+// KeyPackageInventory is statistics about keys in key package
+type KeyPackageInventory struct {
+	Total     int
+	Redeemed  int
+	Revoked   int
+	Available int
+	// Unlimited is true for streams that generate keys on demand
+	Unlimited   bool
+	Redemptions []KeyRedemptionStat
+}
+
+// KeyImportReport describes result of keys import, line numbers start from 1
+type KeyImportReport struct {
+	Accepted   []KeyImportLine
+	Duplicates []KeyImportLine
+	Rejected   []KeyImportLine
+}
+
+type KeyImportLine struct {
+	Line   int
+	Code   string
+	Reason string
+}
+
+// KeyRedemptionStat is count of redeemed keys in period started at Date
+type KeyRedemptionStat struct {
+	Date  time.Time
+	Count int
 }
 
 type KeyStreamType string
@@ -23,11 +66,22 @@ const (
 
 type KeyPackageService interface {
 	Create(packageId uuid.UUID, name string, providerType KeyStreamType) (*KeyPackage, error)
-	Update(keyPackageId uuid.UUID, name string) (*KeyPackage, error)
+	Update(keyPackageId uuid.UUID, name string, lowStockThreshold int, codePattern string) (*KeyPackage, error)
 	List(packageId uuid.UUID) ([]KeyPackage, error)
 	Get(keyPackageId uuid.UUID) (*KeyPackage, error)
+	Redeem(keyPackageId uuid.UUID) (*Key, error)
+	RedeemList(keyPackageId uuid.UUID, count int) ([]Key, error)
+	GetInventory(keyPackageId uuid.UUID, from, to time.Time, period string) (*KeyPackageInventory, error)
+	UpdateCodeFormat(keyPackageId uuid.UUID, format KeyCodeFormat) (*KeyPackage, error)
+	UpdateActivationPolicy(keyPackageId uuid.UUID, policy string, licenseDays int) (*KeyPackage, error)
 }
 
 type KeyListService interface {
 	AddKeys(keyPackageId uuid.UUID, keys []string) error
+	// ImportKeys adds keys skipping empty lines and duplicates, each element of lines is one line of import
+	ImportKeys(keyPackageId uuid.UUID, lines []string) (*KeyImportReport, error)
+	// List returns keys of key package in specified state, all keys are returned if limit is zero
+	List(keyPackageId uuid.UUID, state KeyState, offset, limit int) (total int, keys []Key, err error)
+	// Revoke voids keys with specified activation codes and returns count of revoked keys
+	Revoke(keyPackageId uuid.UUID, userId string, codes []string, reason string) (int, error)
 }
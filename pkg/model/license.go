@@ -1,20 +1,113 @@
 package model
 
 import (
+	"errors"
+	"fmt"
 	"github.com/satori/go.uuid"
 	"time"
 )
 
+const (
+	// ActivationPolicyPerpetual is license without end date
+	ActivationPolicyPerpetual = "perpetual"
+	// ActivationPolicyTimeLimited is license valid for fixed count of days since activation
+	ActivationPolicyTimeLimited = "time_limited"
+	// ActivationPolicySubscription is license valid for paid period, it is extended by period on every renewal
+	ActivationPolicySubscription = "subscription"
+
+	// SubscriptionGracePeriod is time after end of paid period while subscription license stays active and
+	// can be renewed without break, renewal payment may come a bit late
+	SubscriptionGracePeriod = 72 * time.Hour
+)
+
+var activationPolicies = []string{ActivationPolicyPerpetual, ActivationPolicyTimeLimited, ActivationPolicySubscription}
+
+// CheckActivationPolicy validates policy and its duration in days, perpetual policy must not have duration
+func CheckActivationPolicy(policy string, durationDays int) error {
+	found := false
+	for _, p := range activationPolicies {
+		if p == policy {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("Unknown activation policy `%s`", policy)
+	}
+
+	if policy == ActivationPolicyPerpetual && durationDays != 0 {
+		return errors.New("Perpetual policy must not have duration")
+	}
+
+	if policy != ActivationPolicyPerpetual && durationDays <= 0 {
+		return errors.New("Duration must be greater than zero")
+	}
+
+	return nil
+}
+
 type (
 	License struct {
 		Model
-		ExternalContext  string
-		OrderID          string
+		ExternalContext string
+		OrderID         string `gorm:"index"`
+		// UserID is external id of user who owns the license
+		UserID string `gorm:"index"`
+		// KeyID is activated key, unique index guarantees that key is activated once
+		KeyID            *uuid.UUID `gorm:"type:uuid;unique_index"`
 		ActivationCode   string
 		ActivationPolicy string
 		StartDate        time.Time
-		EndDate          time.Time
-		Package          Package
-		PackageID        uuid.UUID
+		// EndDate is nil for perpetual license
+		EndDate      *time.Time
+		RevokeTime   *time.Time
+		RevokeReason string
+		Package      Package
+		PackageID    uuid.UUID `gorm:"type:uuid;index"`
+	}
+
+	// KeyValidation is result of activation code check
+	KeyValidation struct {
+		Valid     bool
+		Reason    string
+		PackageID uuid.UUID
+	}
+
+	LicenseService interface {
+		// Activate finds key by activation code and issues license for package of the key to the user
+		Activate(userId, activationCode, orderId, externalContext string) (*License, error)
+		// Validate checks that activation code can be activated
+		Validate(activationCode string) (*KeyValidation, error)
+		// List returns licenses of package, empty userId and orderId are not used for filtering
+		List(packageId uuid.UUID, userId, orderId string, offset, limit int) (total int, licenses []License, err error)
+		Revoke(packageId, licenseId uuid.UUID, reason string) (*License, error)
+		// Extend moves end date of time-limited or subscription license
+		Extend(packageId, licenseId uuid.UUID, endDate time.Time) (*License, error)
+		// Renew extends subscription license by period of its key package on renewal payment. Subscription is
+		// renewed from its current end date, so it can't be renewed after grace period is over.
+		Renew(packageId, licenseId uuid.UUID) (*License, error)
+		// GetActive returns license giving ownership of package to user at the moment, nil if user doesn't own package
+		GetActive(userId string, packageId uuid.UUID) (*License, error)
 	}
 )
+
+// IsActive reports whether license gives ownership of package at the moment t
+func (license *License) IsActive(t time.Time) bool {
+	if license.RevokeTime != nil || t.Before(license.StartDate) {
+		return false
+	}
+
+	if license.EndDate == nil {
+		return true
+	}
+
+	return t.Before(license.ActiveUntil())
+}
+
+// ActiveUntil returns time when license with end date stops giving ownership, subscription is kept active
+// during grace period
+func (license *License) ActiveUntil() time.Time {
+	if license.ActivationPolicy == ActivationPolicySubscription {
+		return license.EndDate.Add(SubscriptionGracePeriod)
+	}
+	return *license.EndDate
+}
@@ -0,0 +1,47 @@
+package model_test
+
+import (
+	"qilin-api/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CheckActivationPolicy(t *testing.T) {
+	shouldBe := require.New(t)
+	shouldBe.Nil(model.CheckActivationPolicy(model.ActivationPolicyPerpetual, 0))
+	shouldBe.Nil(model.CheckActivationPolicy(model.ActivationPolicyTimeLimited, 7))
+	shouldBe.Nil(model.CheckActivationPolicy(model.ActivationPolicySubscription, 30))
+	shouldBe.NotNil(model.CheckActivationPolicy(model.ActivationPolicyPerpetual, 10))
+	shouldBe.NotNil(model.CheckActivationPolicy(model.ActivationPolicySubscription, 0))
+	shouldBe.NotNil(model.CheckActivationPolicy("lifetime", 0))
+}
+
+func Test_LicenseIsActive(t *testing.T) {
+	shouldBe := require.New(t)
+	now := time.Now()
+	license := model.License{StartDate: now.Add(-time.Hour)}
+	shouldBe.True(license.IsActive(now))
+	shouldBe.False(license.IsActive(now.Add(-2 * time.Hour)))
+
+	endDate := now.Add(time.Hour)
+	license.EndDate = &endDate
+	shouldBe.True(license.IsActive(now))
+	shouldBe.False(license.IsActive(now.Add(2 * time.Hour)))
+
+	license.RevokeTime = &now
+	shouldBe.False(license.IsActive(now))
+}
+
+func Test_SubscriptionGracePeriod(t *testing.T) {
+	shouldBe := require.New(t)
+	now := time.Now()
+	endDate := now.Add(-time.Hour)
+	license := model.License{StartDate: now.AddDate(0, -1, 0), EndDate: &endDate, ActivationPolicy: model.ActivationPolicySubscription}
+	shouldBe.True(license.IsActive(now), "Subscription is active during grace period")
+	shouldBe.False(license.IsActive(endDate.Add(model.SubscriptionGracePeriod)))
+
+	license.ActivationPolicy = model.ActivationPolicyTimeLimited
+	shouldBe.False(license.IsActive(now), "Time-limited license has no grace period")
+}
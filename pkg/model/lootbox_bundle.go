@@ -0,0 +1,174 @@
+package model
+
+import (
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"math/rand"
+	"qilin-api/pkg/model/utils"
+)
+
+type (
+	// LootboxBundle is bundle which gives one random package from drop table on opening.
+	// Chance of package is weight of item divided by total weight of drop table.
+	LootboxBundle struct {
+		Model
+		Bundle    BundleEntry `gorm:"polymorphic:Entry;polymorphic_value:lootbox"`
+		Sku       string
+		Name      utils.LocalizedString `gorm:"type:jsonb; not null; default:'{}'"`
+		IsEnabled bool
+		VendorID  uuid.UUID
+		CreatorID string
+		// Price of lootbox opening
+		Price    float32
+		Currency string
+		// RegionalRestrinctions
+		AllowedCountries pq.StringArray `gorm:"type:text[]"`
+		DeniedCountries  pq.StringArray `gorm:"type:text[]; default:'{}'"`
+		// Drop table
+		Items []LootboxItem `gorm:"-"`
+	}
+
+	// LootboxItem is entry of lootbox drop table
+	LootboxItem struct {
+		ID        uint      `gorm:"primary_key"`
+		BundleID  uuid.UUID `gorm:"type:uuid; not null; index"`
+		PackageID uuid.UUID `gorm:"type:uuid; not null"`
+		Weight    uint      `gorm:"not null"`
+		// Rarity is vendor defined label shown in drop disclosure, e.g. `common` or `legendary`
+		Rarity   string
+		Position int
+		Package  *Package `gorm:"-"`
+	}
+
+	// LootboxDrop contains disclosed chance of item
+	LootboxDrop struct {
+		PackageID   uuid.UUID
+		Rarity      string
+		Weight      uint
+		Probability float64
+	}
+)
+
+func (b *LootboxBundle) GetID() uuid.UUID {
+	return b.ID
+}
+
+func (b *LootboxBundle) GetName() *utils.LocalizedString {
+	return &b.Name
+}
+
+func (b *LootboxBundle) IsContains(productId uuid.UUID) (bool, error) {
+	packages, err := b.GetPackages()
+	if err != nil {
+		return false, err
+	}
+	for _, pkg := range packages {
+		for _, pr := range pkg.Products {
+			if pr.GetID() == productId {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (b *LootboxBundle) GetPrice() (currency string, price float32, discount float32, err error) {
+	return b.Currency, b.Price, 0, nil
+}
+
+func (b *LootboxBundle) GetRestrictions() RegionalRestrictions {
+	return RegionalRestrictions{AllowedCountries: b.AllowedCountries, DeniedCountries: b.DeniedCountries}
+}
+
+// GetPackages returns packages of loaded drop table
+func (b *LootboxBundle) GetPackages() (packages []Package, err error) {
+	packages = []Package{}
+	for _, item := range b.Items {
+		if item.Package == nil {
+			return nil, errors.New("Package of lootbox item isn't loaded")
+		}
+		packages = append(packages, *item.Package)
+	}
+	return
+}
+
+func (b *LootboxBundle) GetGames() (games []*ProductGameImpl, err error) {
+	games = []*ProductGameImpl{}
+	packages, err := b.GetPackages()
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range packages {
+		for _, pr := range pkg.Products {
+			if pr.GetType() == ProductGame {
+				game, ok := pr.(*ProductGameImpl)
+				if !ok {
+					return nil, errors.New("Incorrect product type")
+				}
+				games = append(games, game)
+			}
+		}
+	}
+	return
+}
+
+func (b *LootboxBundle) GetDlc() (dlcs []Dlc, err error) {
+	dlcs = []Dlc{}
+	packages, err := b.GetPackages()
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range packages {
+		for _, pr := range pkg.Products {
+			if pr.GetType() == ProductDLC {
+				dlc, ok := pr.(*Dlc)
+				if !ok {
+					return nil, errors.New("Incorrect product type")
+				}
+				dlcs = append(dlcs, *dlc)
+			}
+		}
+	}
+	return
+}
+
+// GetDrops returns probabilities of drop table items for disclosure
+func (b *LootboxBundle) GetDrops() []LootboxDrop {
+	var total uint
+	for _, item := range b.Items {
+		total += item.Weight
+	}
+
+	drops := make([]LootboxDrop, 0, len(b.Items))
+	for _, item := range b.Items {
+		drop := LootboxDrop{PackageID: item.PackageID, Rarity: item.Rarity, Weight: item.Weight}
+		if total > 0 {
+			drop.Probability = float64(item.Weight) / float64(total)
+		}
+		drops = append(drops, drop)
+	}
+	return drops
+}
+
+// Draw picks item of drop table with chance proportional to its weight.
+// Result depends only on drop table and random source, so the same seed gives the same item.
+func (b *LootboxBundle) Draw(source rand.Source) (*LootboxItem, error) {
+	var total int64
+	for _, item := range b.Items {
+		total += int64(item.Weight)
+	}
+	if total == 0 {
+		return nil, errors.New("Drop table is empty")
+	}
+
+	point := rand.New(source).Int63n(total)
+	for i := range b.Items {
+		point -= int64(b.Items[i].Weight)
+		if point < 0 {
+			return &b.Items[i], nil
+		}
+	}
+
+	return nil, errors.New("Drop table is inconsistent")
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"testing"
+)
+
+func TestLootboxDraw(t *testing.T) {
+	should := require.New(t)
+
+	lootbox := &LootboxBundle{Items: []LootboxItem{
+		{PackageID: uuid.NewV4(), Weight: 70, Rarity: "common"},
+		{PackageID: uuid.NewV4(), Weight: 25, Rarity: "rare"},
+		{PackageID: uuid.NewV4(), Weight: 5, Rarity: "legendary"},
+	}}
+
+	// Same seed gives same sequence
+	for seed := int64(0); seed < 100; seed++ {
+		first, err := lootbox.Draw(rand.NewSource(seed))
+		should.Nil(err)
+		second, err := lootbox.Draw(rand.NewSource(seed))
+		should.Nil(err)
+		should.Equal(first.PackageID, second.PackageID)
+	}
+
+	// Frequencies follow weights
+	counts := map[string]int{}
+	source := rand.NewSource(42)
+	const draws = 100000
+	for i := 0; i < draws; i++ {
+		item, err := lootbox.Draw(source)
+		should.Nil(err)
+		counts[item.Rarity]++
+	}
+	should.InDelta(0.70, float64(counts["common"])/draws, 0.01)
+	should.InDelta(0.25, float64(counts["rare"])/draws, 0.01)
+	should.InDelta(0.05, float64(counts["legendary"])/draws, 0.01)
+
+	drops := lootbox.GetDrops()
+	should.Len(drops, 3)
+	should.InDelta(0.05, drops[2].Probability, 1e-9)
+	should.Equal("legendary", drops[2].Rarity)
+}
+
+func TestLootboxDrawEmpty(t *testing.T) {
+	should := require.New(t)
+
+	_, err := (&LootboxBundle{}).Draw(rand.NewSource(1))
+	should.NotNil(err)
+
+	_, err = (&LootboxBundle{Items: []LootboxItem{{PackageID: uuid.NewV4()}}}).Draw(rand.NewSource(1))
+	should.NotNil(err)
+
+	should.Len((&LootboxBundle{}).GetDrops(), 0)
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"github.com/satori/go.uuid"
+	"time"
+)
+
+type OutboxStatus int8
+
+const (
+	OutboxUndefined OutboxStatus = -1
+	OutboxPending   OutboxStatus = 0 //`pending`
+	OutboxDelivered OutboxStatus = 1 //`delivered`
+	OutboxFailed    OutboxStatus = 2 //`failed`
+
+	// Encodings of payload, legacy events are protobuf messages and typed events are JSON envelopes
+	OutboxProtobufEncoding = "protobuf"
+	OutboxJSONEncoding     = "json"
+
+	// OutboxMaxRetryDelay limits exponential growth of delay between delivery attempts
+	OutboxMaxRetryDelay = time.Hour
+)
+
+type (
+	// OutboxEvent is message for event bus saved in the same transaction as domain change. Dispatcher
+	// delivers pending events to broker, event is failed (dead-lettered) when all attempts are spent.
+	OutboxEvent struct {
+		ID            uuid.UUID    `gorm:"type:uuid; primary_key; default:gen_random_uuid()"`
+		CreatedAt     time.Time    `gorm:"default:now()"`
+		Topic         string       `gorm:"not null; index"`
+		AggregateID   uuid.UUID    `gorm:"type:uuid; not null; unique_index:idx_outbox_events_aggregate_sequence"`
+		Sequence      int64        `gorm:"not null; unique_index:idx_outbox_events_aggregate_sequence"`
+		CorrelationID uuid.UUID    `gorm:"type:uuid; not null"`
+		SchemaVersion int          `gorm:"not null; default:1"`
+		Encoding      string       `gorm:"not null; default:'protobuf'"`
+		Payload       []byte       `gorm:"type:bytea; not null"`
+		Status        OutboxStatus `gorm:"not null; index"`
+		Attempts      int          `gorm:"not null; default:0"`
+		NextAttemptAt time.Time    `gorm:"not null; index"`
+		LastError     string
+		DeliveredAt   *time.Time
+	}
+
+	OutboxService interface {
+		// GetEvents returns events with status and topic, filters are skipped for undefined status and empty topic
+		GetEvents(status OutboxStatus, topic string, offset, limit int) ([]OutboxEvent, int, error)
+		Get(id uuid.UUID) (*OutboxEvent, error)
+		// Replay returns failed event to delivery with reset attempts
+		Replay(id uuid.UUID) (*OutboxEvent, error)
+		// ReplayFailed returns all failed events of topic to delivery, empty topic means all topics
+		ReplayFailed(topic string) (int, error)
+	}
+)
+
+func (OutboxEvent) TableName() string {
+	return "outbox_events"
+}
+
+// Deliver marks event as delivered
+func (e *OutboxEvent) Deliver(t time.Time) {
+	e.Status = OutboxDelivered
+	e.Attempts++
+	e.LastError = ""
+	e.DeliveredAt = &t
+}
+
+// Fail schedules next attempt of delivery with exponential backoff, event is failed after last attempt
+func (e *OutboxEvent) Fail(err error, t time.Time, maxAttempts int, retryDelay time.Duration) {
+	e.Attempts++
+	e.LastError = err.Error()
+	if e.Attempts >= maxAttempts {
+		e.Status = OutboxFailed
+		return
+	}
+	e.NextAttemptAt = t.Add(OutboxRetryDelay(e.Attempts, retryDelay))
+}
+
+// Replay returns event to delivery as new one
+func (e *OutboxEvent) Replay(t time.Time) {
+	e.Status = OutboxPending
+	e.Attempts = 0
+	e.NextAttemptAt = t
+}
+
+// OutboxRetryDelay doubles delay after each failed attempt
+func OutboxRetryDelay(attempts int, retryDelay time.Duration) time.Duration {
+	delay := retryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= OutboxMaxRetryDelay {
+			return OutboxMaxRetryDelay
+		}
+	}
+	return delay
+}
+
+func OutboxStatusFromString(status string) (OutboxStatus, error) {
+	switch status {
+	case "":
+		return OutboxUndefined, nil
+	case "pending":
+		return OutboxPending, nil
+	case "delivered":
+		return OutboxDelivered, nil
+	case "failed":
+		return OutboxFailed, nil
+	}
+	return OutboxUndefined, errors.New(fmt.Sprintf("Unknown event status `%s`", status))
+}
+
+func (status OutboxStatus) ToString() string {
+	switch status {
+	case OutboxPending:
+		return "pending"
+	case OutboxDelivered:
+		return "delivered"
+	case OutboxFailed:
+		return "failed"
+	}
+
+	return ""
+}
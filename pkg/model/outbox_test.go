@@ -0,0 +1,65 @@
+package model_test
+
+import (
+	"errors"
+	"qilin-api/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OutboxRetryDelay(t *testing.T) {
+	shouldBe := require.New(t)
+
+	shouldBe.Equal(10*time.Second, model.OutboxRetryDelay(1, 10*time.Second))
+	shouldBe.Equal(20*time.Second, model.OutboxRetryDelay(2, 10*time.Second))
+	shouldBe.Equal(80*time.Second, model.OutboxRetryDelay(4, 10*time.Second))
+	shouldBe.Equal(model.OutboxMaxRetryDelay, model.OutboxRetryDelay(100, 10*time.Second))
+}
+
+func Test_OutboxEventFail(t *testing.T) {
+	shouldBe := require.New(t)
+
+	now := time.Now().UTC()
+	event := &model.OutboxEvent{Status: model.OutboxPending, NextAttemptAt: now}
+
+	event.Fail(errors.New("connection refused"), now, 3, time.Second)
+	shouldBe.Equal(model.OutboxPending, event.Status)
+	shouldBe.Equal(1, event.Attempts)
+	shouldBe.Equal("connection refused", event.LastError)
+	shouldBe.Equal(now.Add(time.Second), event.NextAttemptAt)
+
+	event.Fail(errors.New("connection refused"), now, 3, time.Second)
+	shouldBe.Equal(now.Add(2*time.Second), event.NextAttemptAt)
+
+	event.Fail(errors.New("connection refused"), now, 3, time.Second)
+	shouldBe.Equal(model.OutboxFailed, event.Status)
+	shouldBe.Equal(3, event.Attempts)
+
+	event.Replay(now)
+	shouldBe.Equal(model.OutboxPending, event.Status)
+	shouldBe.Equal(0, event.Attempts)
+
+	event.Deliver(now)
+	shouldBe.Equal(model.OutboxDelivered, event.Status)
+	shouldBe.Equal("", event.LastError)
+	shouldBe.NotNil(event.DeliveredAt)
+}
+
+func Test_OutboxStatusFromString(t *testing.T) {
+	shouldBe := require.New(t)
+
+	for _, status := range []model.OutboxStatus{model.OutboxPending, model.OutboxDelivered, model.OutboxFailed} {
+		parsed, err := model.OutboxStatusFromString(status.ToString())
+		shouldBe.Nil(err)
+		shouldBe.Equal(status, parsed)
+	}
+
+	status, err := model.OutboxStatusFromString("")
+	shouldBe.Nil(err)
+	shouldBe.Equal(model.OutboxUndefined, status)
+
+	_, err = model.OutboxStatusFromString("lost")
+	shouldBe.NotNil(err)
+}
@@ -32,6 +32,7 @@ type (
 		DiscountBuyOpt BuyOption
 		// RegionalRestrinctions
 		AllowedCountries pq.StringArray `gorm:"type:text[]"`
+		DeniedCountries  pq.StringArray `gorm:"type:text[]; default:'{}'"`
 		// Payload
 		Products []Product `gorm:"-"`
 		// Prices in package
@@ -49,7 +50,8 @@ type (
 	PackageService interface {
 		Create(vendorId uuid.UUID, userId, name string, prods []uuid.UUID) (*Package, error)
 		Get(packageId uuid.UUID) (result *Package, err error)
-		GetList(userId string, vendorId uuid.UUID, query, orderBy string, offset, limit int, filterFunc PackageListingFilter) (total int, result []Package, err error)
+		// GetList returns packages of vendor, packages unavailable in country are skipped if country isn't empty
+		GetList(userId string, vendorId uuid.UUID, query, orderBy, country string, offset, limit int, filterFunc PackageListingFilter) (total int, result []Package, err error)
 		AddProducts(packageId uuid.UUID, prods []uuid.UUID) (*Package, error)
 		RemoveProducts(packageId uuid.UUID, prods []uuid.UUID) (*Package, error)
 		Update(pkg *Package) (result *Package, err error)
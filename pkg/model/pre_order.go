@@ -0,0 +1,110 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"github.com/lib/pq"
+	"github.com/satori/go.uuid"
+	"time"
+)
+
+type PackageState string
+
+const (
+	// PackageStateComingSoon is state of package before pre-order window and release
+	PackageStateComingSoon PackageState = "coming_soon"
+	// PackageStatePreOrder is state of package during pre-order window, pre-order prices are used
+	PackageStatePreOrder PackageState = "pre_order"
+	// PackageStateReleased is state of package when all its games are released, regular prices are used
+	PackageStateReleased PackageState = "released"
+)
+
+type (
+	PreOrderPrice struct {
+		Currency string  `json:"currency"`
+		Price    float32 `json:"price"`
+	}
+
+	PreOrderPrices []PreOrderPrice
+
+	// PreOrder contains pre-order settings of package. Pre-order window starts at DateStart and ends
+	// at release date of package, which is the latest release date of games in the package.
+	PreOrder struct {
+		PackageID uuid.UUID `gorm:"type:uuid; primary_key"`
+		CreatedAt time.Time `gorm:"default:now()"`
+		UpdatedAt time.Time `gorm:"default:now()"`
+		Enabled   bool
+		DateStart time.Time
+		Prices    PreOrderPrices `gorm:"type:jsonb; not null; default:'[]'"`
+		// BonusProducts are given to buyers of package during pre-order
+		BonusProducts pq.StringArray `gorm:"type:text[]"`
+		// ReleasedAt is time when package was switched from pre-order to regular pricing
+		ReleasedAt *time.Time
+		// ReleaseDate is not stored, it is calculated from games of package
+		ReleaseDate time.Time `gorm:"-"`
+	}
+
+	PreOrderService interface {
+		// Get returns pre-order settings of package, disabled settings are returned if pre-order is not configured
+		Get(packageId uuid.UUID) (*PreOrder, error)
+		Update(preOrder *PreOrder) (*PreOrder, error)
+		// ReleaseDue switches packages with release date before t from pre-order to regular pricing
+		// and publishes release event for each of them
+		ReleaseDue(t time.Time) (released []uuid.UUID, err error)
+	}
+)
+
+func (PreOrder) TableName() string {
+	return "package_pre_orders"
+}
+
+// State returns state of package with the pre-order settings at the moment t
+func (p *PreOrder) State(t time.Time) PackageState {
+	if !t.Before(p.ReleaseDate) || p.ReleasedAt != nil {
+		return PackageStateReleased
+	}
+	if p.Enabled && !t.Before(p.DateStart) {
+		return PackageStatePreOrder
+	}
+	return PackageStateComingSoon
+}
+
+// GetPrice returns pre-order price in currency
+func (p *PreOrder) GetPrice(currency string) (float32, bool) {
+	for _, price := range p.Prices {
+		if price.Currency == currency {
+			return price.Price, true
+		}
+	}
+	return 0, false
+}
+
+// Apply replaces regular prices of package with pre-order prices if package is in pre-order state at the moment t
+func (p *PreOrder) Apply(pkg *Package, t time.Time) bool {
+	if p.State(t) != PackageStatePreOrder {
+		return false
+	}
+	prices := []Price{}
+	for _, price := range p.Prices {
+		prices = append(prices, Price{BasePriceID: pkg.ID, Currency: price.Currency, Price: price.Price})
+	}
+	pkg.Prices = prices
+	return true
+}
+
+func (prices PreOrderPrices) Value() (driver.Value, error) {
+	if prices == nil {
+		return "[]", nil
+	}
+	j, err := json.Marshal(prices)
+	return string(j), err
+}
+
+func (prices *PreOrderPrices) Scan(src interface{}) error {
+	source, ok := src.([]byte)
+	if !ok {
+		return errors.New("Type assertion .([]byte) failed.")
+	}
+	return json.Unmarshal(source, prices)
+}
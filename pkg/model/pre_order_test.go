@@ -0,0 +1,62 @@
+package model_test
+
+import (
+	"github.com/satori/go.uuid"
+	"qilin-api/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PreOrderState(t *testing.T) {
+	shouldBe := require.New(t)
+
+	release := time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+	preOrder := model.PreOrder{
+		Enabled:     true,
+		DateStart:   release.AddDate(0, -1, 0),
+		ReleaseDate: release,
+	}
+
+	shouldBe.Equal(model.PackageStateComingSoon, preOrder.State(release.AddDate(0, -2, 0)))
+	shouldBe.Equal(model.PackageStatePreOrder, preOrder.State(release.AddDate(0, 0, -1)))
+	shouldBe.Equal(model.PackageStateReleased, preOrder.State(release))
+
+	preOrder.Enabled = false
+	shouldBe.Equal(model.PackageStateComingSoon, preOrder.State(release.AddDate(0, 0, -1)))
+
+	releasedAt := release.AddDate(0, 0, -2)
+	preOrder.Enabled = true
+	preOrder.ReleasedAt = &releasedAt
+	shouldBe.Equal(model.PackageStateReleased, preOrder.State(release.AddDate(0, 0, -1)))
+}
+
+func Test_PreOrderApply(t *testing.T) {
+	shouldBe := require.New(t)
+
+	release := time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+	preOrder := model.PreOrder{
+		Enabled:     true,
+		DateStart:   release.AddDate(0, -1, 0),
+		ReleaseDate: release,
+		Prices:      model.PreOrderPrices{{Currency: "USD", Price: 49.99}},
+	}
+
+	price, ok := preOrder.GetPrice("USD")
+	shouldBe.True(ok)
+	shouldBe.Equal(float32(49.99), price)
+	_, ok = preOrder.GetPrice("EUR")
+	shouldBe.False(ok)
+
+	pkg := model.Package{Model: model.Model{ID: uuid.NewV4()}}
+	pkg.Prices = []model.Price{{Currency: "USD", Price: 59.99}, {Currency: "EUR", Price: 54.99}}
+
+	shouldBe.False(preOrder.Apply(&pkg, release))
+	shouldBe.Len(pkg.Prices, 2)
+
+	shouldBe.True(preOrder.Apply(&pkg, release.AddDate(0, 0, -1)))
+	shouldBe.Len(pkg.Prices, 1)
+	shouldBe.Equal("USD", pkg.Prices[0].Currency)
+	shouldBe.Equal(float32(49.99), pkg.Prices[0].Price)
+}
@@ -34,11 +34,15 @@ func (BasePrice) TableName() string {
 	return "packages"
 }
 
+// PriceService changes prices of package, every change is recorded to price history on behalf of user
 type PriceService interface {
 	GetBase(id uuid.UUID) (*BasePrice, error)
-	UpdateBase(id uuid.UUID, price *BasePrice) error
-	Delete(id uuid.UUID, price *Price) error
-	Update(id uuid.UUID, price *Price) error
+	UpdateBase(userId string, id uuid.UUID, price *BasePrice) error
+	Delete(userId string, id uuid.UUID, price *Price) error
+	Update(userId string, id uuid.UUID, price *Price) error
+	// GetHistory returns price changes of package and lowest prices during LowestPricePeriod before the moment at,
+	// all currencies are returned if currency is empty
+	GetHistory(id uuid.UUID, currency string, at time.Time) (*PriceHistory, error)
 }
 
 func (prices *PackagePrices) GetPrice() (string, float32) {
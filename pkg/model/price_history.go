@@ -0,0 +1,83 @@
+package model
+
+import (
+	"github.com/satori/go.uuid"
+	"time"
+)
+
+// LowestPricePeriod is period used to search lowest price before discount announcement
+const LowestPricePeriod = 30 * 24 * time.Hour
+
+type PriceChangeKind string
+
+const (
+	// PriceChangeKindPrice is change of price in currency, nil new price means that price was removed
+	PriceChangeKindPrice PriceChangeKind = "price"
+	// PriceChangeKindBase is change of common pricing settings of package, such as default currency
+	PriceChangeKindBase PriceChangeKind = "base"
+)
+
+type (
+	// PriceChange is audit record of package price change, records are never updated or removed
+	PriceChange struct {
+		ID        uuid.UUID `gorm:"type:uuid; primary_key"`
+		CreatedAt time.Time `gorm:"index"`
+		PackageID uuid.UUID `gorm:"type:uuid; index"`
+		UserID    string
+		Kind      PriceChangeKind
+		Currency  string
+		OldPrice  *float32 `gorm:"type:decimal(10,2)"`
+		NewPrice  *float32 `gorm:"type:decimal(10,2)"`
+		OldVat    *int32
+		NewVat    *int32
+		// OldBase and NewBase contain common pricing settings for base changes
+		OldBase JSONB `gorm:"type:jsonb"`
+		NewBase JSONB `gorm:"type:jsonb"`
+	}
+
+	// PriceTimeline is history of package price in currency
+	PriceTimeline struct {
+		Currency string
+		Current  *float32
+		// Lowest is lowest price during LowestPricePeriod before the moment of request
+		Lowest  *float32
+		Changes []PriceChange
+	}
+
+	PriceHistory struct {
+		PackageID   uuid.UUID
+		At          time.Time
+		Prices      []PriceTimeline
+		BaseChanges []PriceChange
+	}
+)
+
+// LowestPrice returns lowest price during period [from, to] by changes of price in single currency sorted by time.
+// Current price is used if there are no changes, it covers prices set before history was recorded.
+func LowestPrice(changes []PriceChange, current *float32, from, to time.Time) *float32 {
+	price := current
+	if len(changes) > 0 {
+		price = changes[0].OldPrice
+	}
+
+	var lowest *float32
+	check := func(p *float32) {
+		if p != nil && (lowest == nil || *p < *lowest) {
+			value := *p
+			lowest = &value
+		}
+	}
+
+	for _, change := range changes {
+		if change.CreatedAt.After(to) {
+			break
+		}
+		if change.CreatedAt.After(from) {
+			check(price)
+		}
+		price = change.NewPrice
+	}
+	check(price)
+
+	return lowest
+}
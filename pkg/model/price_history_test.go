@@ -0,0 +1,40 @@
+package model_test
+
+import (
+	"qilin-api/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LowestPrice(t *testing.T) {
+	shouldBe := require.New(t)
+
+	price := func(v float32) *float32 { return &v }
+	now := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	from := now.Add(-model.LowestPricePeriod)
+
+	shouldBe.Nil(model.LowestPrice(nil, nil, from, now))
+	shouldBe.Equal(float32(10), *model.LowestPrice(nil, price(10), from, now))
+
+	changes := []model.PriceChange{
+		{CreatedAt: now.AddDate(0, -3, 0), OldPrice: nil, NewPrice: price(5)},
+		{CreatedAt: now.AddDate(0, -2, 0), OldPrice: price(5), NewPrice: price(20)},
+		{CreatedAt: now.AddDate(0, 0, -10), OldPrice: price(20), NewPrice: price(15)},
+		{CreatedAt: now.AddDate(0, 0, -1), OldPrice: price(15), NewPrice: price(8)},
+	}
+
+	// 20 was in effect at the start of period, then 15 and 8
+	shouldBe.Equal(float32(8), *model.LowestPrice(changes, price(8), from, now))
+	// discount announced before the last change sees 15 as the lowest price
+	shouldBe.Equal(float32(15), *model.LowestPrice(changes, price(8), from.AddDate(0, 0, -2), now.AddDate(0, 0, -2)))
+	// price set before history was recorded is taken from the first change
+	shouldBe.Equal(float32(20), *model.LowestPrice(changes[2:], price(8), from, now.AddDate(0, 0, -11).Add(time.Hour)))
+
+	// removed price is not taken into account
+	removed := []model.PriceChange{
+		{CreatedAt: now.AddDate(0, 0, -5), OldPrice: price(30), NewPrice: nil},
+	}
+	shouldBe.Equal(float32(30), *model.LowestPrice(removed, nil, from, now))
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"github.com/satori/go.uuid"
+	"math"
+	"time"
+)
+
+type (
+	// ResolvedPrice is effective price of package or bundle in currency at the moment
+	ResolvedPrice struct {
+		ID       uuid.UUID
+		Currency string
+		// Price is price without any discounts
+		Price float32
+		// FinalPrice is price which buyer pays
+		FinalPrice float32
+		// Discount is effective discount percent
+		Discount float32
+		// DiscountID is scheduled discount applied to package, nil when discount policy of package wins
+		DiscountID *uuid.UUID
+		// PreOrder is set when pre-order price of package is used
+		PreOrder bool
+		// Vat is VAT percent configured for package price, it is zero for bundles
+		Vat int32
+		// Tax is tax breakdown of final price, it is calculated only for known buyer country
+		Tax *PriceTax
+		// Packages contains resolved prices of bundle packages
+		Packages []ResolvedPrice
+		// Unpriced contains bundle packages without price in currency, they are not included in bundle price
+		Unpriced []uuid.UUID
+	}
+
+	PricingService interface {
+		// GetPackagePrice resolves price of package at the moment, empty currency means default currency of package
+		GetPackagePrice(packageId uuid.UUID, currency string, at time.Time) (*ResolvedPrice, error)
+		// GetBundlePrice resolves price of bundle at the moment, empty currency means default currency of first package
+		GetBundlePrice(bundleId uuid.UUID, currency string, at time.Time) (*ResolvedPrice, error)
+		// GetPackagesPrices resolves prices of loaded packages at once, prices are resolved in currency or in every
+		// currency of package prices if currency is empty. Currencies package has no price in are skipped.
+		GetPackagesPrices(packages []Package, currency string, at time.Time) (map[uuid.UUID][]ResolvedPrice, error)
+		// GetBundlesPrices resolves prices of loaded store bundles at once like GetPackagesPrices,
+		// bundle is priced in currency only if all its packages are priced in it
+		GetBundlesPrices(bundles []StoreBundle, currency string, at time.Time) (map[uuid.UUID][]ResolvedPrice, error)
+	}
+)
+
+// ResolvePackagePrice computes price of package in currency at the moment t.
+// Discounts are scheduled discounts of games, only active discounts of games contained in package are used.
+// Scheduled discount is combined with discount policy of package according to its buy option, see CombineDiscounts.
+// Returns false if package has no price in currency.
+func ResolvePackagePrice(pkg *Package, currency string, discounts []Discount, t time.Time) (*ResolvedPrice, bool) {
+	if currency == "" {
+		currency = pkg.GetCurrency()
+	}
+
+	found := false
+	result := &ResolvedPrice{ID: pkg.ID, Currency: currency}
+	for _, p := range pkg.Prices {
+		if p.Currency == currency {
+			result.Price = p.Price
+			result.Vat = p.Vat
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	var scheduled float32
+	for i := range discounts {
+		d := &discounts[i]
+		if d.Rate > scheduled && d.IsActive(t) && pkg.containsProduct(d.GameID) {
+			scheduled = d.Rate
+			discountId := d.ID
+			result.DiscountID = &discountId
+		}
+	}
+
+	own := float32(pkg.Discount)
+	if pkg.DiscountBuyOpt == BuyOption_Whole && own >= scheduled {
+		result.DiscountID = nil
+	}
+	result.Discount = CombineDiscounts(own, scheduled, pkg.DiscountBuyOpt)
+	result.FinalPrice = applyDiscount(result.Price, result.Discount)
+
+	return result, true
+}
+
+// ResolveBundlePrice computes price of store bundle in currency at the moment t.
+// Packages are resolved with ResolvePackagePrice and discount policy of bundle is combined with
+// resulting discount of packages according to buy option of bundle.
+func ResolveBundlePrice(bundle *StoreBundle, currency string, discounts []Discount, t time.Time) *ResolvedPrice {
+	if currency == "" && len(bundle.Packages) > 0 {
+		currency = bundle.Packages[0].GetCurrency()
+	}
+
+	result := &ResolvedPrice{
+		ID:       bundle.ID,
+		Currency: currency,
+		Packages: []ResolvedPrice{},
+		Unpriced: []uuid.UUID{},
+	}
+
+	var partsPrice float32
+	for i := range bundle.Packages {
+		pkg := &bundle.Packages[i]
+		resolved, ok := ResolvePackagePrice(pkg, currency, discounts, t)
+		if !ok {
+			result.Unpriced = append(result.Unpriced, pkg.ID)
+			continue
+		}
+		result.Price += resolved.Price
+		partsPrice += resolved.FinalPrice
+		result.Packages = append(result.Packages, *resolved)
+	}
+
+	var parts float32
+	if result.Price > 0 {
+		parts = 100 * (result.Price - partsPrice) / result.Price
+	}
+	result.Discount = CombineDiscounts(float32(bundle.Discount), parts, bundle.DiscountBuyOpt)
+	result.FinalPrice = applyDiscount(result.Price, result.Discount)
+
+	return result
+}
+
+// CombineDiscounts calculates effective discount percent of item from its own discount and discount of its parts.
+// With BuyOption_Whole discounts are not summed and buyer gets the best one,
+// with BuyOption_Part own discount is applied on top of discounted parts.
+func CombineDiscounts(own, parts float32, opt BuyOption) float32 {
+	own = clampPercent(own)
+	parts = clampPercent(parts)
+	if opt == BuyOption_Part {
+		return 100 - (100-own)*(100-parts)/100
+	}
+	if own > parts {
+		return own
+	}
+	return parts
+}
+
+func applyDiscount(price, discount float32) float32 {
+	return roundPrice(price - price*discount*0.01)
+}
+
+func roundPrice(price float32) float32 {
+	return float32(math.Round(float64(price)*100) / 100)
+}
+
+func clampPercent(value float32) float32 {
+	if value < 0 {
+		return 0
+	}
+	if value > 100 {
+		return 100
+	}
+	return value
+}
+
+func (pkg *Package) containsProduct(productId uuid.UUID) bool {
+	for _, pr := range pkg.Products {
+		if pr.GetID() == productId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package model_test
+
+import (
+	"qilin-api/pkg/model"
+	"testing"
+	"time"
+
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func newPricingPackage(gameId uuid.UUID, price float32, discount uint, opt model.BuyOption) model.Package {
+	pkg := model.Package{
+		Discount:       discount,
+		DiscountBuyOpt: opt,
+		Products:       []model.Product{&model.ProductGameImpl{Game: model.Game{ID: gameId}}},
+		PackagePrices: model.PackagePrices{
+			Common: model.JSONB{"Currency": "USD"},
+			Prices: []model.Price{{Currency: "USD", Price: price}, {Currency: "EUR", Price: price - 1}},
+		},
+	}
+	pkg.ID = uuid.NewV4()
+	return pkg
+}
+
+func Test_CombineDiscounts(t *testing.T) {
+	shouldBe := require.New(t)
+	shouldBe.Equal(float32(30), model.CombineDiscounts(10, 30, model.BuyOption_Whole))
+	shouldBe.Equal(float32(30), model.CombineDiscounts(30, 10, model.BuyOption_Whole))
+	shouldBe.Equal(float32(50), model.CombineDiscounts(50, 0, model.BuyOption_Part))
+	shouldBe.InDelta(float32(37), model.CombineDiscounts(10, 30, model.BuyOption_Part), 0.001)
+	shouldBe.Equal(float32(100), model.CombineDiscounts(150, 0, model.BuyOption_Whole))
+}
+
+func Test_ResolvePackagePrice(t *testing.T) {
+	shouldBe := require.New(t)
+	gameId := uuid.NewV4()
+	now := time.Now()
+	discount := model.Discount{Rate: 40, GameID: gameId, DateStart: now.Add(-time.Hour), DateEnd: now.Add(time.Hour)}
+	discount.ID = uuid.NewV4()
+	foreign := model.Discount{Rate: 90, GameID: uuid.NewV4(), DateStart: now.Add(-time.Hour), DateEnd: now.Add(time.Hour)}
+	discounts := []model.Discount{discount, foreign}
+
+	pkg := newPricingPackage(gameId, 20, 10, model.BuyOption_Whole)
+
+	price, ok := model.ResolvePackagePrice(&pkg, "", discounts, now)
+	shouldBe.True(ok)
+	shouldBe.Equal("USD", price.Currency)
+	shouldBe.Equal(float32(20), price.Price)
+	shouldBe.Equal(float32(40), price.Discount)
+	shouldBe.Equal(float32(12), price.FinalPrice)
+	shouldBe.NotNil(price.DiscountID)
+	shouldBe.Equal(discount.ID, *price.DiscountID)
+
+	// Discount is over, only discount policy of package is used
+	price, ok = model.ResolvePackagePrice(&pkg, "EUR", discounts, now.Add(2*time.Hour))
+	shouldBe.True(ok)
+	shouldBe.Equal(float32(19), price.Price)
+	shouldBe.Equal(float32(10), price.Discount)
+	shouldBe.Equal(float32(17.1), price.FinalPrice)
+	shouldBe.Nil(price.DiscountID)
+
+	pkg.DiscountBuyOpt = model.BuyOption_Part
+	price, ok = model.ResolvePackagePrice(&pkg, "USD", discounts, now)
+	shouldBe.True(ok)
+	shouldBe.Equal(float32(10.8), price.FinalPrice)
+
+	_, ok = model.ResolvePackagePrice(&pkg, "RUB", discounts, now)
+	shouldBe.False(ok)
+}
+
+func Test_ResolveBundlePrice(t *testing.T) {
+	shouldBe := require.New(t)
+	pkgA := newPricingPackage(uuid.NewV4(), 10, 0, model.BuyOption_Whole)
+	pkgB := newPricingPackage(uuid.NewV4(), 10, 50, model.BuyOption_Whole)
+	pkgC := newPricingPackage(uuid.NewV4(), 10, 0, model.BuyOption_Whole)
+	pkgC.Prices = pkgC.Prices[1:]
+	bundle := model.StoreBundle{Packages: []model.Package{pkgA, pkgB, pkgC}, Discount: 20}
+
+	price := model.ResolveBundlePrice(&bundle, "", nil, time.Now())
+	shouldBe.Equal("USD", price.Currency)
+	shouldBe.Equal(float32(20), price.Price)
+	shouldBe.Equal(float32(15), price.FinalPrice)
+	shouldBe.Len(price.Packages, 2)
+	shouldBe.Equal([]uuid.UUID{pkgC.ID}, price.Unpriced)
+
+	bundle.DiscountBuyOpt = model.BuyOption_Part
+	price = model.ResolveBundlePrice(&bundle, "USD", nil, time.Now())
+	shouldBe.Equal(float32(12), price.FinalPrice)
+
+	price = model.ResolveBundlePrice(&bundle, "EUR", nil, time.Now())
+	shouldBe.Equal(float32(27), price.Price)
+	shouldBe.Empty(price.Unpriced)
+}
+
+func Test_DiscountOverlaps(t *testing.T) {
+	shouldBe := require.New(t)
+	now := time.Now()
+	first := model.Discount{DateStart: now, DateEnd: now.Add(time.Hour)}
+	second := model.Discount{DateStart: now.Add(time.Hour), DateEnd: now.Add(2 * time.Hour)}
+	third := model.Discount{DateStart: now.Add(30 * time.Minute), DateEnd: now.Add(90 * time.Minute)}
+	shouldBe.False(first.Overlaps(&second))
+	shouldBe.True(first.Overlaps(&third))
+	shouldBe.True(third.Overlaps(&second))
+	shouldBe.True(first.IsActive(now))
+	shouldBe.False(first.IsActive(now.Add(time.Hour)))
+}
@@ -36,6 +36,8 @@ func (p *ProductEntry) TableName() string {
 type ProductService interface {
 	Specialization([]uuid.UUID) (games []uuid.UUID, dlcs []uuid.UUID, err error)
 	GetPackages(uuid.UUID) (packages []Package, err error)
+	// GetDlcGames returns games which DLCs belong to
+	GetDlcGames(dlcIds []uuid.UUID) (games []uuid.UUID, err error)
 }
 
 func (p ProductEntryArray) GetUUIDs() (result []uuid.UUID) {
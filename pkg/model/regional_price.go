@@ -0,0 +1,98 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/satori/go.uuid"
+)
+
+type (
+	// CurrencyRate is locally stored exchange rate and purchasing power of currency used for regional pricing
+	CurrencyRate struct {
+		Currency string `gorm:"primary_key"`
+		// Rate is amount of currency for one USD
+		Rate float64 `gorm:"not null"`
+		// PurchasingPower is price level of region relative to USD region, e.g. 0.5 means goods are twice cheaper
+		PurchasingPower float64 `gorm:"not null; default:1"`
+		// Default rounding of recommended prices in currency
+		RoundingStep   float64 `gorm:"not null; default:1"`
+		RoundingEnding float64 `gorm:"not null; default:0"`
+		UpdatedAt      time.Time
+	}
+
+	// RoundingRule rounds price to nearest value which is multiple of step plus ending, e.g. step 1 and ending 0.99 gives x.99
+	RoundingRule struct {
+		Step   float64
+		Ending float64
+	}
+
+	// RegionalPriceRequest contains parameters of regional prices generation
+	RegionalPriceRequest struct {
+		// BasePrice in base currency of package, price of package in base currency is used if it is zero
+		BasePrice float32
+		// Currencies to generate, all supported currencies are used if empty
+		Currencies []string
+		// IgnorePurchasingPower disables purchasing power adjustment, only exchange rate is applied
+		IgnorePurchasingPower bool
+		// Rounding overrides default rounding rules of currencies
+		Rounding map[string]RoundingRule
+	}
+
+	// RegionalPrice is suggested price in currency
+	RegionalPrice struct {
+		Currency string
+		// Current is price of package in currency now, nil if it is not set
+		Current   *float32
+		Suggested float32
+	}
+
+	RegionalPriceService interface {
+		GetRates() ([]CurrencyRate, error)
+		UpdateRate(rate *CurrencyRate) error
+		// Recommend generates suggested prices for package without saving them (dry run)
+		Recommend(packageId uuid.UUID, request *RegionalPriceRequest) (baseCurrency string, prices []RegionalPrice, err error)
+		// Apply generates suggested prices and saves them as prices of package
+		Apply(userId string, packageId uuid.UUID, request *RegionalPriceRequest) (baseCurrency string, prices []RegionalPrice, err error)
+	}
+)
+
+// Validate checks that rounding rule can be applied
+func (rule RoundingRule) Validate() error {
+	if rule.Step <= 0 {
+		return fmt.Errorf("Rounding step must be greater than zero")
+	}
+	if rule.Ending < 0 || rule.Ending >= rule.Step {
+		return fmt.Errorf("Rounding ending must be in range [0, %v)", rule.Step)
+	}
+	return nil
+}
+
+// Round returns nearest to value price which satisfies rule, result is never less than minimal price of rule
+func (rule RoundingRule) Round(value float64) float64 {
+	steps := math.Round((value - rule.Ending) / rule.Step)
+	if steps < 0 {
+		steps = 0
+	}
+	result := steps*rule.Step + rule.Ending
+	if result <= 0 {
+		result = rule.Step
+	}
+	return math.Round(result*100) / 100
+}
+
+// GetRounding returns default rounding rule of currency
+func (rate *CurrencyRate) GetRounding() RoundingRule {
+	return RoundingRule{Step: rate.RoundingStep, Ending: rate.RoundingEnding}
+}
+
+// ConvertRegionalPrice converts price from base currency into target currency using exchange rates and,
+// if adjust is set, purchasing power of regions
+func ConvertRegionalPrice(price float64, base, target *CurrencyRate, adjust bool) float64 {
+	result := price / base.Rate * target.Rate
+	if adjust && base.PurchasingPower > 0 {
+		result = result * target.PurchasingPower / base.PurchasingPower
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package model_test
+
+import (
+	"qilin-api/pkg/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RoundingRule(t *testing.T) {
+	shouldBe := require.New(t)
+
+	rule := model.RoundingRule{Step: 1, Ending: 0.99}
+	shouldBe.Nil(rule.Validate())
+	shouldBe.Equal(18.99, rule.Round(19.37))
+	shouldBe.Equal(19.99, rule.Round(19.6))
+	shouldBe.Equal(0.99, rule.Round(0.1))
+
+	rule = model.RoundingRule{Step: 100, Ending: 0}
+	shouldBe.Equal(2100.0, rule.Round(2137))
+	shouldBe.Equal(100.0, rule.Round(20))
+
+	shouldBe.NotNil(model.RoundingRule{Step: 0}.Validate())
+	shouldBe.NotNil(model.RoundingRule{Step: 1, Ending: 1}.Validate())
+	shouldBe.NotNil(model.RoundingRule{Step: 1, Ending: -0.01}.Validate())
+}
+
+func Test_ConvertRegionalPrice(t *testing.T) {
+	shouldBe := require.New(t)
+
+	usd := &model.CurrencyRate{Currency: "USD", Rate: 1, PurchasingPower: 1}
+	rub := &model.CurrencyRate{Currency: "RUB", Rate: 64, PurchasingPower: 0.5}
+
+	shouldBe.InDelta(640.0, model.ConvertRegionalPrice(10, usd, rub, false), 0.001)
+	shouldBe.InDelta(320.0, model.ConvertRegionalPrice(10, usd, rub, true), 0.001)
+	shouldBe.InDelta(20.0, model.ConvertRegionalPrice(640, rub, usd, true), 0.001)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/satori/go.uuid"
 	"qilin-api/pkg/model/utils"
+	"time"
 )
 
 type (
@@ -23,6 +24,7 @@ type (
 		DiscountBuyOpt BuyOption
 		// RegionalRestrinctions
 		AllowedCountries pq.StringArray `gorm:"type:text[]"`
+		DeniedCountries  pq.StringArray `gorm:"type:text[]; default:'{}'"`
 		// Bundle payload
 		Packages []Package `gorm:"many2many:bundle_packages;jointable_foreignkey:bundle_id;"`
 	}
@@ -47,26 +49,17 @@ func (b *StoreBundle) IsContains(productId uuid.UUID) (contains bool, err error)
 	return
 }
 
+// GetPrice returns current price of bundle in default currency, scheduled discounts are not included
 func (b *StoreBundle) GetPrice() (currency string, price float32, discount float32, err error) {
 	if len(b.Packages) == 0 {
 		return
 	}
-	currency = b.Packages[0].GetCurrency()
-	var fprice float32
-	for _, p := range b.Packages {
-		// Search for default price
-		for _, pr := range p.Prices {
-			if pr.Currency == currency {
-				price += pr.Price
-				fprice += pr.Price - (pr.Price * float32(p.Discount) * 0.01)
-				break
-			}
-		}
-	}
-	if price != 0 {
-		discount = 100 * (price - fprice) / price
-	}
-	return
+	resolved := ResolveBundlePrice(b, "", nil, time.Now())
+	return resolved.Currency, resolved.Price, resolved.Discount, nil
+}
+
+func (b *StoreBundle) GetRestrictions() RegionalRestrictions {
+	return RegionalRestrictions{AllowedCountries: b.AllowedCountries, DeniedCountries: b.DeniedCountries}
 }
 
 func (b *StoreBundle) GetPackages() (packages []Package, err error) {
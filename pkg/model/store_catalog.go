@@ -0,0 +1,239 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"github.com/lib/pq"
+	"github.com/satori/go.uuid"
+	"qilin-api/pkg/model/game"
+	"qilin-api/pkg/model/utils"
+	"time"
+)
+
+const (
+	StorePlatformWindows = "windows"
+	StorePlatformMacOs   = "macOs"
+	StorePlatformLinux   = "linux"
+)
+
+type (
+	StoreMedia struct {
+		CoverImage  utils.LocalizedString      `json:"coverImage"`
+		CoverVideo  utils.LocalizedString      `json:"coverVideo"`
+		Trailers    utils.LocalizedStringArray `json:"trailers"`
+		Screenshots utils.LocalizedStringArray `json:"screenshots"`
+		Store       JSONB                      `json:"store"`
+		Capsule     JSONB                      `json:"capsule"`
+	}
+
+	StoreRatings struct {
+		PEGI JSONB `json:"PEGI"`
+		ESRB JSONB `json:"ESRB"`
+		BBFC JSONB `json:"BBFC"`
+		USK  JSONB `json:"USK"`
+		CERO JSONB `json:"CERO"`
+	}
+
+	// StoreGame is store-facing document of game, it is captured from vendor data on publication
+	StoreGame struct {
+		ID             uuid.UUID             `json:"id"`
+		Title          string                `json:"title"`
+		Developers     string                `json:"developers"`
+		Publishers     string                `json:"publishers"`
+		ReleaseDate    time.Time             `json:"releaseDate"`
+		FeaturesCommon []string              `json:"featuresCommon"`
+		FeaturesCtrl   string                `json:"featuresCtrl"`
+		Platforms      game.Platforms        `json:"platforms"`
+		Requirements   game.GameRequirements `json:"requirements"`
+		Languages      game.GameLangs        `json:"languages"`
+		GenreMain      int64                 `json:"genreMain"`
+		Genres         []GameTag             `json:"genres"`
+		Tags           []GameTag             `json:"tags"`
+		Tagline        utils.LocalizedString `json:"tagline"`
+		Description    utils.LocalizedString `json:"description"`
+		Reviews        game.GameReviews      `json:"reviews"`
+		GameSite       string                `json:"gameSite"`
+		Socials        game.Socials          `json:"socials"`
+		Media          StoreMedia            `json:"media"`
+		Ratings        StoreRatings          `json:"ratings"`
+	}
+
+	// PublishedGame is the latest snapshot of game exposed by store catalog. Filter columns are
+	// denormalized from the document to search games without unpacking it.
+	PublishedGame struct {
+		GameID      uuid.UUID `gorm:"type:uuid; primary_key"`
+		VendorID    uuid.UUID `gorm:"type:uuid"`
+		Version     int
+		PublishedAt time.Time
+		Genres      pq.Int64Array  `gorm:"type:integer[]; not null; default:array[]::integer[]"`
+		Tags        pq.Int64Array  `gorm:"type:integer[]; not null; default:array[]::integer[]"`
+		Platforms   pq.StringArray `gorm:"type:text[]; not null; default:array[]::text[]"`
+		Languages   pq.StringArray `gorm:"type:text[]; not null; default:array[]::text[]"`
+		Game        StoreGame      `gorm:"type:jsonb; not null"`
+	}
+
+	// StoreGameFilter contains optional filters of store catalog, zero values are ignored
+	StoreGameFilter struct {
+		Genre    int64
+		Tag      int64
+		Platform string
+		Language string
+		Offset   int
+		Limit    int
+	}
+
+	// StoreOffer is enabled package or store bundle of published games with resolved prices
+	StoreOffer struct {
+		ID           uuid.UUID
+		Sku          string
+		Name         utils.LocalizedString
+		Image        utils.LocalizedString
+		Games        []uuid.UUID
+		Packages     []uuid.UUID
+		Restrictions RegionalRestrictions
+		// PackageRestrictions are restrictions of bundle packages, offer is available only if all packages are available
+		PackageRestrictions []RegionalRestrictions
+		Prices              []ResolvedPrice
+	}
+
+	StoreCatalogService interface {
+		GetGames(filter StoreGameFilter) (total int, games []PublishedGame, err error)
+		GetGame(gameId uuid.UUID) (*PublishedGame, error)
+		// GetPackages returns packages of published game which were enabled on publication, empty currency means all currencies of package
+		GetPackages(gameId uuid.UUID, currency string, at time.Time) ([]StoreOffer, error)
+		// GetBundles returns enabled store bundles containing published game, all games of bundle must be published
+		GetBundles(gameId uuid.UUID, currency string, at time.Time) ([]StoreOffer, error)
+	}
+)
+
+func (PublishedGame) TableName() string {
+	return "store_games"
+}
+
+// NewStoreGame builds store document of game, descr and rating are optional
+func NewStoreGame(g *Game, descr *GameDescr, media *Media, rating *GameRating, tags []GameTag, genres []GameGenre) StoreGame {
+	result := StoreGame{
+		ID:             g.ID,
+		Title:          g.Title,
+		Developers:     g.Developers,
+		Publishers:     g.Publishers,
+		ReleaseDate:    g.ReleaseDate,
+		FeaturesCommon: append([]string{}, g.FeaturesCommon...),
+		FeaturesCtrl:   g.FeaturesCtrl,
+		Platforms:      g.Platforms,
+		Requirements:   g.Requirements,
+		Languages:      g.Languages,
+		GenreMain:      g.GenreMain,
+		Genres:         []GameTag{},
+		Tags:           append([]GameTag{}, tags...),
+		Reviews:        game.GameReviews{},
+	}
+	for _, genre := range genres {
+		result.Genres = append(result.Genres, genre.GameTag)
+	}
+	if descr != nil {
+		result.Tagline = descr.Tagline
+		result.Description = descr.Description
+		result.GameSite = descr.GameSite
+		result.Socials = descr.Socials
+		if descr.Reviews != nil {
+			result.Reviews = descr.Reviews
+		}
+	}
+	if media != nil {
+		result.Media = StoreMedia{
+			CoverImage:  media.CoverImage,
+			CoverVideo:  media.CoverVideo,
+			Trailers:    media.Trailers,
+			Screenshots: media.Screenshots,
+			Store:       media.Store,
+			Capsule:     media.Capsule,
+		}
+	}
+	if rating != nil {
+		result.Ratings = StoreRatings{PEGI: rating.PEGI, ESRB: rating.ESRB, BBFC: rating.BBFC, USK: rating.USK, CERO: rating.CERO}
+	}
+	return result
+}
+
+// NewPublishedGame builds published state of game from snapshot
+func NewPublishedGame(vendorId uuid.UUID, snapshot *GameSnapshot) *PublishedGame {
+	doc := snapshot.Game
+	result := &PublishedGame{
+		GameID:      snapshot.GameID,
+		VendorID:    vendorId,
+		Version:     snapshot.Version,
+		PublishedAt: snapshot.CreatedAt,
+		Genres:      pq.Int64Array{},
+		Tags:        pq.Int64Array{},
+		Platforms:   GetPlatforms(doc.Platforms),
+		Languages:   GetLanguages(doc.Languages),
+		Game:        doc,
+	}
+	for _, genre := range doc.Genres {
+		result.Genres = append(result.Genres, genre.ID)
+	}
+	for _, tag := range doc.Tags {
+		result.Tags = append(result.Tags, tag.ID)
+	}
+	return result
+}
+
+// IsAvailable checks regional restrictions of offer and its packages for country
+func (o *StoreOffer) IsAvailable(country string) bool {
+	if !o.Restrictions.IsAvailable(country) {
+		return false
+	}
+	for _, restrictions := range o.PackageRestrictions {
+		if !restrictions.IsAvailable(country) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetPlatforms returns names of supported platforms
+func GetPlatforms(p game.Platforms) pq.StringArray {
+	result := pq.StringArray{}
+	if p.Windows {
+		result = append(result, StorePlatformWindows)
+	}
+	if p.MacOs {
+		result = append(result, StorePlatformMacOs)
+	}
+	if p.Linux {
+		result = append(result, StorePlatformLinux)
+	}
+	return result
+}
+
+// GetLanguages returns codes of languages with any kind of support: interface, voice or subtitles
+func GetLanguages(l game.GameLangs) pq.StringArray {
+	langs := []struct {
+		code  string
+		langs game.Langs
+	}{
+		{"en", l.EN}, {"ru", l.RU}, {"fr", l.FR}, {"es", l.ES}, {"de", l.DE}, {"it", l.IT}, {"pt", l.PT},
+	}
+	result := pq.StringArray{}
+	for _, lang := range langs {
+		if lang.langs.Interface || lang.langs.Voice || lang.langs.Subtitles {
+			result = append(result, lang.code)
+		}
+	}
+	return result
+}
+
+func (g StoreGame) Value() (driver.Value, error) {
+	j, err := json.Marshal(g)
+	return string(j), err
+}
+
+func (g *StoreGame) Scan(src interface{}) error {
+	source, ok := src.([]byte)
+	if !ok {
+		return errors.New("Type assertion .([]byte) failed.")
+	}
+	return json.Unmarshal(source, g)
+}
@@ -0,0 +1,81 @@
+package model_test
+
+import (
+	"github.com/satori/go.uuid"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/model/game"
+	"qilin-api/pkg/model/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewPublishedGame(t *testing.T) {
+	shouldBe := require.New(t)
+
+	g := &model.Game{
+		ID:        uuid.NewV4(),
+		Title:     "Test game",
+		GenreMain: 1,
+		Platforms: game.Platforms{Windows: true, Linux: true},
+		Languages: game.GameLangs{
+			EN: game.Langs{Interface: true},
+			DE: game.Langs{Subtitles: true},
+		},
+	}
+	descr := &model.GameDescr{Tagline: utils.LocalizedString{EN: "Tagline"}}
+	media := &model.Media{CoverImage: utils.LocalizedString{EN: "cover.png"}}
+	tags := []model.GameTag{{ID: 10}, {ID: 11}}
+	genres := []model.GameGenre{{GameTag: model.GameTag{ID: 1}}, {GameTag: model.GameTag{ID: 2}}}
+
+	doc := model.NewStoreGame(g, descr, media, nil, tags, genres)
+	shouldBe.Equal("Test game", doc.Title)
+	shouldBe.Equal("Tagline", doc.Tagline.EN)
+	shouldBe.Equal("cover.png", doc.Media.CoverImage.EN)
+	shouldBe.NotNil(doc.Reviews)
+	shouldBe.Len(doc.Genres, 2)
+
+	vendorId := uuid.NewV4()
+	now := time.Now()
+	published := model.NewPublishedGame(vendorId, &model.GameSnapshot{GameID: g.ID, Version: 2, CreatedAt: now, Game: doc})
+	shouldBe.Equal(g.ID, published.GameID)
+	shouldBe.Equal(vendorId, published.VendorID)
+	shouldBe.Equal(2, published.Version)
+	shouldBe.Equal(now, published.PublishedAt)
+	shouldBe.Equal([]int64{1, 2}, []int64(published.Genres))
+	shouldBe.Equal([]int64{10, 11}, []int64(published.Tags))
+	shouldBe.Equal([]string{model.StorePlatformWindows, model.StorePlatformLinux}, []string(published.Platforms))
+	shouldBe.Equal([]string{"en", "de"}, []string(published.Languages))
+}
+
+func Test_StoreGameValue(t *testing.T) {
+	shouldBe := require.New(t)
+
+	doc := model.StoreGame{ID: uuid.NewV4(), Title: "Test game", Tags: []model.GameTag{{ID: 1, Title: utils.LocalizedString{EN: "Tag"}}}}
+	value, err := doc.Value()
+	shouldBe.Nil(err)
+
+	restored := model.StoreGame{}
+	shouldBe.Nil(restored.Scan([]byte(value.(string))))
+	shouldBe.Equal(doc.ID, restored.ID)
+	shouldBe.Equal(doc.Title, restored.Title)
+	shouldBe.Equal(doc.Tags, restored.Tags)
+
+	shouldBe.NotNil(restored.Scan("string"))
+}
+
+func Test_StoreOfferAvailability(t *testing.T) {
+	shouldBe := require.New(t)
+
+	offer := model.StoreOffer{Restrictions: model.RegionalRestrictions{DeniedCountries: []string{"CN"}}}
+	shouldBe.True(offer.IsAvailable("DE"))
+	shouldBe.False(offer.IsAvailable("CN"))
+
+	offer.PackageRestrictions = []model.RegionalRestrictions{
+		{},
+		{AllowedCountries: []string{"DE", "FR"}},
+	}
+	shouldBe.True(offer.IsAvailable("DE"))
+	shouldBe.False(offer.IsAvailable("RU"))
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+)
+
+type (
+	// TaxRate is VAT/GST rate of buyer country
+	TaxRate struct {
+		Country string `gorm:"primary_key"`
+		// Name of tax shown to buyer, e.g. VAT or GST
+		Name string `gorm:"not null"`
+		// Rate is tax percent
+		Rate float64 `gorm:"not null"`
+		// Inclusive means that prices in the country are configured and shown with tax included,
+		// otherwise tax is added on top of configured price
+		Inclusive bool `gorm:"not null"`
+		UpdatedAt time.Time
+	}
+
+	// PriceTax is tax breakdown of price for buyer country
+	PriceTax struct {
+		Country   string
+		Name      string
+		Rate      float64
+		Inclusive bool
+		Net       float32
+		Tax       float32
+		Gross     float32
+	}
+
+	TaxService interface {
+		GetRates() ([]TaxRate, error)
+		UpdateRate(rate *TaxRate) error
+		// ApplyTax calculates tax of final price for buyer from country.
+		// VAT of package price is used if there is no tax rate for country.
+		ApplyTax(price *ResolvedPrice, country string) error
+	}
+)
+
+// CalculateTax splits amount into net, tax and gross amounts.
+// Inclusive amount is gross amount, otherwise amount is net and tax is added on top of it.
+func CalculateTax(amount float32, rate float64, inclusive bool) (net, tax, gross float32) {
+	if inclusive {
+		gross = amount
+		net = roundPrice(float32(float64(gross) / (1 + rate/100)))
+		return net, roundPrice(gross - net), gross
+	}
+
+	net = amount
+	tax = roundPrice(float32(float64(net) * rate / 100))
+	return net, tax, roundPrice(net + tax)
+}
+
+// Calculate returns tax breakdown of amount with the rate
+func (r *TaxRate) Calculate(amount float32) *PriceTax {
+	net, tax, gross := CalculateTax(amount, r.Rate, r.Inclusive)
+	return &PriceTax{
+		Country:   r.Country,
+		Name:      r.Name,
+		Rate:      r.Rate,
+		Inclusive: r.Inclusive,
+		Net:       net,
+		Tax:       tax,
+		Gross:     gross,
+	}
+}
@@ -0,0 +1,35 @@
+package model_test
+
+import (
+	"qilin-api/pkg/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CalculateTax(t *testing.T) {
+	shouldBe := require.New(t)
+
+	net, tax, gross := model.CalculateTax(11.9, 19, true)
+	shouldBe.Equal(float32(10), net)
+	shouldBe.Equal(float32(1.9), tax)
+	shouldBe.Equal(float32(11.9), gross)
+
+	net, tax, gross = model.CalculateTax(10, 5, false)
+	shouldBe.Equal(float32(10), net)
+	shouldBe.Equal(float32(0.5), tax)
+	shouldBe.Equal(float32(10.5), gross)
+
+	net, tax, gross = model.CalculateTax(9.99, 0, false)
+	shouldBe.Equal(float32(9.99), net)
+	shouldBe.Equal(float32(0), tax)
+	shouldBe.Equal(float32(9.99), gross)
+
+	rate := model.TaxRate{Country: "DE", Name: "VAT", Rate: 19, Inclusive: true}
+	result := rate.Calculate(59.99)
+	shouldBe.Equal("DE", result.Country)
+	shouldBe.True(result.Inclusive)
+	shouldBe.Equal(float32(50.41), result.Net)
+	shouldBe.Equal(float32(9.58), result.Tax)
+	shouldBe.InDelta(result.Gross, result.Net+result.Tax, 0.001)
+}
@@ -52,6 +52,11 @@ const PackageType string = "packages"
 const RoleBundle string = "bundles"
 const PackageListType string = "vendors.packages.*"
 const RoleBundleList string = "vendors.bundles.*"
+const AdminCurrencyRatesType string = "admin.currencies.*"
+const AdminTaxRatesType string = "admin.taxes.*"
+const AdminGameReviewsType string = "admin.games.*"
+const AdminEventsType string = "admin.events.*"
+const AdminCatalogType string = "admin.catalog.*"
 
 type ResourceMeta struct {
 	Preview      string `json:"preview"`
@@ -0,0 +1,197 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"github.com/ProtocolONE/rbac"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"strings"
+	"time"
+)
+
+const (
+	// Actions of custom role permission, `write` includes `read`
+	RoleActionRead  = "read"
+	RoleActionWrite = "write"
+
+	// Resources of vendor granted by custom roles
+	RoleResourceGames       = "games"
+	RoleResourcePackages    = "packages"
+	RoleResourceBundles     = "bundles"
+	RoleResourceDocuments   = "documents"
+	RoleResourceMessages    = "messages"
+	RoleResourceVendor      = "vendor"
+	RoleResourceMemberships = "memberships"
+
+	MaxVendorRoleNameLength = 64
+)
+
+type (
+	// VendorRole is named set of permissions defined by vendor. Role is registered in rbac enforcer with its id as
+	// name, so it's assigned through memberships like built-in roles and can't clash with roles of another vendor.
+	VendorRole struct {
+		ID          uuid.UUID       `gorm:"type:uuid; primary_key"`
+		CreatedAt   time.Time       `gorm:"default:now()"`
+		UpdatedAt   time.Time       `gorm:"default:now()"`
+		VendorID    uuid.UUID       `gorm:"type:uuid; not null; unique_index:idx_vendor_role_name"`
+		Name        string          `gorm:"not null; unique_index:idx_vendor_role_name"`
+		Permissions RolePermissions `gorm:"type:jsonb; not null; default:'[]'"`
+	}
+
+	RolePermissions []RolePermission
+
+	// RolePermission grants action on resource of vendor
+	RolePermission struct {
+		Resource string `json:"resource"`
+		Action   string `json:"action"`
+	}
+
+	VendorRoleService interface {
+		// Init registers policies of all stored roles in enforcer
+		Init() error
+		// Sync reloads policies of roles from database, so roles changed by other instances are applied in enforcer
+		Sync() error
+		GetList(vendorId uuid.UUID) ([]VendorRole, error)
+		Get(vendorId uuid.UUID, roleId uuid.UUID) (*VendorRole, error)
+		Create(vendorId uuid.UUID, name string, permissions RolePermissions) (*VendorRole, error)
+		Update(vendorId uuid.UUID, roleId uuid.UUID, name string, permissions RolePermissions) (*VendorRole, error)
+		// Delete removes role with its policies and unassigns it from members of vendor
+		Delete(vendorId uuid.UUID, roleId uuid.UUID) error
+	}
+)
+
+// readOnlyRoleResources can't be granted for write by custom role. Memberships and roles of vendor are changed by
+// its owner only, otherwise members could grant themselves any permission.
+var readOnlyRoleResources = map[string]bool{
+	RoleResourceMemberships: true,
+}
+
+// roleResourceTypes lists rbac resource types covered by resource of custom role. Resource id `*` means that
+// permission may be restricted by game, `skip` is used for lists and vendor level resources.
+var roleResourceTypes = map[string][]rbac.Policy{
+	RoleResourceGames: {
+		{ResourceType: GameType, ResourceId: "*"},
+		{ResourceType: GameListType, ResourceId: "skip"},
+		{ResourceType: VendorGameType, ResourceId: "skip"},
+	},
+	RoleResourcePackages: {
+		{ResourceType: PackageType, ResourceId: "*"},
+		{ResourceType: PackageListType, ResourceId: "skip"},
+	},
+	RoleResourceBundles: {
+		{ResourceType: RoleBundle, ResourceId: "*"},
+		{ResourceType: RoleBundleList, ResourceId: "skip"},
+	},
+	RoleResourceDocuments: {
+		{ResourceType: DocumentsType, ResourceId: "skip"},
+	},
+	RoleResourceMessages: {
+		{ResourceType: MessagesType, ResourceId: "skip"},
+	},
+	RoleResourceVendor: {
+		{ResourceType: VendorType, ResourceId: "skip"},
+	},
+	RoleResourceMemberships: {
+		{ResourceType: RolesType, ResourceId: "skip"},
+		{ResourceType: RoleUserType, ResourceId: "skip"},
+	},
+}
+
+func (VendorRole) TableName() string {
+	return "vendor_roles"
+}
+
+// Value is marshaling function
+func (p RolePermissions) Value() (driver.Value, error) {
+	valueString, err := json.Marshal(p)
+	return string(valueString), err
+}
+
+// Scan is unmarshaling function
+func (p *RolePermissions) Scan(value interface{}) error {
+	if err := json.Unmarshal(value.([]byte), &p); err != nil {
+		return err
+	}
+	return nil
+}
+
+// NewVendorRole validates name and permissions and makes role of vendor
+func NewVendorRole(vendorId uuid.UUID, name string, permissions RolePermissions) (*VendorRole, error) {
+	role := &VendorRole{ID: uuid.NewV4(), VendorID: vendorId}
+	if err := role.Change(name, permissions); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+// Change validates and sets new name and permissions of role
+func (r *VendorRole) Change(name string, permissions RolePermissions) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("Name of role is required")
+	}
+	if len(name) > MaxVendorRoleNameLength {
+		return errors.Errorf("Name of role must be at most %d characters", MaxVendorRoleNameLength)
+	}
+	if len(permissions) == 0 {
+		return errors.New("Role must have at least one permission")
+	}
+
+	resources := map[string]bool{}
+	for _, permission := range permissions {
+		if _, ok := roleResourceTypes[permission.Resource]; !ok {
+			return errors.Errorf("Unknown resource `%s`", permission.Resource)
+		}
+		if permission.Action != RoleActionRead && permission.Action != RoleActionWrite {
+			return errors.Errorf("Unknown action `%s` for resource `%s`, only `%s` and `%s` are supported", permission.Action, permission.Resource, RoleActionRead, RoleActionWrite)
+		}
+		if permission.Action == RoleActionWrite && readOnlyRoleResources[permission.Resource] {
+			return errors.Errorf("Resource `%s` can be granted for `%s` only", permission.Resource, RoleActionRead)
+		}
+		if resources[permission.Resource] {
+			return errors.Errorf("Resource `%s` is granted twice", permission.Resource)
+		}
+		resources[permission.Resource] = true
+	}
+
+	r.Name = name
+	r.Permissions = permissions
+	return nil
+}
+
+// RbacName is name of role in enforcer
+func (r *VendorRole) RbacName() string {
+	return r.ID.String()
+}
+
+// Policies returns rbac policies granted by role
+func (r *VendorRole) Policies() []rbac.Policy {
+	policies := []rbac.Policy{}
+	for _, permission := range r.Permissions {
+		// Write requests are enforced with `write` and `any` actions, so write permission allows any action
+		// Read only resources stay read only for roles stored before the restriction
+		action := "read"
+		if permission.Action == RoleActionWrite && !readOnlyRoleResources[permission.Resource] {
+			action = "any"
+		}
+
+		for _, resource := range roleResourceTypes[permission.Resource] {
+			policies = append(policies, rbac.Policy{
+				Role:         r.RbacName(),
+				Domain:       VendorDomain,
+				ResourceType: resource.ResourceType,
+				ResourceId:   resource.ResourceId,
+				Action:       action,
+				Effect:       rbac.AllowAccess,
+			})
+		}
+	}
+	return policies
+}
+
+// IsVendorRole reports whether role is reference to custom role of vendor, it's id of role
+func IsVendorRole(role string) bool {
+	_, err := uuid.FromString(role)
+	return err == nil
+}
@@ -0,0 +1,127 @@
+package model_test
+
+import (
+	"github.com/ProtocolONE/rbac"
+	"github.com/satori/go.uuid"
+	"qilin-api/pkg/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewVendorRole(t *testing.T) {
+	shouldBe := require.New(t)
+
+	vendorId := uuid.NewV4()
+	role, err := model.NewVendorRole(vendorId, " Pricing editor ", model.RolePermissions{
+		{Resource: model.RoleResourcePackages, Action: model.RoleActionWrite},
+		{Resource: model.RoleResourceGames, Action: model.RoleActionRead},
+	})
+	shouldBe.Nil(err)
+	shouldBe.Equal("Pricing editor", role.Name)
+	shouldBe.Equal(vendorId, role.VendorID)
+	shouldBe.True(model.IsVendorRole(role.RbacName()))
+	shouldBe.False(model.IsVendorRole(model.Support))
+
+	_, err = model.NewVendorRole(vendorId, "", model.RolePermissions{{Resource: model.RoleResourceGames, Action: model.RoleActionRead}})
+	shouldBe.NotNil(err, "Name is required")
+
+	_, err = model.NewVendorRole(vendorId, "Empty", model.RolePermissions{})
+	shouldBe.NotNil(err, "Permissions are required")
+
+	_, err = model.NewVendorRole(vendorId, "Unknown", model.RolePermissions{{Resource: "taxes", Action: model.RoleActionRead}})
+	shouldBe.NotNil(err, "Resource must be known")
+
+	_, err = model.NewVendorRole(vendorId, "Delete", model.RolePermissions{{Resource: model.RoleResourceGames, Action: "delete"}})
+	shouldBe.NotNil(err, "Action must be read or write")
+
+	_, err = model.NewVendorRole(vendorId, "Twice", model.RolePermissions{
+		{Resource: model.RoleResourceGames, Action: model.RoleActionRead},
+		{Resource: model.RoleResourceGames, Action: model.RoleActionWrite},
+	})
+	shouldBe.NotNil(err, "Resource must be granted once")
+}
+
+func Test_VendorRolePolicies(t *testing.T) {
+	shouldBe := require.New(t)
+
+	role, err := model.NewVendorRole(uuid.NewV4(), "Pricing editor", model.RolePermissions{
+		{Resource: model.RoleResourcePackages, Action: model.RoleActionWrite},
+		{Resource: model.RoleResourceGames, Action: model.RoleActionRead},
+	})
+	shouldBe.Nil(err)
+	shouldBe.Len(role.Policies(), 5)
+
+	enforcer := rbac.NewEnforcer()
+	for _, policy := range role.Policies() {
+		shouldBe.True(enforcer.AddPolicy(policy))
+	}
+
+	owner := "owner"
+	gameId := uuid.NewV4().String()
+	shouldBe.True(enforcer.AddRole(rbac.Role{User: "editor", Role: role.RbacName(), Domain: model.VendorDomain, Owner: owner, RestrictedResourceId: []string{"*"}}))
+
+	check := func(resource, resourceId, action string) bool {
+		return enforcer.Enforce(rbac.Context{
+			User:          "editor",
+			Domain:        model.VendorDomain,
+			Resource:      resource,
+			ResourceId:    resourceId,
+			ResourceOwner: owner,
+			Action:        action,
+		})
+	}
+
+	shouldBe.True(check(model.PackageType, uuid.NewV4().String(), "read"))
+	shouldBe.True(check(model.PackageType, uuid.NewV4().String(), "any"))
+	shouldBe.True(check(model.PackageListType, "*", "write"))
+	shouldBe.True(check(model.GameType, gameId, "read"))
+	shouldBe.False(check(model.GameType, gameId, "any"), "Games are granted for read only")
+	shouldBe.False(check(model.RoleBundle, uuid.NewV4().String(), "read"), "Bundles aren't granted")
+	shouldBe.False(check(model.RolesType, "*", "read"), "Roles of vendor aren't granted")
+
+	shouldBe.False(enforcer.Enforce(rbac.Context{
+		User:          "editor",
+		Domain:        model.VendorDomain,
+		Resource:      model.PackageType,
+		ResourceId:    uuid.NewV4().String(),
+		ResourceOwner: "another_owner",
+		Action:        "read",
+	}), "Role is granted in vendor of owner only")
+}
+
+func Test_VendorRoleMembershipsPolicies(t *testing.T) {
+	shouldBe := require.New(t)
+
+	_, err := model.NewVendorRole(uuid.NewV4(), "Team manager", model.RolePermissions{
+		{Resource: model.RoleResourceMemberships, Action: model.RoleActionWrite},
+	})
+	shouldBe.NotNil(err, "Memberships and roles must not be changed by custom role")
+
+	role, err := model.NewVendorRole(uuid.NewV4(), "Team viewer", model.RolePermissions{
+		{Resource: model.RoleResourceMemberships, Action: model.RoleActionRead},
+	})
+	shouldBe.Nil(err)
+
+	enforcer := rbac.NewEnforcer()
+	for _, policy := range role.Policies() {
+		shouldBe.True(enforcer.AddPolicy(policy))
+	}
+	shouldBe.True(enforcer.AddRole(rbac.Role{User: "viewer", Role: role.RbacName(), Domain: model.VendorDomain, Owner: "owner", RestrictedResourceId: []string{"*"}}))
+
+	check := func(resource, action string) bool {
+		return enforcer.Enforce(rbac.Context{
+			User:          "viewer",
+			Domain:        model.VendorDomain,
+			Resource:      resource,
+			ResourceId:    "*",
+			ResourceOwner: "owner",
+			Action:        action,
+		})
+	}
+
+	for _, resource := range []string{model.RolesType, model.RoleUserType} {
+		shouldBe.True(check(resource, "read"), "Memberships must be granted on `%s` checked by membership routes", resource)
+		shouldBe.False(check(resource, "write"), "Memberships must be granted on `%s` for read only", resource)
+	}
+}
@@ -0,0 +1,107 @@
+package orm
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/model"
+	"strings"
+)
+
+type achievementService struct {
+	db *gorm.DB
+}
+
+func NewAchievementService(db *Database) model.AchievementService {
+	return &achievementService{db: db.DB()}
+}
+
+func (service *achievementService) GetList(gameId uuid.UUID) ([]model.Achievement, error) {
+	if err := checkGameExist(service.db, gameId); err != nil {
+		return nil, err
+	}
+
+	achievements := []model.Achievement{}
+	err := service.db.Where("game_id = ?", gameId).Order("\"order\", created_at").Find(&achievements).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Get achievements"))
+	}
+
+	return achievements, nil
+}
+
+func (service *achievementService) Get(gameId, achId uuid.UUID) (*model.Achievement, error) {
+	achievement := &model.Achievement{}
+	err := service.db.Where("id = ? AND game_id = ?", achId, gameId).First(achievement).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, NewServiceErrorf(http.StatusNotFound, "Achievement `%s` not found", achId)
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Get achievement"))
+	}
+
+	return achievement, nil
+}
+
+func (service *achievementService) Create(gameId uuid.UUID, achievement *model.Achievement) (*model.Achievement, error) {
+	if err := checkGameExist(service.db, gameId); err != nil {
+		return nil, err
+	}
+
+	if err := validateAchievement(achievement); err != nil {
+		return nil, err
+	}
+
+	achievement.ID = uuid.NewV4()
+	achievement.GameID = gameId
+	if err := service.db.Create(achievement).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Create achievement"))
+	}
+
+	return achievement, nil
+}
+
+func (service *achievementService) Update(gameId uuid.UUID, achievement *model.Achievement) (*model.Achievement, error) {
+	current, err := service.Get(gameId, achievement.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateAchievement(achievement); err != nil {
+		return nil, err
+	}
+
+	achievement.GameID = gameId
+	achievement.CreatedAt = current.CreatedAt
+	if err := service.db.Save(achievement).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Update achievement"))
+	}
+
+	return achievement, nil
+}
+
+func (service *achievementService) Delete(gameId, achId uuid.UUID) error {
+	achievement, err := service.Get(gameId, achId)
+	if err != nil {
+		return err
+	}
+
+	if err := service.db.Delete(achievement).Error; err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Delete achievement"))
+	}
+
+	return nil
+}
+
+func validateAchievement(achievement *model.Achievement) error {
+	if strings.TrimSpace(achievement.Name.EN) == "" {
+		return NewServiceError(http.StatusUnprocessableEntity, "English name of achievement is required")
+	}
+
+	if achievement.Points < 0 {
+		return NewServiceError(http.StatusUnprocessableEntity, "Points must be not negative")
+	}
+
+	return nil
+}
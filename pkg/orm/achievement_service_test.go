@@ -0,0 +1,160 @@
+package orm_test
+
+import (
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"qilin-api/pkg/model"
+	bto "qilin-api/pkg/model/game"
+	"qilin-api/pkg/model/utils"
+	"qilin-api/pkg/orm"
+	"qilin-api/pkg/test"
+	"testing"
+)
+
+type AchievementServiceTestSuite struct {
+	suite.Suite
+	db      *orm.Database
+	service model.AchievementService
+	gameId  uuid.UUID
+}
+
+func Test_AchievementService(t *testing.T) {
+	suite.Run(t, new(AchievementServiceTestSuite))
+}
+
+func (suite *AchievementServiceTestSuite) SetupTest() {
+	should := require.New(suite.T())
+
+	config, err := qilin_test.LoadTestConfig()
+	should.Nil(err, "Unable to load config")
+
+	db, err := orm.NewDatabase(&config.Database)
+	should.Nil(err, "Unable to connect to database")
+
+	should.Nil(db.DropAllTables(), "Unable to drop tables")
+	should.Nil(db.Init(), "Unable to init tables")
+
+	suite.db = db
+	suite.service = orm.NewAchievementService(db)
+
+	game := model.Game{
+		ID:             uuid.NewV4(),
+		InternalName:   "achievementsGame",
+		FeaturesCommon: []string{},
+		Platforms:      bto.Platforms{},
+		Requirements:   bto.GameRequirements{},
+		Languages:      bto.GameLangs{},
+		GenreAddition:  []int64{},
+		Tags:           []int64{},
+	}
+	should.Nil(db.DB().Create(&game).Error)
+	suite.gameId = game.ID
+}
+
+func (suite *AchievementServiceTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *AchievementServiceTestSuite) TestCrud() {
+	should := require.New(suite.T())
+
+	list, err := suite.service.GetList(suite.gameId)
+	should.Nil(err)
+	should.Len(list, 0)
+
+	second, err := suite.service.Create(suite.gameId, &model.Achievement{
+		Name:   utils.LocalizedString{EN: "Second", RU: "Второе"},
+		Order:  2,
+		Points: 20,
+	})
+	should.Nil(err)
+	should.NotEqual(uuid.Nil, second.ID)
+	should.Equal(suite.gameId, second.GameID)
+
+	first, err := suite.service.Create(suite.gameId, &model.Achievement{
+		Name:         utils.LocalizedString{EN: "First"},
+		Description:  utils.LocalizedString{EN: "Finish tutorial"},
+		LockedIcon:   "http://example.com/locked.png",
+		UnlockedIcon: "http://example.com/unlocked.png",
+		Hidden:       true,
+		Order:        1,
+		Points:       10,
+	})
+	should.Nil(err)
+
+	list, err = suite.service.GetList(suite.gameId)
+	should.Nil(err)
+	should.Len(list, 2)
+	should.Equal(first.ID, list[0].ID)
+	should.Equal(second.ID, list[1].ID)
+	should.True(list[0].Hidden)
+	should.Equal("Finish tutorial", list[0].Description.EN)
+	should.Equal("Второе", list[1].Name.RU)
+
+	updated, err := suite.service.Update(suite.gameId, &model.Achievement{
+		Model:  model.Model{ID: second.ID},
+		Name:   utils.LocalizedString{EN: "Second updated"},
+		Order:  0,
+		Points: 30,
+	})
+	should.Nil(err)
+	should.Equal(30, updated.Points)
+
+	saved, err := suite.service.Get(suite.gameId, second.ID)
+	should.Nil(err)
+	should.Equal("Second updated", saved.Name.EN)
+	should.Equal(30, saved.Points)
+	should.Equal(0, saved.Order)
+
+	list, err = suite.service.GetList(suite.gameId)
+	should.Nil(err)
+	should.Equal(second.ID, list[0].ID)
+
+	should.Nil(suite.service.Delete(suite.gameId, first.ID))
+	_, err = suite.service.Get(suite.gameId, first.ID)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	list, err = suite.service.GetList(suite.gameId)
+	should.Nil(err)
+	should.Len(list, 1)
+}
+
+func (suite *AchievementServiceTestSuite) TestErrors() {
+	should := require.New(suite.T())
+
+	_, err := suite.service.GetList(uuid.NewV4())
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	_, err = suite.service.Create(uuid.NewV4(), &model.Achievement{Name: utils.LocalizedString{EN: "Name"}})
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	_, err = suite.service.Create(suite.gameId, &model.Achievement{})
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
+	_, err = suite.service.Create(suite.gameId, &model.Achievement{Name: utils.LocalizedString{EN: "Name"}, Points: -1})
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
+	achievement, err := suite.service.Create(suite.gameId, &model.Achievement{Name: utils.LocalizedString{EN: "Name"}})
+	should.Nil(err)
+
+	// Achievement of another game is not visible
+	_, err = suite.service.Get(uuid.NewV4(), achievement.ID)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	err = suite.service.Delete(uuid.NewV4(), achievement.ID)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+}
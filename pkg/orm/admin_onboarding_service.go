@@ -119,11 +119,23 @@ func (p *AdminOnboardingService) ChangeStatus(id uuid.UUID, status model.ReviewS
 	}
 	doc.ReviewStatus = status
 
-	err = p.db.Save(doc).Error
+	transaction := p.db.Begin()
+	err = transaction.Save(doc).Error
 	if err != nil {
+		transaction.Rollback()
 		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Saving document error"))
 	}
 
+	event := model.VendorStatusChanged{VendorID: doc.VendorID, Status: doc.Status.ToString(), ReviewStatus: status.ToString()}
+	if err := NewEventBus(transaction).Publish(event); err != nil {
+		transaction.Rollback()
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish vendor status"))
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Commit document status"))
+	}
+
 	if doc.Status == model.StatusApproved {
 		owner, err := p.ownerProvider.GetOwnerForVendor(doc.VendorID)
 		if err != nil {
@@ -0,0 +1,78 @@
+package orm
+
+import (
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/utils"
+)
+
+type availabilityService struct {
+	packageService model.PackageService
+	bundleService  model.BundleService
+}
+
+func NewAvailabilityService(packageService model.PackageService, bundleService model.BundleService) model.AvailabilityService {
+	return &availabilityService{packageService: packageService, bundleService: bundleService}
+}
+
+func (s *availabilityService) GetPackageAvailability(packageId uuid.UUID, country string) (*model.Availability, error) {
+	if err := checkCountry(country); err != nil {
+		return nil, err
+	}
+
+	pkg, err := s.packageService.Get(packageId)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.ResolvePackageAvailability(pkg, country), nil
+}
+
+func (s *availabilityService) GetBundleAvailability(bundleId uuid.UUID, country string) (*model.Availability, error) {
+	if err := checkCountry(country); err != nil {
+		return nil, err
+	}
+
+	bundle, err := s.bundleService.Get(bundleId)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.ResolveBundleAvailability(bundle, country)
+}
+
+func checkCountry(country string) error {
+	if country == "" || !utils.ValidateCountryList([]string{country}) {
+		return NewServiceErrorf(http.StatusUnprocessableEntity, "Wrong country `%s`", country)
+	}
+	return nil
+}
+
+// availableInCountry is SQL condition on regional restrictions of table rows, it takes country twice
+func availableInCountry(table string) string {
+	return fmt.Sprintf("(not ? = any(coalesce(%[1]s.denied_countries, '{}')) and "+
+		"(array_length(%[1]s.allowed_countries, 1) is null or ? = any(%[1]s.allowed_countries)))", table)
+}
+
+// whereAvailablePackage filters packages available for buyer from country, empty country disables filtering
+func whereAvailablePackage(query *gorm.DB, country string) *gorm.DB {
+	if country == "" {
+		return query
+	}
+	return query.Where(availableInCountry("packages"), country, country)
+}
+
+// whereAvailableBundle filters bundles available for buyer from country with all their packages, packages of
+// bundle are linked by join table with `bundle_id` and `package_id` columns. Empty country disables filtering.
+func whereAvailableBundle(query *gorm.DB, table, joinTable, country string) *gorm.DB {
+	if country == "" {
+		return query
+	}
+	return query.
+		Where(availableInCountry(table), country, country).
+		Where(fmt.Sprintf("not exists (select 1 from %[2]s inner join packages on packages.id = %[2]s.package_id "+
+			"where %[2]s.bundle_id = %[1]s.id and not %[3]s)", table, joinTable, availableInCountry("packages")), country, country)
+}
@@ -8,6 +8,7 @@ import (
 	"qilin-api/pkg/model"
 	mutils "qilin-api/pkg/model/utils"
 	"qilin-api/pkg/orm/utils"
+	qutils "qilin-api/pkg/utils"
 	"strings"
 	"time"
 )
@@ -56,12 +57,15 @@ func (p *bundleService) CreateStore(vendorId uuid.UUID, userId, name string, pac
 		CreatorID: userId,
 	}
 	newBundle.Bundle.EntryID = newBundle.ID
-	err = p.db.Create(&newBundle).Error
+
+	db := p.db.Begin()
+	err = db.Create(&newBundle).Error
 	if err != nil {
+		db.Rollback()
 		return nil, errors.Wrap(err, "While create new bundle")
 	}
 
-	db := p.db.Begin()
+	bundlePackages := []uuid.UUID{}
 	// We walks `packageIds` first cuz want to persistent ordering
 	for index, pkgID := range packageIds {
 		for _, pkg := range packages {
@@ -77,8 +81,14 @@ func (p *bundleService) CreateStore(vendorId uuid.UUID, userId, name string, pac
 				db.Rollback()
 				return nil, errors.Wrap(err, "While append packages into bundle")
 			}
+			bundlePackages = append(bundlePackages, pkg.ID)
 		}
 	}
+	err = NewEventBus(db).Publish(model.BundleCreated{BundleEventState: model.NewBundleEventState(&newBundle), Packages: bundlePackages})
+	if err != nil {
+		db.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish bundle creation"))
+	}
 	err = db.Commit().Error
 	if err != nil {
 		return nil, errors.Wrap(err, "While commit packages")
@@ -92,10 +102,15 @@ func (p *bundleService) CreateStore(vendorId uuid.UUID, userId, name string, pac
 func (p *bundleService) GetStoreList(
 	userId string,
 	vendorId uuid.UUID,
-	query, sort string,
+	query, sort, country string,
 	offset, limit int,
 	filterFunc model.BundleListingFilter,
 ) (total int, result []model.Bundle, err error) {
+	if country != "" {
+		if err := checkCountry(country); err != nil {
+			return 0, nil, err
+		}
+	}
 
 	orderBy := ""
 	orderBy = "created_at ASC"
@@ -132,7 +147,7 @@ func (p *bundleService) GetStoreList(
 
 	storeBundles := []model.StoreBundle{}
 	vendorBundles := []model.StoreBundle{}
-	err = p.db.
+	err = whereAvailableBundle(p.db.Model(model.StoreBundle{}), "store_bundles", "bundle_packages", country).
 		Select("id").
 		Where(`vendor_id = ?`, vendorId).
 		Where(strings.Join(conds, " or "), vals...).
@@ -236,7 +251,11 @@ func (p *bundleService) Get(bundleId uuid.UUID) (bundle model.Bundle, err error)
 		return &bundle, nil
 	}
 
-	return nil, NewServiceError(http.StatusNotImplemented, "Only store bundle, yet")
+	if entry.EntryType == model.BundleLootbox {
+		return p.getLootbox(bundleId)
+	}
+
+	return nil, NewServiceError(http.StatusNotImplemented, "Unknown bundle type")
 }
 
 func (p *bundleService) Delete(bundleId uuid.UUID) (err error) {
@@ -250,12 +269,44 @@ func (p *bundleService) Delete(bundleId uuid.UUID) (err error) {
 	}
 
 	if entry.EntryType == model.BundleStore {
-		err = p.db.Delete(model.StoreBundle{}, "id = ?", bundleId).Error
+		db := p.db.Begin()
+		err = db.Delete(model.StoreBundle{}, "id = ?", bundleId).Error
 		if err != nil {
+			db.Rollback()
 			return errors.Wrap(err, "Retrieve store bundle")
 		}
+		err = NewEventBus(db).Publish(model.BundleDeleted{BundleID: bundleId, Type: model.BundleStore})
+		if err != nil {
+			db.Rollback()
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish bundle deletion"))
+		}
+		err = db.Commit().Error
+		if err != nil {
+			return errors.Wrap(err, "While commit store bundle")
+		}
+	} else if entry.EntryType == model.BundleLootbox {
+		db := p.db.Begin()
+		err = db.Delete(model.LootboxItem{}, "bundle_id = ?", bundleId).Error
+		if err != nil {
+			db.Rollback()
+			return errors.Wrap(err, "Delete lootbox items")
+		}
+		err = db.Delete(model.LootboxBundle{}, "id = ?", bundleId).Error
+		if err != nil {
+			db.Rollback()
+			return errors.Wrap(err, "Delete lootbox")
+		}
+		err = NewEventBus(db).Publish(model.BundleDeleted{BundleID: bundleId, Type: model.BundleLootbox})
+		if err != nil {
+			db.Rollback()
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish bundle deletion"))
+		}
+		err = db.Commit().Error
+		if err != nil {
+			return errors.Wrap(err, "While commit lootbox")
+		}
 	} else {
-		return NewServiceError(http.StatusNotImplemented, "Only store bundle, yet")
+		return NewServiceError(http.StatusNotImplemented, "Unknown bundle type")
 	}
 
 	return nil
@@ -277,10 +328,21 @@ func (p *bundleService) UpdateStore(storeBundle model.Bundle) (result model.Bund
 	bundle.UpdatedAt = time.Now()
 	bundle.VendorID = exist.VendorID
 	bundle.Packages = []model.Package{}
-	err = p.db.Save(bundle).Error
+	db := p.db.Begin()
+	err = db.Save(bundle).Error
 	if err != nil {
+		db.Rollback()
 		return nil, errors.Wrap(err, "Save package")
 	}
+	err = NewEventBus(db).Publish(model.BundleUpdated{BundleEventState: model.NewBundleEventState(bundle)})
+	if err != nil {
+		db.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish bundle update"))
+	}
+	err = db.Commit().Error
+	if err != nil {
+		return nil, errors.Wrap(err, "While commit bundle")
+	}
 
 	bu, err := p.Get(bundle.ID)
 
@@ -328,7 +390,7 @@ func (p *bundleService) AddPackages(bundleId uuid.UUID, packageIds []uuid.UUID)
 
 	// 3. Filter already bound packages
 	exists := []model.BundlePackage{}
-	err = p.db.Where("bundle_id = ?", bundleId).Find(&exists).Error
+	err = p.db.Where("bundle_id = ?", bundleId).Order("position").Find(&exists).Error
 	if err != nil {
 		return errors.Wrap(err, "Retrieve bundle packages")
 	}
@@ -345,6 +407,7 @@ func (p *bundleService) AddPackages(bundleId uuid.UUID, packageIds []uuid.UUID)
 	}
 
 	// 4. Append packages with defined order
+	added := []uuid.UUID{}
 	db := p.db.Begin()
 	// We walks `packages` first cuz want to persistent ordering
 	for index, pkgID := range packageIds {
@@ -361,9 +424,24 @@ func (p *bundleService) AddPackages(bundleId uuid.UUID, packageIds []uuid.UUID)
 				db.Rollback()
 				return errors.Wrap(err, "While append packages into bundle")
 			}
+			added = append(added, pkg.ID)
 			break
 		}
 	}
+	bundlePackages := []uuid.UUID{}
+	for _, exist := range exists {
+		bundlePackages = append(bundlePackages, exist.PackageID)
+	}
+	err = NewEventBus(db).Publish(model.BundlePackagesChanged{
+		BundleID: bundleId,
+		Added:    added,
+		Removed:  []uuid.UUID{},
+		Packages: append(bundlePackages, added...),
+	})
+	if err != nil {
+		db.Rollback()
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish bundle packages"))
+	}
 	err = db.Commit().Error
 	if err != nil {
 		return errors.Wrap(err, "While commit packages")
@@ -380,7 +458,7 @@ func (p *bundleService) RemovePackages(bundleId uuid.UUID, packages []uuid.UUID)
 	}
 
 	exists := []model.BundlePackage{}
-	err = p.db.Where("bundle_id = ?", bundleId).Find(&exists).Error
+	err = p.db.Where("bundle_id = ?", bundleId).Order("position").Find(&exists).Error
 	if err != nil {
 		return errors.Wrap(err, "Retrieve bundle packages")
 	}
@@ -398,13 +476,324 @@ func (p *bundleService) RemovePackages(bundleId uuid.UUID, packages []uuid.UUID)
 	}
 
 	if len(packages) > 0 {
-		err = p.db.Delete(model.BundlePackage{}, "bundle_id = ? and package_id in (?)", bundleId, packages).Error
+		db := p.db.Begin()
+		err = db.Delete(model.BundlePackage{}, "bundle_id = ? and package_id in (?)", bundleId, packages).Error
 		if err != nil {
+			db.Rollback()
 			return errors.Wrap(err, "While delete packages from bundle")
 		}
+
+		bundlePackages := []uuid.UUID{}
+		for _, exist := range exists {
+			removed := false
+			for _, packageID := range packages {
+				if exist.PackageID == packageID {
+					removed = true
+					break
+				}
+			}
+			if !removed {
+				bundlePackages = append(bundlePackages, exist.PackageID)
+			}
+		}
+		err = NewEventBus(db).Publish(model.BundlePackagesChanged{
+			BundleID: bundleId,
+			Added:    []uuid.UUID{},
+			Removed:  packages,
+			Packages: bundlePackages,
+		})
+		if err != nil {
+			db.Rollback()
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish bundle packages"))
+		}
+
+		err = db.Commit().Error
+		if err != nil {
+			return errors.Wrap(err, "While commit packages")
+		}
 	} else {
 		return NewServiceError(http.StatusUnprocessableEntity, "No any packages for remove")
 	}
 
 	return
 }
+
+// maxLootboxItems limits size of lootbox drop table
+const maxLootboxItems = 1000
+
+func (p *bundleService) CreateLootbox(vendorId uuid.UUID, userId, name, currency string, items []model.LootboxItem) (bundle model.Bundle, err error) {
+
+	if len(strings.Trim(name, " \r\n\t")) == 0 {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, "Name is empty")
+	}
+
+	if !qutils.IsCurrency(currency) {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, "Invalid currency")
+	}
+
+	if err := p.checkLootboxItems(items); err != nil {
+		return nil, err
+	}
+
+	vendorFound, err := utils.CheckExists(p.db, model.Vendor{}, vendorId)
+	if err != nil {
+		return nil, errors.Wrap(err, "Vendor exists")
+	}
+	if !vendorFound {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, "Invalid vendor")
+	}
+
+	newBundle := model.LootboxBundle{
+		Model:     model.Model{ID: uuid.NewV4()},
+		Sku:       uuid.NewV4().String(),
+		Name:      mutils.LocalizedString{EN: name},
+		VendorID:  vendorId,
+		IsEnabled: false,
+		CreatorID: userId,
+		Currency:  currency,
+	}
+	newBundle.Bundle.EntryID = newBundle.ID
+
+	db := p.db.Begin()
+	err = db.Create(&newBundle).Error
+	if err != nil {
+		db.Rollback()
+		return nil, errors.Wrap(err, "While create new lootbox")
+	}
+	err = createLootboxItems(db, newBundle.ID, items)
+	if err != nil {
+		db.Rollback()
+		return nil, err
+	}
+	packageIds := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		packageIds = append(packageIds, item.PackageID)
+	}
+	err = NewEventBus(db).Publish(model.BundleCreated{BundleEventState: model.NewLootboxEventState(&newBundle), Packages: packageIds})
+	if err != nil {
+		db.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish bundle creation"))
+	}
+	err = db.Commit().Error
+	if err != nil {
+		return nil, errors.Wrap(err, "While commit lootbox")
+	}
+
+	return p.Get(newBundle.ID)
+}
+
+func (p *bundleService) GetLootboxList(
+	userId string,
+	vendorId uuid.UUID,
+	query, sort, country string,
+	offset, limit int,
+	filterFunc model.BundleListingFilter,
+) (total int, result []model.Bundle, err error) {
+	if country != "" {
+		if err := checkCountry(country); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	orderBy := "created_at ASC"
+	switch sort {
+	case "-date":
+		orderBy = "created_at DESC"
+	case "-name":
+		orderBy = "name DESC"
+	case "+name":
+		orderBy = "name ASC"
+	case "-price":
+		orderBy = "price DESC"
+	case "+price":
+		orderBy = "price ASC"
+	}
+
+	conds := []string{}
+	vals := []interface{}{}
+	if query != "" {
+		user := model.User{}
+		err = p.db.Select("lang").Where("id = ?", userId).First(&user).Error
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "while fetch user")
+		}
+		conds = append(conds, "(name ->> ? ilike ? or name ->> 'en' ilike ?)")
+		vals = append(vals, user.GetLocale(), "%"+query+"%", "%"+query+"%")
+	}
+
+	vendorBundles := []model.LootboxBundle{}
+	err = whereAvailableBundle(p.db.Model(model.LootboxBundle{}), "lootbox_bundles", "lootbox_items", country).
+		Select("id").
+		Where(`vendor_id = ?`, vendorId).
+		Where(strings.Join(conds, " or "), vals...).
+		Order(orderBy).
+		Find(&vendorBundles).Error
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "Fetch lootbox ids")
+	}
+	ids := []uuid.UUID{}
+	for _, bundle := range vendorBundles {
+		if filterFunc == nil {
+			ids = append(ids, bundle.ID)
+		} else if isGrant, err := filterFunc(bundle.ID); isGrant {
+			ids = append(ids, bundle.ID)
+		} else if err != nil {
+			return 0, nil, err
+		}
+	}
+	total = len(ids)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(ids) {
+		offset = len(ids)
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	if offset+limit > len(ids) {
+		limit = len(ids) - offset
+	}
+	ids = ids[offset : offset+limit]
+
+	result = []model.Bundle{}
+	for _, id := range ids {
+		bundle, err := p.Get(id)
+		if err != nil {
+			return 0, nil, err
+		}
+		result = append(result, bundle)
+	}
+
+	return
+}
+
+func (p *bundleService) UpdateLootbox(lootbox model.Bundle) (result model.Bundle, err error) {
+	bundle, ok := lootbox.(*model.LootboxBundle)
+	if !ok {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, "Bundle isn't lootbox")
+	}
+	exist := &model.LootboxBundle{Model: model.Model{ID: bundle.ID}}
+	err = p.db.First(exist).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, NewServiceError(http.StatusNotFound, "Bundle not found")
+	} else if err != nil {
+		return nil, errors.Wrap(err, "Retrieve lootbox")
+	}
+
+	if bundle.Price < 0 {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, "Price must be not negative")
+	}
+	if !qutils.IsCurrency(bundle.Currency) {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, "Invalid currency")
+	}
+	if err := p.checkLootboxItems(bundle.Items); err != nil {
+		return nil, err
+	}
+
+	bundle.CreatedAt = exist.CreatedAt
+	bundle.UpdatedAt = time.Now()
+	bundle.VendorID = exist.VendorID
+	bundle.CreatorID = exist.CreatorID
+
+	db := p.db.Begin()
+	err = db.Set("gorm:association_autoupdate", false).Save(bundle).Error
+	if err != nil {
+		db.Rollback()
+		return nil, errors.Wrap(err, "Save lootbox")
+	}
+	err = db.Delete(model.LootboxItem{}, "bundle_id = ?", bundle.ID).Error
+	if err != nil {
+		db.Rollback()
+		return nil, errors.Wrap(err, "Delete lootbox items")
+	}
+	err = createLootboxItems(db, bundle.ID, bundle.Items)
+	if err != nil {
+		db.Rollback()
+		return nil, err
+	}
+	err = NewEventBus(db).Publish(model.BundleUpdated{BundleEventState: model.NewLootboxEventState(bundle)})
+	if err != nil {
+		db.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish bundle update"))
+	}
+	err = db.Commit().Error
+	if err != nil {
+		return nil, errors.Wrap(err, "While commit lootbox")
+	}
+
+	return p.Get(bundle.ID)
+}
+
+func (p *bundleService) getLootbox(bundleId uuid.UUID) (*model.LootboxBundle, error) {
+	bundle := model.LootboxBundle{}
+	err := p.db.Where("id = ?", bundleId).First(&bundle).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, NewServiceError(http.StatusNotFound, "Bundle not found")
+	} else if err != nil {
+		return nil, errors.Wrap(err, "Retrieve lootbox")
+	}
+	err = p.db.
+		Where("bundle_id = ?", bundleId).
+		Order("position asc").
+		Find(&bundle.Items).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "Retrieve lootbox items")
+	}
+	for i := range bundle.Items {
+		bundle.Items[i].Package, err = p.packageService.Get(bundle.Items[i].PackageID)
+		if err != nil {
+			return nil, errors.Wrap(err, "Retrieve lootbox packages")
+		}
+	}
+	return &bundle, nil
+}
+
+func (p *bundleService) checkLootboxItems(items []model.LootboxItem) error {
+	if len(items) == 0 {
+		return NewServiceError(http.StatusUnprocessableEntity, "Drop table is empty")
+	}
+	if len(items) > maxLootboxItems {
+		return NewServiceErrorf(http.StatusUnprocessableEntity, "Drop table must contain not more than %d items", maxLootboxItems)
+	}
+
+	packageIds := []uuid.UUID{}
+	seen := map[uuid.UUID]bool{}
+	for _, item := range items {
+		if item.Weight == 0 {
+			return NewServiceErrorf(http.StatusUnprocessableEntity, "Weight of package `%s` must be positive", item.PackageID)
+		}
+		if seen[item.PackageID] {
+			return NewServiceErrorf(http.StatusUnprocessableEntity, "Package `%s` is in drop table twice", item.PackageID)
+		}
+		seen[item.PackageID] = true
+		packageIds = append(packageIds, item.PackageID)
+	}
+
+	count := 0
+	err := p.db.Model(model.Package{}).Where("id in (?)", packageIds).Count(&count).Error
+	if err != nil {
+		return errors.Wrap(err, "Search packages")
+	}
+	if count != len(packageIds) {
+		return NewServiceError(http.StatusUnprocessableEntity, "Some packages of drop table are not found")
+	}
+
+	return nil
+}
+
+func createLootboxItems(db *gorm.DB, bundleId uuid.UUID, items []model.LootboxItem) error {
+	for index, item := range items {
+		err := db.Create(&model.LootboxItem{
+			BundleID:  bundleId,
+			PackageID: item.PackageID,
+			Weight:    item.Weight,
+			Rarity:    item.Rarity,
+			Position:  index + 1,
+		}).Error
+		if err != nil {
+			return errors.Wrap(err, "While append items into lootbox")
+		}
+	}
+	return nil
+}
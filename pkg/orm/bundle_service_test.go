@@ -1,10 +1,12 @@
 package orm_test
 
 import (
+	"encoding/json"
 	"github.com/ProtocolONE/rbac"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"math"
+	"net/http"
 	"qilin-api/pkg/api/mock"
 	"qilin-api/pkg/model"
 	"qilin-api/pkg/model/utils"
@@ -12,6 +14,7 @@ import (
 	"qilin-api/pkg/test"
 	"testing"
 
+	"github.com/lib/pq"
 	"github.com/satori/go.uuid"
 
 	"github.com/stretchr/testify/suite"
@@ -125,7 +128,7 @@ func (suite *bundleServiceTestSuite) SetupTest() {
 	}
 
 	priceService := orm.NewPriceService(db)
-	err = priceService.UpdateBase(pkgA.ID, &model.BasePrice{
+	err = priceService.UpdateBase(user.ID, pkgA.ID, &model.BasePrice{
 		PackagePrices: model.PackagePrices{
 			Common: model.JSONB{
 				"currency":        "USD",
@@ -140,7 +143,7 @@ func (suite *bundleServiceTestSuite) SetupTest() {
 	if err != nil {
 		suite.Fail("Error while update base game price", "%v", err)
 	}
-	err = priceService.Update(pkgA.ID, &model.Price{
+	err = priceService.Update(user.ID, pkgA.ID, &model.Price{
 		Currency: "USD",
 		Price:    10.25,
 	})
@@ -148,7 +151,7 @@ func (suite *bundleServiceTestSuite) SetupTest() {
 		suite.Fail("Error while update game price", "%v", err)
 	}
 
-	err = priceService.Update(pkgB.ID, &model.Price{
+	err = priceService.Update(user.ID, pkgB.ID, &model.Price{
 		Currency: "USD",
 		Price:    5,
 	})
@@ -220,37 +223,50 @@ func (suite *bundleServiceTestSuite) TestBundles() {
 	should.NotNil(err, "Vendor not found")
 	should.Nil(bundleErr)
 
-	total, list, err := suite.service.GetStoreList(suite.userId, suite.vendorId, "", "-date", 0, 20, nil)
+	total, list, err := suite.service.GetStoreList(suite.userId, suite.vendorId, "", "-date", "", 0, 20, nil)
 	should.Nil(err)
 	should.Equal(2, total)
 	should.Equal(2, len(list))
 	should.Equal("Bundle Humble", list[0].GetName().EN)
 	should.Equal("Mega bundle", list[1].GetName().EN)
 
-	total, list2, err := suite.service.GetStoreList(suite.userId, suite.vendorId, "", "+date", 1, 20, nil)
+	total, list2, err := suite.service.GetStoreList(suite.userId, suite.vendorId, "", "+date", "", 1, 20, nil)
 	should.Nil(err)
 	should.Equal(1, len(list2))
 	should.Equal("Bundle Humble", list2[0].GetName().EN)
 
-	total, list3, err := suite.service.GetStoreList(suite.userId, suite.vendorId, "", "-name", 0, 1, nil)
+	total, list3, err := suite.service.GetStoreList(suite.userId, suite.vendorId, "", "-name", "", 0, 1, nil)
 	should.Nil(err)
 	should.Equal(1, len(list3))
 	should.Equal("Mega bundle", list3[0].GetName().EN)
 
-	total, list4, err := suite.service.GetStoreList(suite.userId, suite.vendorId, "", "-date", 0, 10, func(bundleId uuid.UUID) (bool, error) {
+	total, list4, err := suite.service.GetStoreList(suite.userId, suite.vendorId, "", "-date", "", 0, 10, func(bundleId uuid.UUID) (bool, error) {
 		return bundleId != list[0].GetID(), nil
 	})
 	should.Nil(err)
 	should.Equal(1, len(list4))
 	should.Equal("Mega bundle", list4[0].GetName().EN)
 
-	total, list5, err := suite.service.GetStoreList(suite.userId, suite.vendorId, "", "-date", 1, 10, func(bundleId uuid.UUID) (bool, error) {
+	total, list5, err := suite.service.GetStoreList(suite.userId, suite.vendorId, "", "-date", "", 1, 10, func(bundleId uuid.UUID) (bool, error) {
 		return bundleId != list[0].GetID(), nil
 	})
 	should.Nil(err)
 	should.Equal(0, len(list5))
 	should.Equal(1, total)
 
+	// Bundle is unavailable if any of its packages is unavailable
+	err = suite.db.DB().Model(&model.Package{}).Where("id = ?", suite.packages[1]).Update("denied_countries", pq.StringArray{"DE"}).Error
+	should.Nil(err)
+	total, list6, err := suite.service.GetStoreList(suite.userId, suite.vendorId, "", "-date", "DE", 0, 10, nil)
+	should.Nil(err)
+	should.Equal(1, total)
+	should.Equal("Bundle Humble", list6[0].GetName().EN)
+	err = suite.db.DB().Model(&model.StoreBundle{}).Where("id = ?", list[0].GetID()).Update("allowed_countries", pq.StringArray{"FR"}).Error
+	should.Nil(err)
+	total, _, err = suite.service.GetStoreList(suite.userId, suite.vendorId, "", "-date", "DE", 0, 10, nil)
+	should.Nil(err)
+	should.Equal(0, total)
+
 	bundle3, err := suite.service.Get(bundle.ID)
 	should.Nil(err)
 	b3_pkgs, err := bundle3.GetPackages()
@@ -321,7 +337,7 @@ func (suite *bundleServiceTestSuite) TestBundles() {
 	err = suite.service.Delete(bundle.ID)
 	should.Nil(err, "Remove bundle")
 
-	total, list, err = suite.service.GetStoreList(suite.userId, suite.vendorId, "", "+date", 0, 20, nil)
+	total, list, err = suite.service.GetStoreList(suite.userId, suite.vendorId, "", "+date", "", 0, 20, nil)
 	should.Nil(err)
 	should.Equal(1, total)
 	should.Equal(1, len(list))
@@ -335,3 +351,97 @@ func (suite *bundleServiceTestSuite) TestBundles() {
 	should.NotNil(err, "Update deleted bundle")
 	should.Nil(bundleErr)
 }
+
+func (suite *bundleServiceTestSuite) TestLootbox() {
+	should := require.New(suite.T())
+
+	items := []model.LootboxItem{
+		{PackageID: suite.packages[0], Weight: 90, Rarity: "common"},
+		{PackageID: suite.packages[1], Weight: 10, Rarity: "legendary"},
+	}
+	_, err := suite.service.CreateLootbox(suite.vendorId, suite.userId, "Mystery box", "XXX", items)
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
+	bundleIface, err := suite.service.CreateLootbox(suite.vendorId, suite.userId, "Mystery box", "EUR", items)
+	should.Nil(err)
+	lootbox, ok := bundleIface.(*model.LootboxBundle)
+	should.True(ok)
+	should.Equal("Mystery box", lootbox.Name.EN)
+	should.Equal("EUR", lootbox.Currency)
+	suite.checkLastBundleEvent(model.BundleCreatedTopic, lootbox.ID)
+	should.Len(lootbox.Items, 2)
+	should.Equal(suite.packages[0], lootbox.Items[0].PackageID)
+	should.Equal("legendary", lootbox.Items[1].Rarity)
+
+	drops := lootbox.GetDrops()
+	should.InDelta(0.9, drops[0].Probability, 1e-9)
+	should.InDelta(0.1, drops[1].Probability, 1e-9)
+
+	isIn, err := lootbox.IsContains(suite.games[1])
+	should.Nil(err)
+	should.True(isIn)
+
+	// Store bundles and lootboxes are listed separately
+	total, list, err := suite.service.GetLootboxList(suite.userId, suite.vendorId, "", "", "", 0, 20, nil)
+	should.Nil(err)
+	should.Equal(1, total)
+	should.Equal(lootbox.ID, list[0].GetID())
+	total, _, err = suite.service.GetStoreList(suite.userId, suite.vendorId, "", "", "", 0, 20, nil)
+	should.Nil(err)
+	should.Equal(0, total)
+
+	lootbox.Name.RU = "Коробка"
+	lootbox.Price = 4.99
+	lootbox.Items = []model.LootboxItem{
+		{PackageID: suite.packages[1], Weight: 1, Rarity: "rare"},
+		{PackageID: suite.extraGames[0].DefaultPackageID, Weight: 3},
+	}
+	bundleIface, err = suite.service.UpdateLootbox(lootbox)
+	should.Nil(err)
+	updated := bundleIface.(*model.LootboxBundle)
+	should.Equal("Коробка", updated.Name.RU)
+	should.Equal(float32(4.99), updated.Price)
+	should.Len(updated.Items, 2)
+	should.Equal(suite.packages[1], updated.Items[0].PackageID)
+	should.InDelta(0.75, updated.GetDrops()[1].Probability, 1e-9)
+	suite.checkLastBundleEvent(model.BundleUpdatedTopic, lootbox.ID)
+
+	updated.Currency = "EU"
+	_, err = suite.service.UpdateLootbox(updated)
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+	updated.Currency = "EUR"
+
+	_, err = suite.service.CreateLootbox(suite.vendorId, suite.userId, "Empty box", "EUR", []model.LootboxItem{})
+	should.NotNil(err)
+	_, err = suite.service.CreateLootbox(suite.vendorId, suite.userId, "Zero box", "EUR", []model.LootboxItem{{PackageID: suite.packages[0]}})
+	should.NotNil(err)
+	_, err = suite.service.CreateLootbox(suite.vendorId, suite.userId, "Unknown box", "EUR", []model.LootboxItem{{PackageID: uuid.NewV4(), Weight: 1}})
+	should.NotNil(err)
+	_, err = suite.service.CreateLootbox(suite.vendorId, suite.userId, "Twice box", "EUR", []model.LootboxItem{
+		{PackageID: suite.packages[0], Weight: 1},
+		{PackageID: suite.packages[0], Weight: 2},
+	})
+	should.NotNil(err)
+
+	should.Nil(suite.service.Delete(lootbox.ID))
+	_, err = suite.service.Get(lootbox.ID)
+	should.NotNil(err)
+}
+
+// checkLastBundleEvent checks that the latest event of topic is published for lootbox
+func (suite *bundleServiceTestSuite) checkLastBundleEvent(topic string, bundleId uuid.UUID) {
+	should := require.New(suite.T())
+
+	events, _, err := orm.NewOutboxService(suite.db).GetEvents(model.OutboxUndefined, topic, 0, 1)
+	should.Nil(err)
+	should.Len(events, 1, "Event `%s` must be published", topic)
+	should.Equal(bundleId, events[0].AggregateID)
+
+	state := model.BundleEventState{}
+	should.Nil(json.Unmarshal(events[0].Payload, &struct {
+		Payload *model.BundleEventState `json:"payload"`
+	}{&state}))
+	should.Equal(model.BundleLootbox, state.Type)
+}
@@ -0,0 +1,330 @@
+package orm
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm/utils"
+	"time"
+)
+
+const resyncBatchSize = 100
+
+// resyncHeartbeatInterval is period of saving progress of running resync. It is shorter than stale timeout, so slow
+// resync isn't considered as abandoned while it's publishing one batch.
+const resyncHeartbeatInterval = model.ResyncStaleTimeout / 4
+
+type catalogResyncService struct {
+	db *gorm.DB
+}
+
+func NewCatalogResyncService(db *Database) model.CatalogResyncService {
+	return &catalogResyncService{db: db.database}
+}
+
+func (s *catalogResyncService) Create(userId string, filter model.ResyncFilter) (*model.CatalogResync, error) {
+	resync, err := model.NewCatalogResync(userId, filter)
+	if err != nil {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, err)
+	}
+
+	if resync.VendorID != nil {
+		found, err := utils.CheckExists(s.db, model.Vendor{}, *resync.VendorID)
+		if err != nil {
+			return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Vendor exists"))
+		}
+		if !found {
+			return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Vendor `%s` not found", *resync.VendorID)
+		}
+	}
+
+	if err := s.db.Create(resync).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Create resync"))
+	}
+
+	return resync, nil
+}
+
+func (s *catalogResyncService) Get(id uuid.UUID) (*model.CatalogResync, error) {
+	resync := &model.CatalogResync{}
+	err := s.db.Where("id = ?", id).First(resync).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, NewServiceErrorf(http.StatusNotFound, "Resync `%s` not found", id)
+	} else if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch resync"))
+	}
+	return resync, nil
+}
+
+func (s *catalogResyncService) GetList(offset, limit int) ([]model.CatalogResync, int, error) {
+	count := 0
+	if err := s.db.Model(&model.CatalogResync{}).Count(&count).Error; err != nil {
+		return nil, 0, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Counting resyncs"))
+	}
+
+	list := []model.CatalogResync{}
+	if err := s.db.Order("created_at desc").Offset(offset).Limit(limit).Find(&list).Error; err != nil {
+		return nil, 0, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch resyncs"))
+	}
+
+	return list, count, nil
+}
+
+func (s *catalogResyncService) Resume(id uuid.UUID) (*model.CatalogResync, error) {
+	resync, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !resync.CanBeResumed(time.Now()) {
+		return nil, NewServiceErrorf(http.StatusConflict, "Resync with status `%s` can't be resumed", resync.Status.ToString())
+	}
+
+	// Resync is taken only if nobody has changed it after reading, so two runners can't resume it together
+	res := s.db.
+		Model(&model.CatalogResync{}).
+		Where("id = ? and status = ? and updated_at = ?", id, resync.Status, resync.UpdatedAt).
+		UpdateColumns(map[string]interface{}{
+			"status":      model.ResyncRunning,
+			"last_error":  "",
+			"finished_at": nil,
+			"updated_at":  time.Now().UTC(),
+		})
+	if res.Error != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(res.Error, "Resume resync"))
+	}
+	if res.RowsAffected == 0 {
+		return nil, NewServiceError(http.StatusConflict, "Resync is resumed by another runner")
+	}
+
+	return s.Get(id)
+}
+
+func (s *catalogResyncService) Cancel(id uuid.UUID) (*model.CatalogResync, error) {
+	resync, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if resync.Status != model.ResyncRunning {
+		return nil, NewServiceErrorf(http.StatusConflict, "Resync with status `%s` can't be cancelled", resync.Status.ToString())
+	}
+
+	if err := s.finish(resync, model.ResyncCancelled, ""); err != nil {
+		return nil, err
+	}
+
+	return s.Get(id)
+}
+
+func (s *catalogResyncService) Run(id uuid.UUID, stop <-chan struct{}) error {
+	resync, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if resync.Status != model.ResyncRunning {
+		return NewServiceErrorf(http.StatusConflict, "Resync with status `%s` can't be run", resync.Status.ToString())
+	}
+
+	// Resync is claimed by compare and set of its update time, so only one of runners which read it proceeds
+	claimed, err := s.saveProgress(resync)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return NewServiceError(http.StatusConflict, "Resync is run by another runner")
+	}
+
+	// Consumers are able to distinguish events of resync by correlation id. Bus isn't bound to transaction,
+	// so each event is numbered and saved in its own one.
+	bus := newCorrelatedEventBus(s.db, resync.ID)
+	ticker := time.NewTicker(time.Second / time.Duration(resync.Rate))
+	defer ticker.Stop()
+	heartbeat := time.Now()
+
+	for resync.Entity != "" {
+		ids, err := s.nextBatch(resync)
+		if err != nil {
+			return s.fail(resync, err)
+		}
+
+		if len(ids) == 0 {
+			resync.Entity = resync.NextEntity()
+			resync.Cursor = uuid.Nil
+		}
+
+		for _, entityId := range ids {
+			select {
+			case <-stop:
+				running, err := s.saveProgress(resync)
+				if err != nil || !running {
+					return err
+				}
+				return s.finish(resync, model.ResyncCancelled, "Resync is stopped")
+			case <-ticker.C:
+			}
+
+			if err := s.publish(bus, resync.Entity, entityId); err != nil {
+				return s.fail(resync, err)
+			}
+			resync.Cursor = entityId
+			resync.Published++
+
+			if time.Since(heartbeat) >= resyncHeartbeatInterval {
+				running, err := s.saveProgress(resync)
+				if err != nil {
+					return err
+				}
+				if !running {
+					zap.L().Info("Resync is cancelled", zap.String("resync", resync.ID.String()), zap.Int("published", resync.Published))
+					return nil
+				}
+				heartbeat = time.Now()
+			}
+		}
+
+		running, err := s.saveProgress(resync)
+		if err != nil {
+			return err
+		}
+		if !running {
+			zap.L().Info("Resync is cancelled", zap.String("resync", resync.ID.String()), zap.Int("published", resync.Published))
+			return nil
+		}
+		heartbeat = time.Now()
+	}
+
+	return s.finish(resync, model.ResyncCompleted, "")
+}
+
+// nextBatch returns ids of entities after cursor matching filter of resync. Games are taken from store catalog,
+// so only approved and published versions of games are resynced.
+func (s *catalogResyncService) nextBatch(resync *model.CatalogResync) ([]uuid.UUID, error) {
+	var query *gorm.DB
+	idColumn, updatedColumn := "id", "updated_at"
+	switch resync.Entity {
+	case model.ResyncGames:
+		query = s.db.Model(&model.PublishedGame{})
+		idColumn, updatedColumn = "game_id", "published_at"
+	case model.ResyncPackages:
+		query = s.db.Model(&model.Package{})
+	case model.ResyncBundles:
+		query = s.db.Model(&model.StoreBundle{})
+	default:
+		return nil, errors.Errorf("Unknown entity `%s`", resync.Entity)
+	}
+
+	query = query.Where(idColumn+" > ?", resync.Cursor)
+	if resync.VendorID != nil {
+		query = query.Where("vendor_id = ?", *resync.VendorID)
+	}
+	if resync.UpdatedSince != nil {
+		query = query.Where(updatedColumn+" >= ?", *resync.UpdatedSince)
+	}
+
+	ids := []uuid.UUID{}
+	if err := query.Order(idColumn).Limit(resyncBatchSize).Pluck(idColumn, &ids).Error; err != nil {
+		return nil, errors.Wrapf(err, "Fetch %s", resync.Entity)
+	}
+
+	return ids, nil
+}
+
+func (s *catalogResyncService) publish(bus *eventBus, entity string, id uuid.UUID) error {
+	switch entity {
+	case model.ResyncGames:
+		published := &model.PublishedGame{}
+		if err := s.db.Where("game_id = ?", id).First(published).Error; err != nil {
+			return errors.Wrap(err, "Fetch published game")
+		}
+		return bus.PublishStoreGame(&published.Game)
+	case model.ResyncPackages:
+		pkg := &model.Package{}
+		if err := s.db.Where("id = ?", id).First(pkg).Error; err != nil {
+			return errors.Wrap(err, "Fetch package")
+		}
+		products := []uuid.UUID{}
+		err := s.db.Model(&model.PackageProduct{}).Where("package_id = ?", id).Order("position").Pluck("product_id", &products).Error
+		if err != nil {
+			return errors.Wrap(err, "Fetch package products")
+		}
+		return bus.Publish(model.PackageSynced{PackageEventState: model.NewPackageEventState(pkg), Products: products})
+	case model.ResyncBundles:
+		bundle := &model.StoreBundle{}
+		if err := s.db.Where("id = ?", id).First(bundle).Error; err != nil {
+			return errors.Wrap(err, "Fetch bundle")
+		}
+		packages := []uuid.UUID{}
+		err := s.db.Model(&model.BundlePackage{}).Where("bundle_id = ?", id).Order("position").Pluck("package_id", &packages).Error
+		if err != nil {
+			return errors.Wrap(err, "Fetch bundle packages")
+		}
+		return bus.Publish(model.BundleSynced{BundleEventState: model.NewBundleEventState(bundle), Packages: packages})
+	}
+	return errors.Errorf("Unknown entity `%s`", entity)
+}
+
+// saveProgress stores cursor of resync and refreshes its update time. Progress is saved only if resync wasn't
+// changed since the last save of runner, it returns false if resync isn't running anymore (e.g. cancelled by admin)
+// or it was taken by another runner.
+func (s *catalogResyncService) saveProgress(resync *model.CatalogResync) (bool, error) {
+	// Time is truncated to precision of database, so it's equal to the stored one on the next save
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	res := s.db.
+		Model(&model.CatalogResync{}).
+		Where("id = ? and status = ? and updated_at = ?", resync.ID, model.ResyncRunning, resync.UpdatedAt).
+		UpdateColumns(map[string]interface{}{
+			"entity":     resync.Entity,
+			"cursor":     resync.Cursor,
+			"published":  resync.Published,
+			"updated_at": now,
+		})
+	if res.Error != nil {
+		return false, NewServiceError(http.StatusInternalServerError, errors.Wrap(res.Error, "Save resync progress"))
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+
+	resync.UpdatedAt = now
+	return true, nil
+}
+
+func (s *catalogResyncService) fail(resync *model.CatalogResync, err error) error {
+	zap.L().Error("Resync failed", zap.String("resync", resync.ID.String()), zap.String("entity", resync.Entity), zap.Error(err))
+	running, saveErr := s.saveProgress(resync)
+	if saveErr != nil {
+		return saveErr
+	}
+	if running {
+		if finishErr := s.finish(resync, model.ResyncFailed, err.Error()); finishErr != nil {
+			return finishErr
+		}
+	}
+	return NewServiceError(http.StatusInternalServerError, err)
+}
+
+func (s *catalogResyncService) finish(resync *model.CatalogResync, status model.ResyncStatus, lastError string) error {
+	now := time.Now().UTC()
+	resync.Status = status
+	resync.LastError = lastError
+	resync.FinishedAt = &now
+
+	err := s.db.
+		Model(&model.CatalogResync{}).
+		Where("id = ?", resync.ID).
+		UpdateColumns(map[string]interface{}{
+			"status":      status,
+			"last_error":  lastError,
+			"finished_at": now,
+			"updated_at":  now,
+		}).Error
+	if err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Finish resync"))
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package orm_test
+
+import (
+	"github.com/ProtocolONE/rbac"
+	"github.com/labstack/gommon/random"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"qilin-api/pkg/api/mock"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"qilin-api/pkg/test"
+	"testing"
+	"time"
+)
+
+type catalogResyncServiceTestSuite struct {
+	suite.Suite
+	db          *orm.Database
+	service     model.CatalogResyncService
+	outbox      model.OutboxService
+	gameService model.GameService
+	vendorId    uuid.UUID
+	otherVendor uuid.UUID
+	userId      string
+}
+
+func Test_CatalogResyncService(t *testing.T) {
+	suite.Run(t, new(catalogResyncServiceTestSuite))
+}
+
+func (suite *catalogResyncServiceTestSuite) SetupTest() {
+	config, err := qilin_test.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+
+	db, err := orm.NewDatabase(&config.Database)
+	if err != nil {
+		suite.Fail("Unable to connect to database:", "%v", err)
+	}
+
+	if err := db.DropAllTables(); err != nil {
+		assert.FailNow(suite.T(), "Unable to drop tables", err)
+	}
+	if err := db.Init(); err != nil {
+		assert.FailNow(suite.T(), "Unable to init tables", err)
+	}
+
+	suite.db = db
+
+	user := model.User{
+		ID:       random.String(8, "0123456789"),
+		Login:    "test@protocol.one",
+		Password: "megapass",
+		Nickname: "Test",
+		Lang:     "ru",
+	}
+	err = db.DB().Create(&user).Error
+	suite.Nil(err, "Unable to create user")
+	suite.userId = user.ID
+
+	ownProvider := orm.NewOwnerProvider(suite.db)
+	membershipService := orm.NewMembershipService(suite.db, ownProvider, rbac.NewEnforcer(), mock.NewMailer(), "")
+	vendorService, err := orm.NewVendorService(db, membershipService)
+	suite.Nil(err, "Unable make vendor service")
+	for i, domain := range []string{"domino", "another"} {
+		vendor := model.Vendor{
+			ID:              uuid.NewV4(),
+			Name:            domain,
+			Domain3:         domain,
+			Email:           domain + "@proto.com",
+			HowManyProducts: "+1000",
+			ManagerID:       suite.userId,
+		}
+		_, err = vendorService.Create(&vendor)
+		suite.Nil(err, "Must create new vendor")
+		if i == 0 {
+			suite.vendorId = vendor.ID
+		} else {
+			suite.otherVendor = vendor.ID
+		}
+	}
+
+	// GameC is draft of vendor which was never published
+	suite.gameService, _ = orm.NewGameService(db)
+	publications := orm.NewPublicationService(db)
+	for _, name := range []string{"GameA", "GameB", "GameC"} {
+		game, err := suite.gameService.Create(suite.userId, suite.vendorId, name)
+		suite.Nil(err, "Must create game")
+		if name != "GameC" {
+			_, err = publications.Publish(suite.userId, game.ID)
+			suite.Nil(err, "Must publish game")
+		}
+	}
+	game, err := suite.gameService.Create(suite.userId, suite.otherVendor, "OtherGame")
+	suite.Nil(err, "Must create game")
+	_, err = publications.Publish(suite.userId, game.ID)
+	suite.Nil(err, "Must publish game")
+
+	suite.service = orm.NewCatalogResyncService(db)
+	suite.outbox = orm.NewOutboxService(db)
+}
+
+func (suite *catalogResyncServiceTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+// resyncEvents returns events of topic saved by resync
+func (suite *catalogResyncServiceTestSuite) resyncEvents(resyncId uuid.UUID, topic string) []model.OutboxEvent {
+	events, _, err := suite.outbox.GetEvents(model.OutboxUndefined, topic, 0, 100)
+	require.Nil(suite.T(), err)
+
+	result := []model.OutboxEvent{}
+	for _, event := range events {
+		if event.CorrelationID == resyncId {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+func (suite *catalogResyncServiceTestSuite) TestRun() {
+	should := require.New(suite.T())
+
+	resync, err := suite.service.Create(suite.userId, model.ResyncFilter{
+		Entities: []string{model.ResyncGames, model.ResyncPackages},
+		VendorID: &suite.vendorId,
+		Rate:     model.MaxResyncRate,
+	})
+	should.Nil(err)
+	should.Nil(suite.service.Run(resync.ID, nil))
+
+	resync, err = suite.service.Get(resync.ID)
+	should.Nil(err)
+	should.Equal(model.ResyncCompleted, resync.Status)
+	should.Equal(5, resync.Published, "2 published games and 3 default packages must be published")
+	should.NotNil(resync.FinishedAt)
+
+	should.Len(suite.resyncEvents(resync.ID, "game_changed"), 2, "Games of other vendors and unpublished games must be skipped")
+	should.Len(suite.resyncEvents(resync.ID, model.PackageSyncedTopic), 3)
+
+	_, err = suite.service.Resume(resync.ID)
+	should.NotNil(err)
+	should.Equal(http.StatusConflict, err.(*orm.ServiceError).Code, "Completed resync must not be resumed")
+}
+
+func (suite *catalogResyncServiceTestSuite) TestUpdatedSince() {
+	should := require.New(suite.T())
+
+	since := time.Now().UTC().Add(time.Hour)
+	resync, err := suite.service.Create(suite.userId, model.ResyncFilter{UpdatedSince: &since, Rate: model.MaxResyncRate})
+	should.Nil(err)
+	should.Nil(suite.service.Run(resync.ID, nil))
+
+	resync, err = suite.service.Get(resync.ID)
+	should.Nil(err)
+	should.Equal(model.ResyncCompleted, resync.Status)
+	should.Equal(0, resync.Published)
+}
+
+func (suite *catalogResyncServiceTestSuite) TestStopAndResume() {
+	should := require.New(suite.T())
+
+	resync, err := suite.service.Create(suite.userId, model.ResyncFilter{Rate: model.MaxResyncRate})
+	should.Nil(err)
+
+	stop := make(chan struct{})
+	close(stop)
+	should.Nil(suite.service.Run(resync.ID, stop))
+
+	resync, err = suite.service.Get(resync.ID)
+	should.Nil(err)
+	should.Equal(model.ResyncCancelled, resync.Status)
+	should.Equal(0, resync.Published)
+
+	should.NotNil(suite.service.Run(resync.ID, nil), "Cancelled resync must be resumed before run")
+
+	resync, err = suite.service.Resume(resync.ID)
+	should.Nil(err)
+	should.Equal(model.ResyncRunning, resync.Status)
+
+	_, err = suite.service.Resume(resync.ID)
+	should.NotNil(err)
+	should.Equal(http.StatusConflict, err.(*orm.ServiceError).Code, "Running resync must not be resumed twice")
+
+	should.Nil(suite.service.Run(resync.ID, nil))
+	resync, err = suite.service.Get(resync.ID)
+	should.Nil(err)
+	should.Equal(model.ResyncCompleted, resync.Status)
+	should.Equal(3, resync.Published)
+	should.Len(suite.resyncEvents(resync.ID, "game_changed"), 3)
+}
+
+func (suite *catalogResyncServiceTestSuite) TestRunTakenOver() {
+	should := require.New(suite.T())
+
+	resync, err := suite.service.Create(suite.userId, model.ResyncFilter{Rate: 4})
+	should.Nil(err)
+
+	first := make(chan error, 1)
+	go func() {
+		first <- suite.service.Run(resync.ID, nil)
+	}()
+
+	// waits until the first runner claims resync
+	for i := 0; i < 100; i++ {
+		claimed, err := suite.service.Get(resync.ID)
+		should.Nil(err)
+		if !claimed.UpdatedAt.Equal(resync.UpdatedAt) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	should.Nil(suite.service.Run(resync.ID, nil))
+	should.Nil(<-first, "Runner must stop when resync is taken by another one")
+
+	resync, err = suite.service.Get(resync.ID)
+	should.Nil(err)
+	should.Equal(model.ResyncCompleted, resync.Status)
+	should.Equal(3, resync.Published)
+}
+
+func (suite *catalogResyncServiceTestSuite) TestCancel() {
+	should := require.New(suite.T())
+
+	resync, err := suite.service.Create(suite.userId, model.ResyncFilter{})
+	should.Nil(err)
+
+	resync, err = suite.service.Cancel(resync.ID)
+	should.Nil(err)
+	should.Equal(model.ResyncCancelled, resync.Status)
+
+	_, err = suite.service.Cancel(resync.ID)
+	should.NotNil(err)
+	should.Equal(http.StatusConflict, err.(*orm.ServiceError).Code)
+
+	_, err = suite.service.Create(suite.userId, model.ResyncFilter{Entities: []string{"dlc"}})
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
+	unknownVendor := uuid.NewV4()
+	_, err = suite.service.Create(suite.userId, model.ResyncFilter{VendorID: &unknownVendor})
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
+	list, count, err := suite.service.GetList(0, 10)
+	should.Nil(err)
+	should.Equal(1, count)
+	should.Len(list, 1)
+}
@@ -0,0 +1,40 @@
+package orm
+
+import "qilin-api/pkg/model"
+
+// defaultCurrencyRates is bundled table used to seed currency rates storage.
+// Rates are amount of currency for one USD, purchasing power is price level of region relative to USD region.
+var defaultCurrencyRates = []model.CurrencyRate{
+	{Currency: "USD", Rate: 1, PurchasingPower: 1, RoundingStep: 1, RoundingEnding: 0.99},
+	{Currency: "EUR", Rate: 0.89, PurchasingPower: 1, RoundingStep: 1, RoundingEnding: 0.99},
+	{Currency: "GBP", Rate: 0.79, PurchasingPower: 1, RoundingStep: 1, RoundingEnding: 0.99},
+	{Currency: "RUB", Rate: 64.5, PurchasingPower: 0.45, RoundingStep: 10, RoundingEnding: 9},
+	{Currency: "JPY", Rate: 108.5, PurchasingPower: 0.95, RoundingStep: 10, RoundingEnding: 0},
+	{Currency: "BGN", Rate: 1.74, PurchasingPower: 0.6, RoundingStep: 1, RoundingEnding: 0.99},
+	{Currency: "CZK", Rate: 22.9, PurchasingPower: 0.7, RoundingStep: 10, RoundingEnding: 9},
+	{Currency: "DKK", Rate: 6.66, PurchasingPower: 1, RoundingStep: 5, RoundingEnding: 0},
+	{Currency: "HUF", Rate: 291.5, PurchasingPower: 0.65, RoundingStep: 100, RoundingEnding: 90},
+	{Currency: "PLN", Rate: 3.81, PurchasingPower: 0.65, RoundingStep: 1, RoundingEnding: 0.99},
+	{Currency: "RON", Rate: 4.22, PurchasingPower: 0.6, RoundingStep: 1, RoundingEnding: 0.99},
+	{Currency: "SEK", Rate: 9.45, PurchasingPower: 1, RoundingStep: 5, RoundingEnding: 0},
+	{Currency: "CHF", Rate: 0.99, PurchasingPower: 1, RoundingStep: 1, RoundingEnding: 0.9},
+	{Currency: "NOK", Rate: 8.62, PurchasingPower: 1, RoundingStep: 5, RoundingEnding: 0},
+	{Currency: "HRK", Rate: 6.6, PurchasingPower: 0.65, RoundingStep: 1, RoundingEnding: 0.99},
+	{Currency: "TRY", Rate: 5.75, PurchasingPower: 0.4, RoundingStep: 1, RoundingEnding: 0.99},
+	{Currency: "AUD", Rate: 1.44, PurchasingPower: 1, RoundingStep: 1, RoundingEnding: 0.95},
+	{Currency: "BRL", Rate: 3.85, PurchasingPower: 0.55, RoundingStep: 1, RoundingEnding: 0.99},
+	{Currency: "CAD", Rate: 1.33, PurchasingPower: 0.95, RoundingStep: 1, RoundingEnding: 0.99},
+	{Currency: "CNY", Rate: 6.88, PurchasingPower: 0.55, RoundingStep: 1, RoundingEnding: 0},
+	{Currency: "HKD", Rate: 7.83, PurchasingPower: 0.8, RoundingStep: 1, RoundingEnding: 0},
+	{Currency: "IDR", Rate: 14100, PurchasingPower: 0.4, RoundingStep: 1000, RoundingEnding: 0},
+	{Currency: "ILS", Rate: 3.55, PurchasingPower: 0.9, RoundingStep: 1, RoundingEnding: 0.9},
+	{Currency: "INR", Rate: 69.5, PurchasingPower: 0.35, RoundingStep: 10, RoundingEnding: 9},
+	{Currency: "KRW", Rate: 1175, PurchasingPower: 0.8, RoundingStep: 100, RoundingEnding: 0},
+	{Currency: "MXN", Rate: 19.1, PurchasingPower: 0.5, RoundingStep: 1, RoundingEnding: 0.99},
+	{Currency: "MYR", Rate: 4.15, PurchasingPower: 0.45, RoundingStep: 1, RoundingEnding: 0},
+	{Currency: "NZD", Rate: 1.5, PurchasingPower: 1, RoundingStep: 1, RoundingEnding: 0.95},
+	{Currency: "PHP", Rate: 51.5, PurchasingPower: 0.45, RoundingStep: 5, RoundingEnding: 0},
+	{Currency: "SGD", Rate: 1.36, PurchasingPower: 0.85, RoundingStep: 1, RoundingEnding: 0.95},
+	{Currency: "THB", Rate: 31, PurchasingPower: 0.45, RoundingStep: 1, RoundingEnding: 0},
+	{Currency: "ZAR", Rate: 14.2, PurchasingPower: 0.5, RoundingStep: 1, RoundingEnding: 0.99},
+}
@@ -29,7 +29,11 @@ func NewDatabase(config *conf.Database) (*Database, error) {
 // Unable to migrate with message: gen_random_uuid() does not exist?
 // Execute query: CREATE EXTENSION pgcrypto;
 func (db *Database) Init() error {
-	return db.database.AutoMigrate(
+	if err := dedupeKeys(db.database); err != nil {
+		return err
+	}
+
+	err := db.database.AutoMigrate(
 		&model.User{},
 		&model.Vendor{},
 		&model.Game{},
@@ -51,12 +55,40 @@ func (db *Database) Init() error {
 		&model.BundlePackage{},
 		&model.BundleEntry{},
 		&model.StoreBundle{},
+		&model.LootboxBundle{},
+		&model.LootboxItem{},
 		&model.Dlc{},
 		&model.Achievement{},
 		&model.KeyPackage{},
 		&model.Key{},
+		&model.KeyRevocation{},
+		&model.KeyBatch{},
+		&model.License{},
 		&model.KeyStream{},
+		&model.CurrencyRate{},
+		&model.PreOrder{},
+		&model.PriceChange{},
+		&model.TaxRate{},
+		&model.PublishedGame{},
+		&model.GameSnapshot{},
+		&model.GameReview{},
+		&model.OutboxEvent{},
+		&model.CatalogResync{},
+		&model.VendorRole{},
 	).Error
+	if err != nil {
+		return err
+	}
+
+	if err := addPendingReviewIndex(db.database); err != nil {
+		return err
+	}
+
+	if err := seedCurrencyRates(db.database); err != nil {
+		return err
+	}
+
+	return seedTaxRates(db.database)
 }
 
 //DropAllTables is method for clearing DB. WARNING: Use it only for testing purposes
@@ -83,11 +115,26 @@ func (db *Database) DropAllTables() error {
 			model.BundlePackage{},
 			model.BundleEntry{},
 			model.StoreBundle{},
+			model.LootboxBundle{},
+			model.LootboxItem{},
 			model.Dlc{},
 			model.Achievement{},
 			model.KeyPackage{},
 			model.Key{},
+			model.KeyRevocation{},
+			model.KeyBatch{},
+			model.License{},
 			model.KeyStream{},
+			model.CurrencyRate{},
+			model.PreOrder{},
+			model.PriceChange{},
+			model.TaxRate{},
+			model.PublishedGame{},
+			model.GameSnapshot{},
+			model.GameReview{},
+			model.OutboxEvent{},
+			model.CatalogResync{},
+			model.VendorRole{},
 		).Error
 	}
 	return nil
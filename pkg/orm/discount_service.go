@@ -64,9 +64,24 @@ func (s *DiscountService) AddDiscountForGame(id uuid.UUID, discount *model.Disco
 	discount.CreatedAt = time.Now()
 	discount.UpdatedAt = discount.CreatedAt
 
-	err := s.db.Create(discount).Error
+	err := inTransaction(s.db, func(tx *gorm.DB) error {
+		if err := lockGame(tx, id); err != nil {
+			return err
+		}
+		if err := checkDiscountWindow(tx, discount); err != nil {
+			return err
+		}
+		if err := tx.Create(discount).Error; err != nil {
+			return errors.Wrap(err, "Insert discount")
+		}
+		err := NewEventBus(tx).Publish(model.DiscountCreated{DiscountEventState: model.NewDiscountEventState(discount)})
+		if err != nil {
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish discount creation"))
+		}
+		return nil
+	})
 	if err != nil {
-		return uuid.Nil, errors.Wrap(err, "Insert discount")
+		return uuid.Nil, err
 	}
 
 	return discount.ID, nil
@@ -87,24 +102,68 @@ func (s *DiscountService) UpdateDiscountForGame(discount *model.Discount) error
 		return NewServiceError(http.StatusUnprocessableEntity, "Rate should be more than 0")
 	}
 
-	err = s.db.Save(discount).Error
-	if err != nil {
-		return errors.Wrap(err, "Update discount")
-	}
-
-	return nil
+	return inTransaction(s.db, func(tx *gorm.DB) error {
+		// game row is locked to serialize discount changes of the game, so concurrent windows can't overlap
+		if err := lockGame(tx, discount.GameID); err != nil {
+			return err
+		}
+		if err := checkDiscountWindow(tx, discount); err != nil {
+			return err
+		}
+		if err := tx.Save(discount).Error; err != nil {
+			return errors.Wrap(err, "Update discount")
+		}
+		err := NewEventBus(tx).Publish(model.DiscountUpdated{DiscountEventState: model.NewDiscountEventState(discount)})
+		if err != nil {
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish discount update"))
+		}
+		return nil
+	})
 }
 
 //RemoveDiscountForGame is method for removing discount for game
 func (s *DiscountService) RemoveDiscountForGame(id uuid.UUID) error {
 	discountInDb := model.Discount{}
-	discountInDb.ID = id
-
-	res := s.db.Delete(&discountInDb)
-	if res.Error == gorm.ErrRecordNotFound || res.RowsAffected == 0 {
+	err := s.db.Where("id = ?", id).First(&discountInDb).Error
+	if err == gorm.ErrRecordNotFound {
 		return NewServiceError(http.StatusNotFound, "Discount not found")
-	} else if res.Error != nil {
-		return errors.Wrap(res.Error, "search game by id")
+	} else if err != nil {
+		return errors.Wrap(err, "search discount by id")
+	}
+
+	transaction := s.db.Begin()
+	if err := transaction.Delete(&discountInDb).Error; err != nil {
+		transaction.Rollback()
+		return errors.Wrap(err, "Delete discount")
+	}
+
+	err = NewEventBus(transaction).Publish(model.DiscountRemoved{DiscountID: discountInDb.ID, GameID: discountInDb.GameID})
+	if err != nil {
+		transaction.Rollback()
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish discount removal"))
+	}
+
+	return transaction.Commit().Error
+}
+
+// checkDiscountWindow validates dates of discount, windows of discounts for the same game must not overlap.
+// It must be called in transaction holding the lock of the game row.
+func checkDiscountWindow(tx *gorm.DB, discount *model.Discount) error {
+	if !discount.DateStart.Before(discount.DateEnd) {
+		return NewServiceError(http.StatusUnprocessableEntity, "End date should be after start date")
+	}
+
+	count := 0
+	err := tx.
+		Model(&model.Discount{}).
+		Where("game_id = ? AND id <> ? AND date_start < ? AND date_end > ?", discount.GameID, discount.ID, discount.DateEnd, discount.DateStart).
+		Count(&count).Error
+	if err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "search overlapping discounts"))
+	}
+
+	if count > 0 {
+		return NewServiceError(http.StatusConflict, "Discount overlaps with another discount of the game")
 	}
 
 	return nil
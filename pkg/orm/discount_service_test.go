@@ -317,3 +317,62 @@ func (suite *DiscountServiceTestSuite) TestRemoveDiscountShouldDeleteIntoDB() {
 	assert.Nil(suite.T(), err, "Unable to get discount for game")
 	assert.Equal(suite.T(), 0, count, "Count not equal")
 }
+
+func (suite *DiscountServiceTestSuite) TestOverlappingDiscountShouldBeRejected() {
+	should := require.New(suite.T())
+	id, _ := uuid.FromString(GameID)
+
+	start, _ := time.Parse(time.RFC3339, "2019-01-22T07:53:16Z")
+	end, _ := time.Parse(time.RFC3339, "2019-02-22T07:53:16Z")
+
+	discount := model.Discount{Rate: 33, Title: model.JSONB{"en": "WINTER SALE"}, DateStart: start, DateEnd: end}
+	firstId, err := suite.service.AddDiscountForGame(id, &discount)
+	should.Nil(err)
+
+	overlapping := model.Discount{Rate: 10, Title: model.JSONB{"en": "FLASH SALE"}, DateStart: start.AddDate(0, 0, 10), DateEnd: end.AddDate(0, 0, 10)}
+	_, err = suite.service.AddDiscountForGame(id, &overlapping)
+	should.NotNil(err)
+	should.Equal(http.StatusConflict, err.(*orm.ServiceError).Code)
+
+	next := model.Discount{Rate: 10, Title: model.JSONB{"en": "SPRING SALE"}, DateStart: end, DateEnd: end.AddDate(0, 1, 0)}
+	nextId, err := suite.service.AddDiscountForGame(id, &next)
+	should.Nil(err)
+
+	next.ID = nextId
+	next.DateStart = start
+	err = suite.service.UpdateDiscountForGame(&next)
+	should.NotNil(err)
+	should.Equal(http.StatusConflict, err.(*orm.ServiceError).Code)
+
+	discount.ID = firstId
+	discount.DateEnd = start
+	err = suite.service.UpdateDiscountForGame(&discount)
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+}
+
+func (suite *DiscountServiceTestSuite) TestConcurrentOverlappingDiscountsShouldBeRejected() {
+	should := require.New(suite.T())
+	id, _ := uuid.FromString(GameID)
+
+	start, _ := time.Parse(time.RFC3339, "2019-01-22T07:53:16Z")
+	end, _ := time.Parse(time.RFC3339, "2019-02-22T07:53:16Z")
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			discount := model.Discount{Rate: 33, Title: model.JSONB{"en": "WINTER SALE"}, DateStart: start, DateEnd: end}
+			_, err := suite.service.AddDiscountForGame(id, &discount)
+			errs <- err
+		}()
+	}
+
+	failed := 0
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			should.Equal(http.StatusConflict, err.(*orm.ServiceError).Code)
+			failed++
+		}
+	}
+	should.Equal(1, failed, "Only one of overlapping discounts must be created")
+}
@@ -0,0 +1,167 @@
+package orm
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/model"
+	"strings"
+)
+
+type dlcService struct {
+	db *gorm.DB
+}
+
+func NewDlcService(db *Database) model.DlcService {
+	return &dlcService{db: db.DB()}
+}
+
+func (service *dlcService) Create(userId string, gameId uuid.UUID, dlc *model.Dlc) (*model.Dlc, error) {
+	game := model.Game{}
+	err := service.db.Select("id, vendor_id").Where("id = ?", gameId).First(&game).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, NewServiceError(http.StatusNotFound, "Game not found")
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch game"))
+	}
+
+	if err := validateDlc(dlc); err != nil {
+		return nil, err
+	}
+
+	dlc.ID = uuid.NewV4()
+	dlc.GameID = gameId
+	dlc.VendorID = game.VendorID
+	dlc.CreatorID = userId
+	dlc.DefaultPackageID = uuid.NewV4()
+	dlc.Product.EntryID = dlc.ID
+
+	transaction := service.db.Begin()
+	if err := transaction.Create(dlc).Error; err != nil {
+		transaction.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Create dlc"))
+	}
+
+	err = createPackage(transaction, dlc.DefaultPackageID, dlc.VendorID, dlc.ID, userId, dlc.Name.EN, []uuid.UUID{dlc.ID})
+	if err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Commit for making dlc"))
+	}
+
+	return dlc, nil
+}
+
+func (service *dlcService) GetList(gameId uuid.UUID) ([]model.Dlc, error) {
+	if err := checkGameExist(service.db, gameId); err != nil {
+		return nil, err
+	}
+
+	dlcs := []model.Dlc{}
+	if err := service.db.Where("game_id = ?", gameId).Order("created_at").Find(&dlcs).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch dlc list"))
+	}
+
+	return dlcs, nil
+}
+
+func (service *dlcService) Get(gameId, dlcId uuid.UUID) (*model.Dlc, error) {
+	dlc := &model.Dlc{}
+	err := service.db.Where("id = ? AND game_id = ?", dlcId, gameId).First(dlc).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, NewServiceErrorf(http.StatusNotFound, "Dlc `%s` not found", dlcId)
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch dlc"))
+	}
+
+	return dlc, nil
+}
+
+func (service *dlcService) Update(gameId uuid.UUID, dlc *model.Dlc) (*model.Dlc, error) {
+	current, err := service.Get(gameId, dlc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateDlc(dlc); err != nil {
+		return nil, err
+	}
+
+	current.Name = dlc.Name
+	current.Image = dlc.Image
+	current.ImageCover = dlc.ImageCover
+	current.Screenshots = dlc.Screenshots
+	err = service.db.
+		Set("gorm:association_autoupdate", false).
+		Set("gorm:association_autocreate", false).
+		Save(current).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Update dlc"))
+	}
+
+	return current, nil
+}
+
+// Delete removes DLC with its default package, DLC is also excluded from other packages
+func (service *dlcService) Delete(gameId, dlcId uuid.UUID) error {
+	dlc, err := service.Get(gameId, dlcId)
+	if err != nil {
+		return err
+	}
+
+	transaction := service.db.Begin()
+	if err := transaction.Delete(dlc).Error; err != nil {
+		transaction.Rollback()
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Delete dlc"))
+	}
+
+	if err := transaction.Delete(model.PackageProduct{}, "product_id = ?", dlc.ID).Error; err != nil {
+		transaction.Rollback()
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Delete dlc from packages"))
+	}
+
+	if err := transaction.Delete(model.ProductEntry{}, "entry_id = ?", dlc.ID).Error; err != nil {
+		transaction.Rollback()
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Delete dlc product"))
+	}
+
+	if err := transaction.Delete(model.Package{}, "id = ?", dlc.DefaultPackageID).Error; err != nil {
+		transaction.Rollback()
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Delete dlc package"))
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return nil
+}
+
+func (service *dlcService) GetProduct(dlcId uuid.UUID) (model.Product, error) {
+	return getDlcProduct(service.db, dlcId)
+}
+
+func getDlcProduct(db *gorm.DB, dlcId uuid.UUID) (model.Product, error) {
+	dlc := &model.Dlc{}
+	err := db.Where("id = ?", dlcId).First(dlc).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, NewServiceError(http.StatusNotFound, "Dlc not found")
+	} else if err != nil {
+		return nil, errors.Wrap(err, "Fetch dlc")
+	}
+
+	return dlc, nil
+}
+
+func validateDlc(dlc *model.Dlc) error {
+	if strings.TrimSpace(dlc.Name.EN) == "" {
+		return NewServiceError(http.StatusUnprocessableEntity, "English name of dlc is required")
+	}
+	return nil
+}
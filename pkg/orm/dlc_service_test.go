@@ -0,0 +1,170 @@
+package orm_test
+
+import (
+	"github.com/ProtocolONE/rbac"
+	"github.com/labstack/gommon/random"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"qilin-api/pkg/api/mock"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/model/utils"
+	"qilin-api/pkg/orm"
+	"qilin-api/pkg/test"
+	"testing"
+)
+
+type dlcServiceTestSuite struct {
+	suite.Suite
+	db             *orm.Database
+	service        model.DlcService
+	packageService model.PackageService
+	game           *model.Game
+	userId         string
+}
+
+func Test_DlcService(t *testing.T) {
+	suite.Run(t, new(dlcServiceTestSuite))
+}
+
+func (suite *dlcServiceTestSuite) SetupTest() {
+	should := require.New(suite.T())
+
+	config, err := qilin_test.LoadTestConfig()
+	should.Nil(err, "Unable to load config")
+
+	db, err := orm.NewDatabase(&config.Database)
+	should.Nil(err, "Unable to connect to database")
+
+	should.Nil(db.DropAllTables(), "Unable to drop tables")
+	should.Nil(db.Init(), "Unable to init tables")
+	suite.db = db
+
+	user := model.User{
+		ID:       random.String(8, "0123456789"),
+		Login:    "test@protocol.one",
+		Password: "megapass",
+		Nickname: "Test",
+		Lang:     "ru",
+	}
+	should.Nil(db.DB().Create(&user).Error)
+	suite.userId = user.ID
+
+	ownProvider := orm.NewOwnerProvider(db)
+	membershipService := orm.NewMembershipService(db, ownProvider, rbac.NewEnforcer(), mock.NewMailer(), "")
+	vendorService, err := orm.NewVendorService(db, membershipService)
+	should.Nil(err)
+	vendor := model.Vendor{
+		ID:              uuid.NewV4(),
+		Name:            "domino",
+		Domain3:         "domino",
+		Email:           "domino@proto.com",
+		HowManyProducts: "+1000",
+		ManagerID:       user.ID,
+	}
+	_, err = vendorService.Create(&vendor)
+	should.Nil(err)
+
+	gameService, err := orm.NewGameService(db)
+	should.Nil(err)
+	suite.game, err = gameService.Create(user.ID, vendor.ID, "GameWithDlc")
+	should.Nil(err)
+
+	suite.service = orm.NewDlcService(db)
+	suite.packageService, err = orm.NewPackageService(db, gameService)
+	should.Nil(err)
+}
+
+func (suite *dlcServiceTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *dlcServiceTestSuite) TestDlc() {
+	should := require.New(suite.T())
+
+	dlc, err := suite.service.Create(suite.userId, suite.game.ID, &model.Dlc{
+		Name:  utils.LocalizedString{EN: "Season pass", RU: "Сезонный абонемент"},
+		Image: utils.LocalizedString{EN: "http://example.com/image.png"},
+	})
+	should.Nil(err)
+	should.Equal(suite.game.ID, dlc.GameID)
+	should.Equal(suite.game.VendorID, dlc.VendorID)
+	should.NotEqual(uuid.Nil, dlc.DefaultPackageID)
+
+	// Default package contains dlc
+	pkg, err := suite.packageService.Get(dlc.DefaultPackageID)
+	should.Nil(err)
+	should.Equal(dlc.ID, pkg.DefaultProductID)
+	should.Equal("Season pass", pkg.Name.EN)
+	should.Len(pkg.Products, 1)
+	should.Equal(dlc.ID, pkg.Products[0].GetID())
+	should.Equal(model.ProductDLC, pkg.Products[0].GetType())
+	should.Equal("http://example.com/image.png", pkg.Products[0].GetImage().EN)
+
+	// Dlc can be added to another package
+	pkg, err = suite.packageService.AddProducts(suite.game.DefaultPackageID, []uuid.UUID{dlc.ID})
+	should.Nil(err)
+	should.Len(pkg.Products, 2)
+	should.Equal(model.ProductGame, pkg.Products[0].GetType())
+	should.Equal(dlc.ID, pkg.Products[1].GetID())
+
+	list, err := suite.service.GetList(suite.game.ID)
+	should.Nil(err)
+	should.Len(list, 1)
+	should.Equal("Сезонный абонемент", list[0].Name.RU)
+
+	updated, err := suite.service.Update(suite.game.ID, &model.Dlc{
+		Model:       model.Model{ID: dlc.ID},
+		Name:        utils.LocalizedString{EN: "Expansion"},
+		Screenshots: utils.LocalizedStringArray{EN: []string{"http://example.com/1.png"}},
+	})
+	should.Nil(err)
+	should.Equal(dlc.DefaultPackageID, updated.DefaultPackageID)
+
+	saved, err := suite.service.Get(suite.game.ID, dlc.ID)
+	should.Nil(err)
+	should.Equal("Expansion", saved.Name.EN)
+	should.Equal([]string{"http://example.com/1.png"}, saved.Screenshots.EN)
+
+	should.Nil(suite.service.Delete(suite.game.ID, dlc.ID))
+
+	_, err = suite.service.Get(suite.game.ID, dlc.ID)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	_, err = suite.packageService.Get(dlc.DefaultPackageID)
+	should.NotNil(err)
+
+	pkg, err = suite.packageService.Get(suite.game.DefaultPackageID)
+	should.Nil(err)
+	should.Len(pkg.Products, 1)
+}
+
+func (suite *dlcServiceTestSuite) TestErrors() {
+	should := require.New(suite.T())
+
+	_, err := suite.service.Create(suite.userId, uuid.NewV4(), &model.Dlc{Name: utils.LocalizedString{EN: "Dlc"}})
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	_, err = suite.service.Create(suite.userId, suite.game.ID, &model.Dlc{})
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
+	_, err = suite.service.GetList(uuid.NewV4())
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	dlc, err := suite.service.Create(suite.userId, suite.game.ID, &model.Dlc{Name: utils.LocalizedString{EN: "Dlc"}})
+	should.Nil(err)
+
+	err = suite.service.Delete(uuid.NewV4(), dlc.ID)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+}
@@ -1,11 +1,13 @@
 package orm
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/ProtocolONE/qilin-common/pkg/proto"
-	"github.com/ProtocolONE/rabbitmq/pkg"
+	gogo_proto "github.com/gogo/protobuf/proto"
 	"github.com/jinzhu/gorm"
 	"github.com/lib/pq"
+	"github.com/pkg/errors"
 	"github.com/satori/go.uuid"
 	"go.uber.org/zap"
 	"qilin-api/pkg/mapper"
@@ -16,17 +18,36 @@ import (
 )
 
 type eventBus struct {
-	broker *rabbitmq.Broker
-	db     *gorm.DB
+	db            *gorm.DB
+	correlationId uuid.UUID
 }
 
-func NewEventBus(db *gorm.DB, host string) (model.EventBus, error) {
-	broker, err := rabbitmq.NewBroker(host)
-	if err != nil {
-		return nil, err
-	}
+// NewEventBus creates event bus writing events into outbox with db, which may be a transaction. All events
+// of bus share correlation id, so bus should be created for each domain change.
+func NewEventBus(db *gorm.DB) model.EventBus {
+	return &eventBus{db: db, correlationId: uuid.NewV4()}
+}
+
+// newCorrelatedEventBus creates event bus for long operation, e.g. resync of catalog, all events of operation
+// are saved with its id as correlation id
+func newCorrelatedEventBus(db *gorm.DB, correlationId uuid.UUID) *eventBus {
+	return &eventBus{db: db, correlationId: correlationId}
+}
 
-	return &eventBus{db: db, broker: broker}, nil
+func (bus *eventBus) WithTransaction(tx *gorm.DB) model.EventBus {
+	return NewEventBus(tx)
+}
+
+// Publish saves typed event as JSON envelope
+func (bus *eventBus) Publish(payload model.EventPayload) error {
+	return bus.save(payload.EventTopic(), payload.EventAggregateID(), payload.EventSchemaVersion(), func(event *model.OutboxEvent) (err error) {
+		envelope := model.NewEvent(event.ID, event.Sequence, event.CorrelationID, event.CreatedAt, payload)
+		event.Encoding = model.OutboxJSONEncoding
+		if event.Payload, err = json.Marshal(envelope); err != nil {
+			return errors.Wrapf(err, "Serialize `%s` event", event.Topic)
+		}
+		return nil
+	})
 }
 
 func (bus *eventBus) PublishGameChanges(gameId uuid.UUID) error {
@@ -74,7 +95,57 @@ func (bus *eventBus) PublishGameChanges(gameId uuid.UUID) error {
 	}
 
 	gameObject := MapGameObject(&game, &media, tags, genres, ratings, description)
-	return bus.broker.Publish("game_changed", gameObject, nil)
+	return bus.enqueueMessage("game_changed", gameId, gameObject)
+}
+
+// enqueueMessage saves protobuf message of legacy event, metadata of event is sent in headers only
+func (bus *eventBus) enqueueMessage(topic string, aggregateId uuid.UUID, msg gogo_proto.Message) error {
+	return bus.save(topic, aggregateId, 1, func(event *model.OutboxEvent) (err error) {
+		event.Encoding = model.OutboxProtobufEncoding
+		if event.Payload, err = gogo_proto.Marshal(msg); err != nil {
+			return errors.Wrapf(err, "Serialize `%s` event", topic)
+		}
+		return nil
+	})
+}
+
+// save numbers event within aggregate, fills its payload with encode and writes it to outbox. Numbering is done
+// under advisory lock of aggregate, which is held until the end of transaction, so concurrent changes of aggregate
+// get successive numbers. Event is saved in transaction of bus, bus without transaction opens its own one,
+// otherwise the lock would be released right after it's taken.
+func (bus *eventBus) save(topic string, aggregateId uuid.UUID, schemaVersion int, encode func(event *model.OutboxEvent) error) error {
+	return inTransaction(bus.db, func(tx *gorm.DB) error {
+		if err := tx.Exec("select pg_advisory_xact_lock(hashtext(?))", aggregateId.String()).Error; err != nil {
+			return errors.Wrap(err, "Lock aggregate of event")
+		}
+
+		var sequence int64
+		row := tx.Model(&model.OutboxEvent{}).Where("aggregate_id = ?", aggregateId).Select("coalesce(max(sequence), 0)").Row()
+		if err := row.Scan(&sequence); err != nil {
+			return errors.Wrap(err, "Fetch sequence of event")
+		}
+
+		now := time.Now().UTC()
+		event := &model.OutboxEvent{
+			ID:            uuid.NewV4(),
+			CreatedAt:     now,
+			Topic:         topic,
+			AggregateID:   aggregateId,
+			Sequence:      sequence + 1,
+			CorrelationID: bus.correlationId,
+			SchemaVersion: schemaVersion,
+			Status:        model.OutboxPending,
+			NextAttemptAt: now,
+		}
+		if err := encode(event); err != nil {
+			return err
+		}
+
+		if err := tx.Create(event).Error; err != nil {
+			return errors.Wrapf(err, "Save `%s` event", topic)
+		}
+		return nil
+	})
 }
 
 func toPgArray(array pq.Int64Array) []int64 {
@@ -85,9 +156,54 @@ func toPgArray(array pq.Int64Array) []int64 {
 	return s
 }
 
+// PublishAchievementsChanges sends all achievements of game, achievements are hidden from production
+// until `AchievementOnProd` flag of game is set
+// PublishStoreGame publishes changes of game from its published document, so unpublished drafts of vendor aren't exposed
+func (bus *eventBus) PublishStoreGame(doc *model.StoreGame) error {
+	return bus.enqueueMessage("game_changed", doc.ID, MapStoreGameObject(doc))
+}
+
+func (bus *eventBus) PublishAchievementsChanges(gameId uuid.UUID) error {
+	game := model.Game{}
+	if err := bus.db.Model(model.Game{}).Where("id = ?", gameId).First(&game).Error; err != nil {
+		return err
+	}
+
+	var achievements []model.Achievement
+	if game.AchievementOnProd {
+		err := bus.db.Model(model.Achievement{}).Where("game_id = ?", gameId).Order("\"order\", created_at").Find(&achievements).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return bus.enqueueMessage("achievements_changed", gameId, MapAchievementsObject(&game, achievements))
+}
+
+// PublishPackageReleased sends release of package with games contained in it
+func (bus *eventBus) PublishPackageReleased(packageId uuid.UUID, releaseDate time.Time) error {
+	var products []model.PackageProduct
+	err := bus.db.
+		Model(model.PackageProduct{}).
+		Joins("inner join games on games.id = package_products.product_id").
+		Where("package_products.package_id = ?", packageId).
+		Order("position").
+		Find(&products).Error
+	if err != nil {
+		return err
+	}
+
+	message := &PackageReleasedObject{PackageID: packageId.String(), ReleaseDate: releaseDate.Format(time.RFC3339)}
+	for _, product := range products {
+		message.GameIDs = append(message.GameIDs, product.ProductID.String())
+	}
+
+	return bus.enqueueMessage("package_released", packageId, message)
+}
+
 func (bus *eventBus) PublishGameDelete(gameId uuid.UUID) error {
 	gameObject := &proto.GameDeleted{ID: gameId.String()}
-	return bus.broker.Publish("game_deleted", gameObject, nil)
+	return bus.enqueueMessage("game_deleted", gameId, gameObject)
 }
 
 func MapGameObject(game *model.Game, media *model.Media, tags []model.GameTag, genre []model.GameGenre, ratings model.GameRating, descr model.GameDescr) *proto.GameObject {
@@ -112,8 +228,61 @@ func MapGameObject(game *model.Game, media *model.Media, tags []model.GameTag, g
 		GameSite:             descr.GameSite,
 		Reviews:              MapReviews(descr.Reviews),
 		Tagline:              MapLocalizedString(descr.Tagline),
-		Publisher:			  &proto.LinkObject{ID: "", Title: game.Publishers},
+		Publisher:            &proto.LinkObject{ID: "", Title: game.Publishers},
+	}
+}
+
+// MapStoreGameObject maps published document of game to the same message as changes of game
+func MapStoreGameObject(doc *model.StoreGame) *proto.GameObject {
+	game := &model.Game{
+		ID:             doc.ID,
+		Title:          doc.Title,
+		Developers:     doc.Developers,
+		Publishers:     doc.Publishers,
+		ReleaseDate:    doc.ReleaseDate,
+		FeaturesCommon: doc.FeaturesCommon,
+		FeaturesCtrl:   doc.FeaturesCtrl,
+		Platforms:      doc.Platforms,
+		Requirements:   doc.Requirements,
+		Languages:      doc.Languages,
+		GenreMain:      doc.GenreMain,
 	}
+
+	genres := []model.GameGenre{}
+	for _, genre := range doc.Genres {
+		genres = append(genres, model.GameGenre{GameTag: genre})
+		if genre.ID != doc.GenreMain {
+			game.GenreAddition = append(game.GenreAddition, genre.ID)
+		}
+	}
+
+	media := &model.Media{
+		CoverImage:  doc.Media.CoverImage,
+		CoverVideo:  doc.Media.CoverVideo,
+		Trailers:    doc.Media.Trailers,
+		Screenshots: doc.Media.Screenshots,
+	}
+	ratings := model.GameRating{PEGI: doc.Ratings.PEGI, ESRB: doc.Ratings.ESRB, BBFC: doc.Ratings.BBFC, USK: doc.Ratings.USK, CERO: doc.Ratings.CERO}
+	descr := model.GameDescr{Tagline: doc.Tagline, Description: doc.Description, Reviews: doc.Reviews, GameSite: doc.GameSite}
+
+	return MapGameObject(game, media, doc.Tags, genres, ratings, descr)
+}
+
+func MapAchievementsObject(game *model.Game, achievements []model.Achievement) *AchievementsObject {
+	result := &AchievementsObject{GameID: game.ID.String(), Enabled: game.AchievementOnProd}
+	for _, achievement := range achievements {
+		result.Achievements = append(result.Achievements, &AchievementObject{
+			ID:           achievement.ID.String(),
+			Name:         MapLocalizedString(achievement.Name),
+			Description:  MapLocalizedString(achievement.Description),
+			LockedIcon:   achievement.LockedIcon,
+			UnlockedIcon: achievement.UnlockedIcon,
+			Hidden:       achievement.Hidden,
+			Order:        int32(achievement.Order),
+			Points:       int32(achievement.Points),
+		})
+	}
+	return result
 }
 
 func MapReviews(reviews game.GameReviews) []*proto.Review {
@@ -148,7 +317,7 @@ func MapMedia(media *model.Media) *proto.Media {
 	}
 
 	return &proto.Media{
-		CoverImage: MapLocalizedString(media.CoverImage),
+		CoverImage:  MapLocalizedString(media.CoverImage),
 		CoverVideo:  MapLocalizedString(media.CoverVideo),
 		Trailers:    MapLocalizedStringArray(media.Trailers),
 		Screenshots: MapLocalizedStringArray(media.Screenshots),
@@ -0,0 +1,198 @@
+package orm_test
+
+import (
+	"encoding/json"
+	"github.com/ProtocolONE/rbac"
+	"github.com/labstack/gommon/random"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"qilin-api/pkg/api/mock"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"qilin-api/pkg/test"
+	"testing"
+	"time"
+)
+
+type eventBusTestSuite struct {
+	suite.Suite
+	db          *orm.Database
+	outbox      model.OutboxService
+	service     model.PackageService
+	gameService model.GameService
+	vendorId    uuid.UUID
+	userId      string
+}
+
+func Test_EventBus(t *testing.T) {
+	suite.Run(t, new(eventBusTestSuite))
+}
+
+func (suite *eventBusTestSuite) SetupTest() {
+	config, err := qilin_test.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+
+	db, err := orm.NewDatabase(&config.Database)
+	if err != nil {
+		suite.Fail("Unable to connect to database:", "%v", err)
+	}
+
+	if err := db.DropAllTables(); err != nil {
+		assert.FailNow(suite.T(), "Unable to drop tables", err)
+	}
+	if err := db.Init(); err != nil {
+		assert.FailNow(suite.T(), "Unable to init tables", err)
+	}
+
+	suite.db = db
+
+	user := model.User{
+		ID:       random.String(8, "0123456789"),
+		Login:    "test@protocol.one",
+		Password: "megapass",
+		Nickname: "Test",
+		Lang:     "ru",
+	}
+	err = db.DB().Create(&user).Error
+	suite.Nil(err, "Unable to create user")
+	suite.userId = user.ID
+
+	ownProvider := orm.NewOwnerProvider(suite.db)
+	membershipService := orm.NewMembershipService(suite.db, ownProvider, rbac.NewEnforcer(), mock.NewMailer(), "")
+	vendorService, err := orm.NewVendorService(db, membershipService)
+	suite.Nil(err, "Unable make vendor service")
+	vendor := model.Vendor{
+		ID:              uuid.NewV4(),
+		Name:            "domino",
+		Domain3:         "domino",
+		Email:           "domino@proto.com",
+		HowManyProducts: "+1000",
+		ManagerID:       suite.userId,
+	}
+	_, err = vendorService.Create(&vendor)
+	suite.Nil(err, "Must create new vendor")
+	suite.vendorId = vendor.ID
+
+	suite.outbox = orm.NewOutboxService(db)
+	suite.gameService, _ = orm.NewGameService(db)
+	suite.service, _ = orm.NewPackageService(db, suite.gameService)
+}
+
+func (suite *eventBusTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+// lastEvent returns the latest event of topic with decoded envelope
+func (suite *eventBusTestSuite) lastEvent(topic string) (*model.OutboxEvent, map[string]interface{}) {
+	should := require.New(suite.T())
+
+	events, _, err := suite.outbox.GetEvents(model.OutboxUndefined, topic, 0, 1)
+	should.Nil(err)
+	should.Len(events, 1, "Event `%s` must be saved", topic)
+	should.Equal(model.OutboxJSONEncoding, events[0].Encoding)
+
+	envelope := map[string]interface{}{}
+	should.Nil(json.Unmarshal(events[0].Payload, &envelope))
+
+	return &events[0], envelope
+}
+
+func (suite *eventBusTestSuite) TestPackageEvents() {
+	should := require.New(suite.T())
+
+	gameA, err := suite.gameService.Create(suite.userId, suite.vendorId, "GameA")
+	should.Nil(err)
+	gameB, err := suite.gameService.Create(suite.userId, suite.vendorId, "GameB")
+	should.Nil(err)
+
+	pkg, err := suite.service.Create(suite.vendorId, suite.userId, "Mega package", []uuid.UUID{gameA.ID, gameB.ID})
+	should.Nil(err)
+
+	created, envelope := suite.lastEvent(model.PackageCreatedTopic)
+	should.Equal(pkg.ID, created.AggregateID)
+	should.Equal(int64(1), created.Sequence)
+	should.Equal(1, created.SchemaVersion)
+	should.Equal(created.ID.String(), envelope["id"])
+	should.Equal(float64(1), envelope["sequence"])
+	should.Equal(created.CorrelationID.String(), envelope["correlationId"])
+	payload := envelope["payload"].(map[string]interface{})
+	should.Equal(pkg.ID.String(), payload["packageId"])
+	should.Equal([]interface{}{gameA.ID.String(), gameB.ID.String()}, payload["products"])
+
+	_, err = suite.service.RemoveProducts(pkg.ID, []uuid.UUID{gameB.ID})
+	should.Nil(err)
+
+	changed, envelope := suite.lastEvent(model.PackageProductsChangedTopic)
+	should.Equal(pkg.ID, changed.AggregateID)
+	should.Equal(int64(2), changed.Sequence, "Sequence must grow within aggregate")
+	should.NotEqual(created.CorrelationID, changed.CorrelationID, "Each change must have own correlation id")
+	payload = envelope["payload"].(map[string]interface{})
+	should.Equal([]interface{}{gameB.ID.String()}, payload["removed"])
+	should.Equal([]interface{}{gameA.ID.String()}, payload["products"])
+
+	should.Nil(suite.service.Remove(pkg.ID))
+	removed, _ := suite.lastEvent(model.PackageRemovedTopic)
+	should.Equal(int64(3), removed.Sequence)
+}
+
+func (suite *eventBusTestSuite) TestCorrelation() {
+	should := require.New(suite.T())
+
+	aggregateId := uuid.NewV4()
+	transaction := suite.db.DB().Begin()
+	bus := orm.NewEventBus(transaction)
+	should.Nil(bus.Publish(model.PriceChanged{PackageID: aggregateId, Currency: "USD", Price: 10}))
+	should.Nil(bus.Publish(model.PriceRemoved{PackageID: aggregateId, Currency: "EUR"}))
+	should.Nil(transaction.Commit().Error)
+
+	changed, _ := suite.lastEvent(model.PriceChangedTopic)
+	removed, _ := suite.lastEvent(model.PriceRemovedTopic)
+	should.Equal(changed.CorrelationID, removed.CorrelationID, "Events of one change must share correlation id")
+	should.Equal(int64(1), changed.Sequence)
+	should.Equal(int64(2), removed.Sequence)
+
+	broker := mock.NewBroker()
+	delivered, err := orm.NewOutboxDispatcher(suite.db, broker, 1, time.Second).Dispatch(time.Now().UTC().Add(time.Second))
+	should.Nil(err)
+	should.Equal(2, delivered)
+
+	headers := broker.Messages()[0].Headers
+	should.Equal(aggregateId.String(), headers["aggregate_id"])
+	should.Equal(changed.CorrelationID.String(), headers["correlation_id"])
+	should.Equal(int32(1), headers["schema_version"])
+	should.Equal(model.OutboxJSONEncoding, headers["encoding"])
+}
+
+func (suite *eventBusTestSuite) TestConcurrentEventsWithoutTransaction() {
+	should := require.New(suite.T())
+
+	aggregateId := uuid.NewV4()
+	count := 10
+	errs := make(chan error, count)
+	for i := 0; i < count; i++ {
+		go func(price float32) {
+			errs <- orm.NewEventBus(suite.db.DB()).Publish(model.PriceChanged{PackageID: aggregateId, Currency: "USD", Price: price})
+		}(float32(i))
+	}
+	for i := 0; i < count; i++ {
+		should.Nil(<-errs, "Bus without transaction must number events in its own one")
+	}
+
+	sequences := []int64{}
+	err := suite.db.DB().Model(&model.OutboxEvent{}).Where("aggregate_id = ?", aggregateId).Order("sequence").Pluck("sequence", &sequences).Error
+	should.Nil(err)
+	should.Len(sequences, count)
+	for i, sequence := range sequences {
+		should.Equal(int64(i+1), sequence)
+	}
+}
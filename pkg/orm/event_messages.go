@@ -0,0 +1,47 @@
+package orm
+
+import (
+	"github.com/ProtocolONE/qilin-common/pkg/proto"
+	protobuf "github.com/golang/protobuf/proto"
+)
+
+// Messages below are not part of qilin-common yet, they are described with protobuf tags
+// and serialized by reflection.
+type (
+	AchievementObject struct {
+		ID           string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+		Name         *proto.LocalizedString `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+		Description  *proto.LocalizedString `protobuf:"bytes,3,opt,name=Description,proto3" json:"Description,omitempty"`
+		LockedIcon   string                 `protobuf:"bytes,4,opt,name=LockedIcon,proto3" json:"LockedIcon,omitempty"`
+		UnlockedIcon string                 `protobuf:"bytes,5,opt,name=UnlockedIcon,proto3" json:"UnlockedIcon,omitempty"`
+		Hidden       bool                   `protobuf:"varint,6,opt,name=Hidden,proto3" json:"Hidden,omitempty"`
+		Order        int32                  `protobuf:"varint,7,opt,name=Order,proto3" json:"Order,omitempty"`
+		Points       int32                  `protobuf:"varint,8,opt,name=Points,proto3" json:"Points,omitempty"`
+	}
+
+	// AchievementsObject contains all achievements of game, list is empty while achievements are not on production
+	AchievementsObject struct {
+		GameID       string               `protobuf:"bytes,1,opt,name=GameID,proto3" json:"GameID,omitempty"`
+		Enabled      bool                 `protobuf:"varint,2,opt,name=Enabled,proto3" json:"Enabled,omitempty"`
+		Achievements []*AchievementObject `protobuf:"bytes,3,rep,name=Achievements,proto3" json:"Achievements,omitempty"`
+	}
+
+	// PackageReleasedObject is sent when package is switched from pre-order to regular pricing
+	PackageReleasedObject struct {
+		PackageID   string   `protobuf:"bytes,1,opt,name=PackageID,proto3" json:"PackageID,omitempty"`
+		GameIDs     []string `protobuf:"bytes,2,rep,name=GameIDs,proto3" json:"GameIDs,omitempty"`
+		ReleaseDate string   `protobuf:"bytes,3,opt,name=ReleaseDate,proto3" json:"ReleaseDate,omitempty"`
+	}
+)
+
+func (m *AchievementObject) Reset()         { *m = AchievementObject{} }
+func (m *AchievementObject) String() string { return protobuf.CompactTextString(m) }
+func (*AchievementObject) ProtoMessage()    {}
+
+func (m *AchievementsObject) Reset()         { *m = AchievementsObject{} }
+func (m *AchievementsObject) String() string { return protobuf.CompactTextString(m) }
+func (*AchievementsObject) ProtoMessage()    {}
+
+func (m *PackageReleasedObject) Reset()         { *m = PackageReleasedObject{} }
+func (m *PackageReleasedObject) String() string { return protobuf.CompactTextString(m) }
+func (*PackageReleasedObject) ProtoMessage()    {}
@@ -0,0 +1,61 @@
+package orm_test
+
+import (
+	"github.com/ProtocolONE/qilin-common/pkg/proto"
+	protobuf "github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"qilin-api/pkg/orm"
+	"testing"
+)
+
+func Test_AchievementsObjectMarshal(t *testing.T) {
+	should := require.New(t)
+
+	source := &orm.AchievementsObject{
+		GameID:  "029ce039-888a-481a-a831-cde7ff4e50b9",
+		Enabled: true,
+		Achievements: []*orm.AchievementObject{
+			{
+				ID:         "b6e9d5ad-2b4f-4e26-9a42-5e1c49d6f6a0",
+				Name:       &proto.LocalizedString{EN: "First blood", RU: "Первая кровь"},
+				LockedIcon: "http://example.com/locked.png",
+				Hidden:     true,
+				Order:      2,
+				Points:     50,
+			},
+		},
+	}
+
+	data, err := protobuf.Marshal(source)
+	should.Nil(err)
+
+	result := &orm.AchievementsObject{}
+	should.Nil(protobuf.Unmarshal(data, result))
+	should.Equal(source.GameID, result.GameID)
+	should.True(result.Enabled)
+	should.Len(result.Achievements, 1)
+	should.Equal("First blood", result.Achievements[0].Name.EN)
+	should.Equal("Первая кровь", result.Achievements[0].Name.RU)
+	should.Equal(int32(50), result.Achievements[0].Points)
+	should.Equal(int32(2), result.Achievements[0].Order)
+	should.True(result.Achievements[0].Hidden)
+}
+
+func Test_PackageReleasedObjectMarshal(t *testing.T) {
+	should := require.New(t)
+
+	source := &orm.PackageReleasedObject{
+		PackageID:   "4a1b0f5e-3d2c-4b7a-9a51-8c2f1d6e7b30",
+		GameIDs:     []string{"029ce039-888a-481a-a831-cde7ff4e50b9"},
+		ReleaseDate: "2019-06-14T00:00:00Z",
+	}
+
+	data, err := protobuf.Marshal(source)
+	should.Nil(err)
+
+	result := &orm.PackageReleasedObject{}
+	should.Nil(protobuf.Unmarshal(data, result))
+	should.Equal(source.PackageID, result.PackageID)
+	should.Equal(source.GameIDs, result.GameIDs)
+	should.Equal(source.ReleaseDate, result.ReleaseDate)
+}
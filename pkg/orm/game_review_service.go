@@ -0,0 +1,275 @@
+package orm
+
+import (
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"qilin-api/pkg/model"
+	"strings"
+	"time"
+)
+
+type gameReviewService struct {
+	db            *gorm.DB
+	publications  model.PublicationService
+	notifications model.NotificationService
+}
+
+func NewGameReviewService(db *Database, publications model.PublicationService, notifications model.NotificationService) model.GameReviewService {
+	return &gameReviewService{db: db.database, publications: publications, notifications: notifications}
+}
+
+func (s *gameReviewService) SendToReview(userId string, gameId uuid.UUID) (*model.GameReview, error) {
+	if err := s.checkNotPending(gameId); err != nil {
+		return nil, err
+	}
+
+	draft, err := s.publications.Draft(gameId)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := s.create(s.db, userId, draft, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(review, fmt.Sprintf("Game `%s` was sent to review", review.Game.Title), "")
+	return review, nil
+}
+
+// Rollback restores vendor data and creates review of it in one transaction, so restored data can't be left
+// without review. Current vendor data of game is overwritten like on manual rollback by vendor.
+func (s *gameReviewService) Rollback(userId string, gameId uuid.UUID, version int) (*model.GameReview, error) {
+	if err := s.checkNotPending(gameId); err != nil {
+		return nil, err
+	}
+
+	var review *model.GameReview
+	err := inTransaction(s.db, func(tx *gorm.DB) error {
+		draft, err := s.publications.WithTransaction(tx).Restore(gameId, version)
+		if err != nil {
+			return err
+		}
+		review, err = s.create(tx, userId, draft, &version)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(review, fmt.Sprintf("Game `%s` was restored from version %d and sent to review", review.Game.Title, version), "")
+	return review, nil
+}
+
+func (s *gameReviewService) Revoke(userId string, gameId uuid.UUID) (*model.GameReview, error) {
+	review, err := s.getPending(s.db, gameId)
+	if err != nil {
+		return nil, err
+	}
+
+	review.Status = model.ReviewArchived
+	if err := s.db.Save(review).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Save game review"))
+	}
+
+	s.notify(review, fmt.Sprintf("Review of game `%s` was revoked", review.Game.Title), "")
+	return review, nil
+}
+
+func (s *gameReviewService) Get(gameId uuid.UUID) (*model.GameReview, error) {
+	review, err := s.getLast(s.db, gameId)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil {
+		return nil, NewServiceError(http.StatusNotFound, "Game was not sent to review")
+	}
+	return review, nil
+}
+
+func (s *gameReviewService) GetQueue(status model.ReviewStatus, offset, limit int) ([]model.GameReview, int, error) {
+	query := s.db.Model(&model.GameReview{})
+	if status == model.ReviewUndefined {
+		query = query.Where("status in (?)", []model.ReviewStatus{model.ReviewNew, model.ReviewChecking})
+	} else {
+		query = query.Where("status = ?", status)
+	}
+
+	count := 0
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Counting game reviews"))
+	}
+
+	reviews := []model.GameReview{}
+	if err := query.Order("created_at").Offset(offset).Limit(limit).Find(&reviews).Error; err != nil {
+		return nil, 0, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch game reviews"))
+	}
+
+	return reviews, count, nil
+}
+
+func (s *gameReviewService) AddComment(userId string, gameId uuid.UUID, field, message string) (*model.GameReview, error) {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, "Message of comment is required")
+	}
+
+	review, err := s.getPending(s.db, gameId)
+	if err != nil {
+		return nil, err
+	}
+
+	field = strings.TrimSpace(field)
+	exists, err := review.GetSnapshot().HasField(field)
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Check field of game review"))
+	}
+	if !exists {
+		return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Unknown field `%s`", field)
+	}
+
+	review.Comments = append(review.Comments, model.ReviewComment{
+		Field:     field,
+		Message:   message,
+		AuthorID:  userId,
+		CreatedAt: time.Now().UTC(),
+	})
+	review.ReviewerID = userId
+	if err := s.db.Save(review).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Save game review"))
+	}
+
+	return review, nil
+}
+
+// ChangeStatus publishes game on approval. Vendor data of game must be the same as submitted one, otherwise
+// unreviewed changes would be published, vendor has to send game to review again in this case.
+func (s *gameReviewService) ChangeStatus(userId string, gameId uuid.UUID, status model.ReviewStatus, message string) (*model.GameReview, error) {
+	var review *model.GameReview
+	var title string
+	// Game and review are locked, so concurrent approvals can't publish two versions and approved review is saved
+	// in transaction of publication
+	err := inTransaction(s.db, func(tx *gorm.DB) error {
+		if err := lockGame(tx, gameId); err != nil {
+			return err
+		}
+		var err error
+		if review, err = s.getPending(tx.Set("gorm:query_option", "FOR UPDATE"), gameId); err != nil {
+			return err
+		}
+
+		switch status {
+		case model.ReviewChecking:
+			title = fmt.Sprintf("Game `%s` is being checked", review.Game.Title)
+		case model.ReviewReturned:
+			title = fmt.Sprintf("Game `%s` was returned for changes", review.Game.Title)
+		case model.ReviewApproved:
+			snapshot, err := s.publications.WithTransaction(tx).PublishReviewed(userId, review)
+			if err != nil {
+				return err
+			}
+			review.Version = &snapshot.Version
+			title = fmt.Sprintf("Game `%s` was approved and published", review.Game.Title)
+		default:
+			return NewServiceErrorf(http.StatusBadRequest, "Can't change to status `%s`", status.ToString())
+		}
+
+		review.Status = status
+		review.ReviewerID = userId
+		review.Message = message
+		if err := tx.Save(review).Error; err != nil {
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Save game review"))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(review, title, message)
+	return review, nil
+}
+
+// getLast returns the latest review of game with db, which may be a locking transaction
+func (s *gameReviewService) getLast(db *gorm.DB, gameId uuid.UUID) (*model.GameReview, error) {
+	review := &model.GameReview{}
+	err := db.Where("game_id = ?", gameId).Order("created_at desc").First(review).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch game review"))
+	}
+	return review, nil
+}
+
+func (s *gameReviewService) getPending(db *gorm.DB, gameId uuid.UUID) (*model.GameReview, error) {
+	review, err := s.getLast(db, gameId)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil || !review.IsPending() {
+		return nil, NewServiceError(http.StatusNotFound, "Game has no pending review")
+	}
+	return review, nil
+}
+
+// checkNotPending fails if game is already on review, unique index on pending reviews guards concurrent submissions
+func (s *gameReviewService) checkNotPending(gameId uuid.UUID) error {
+	last, err := s.getLast(s.db, gameId)
+	if err != nil {
+		return err
+	}
+	if last != nil && last.IsPending() {
+		return NewServiceErrorf(http.StatusConflict, "Game is already on review with status `%s`", last.Status.ToString())
+	}
+	return nil
+}
+
+// create saves pending review of draft snapshot with db, which may be a transaction
+func (s *gameReviewService) create(db *gorm.DB, userId string, draft *model.GameSnapshot, restoredFrom *int) (*model.GameReview, error) {
+	game := model.Game{}
+	if err := db.Select("vendor_id").Where("id = ?", draft.GameID).First(&game).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, NewServiceError(http.StatusNotFound, "Game not found")
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch game"))
+	}
+
+	review := model.NewGameReview(userId, game.VendorID, draft)
+	review.RestoredFrom = restoredFrom
+	if err := db.Create(review).Error; err != nil {
+		if isUniqueViolation(err) {
+			return nil, NewServiceError(http.StatusConflict, "Game is already on review")
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Create game review"))
+	}
+	return review, nil
+}
+
+// notify sends notification to vendor, review is already saved so failure is only logged
+func (s *gameReviewService) notify(review *model.GameReview, title, message string) {
+	if s.notifications == nil {
+		return
+	}
+	_, err := s.notifications.SendNotification(&model.Notification{
+		Title:    title,
+		Message:  message,
+		VendorID: review.VendorID,
+		UserID:   review.SubmitterID,
+	})
+	if err != nil {
+		zap.L().Error("Can't send notification about game review", zap.Error(err))
+	}
+}
+
+func joinChangePaths(changes []model.SnapshotChange) string {
+	paths := []string{}
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+	return strings.Join(paths, ", ")
+}
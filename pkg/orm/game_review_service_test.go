@@ -0,0 +1,351 @@
+package orm_test
+
+import (
+	"github.com/ProtocolONE/rbac"
+	"github.com/labstack/gommon/random"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"qilin-api/pkg/api/mock"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"qilin-api/pkg/test"
+	"testing"
+)
+
+type gameReviewServiceTestSuite struct {
+	suite.Suite
+	db            *orm.Database
+	service       model.GameReviewService
+	publications  model.PublicationService
+	notifications model.NotificationService
+	userId        string
+	adminId       string
+	vendorId      uuid.UUID
+	gameId        uuid.UUID
+}
+
+func Test_GameReviewService(t *testing.T) {
+	suite.Run(t, new(gameReviewServiceTestSuite))
+}
+
+func (suite *gameReviewServiceTestSuite) SetupTest() {
+	config, err := qilin_test.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+
+	db, err := orm.NewDatabase(&config.Database)
+	if err != nil {
+		suite.Fail("Unable to connect to database:", "%v", err)
+	}
+
+	if err := db.DropAllTables(); err != nil {
+		assert.FailNow(suite.T(), "Unable to drop tables", err)
+	}
+	if err := db.Init(); err != nil {
+		assert.FailNow(suite.T(), "Unable to init tables", err)
+	}
+
+	suite.db = db
+
+	user := model.User{
+		ID:       random.String(8, "0123456789"),
+		Login:    "test@protocol.one",
+		Password: "megapass",
+		Nickname: "Test",
+		Lang:     "ru",
+	}
+	err = db.DB().Create(&user).Error
+	suite.Nil(err, "Unable to create user")
+	suite.userId = user.ID
+	suite.adminId = random.String(8, "0123456789")
+
+	ownProvider := orm.NewOwnerProvider(suite.db)
+	membershipService := orm.NewMembershipService(suite.db, ownProvider, rbac.NewEnforcer(), mock.NewMailer(), "")
+	vendorService, err := orm.NewVendorService(db, membershipService)
+	suite.Nil(err, "Unable make vendor service")
+	vendor := model.Vendor{
+		ID:              uuid.NewV4(),
+		Name:            "domino",
+		Domain3:         "domino",
+		Email:           "domino@proto.com",
+		HowManyProducts: "+1000",
+		ManagerID:       user.ID,
+	}
+	_, err = vendorService.Create(&vendor)
+	suite.Nil(err, "Must create new vendor")
+	suite.vendorId = vendor.ID
+
+	gameService, err := orm.NewGameService(db)
+	suite.Nil(err, "Unable make game service")
+	game, err := gameService.Create(user.ID, vendor.ID, "Game")
+	if err != nil {
+		suite.FailNow("Unable to create game", "%v", err)
+	}
+	suite.gameId = game.ID
+
+	suite.notifications, err = orm.NewNotificationService(db, nil, "")
+	suite.Nil(err, "Unable make notification service")
+	suite.publications = orm.NewPublicationService(db)
+	suite.service = orm.NewGameReviewService(db, suite.publications, suite.notifications)
+}
+
+func (suite *gameReviewServiceTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *gameReviewServiceTestSuite) setTitle(title string) {
+	err := suite.db.DB().Model(&model.Game{}).Where("id = ?", suite.gameId).UpdateColumn("title", title).Error
+	suite.Nil(err, "Unable to update game")
+}
+
+func (suite *gameReviewServiceTestSuite) getNotificationTitles() []string {
+	notifications, _, err := suite.notifications.GetNotifications(suite.vendorId, 20, 0, "", "+createdDate")
+	suite.Nil(err, "Unable to get notifications")
+
+	titles := []string{}
+	for _, notification := range notifications {
+		titles = append(titles, notification.Title)
+	}
+	return titles
+}
+
+func (suite *gameReviewServiceTestSuite) TestApprove() {
+	should := require.New(suite.T())
+
+	_, err := suite.service.Get(suite.gameId)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	suite.setTitle("First")
+	review, err := suite.service.SendToReview(suite.userId, suite.gameId)
+	should.Nil(err)
+	should.Equal(model.ReviewNew, review.Status)
+	should.Equal(suite.vendorId, review.VendorID)
+	should.Equal("First", review.Game.Title)
+	should.Len(review.Packages, 1)
+
+	_, err = suite.service.SendToReview(suite.userId, suite.gameId)
+	should.NotNil(err)
+	should.Equal(http.StatusConflict, err.(*orm.ServiceError).Code)
+
+	reviews, total, err := suite.service.GetQueue(model.ReviewUndefined, 0, 20)
+	should.Nil(err)
+	should.Equal(1, total)
+	should.Len(reviews, 1)
+
+	_, err = suite.service.ChangeStatus(suite.adminId, suite.gameId, model.ReviewArchived, "")
+	should.NotNil(err)
+	should.Equal(http.StatusBadRequest, err.(*orm.ServiceError).Code)
+
+	review, err = suite.service.ChangeStatus(suite.adminId, suite.gameId, model.ReviewChecking, "")
+	should.Nil(err)
+	should.Equal(model.ReviewChecking, review.Status)
+
+	// Submission isn't published until approval
+	_, err = suite.publications.Get(suite.gameId, 0)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	review, err = suite.service.ChangeStatus(suite.adminId, suite.gameId, model.ReviewApproved, "Welcome")
+	should.Nil(err)
+	should.Equal(model.ReviewApproved, review.Status)
+	should.Equal(suite.adminId, review.ReviewerID)
+	should.NotNil(review.Version)
+	should.Equal(1, *review.Version)
+
+	snapshot, err := suite.publications.Get(suite.gameId, 0)
+	should.Nil(err)
+	should.Equal("First", snapshot.Game.Title)
+
+	_, err = suite.service.ChangeStatus(suite.adminId, suite.gameId, model.ReviewReturned, "")
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	reviews, total, err = suite.service.GetQueue(model.ReviewUndefined, 0, 20)
+	should.Nil(err)
+	should.Equal(0, total)
+	should.Len(reviews, 0)
+
+	should.Equal([]string{
+		"Game `First` was sent to review",
+		"Game `First` is being checked",
+		"Game `First` was approved and published",
+	}, suite.getNotificationTitles())
+}
+
+func (suite *gameReviewServiceTestSuite) TestReturn() {
+	should := require.New(suite.T())
+
+	_, err := suite.service.AddComment(suite.adminId, suite.gameId, "game.title", "Bad title")
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	suite.setTitle("First")
+	_, err = suite.service.SendToReview(suite.userId, suite.gameId)
+	should.Nil(err)
+
+	_, err = suite.service.AddComment(suite.adminId, suite.gameId, "game.unknown", "Bad field")
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
+	_, err = suite.service.AddComment(suite.adminId, suite.gameId, "game.title", " ")
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code, "Empty comment must be rejected")
+
+	review, err := suite.service.AddComment(suite.adminId, suite.gameId, "game.title", "Bad title")
+	should.Nil(err)
+	should.Len(review.Comments, 1)
+	should.Equal("game.title", review.Comments[0].Field)
+	should.Equal(suite.adminId, review.Comments[0].AuthorID)
+
+	review, err = suite.service.ChangeStatus(suite.adminId, suite.gameId, model.ReviewReturned, "Fix title")
+	should.Nil(err)
+	should.Equal(model.ReviewReturned, review.Status)
+	should.Nil(review.Version)
+
+	// Vendor sees comments of returned review
+	review, err = suite.service.Get(suite.gameId)
+	should.Nil(err)
+	should.Equal("Fix title", review.Message)
+	should.Len(review.Comments, 1)
+
+	_, err = suite.publications.Get(suite.gameId, 0)
+	should.NotNil(err)
+
+	suite.setTitle("Second")
+	review, err = suite.service.SendToReview(suite.userId, suite.gameId)
+	should.Nil(err)
+	should.Equal("Second", review.Game.Title)
+	should.Len(review.Comments, 0)
+
+	review, err = suite.service.Revoke(suite.userId, suite.gameId)
+	should.Nil(err)
+	should.Equal(model.ReviewArchived, review.Status)
+
+	_, err = suite.service.Revoke(suite.userId, suite.gameId)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	should.Equal([]string{
+		"Game `First` was sent to review",
+		"Game `First` was returned for changes",
+		"Game `Second` was sent to review",
+		"Review of game `Second` was revoked",
+	}, suite.getNotificationTitles())
+}
+
+func (suite *gameReviewServiceTestSuite) TestApproveChangedGame() {
+	should := require.New(suite.T())
+
+	suite.setTitle("First")
+	_, err := suite.service.SendToReview(suite.userId, suite.gameId)
+	should.Nil(err)
+
+	suite.setTitle("Changed")
+	_, err = suite.service.ChangeStatus(suite.adminId, suite.gameId, model.ReviewApproved, "")
+	should.NotNil(err)
+	should.Equal(http.StatusConflict, err.(*orm.ServiceError).Code)
+	should.Contains(err.Error(), "game.title")
+
+	review, err := suite.service.Get(suite.gameId)
+	should.Nil(err)
+	should.True(review.IsPending())
+
+	_, err = suite.publications.Get(suite.gameId, 0)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+}
+
+func (suite *gameReviewServiceTestSuite) TestConcurrentApprove() {
+	should := require.New(suite.T())
+
+	suite.setTitle("First")
+	_, err := suite.service.SendToReview(suite.userId, suite.gameId)
+	should.Nil(err)
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := suite.service.ChangeStatus(suite.adminId, suite.gameId, model.ReviewApproved, "")
+			errs <- err
+		}()
+	}
+
+	failed := 0
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code, "Review must be approved once")
+			failed++
+		}
+	}
+	should.Equal(1, failed)
+
+	snapshots, err := suite.publications.GetList(suite.gameId)
+	should.Nil(err)
+	should.Len(snapshots, 1, "Only one version must be published")
+}
+
+func (suite *gameReviewServiceTestSuite) TestRollback() {
+	should := require.New(suite.T())
+
+	suite.setTitle("First")
+	_, err := suite.publications.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+	suite.setTitle("Second")
+	_, err = suite.publications.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+
+	_, err = suite.service.Rollback(suite.userId, suite.gameId, 5)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	review, err := suite.service.Rollback(suite.userId, suite.gameId, 1)
+	should.Nil(err)
+	should.Equal(model.ReviewNew, review.Status)
+	should.Equal("First", review.Game.Title)
+	should.NotNil(review.RestoredFrom)
+	should.Equal(1, *review.RestoredFrom)
+
+	// Restored data isn't published until approval
+	snapshot, err := suite.publications.Get(suite.gameId, 0)
+	should.Nil(err)
+	should.Equal(2, snapshot.Version)
+	should.Equal("Second", snapshot.Game.Title)
+
+	_, err = suite.service.Rollback(suite.userId, suite.gameId, 2)
+	should.NotNil(err)
+	should.Equal(http.StatusConflict, err.(*orm.ServiceError).Code, "Game is already on review")
+
+	review, err = suite.service.ChangeStatus(suite.adminId, suite.gameId, model.ReviewApproved, "")
+	should.Nil(err)
+	should.Equal(3, *review.Version)
+
+	snapshot, err = suite.publications.Get(suite.gameId, 0)
+	should.Nil(err)
+	should.Equal(3, snapshot.Version)
+	should.Equal("First", snapshot.Game.Title)
+	should.NotNil(snapshot.RestoredFrom)
+	should.Equal(1, *snapshot.RestoredFrom)
+}
+
+func (suite *gameReviewServiceTestSuite) TestPendingReviewIndex() {
+	should := require.New(suite.T())
+
+	_, err := suite.service.SendToReview(suite.userId, suite.gameId)
+	should.Nil(err)
+
+	draft, err := suite.publications.Draft(suite.gameId)
+	should.Nil(err)
+	err = suite.db.DB().Create(model.NewGameReview(suite.userId, suite.vendorId, draft)).Error
+	should.NotNil(err, "Second pending review of game must be rejected by index")
+}
@@ -310,12 +310,17 @@ func (p *gameService) Delete(userId string, gameId uuid.UUID) (err error) {
 		return err
 	}
 
-	err = p.db.Delete(game).Error
-	if err != nil {
+	transaction := p.db.Begin()
+	if err := transaction.Delete(game).Error; err != nil {
+		transaction.Rollback()
 		return errors.Wrap(err, "Delete game")
 	}
+	if err := NewEventBus(transaction).PublishGameDelete(gameId); err != nil {
+		transaction.Rollback()
+		return NewServiceError(http.StatusInternalServerError, err)
+	}
 
-	return nil
+	return transaction.Commit().Error
 }
 
 func (p *gameService) UpdateInfo(game *model.Game) (err error) {
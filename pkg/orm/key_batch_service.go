@@ -0,0 +1,107 @@
+package orm
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/model"
+	"strings"
+)
+
+// maxKeyBatchSize limits count of keys generated in one request
+const maxKeyBatchSize = 100000
+
+type keyBatchService struct {
+	db *Database
+}
+
+func NewKeyBatchService(db *Database) model.KeyBatchService {
+	return &keyBatchService{db: db}
+}
+
+func (service *keyBatchService) Create(keyPackageId uuid.UUID, userId, partnerLabel string, count int) (*model.KeyBatch, error) {
+	if count <= 0 || count > maxKeyBatchSize {
+		return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Count must be between 1 and %d", maxKeyBatchSize)
+	}
+
+	partnerLabel = strings.TrimSpace(partnerLabel)
+	if partnerLabel == "" {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, "Partner label must be not empty")
+	}
+
+	keyPackage := model.KeyPackage{}
+	err := service.db.DB().Where("id = ?", keyPackageId).First(&keyPackage).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, NewServiceErrorf(http.StatusNotFound, "Key Package with id `%s` not found", keyPackageId)
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	if keyPackage.KeyStreamType != model.PlatformKeysStream {
+		return nil, NewServiceErrorf(http.StatusBadRequest, "Key package `%s` is not platform key package", keyPackageId)
+	}
+
+	batch := &model.KeyBatch{
+		KeyPackageID: keyPackageId,
+		PartnerLabel: partnerLabel,
+		Count:        count,
+		CreatedBy:    userId,
+	}
+	batch.ID = uuid.NewV4()
+
+	transaction := service.db.DB().Begin()
+	if err := transaction.Create(batch).Error; err != nil {
+		transaction.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Create key batch"))
+	}
+
+	if _, err := issueKeys(transaction, keyPackage.KeyStreamID, keyPackage.CodeFormat.OrDefault(), count, batch.ID); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return batch, nil
+}
+
+func (service *keyBatchService) List(keyPackageId uuid.UUID) ([]model.KeyBatch, error) {
+	var batches []model.KeyBatch
+	err := service.db.DB().Where("key_package_id = ?", keyPackageId).Order("created_at desc").Find(&batches).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return batches, nil
+}
+
+func (service *keyBatchService) Get(keyPackageId, batchId uuid.UUID) (*model.KeyBatch, error) {
+	batch := &model.KeyBatch{}
+	err := service.db.DB().Where("id = ? AND key_package_id = ?", batchId, keyPackageId).First(batch).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, NewServiceErrorf(http.StatusNotFound, "Key batch with id `%s` not found", batchId)
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return batch, nil
+}
+
+func (service *keyBatchService) GetKeys(keyPackageId, batchId uuid.UUID) ([]model.Key, error) {
+	if _, err := service.Get(keyPackageId, batchId); err != nil {
+		return nil, err
+	}
+
+	var keys []model.Key
+	err := service.db.DB().Where("batch_id = ?", batchId).Order("activation_code").Find(&keys).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return keys, nil
+}
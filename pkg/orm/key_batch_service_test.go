@@ -0,0 +1,135 @@
+package orm
+
+import (
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"qilin-api/pkg/model"
+	qilintest "qilin-api/pkg/test"
+	"testing"
+)
+
+type KeyBatchServiceTestSuite struct {
+	suite.Suite
+	db      *Database
+	service model.KeyBatchService
+
+	platformKeyPackage uuid.UUID
+	listKeyPackage     uuid.UUID
+}
+
+func Test_KeyBatchService(t *testing.T) {
+	suite.Run(t, new(KeyBatchServiceTestSuite))
+}
+
+func (suite *KeyBatchServiceTestSuite) SetupTest() {
+	shouldBe := require.New(suite.T())
+	config, err := qilintest.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+	db, err := NewDatabase(&config.Database)
+	if err != nil {
+		suite.FailNow("Unable to connect to database", "%v", err)
+	}
+
+	if err := db.DropAllTables(); err != nil {
+		assert.FailNow(suite.T(), "Unable to drop tables", err)
+	}
+	if err := db.Init(); err != nil {
+		assert.FailNow(suite.T(), "Unable to init tables", err)
+	}
+
+	keyStreamService := NewKeyStreamService(db)
+	packageId := uuid.NewV4()
+
+	platformStreamId, err := keyStreamService.Create(model.PlatformKeysStream)
+	shouldBe.Nil(err)
+	platformKeyPackage := model.KeyPackage{
+		KeyStreamID:   platformStreamId,
+		Name:          "Platform",
+		PackageID:     packageId,
+		KeyStreamType: model.PlatformKeysStream,
+	}
+	platformKeyPackage.ID = uuid.NewV4()
+	shouldBe.Nil(db.DB().Create(&platformKeyPackage).Error)
+	suite.platformKeyPackage = platformKeyPackage.ID
+
+	listStreamId, err := keyStreamService.Create(model.ListKeyStream)
+	shouldBe.Nil(err)
+	listKeyPackage := model.KeyPackage{
+		KeyStreamID:   listStreamId,
+		Name:          "List",
+		PackageID:     packageId,
+		KeyStreamType: model.ListKeyStream,
+	}
+	listKeyPackage.ID = uuid.NewV4()
+	shouldBe.Nil(db.DB().Create(&listKeyPackage).Error)
+	suite.listKeyPackage = listKeyPackage.ID
+
+	suite.db = db
+	suite.service = NewKeyBatchService(db)
+}
+
+func (suite *KeyBatchServiceTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *KeyBatchServiceTestSuite) TestCreate() {
+	shouldBe := require.New(suite.T())
+
+	_, err := suite.service.Create(suite.platformKeyPackage, "user", "Partner", 0)
+	shouldBe.NotNil(err)
+
+	_, err = suite.service.Create(suite.platformKeyPackage, "user", " ", 10)
+	shouldBe.NotNil(err)
+
+	_, err = suite.service.Create(suite.listKeyPackage, "user", "Partner", 10)
+	shouldBe.NotNil(err)
+
+	_, err = suite.service.Create(uuid.NewV4(), "user", "Partner", 10)
+	shouldBe.NotNil(err)
+
+	batch, err := suite.service.Create(suite.platformKeyPackage, "user", "Partner", 1200)
+	shouldBe.Nil(err)
+	shouldBe.Equal(1200, batch.Count)
+	shouldBe.Equal("user", batch.CreatedBy)
+
+	keys, err := suite.service.GetKeys(suite.platformKeyPackage, batch.ID)
+	shouldBe.Nil(err)
+	shouldBe.Len(keys, 1200)
+	codes := make(map[string]bool)
+	for _, key := range keys {
+		// keys of batch are issued but not redeemed
+		shouldBe.Nil(key.RedeemTime)
+		shouldBe.Equal(batch.ID, *key.BatchID)
+		codes[key.ActivationCode] = true
+	}
+	shouldBe.Len(codes, 1200)
+}
+
+func (suite *KeyBatchServiceTestSuite) TestGet() {
+	shouldBe := require.New(suite.T())
+	batch, err := suite.service.Create(suite.platformKeyPackage, "user", "Partner", 10)
+	shouldBe.Nil(err)
+
+	found, err := suite.service.Get(suite.platformKeyPackage, batch.ID)
+	shouldBe.Nil(err)
+	shouldBe.Equal("Partner", found.PartnerLabel)
+
+	_, err = suite.service.Get(suite.listKeyPackage, batch.ID)
+	shouldBe.NotNil(err)
+
+	_, err = suite.service.GetKeys(suite.listKeyPackage, batch.ID)
+	shouldBe.NotNil(err)
+
+	batches, err := suite.service.List(suite.platformKeyPackage)
+	shouldBe.Nil(err)
+	shouldBe.Len(batches, 1)
+}
@@ -0,0 +1,38 @@
+package orm
+
+import (
+	"crypto/rand"
+	"math/big"
+	"qilin-api/pkg/model"
+	"strings"
+)
+
+// generateCode creates activation code by format using cryptographically secure generator
+func generateCode(format model.KeyCodeFormat) (string, error) {
+	runes := format.Runes()
+	max := big.NewInt(int64(len(runes)))
+
+	payload := make([]rune, 0, format.GroupCount*format.GroupLength)
+	for i := 0; i < format.GroupCount*format.GroupLength; i++ {
+		index, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		payload = append(payload, runes[index.Int64()])
+	}
+
+	groups := make([]string, 0, format.GroupCount)
+	for i := 0; i < format.GroupCount; i++ {
+		groups = append(groups, string(payload[i*format.GroupLength:(i+1)*format.GroupLength]))
+	}
+
+	if format.Checksum {
+		checksum, err := format.ChecksumRune(payload)
+		if err != nil {
+			return "", err
+		}
+		groups[len(groups)-1] += string(checksum)
+	}
+
+	return format.Prefix + strings.Join(groups, model.KeyCodeSeparator), nil
+}
@@ -5,9 +5,12 @@ import (
 	uuid "github.com/satori/go.uuid"
 	"net/http"
 	"qilin-api/pkg/model"
+	"strings"
 	"time"
 )
 
+const keyInsertBatchSize = 500
+
 type keyListProvider struct {
 	streamId uuid.UUID
 	db       *Database
@@ -30,49 +33,59 @@ func NewKeyListProvider(streamId uuid.UUID, db *Database) (model.KeyStreamProvid
 	return &keyListProvider{streamId: streamId, db: db}, nil
 }
 
+// Redeem takes first free key in the stream. Row is locked with `FOR UPDATE SKIP LOCKED`
+// so concurrent callers never get the same activation code.
 func (provider *keyListProvider) Redeem() (model.Key, error) {
-	key := model.Key{}
-	err := provider.db.DB().Model(model.Key{}).Where("key_stream_id = ?", provider.streamId).Where("redeem_time IS NULL").First(&key).Error
+	keys, err := provider.RedeemList(1)
 	if err != nil {
-		if gorm.IsRecordNotFoundError(err) {
-			return key, NewServiceErrorf(http.StatusBadRequest, "No free keys in the stream `%s`", provider.streamId)
-		}
-		return key, NewServiceError(http.StatusInternalServerError, err)
+		return model.Key{}, err
 	}
 
-	t := time.Now().UTC()
-	key.RedeemTime = &t
-	err = provider.db.DB().Model(model.Key{}).Update(key).Error
-	if err != nil {
-		return key, NewServiceError(http.StatusInternalServerError, err)
-	}
-
-	return key, nil
+	return keys[0], nil
 }
 
 func (provider *keyListProvider) RedeemList(count int) ([]model.Key, error) {
+	if count <= 0 {
+		return nil, NewServiceError(http.StatusBadRequest, "Count must be greater than zero")
+	}
+
 	transaction := provider.db.DB().Begin()
 
 	var keys []model.Key
-	err := transaction.Model(model.Key{}).Where("key_stream_id = ?", provider.streamId).Where("redeem_time IS NULL").Limit(count).Find(&keys).Error
+	err := transaction.
+		Set("gorm:query_option", "FOR UPDATE SKIP LOCKED").
+		Model(model.Key{}).
+		Where("key_stream_id = ?", provider.streamId).
+		Where("redeem_time IS NULL AND revoke_time IS NULL").
+		Order("created_at").
+		Limit(count).
+		Find(&keys).Error
 	if err != nil {
 		transaction.Rollback()
 		return nil, NewServiceError(http.StatusInternalServerError, err)
 	}
 
+	if len(keys) == 0 {
+		transaction.Rollback()
+		return nil, NewServiceErrorf(http.StatusBadRequest, "No free keys in the stream `%s`", provider.streamId)
+	}
+
 	if len(keys) != count {
 		transaction.Rollback()
 		return nil, NewServiceErrorf(http.StatusBadRequest, "Keys not enough. Stream have `%d` keys.", len(keys))
 	}
-	
-	for _, key := range keys {
-		t := time.Now().UTC()
-		key.RedeemTime = &t
-		err := transaction.Model(key).Update(key).Error
-		if err != nil {
-			transaction.Rollback()
-			return nil, NewServiceError(http.StatusInternalServerError, err)
-		}
+
+	t := time.Now().UTC()
+	ids := make([]uuid.UUID, 0, len(keys))
+	for i := range keys {
+		keys[i].RedeemTime = &t
+		ids = append(ids, keys[i].ID)
+	}
+
+	err = transaction.Model(model.Key{}).Where("id in (?)", ids).UpdateColumn("redeem_time", t).Error
+	if err != nil {
+		transaction.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, err)
 	}
 
 	err = transaction.Commit().Error
@@ -86,17 +99,9 @@ func (provider *keyListProvider) RedeemList(count int) ([]model.Key, error) {
 func (provider *keyListProvider) AddKeys(codes []string) error {
 	transaction := provider.db.DB().Begin()
 
-	for _, code := range codes {
-		key := model.Key{
-			ActivationCode: code,
-			KeyStreamID:    provider.streamId,
-		}
-		key.ID = uuid.NewV4()
-		err := transaction.Model(model.Key{}).Create(&key).Error
-		if err != nil {
-			transaction.Rollback()
-			return NewServiceError(http.StatusBadRequest, err)
-		}
+	if err := insertKeys(transaction, provider.streamId, codes); err != nil {
+		transaction.Rollback()
+		return NewServiceError(http.StatusBadRequest, err)
 	}
 
 	err := transaction.Commit().Error
@@ -105,3 +110,30 @@ func (provider *keyListProvider) AddKeys(codes []string) error {
 	}
 	return nil
 }
+
+// insertKeys creates keys in the stream with multi-row inserts of keyInsertBatchSize rows, codes already
+// existing in the stream are skipped
+func insertKeys(db *gorm.DB, streamId uuid.UUID, codes []string) error {
+	t := time.Now().UTC()
+	for start := 0; start < len(codes); start += keyInsertBatchSize {
+		end := start + keyInsertBatchSize
+		if end > len(codes) {
+			end = len(codes)
+		}
+
+		values := make([]string, 0, end-start)
+		args := make([]interface{}, 0, (end-start)*5)
+		for _, code := range codes[start:end] {
+			values = append(values, "(?, ?, ?, ?, ?)")
+			args = append(args, uuid.NewV4(), t, t, streamId, code)
+		}
+
+		query := "INSERT INTO keys (id, created_at, updated_at, key_stream_id, activation_code) VALUES " + strings.Join(values, ", ") +
+			" ON CONFLICT (key_stream_id, activation_code) DO NOTHING"
+		if err := db.Exec(query, args...).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
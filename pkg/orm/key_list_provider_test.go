@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"qilin-api/pkg/model"
 	qilintest "qilin-api/pkg/test"
+	"sync"
 	"testing"
 )
 
@@ -158,3 +159,42 @@ func (suite *KeyListProviderTestSuite) TestRedeem() {
 	_, err = suite.provider.Redeem()
 	shouldBe.NotNil(err)
 }
+
+func (suite *KeyListProviderTestSuite) TestRedeemConcurrent() {
+	shouldBe := require.New(suite.T())
+
+	for i := 0; i < 20; i++ {
+		key := model.Key{
+			KeyStreamID:    suite.rightKeyStream,
+			ActivationCode: model.RandStringRunes(10),
+		}
+		key.ID = uuid.NewV4()
+		shouldBe.Nil(suite.db.DB().Create(&key).Error)
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	redeemed := map[uuid.UUID]int{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key, err := suite.provider.Redeem()
+			if err != nil {
+				return
+			}
+			mutex.Lock()
+			redeemed[key.ID]++
+			mutex.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for _, count := range redeemed {
+		shouldBe.Equal(1, count)
+	}
+
+	free := 0
+	shouldBe.Nil(suite.db.DB().Model(model.Key{}).Where("key_stream_id = ? AND redeem_time IS NULL", suite.rightKeyStream).Count(&free).Error)
+	shouldBe.Equal(20-len(redeemed), free)
+}
@@ -1,10 +1,16 @@
 package orm
 
 import (
+	"fmt"
 	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
 	"github.com/satori/go.uuid"
 	"net/http"
 	"qilin-api/pkg/model"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 )
 
 type keyListService struct {
@@ -17,13 +23,9 @@ func NewKeyListService(db *Database) model.KeyListService {
 }
 
 func (service *keyListService) AddKeys(keyPackageId uuid.UUID, keys []string) error {
-	keyPackage := model.KeyPackage{}
-	err := service.db.DB().Model(model.KeyPackage{}).Where("id = ?", keyPackageId).First(&keyPackage).Error
+	keyPackage, err := service.getKeyPackage(keyPackageId)
 	if err != nil {
-		if gorm.IsRecordNotFoundError(err) {
-			return NewServiceErrorf(http.StatusNotFound, "Key Package with id `%s` not found", keyPackageId)
-		}
-		return NewServiceError(http.StatusInternalServerError, err)
+		return err
 	}
 
 	streamProvider, err := NewKeyListProvider(keyPackage.KeyStreamID, service.db)
@@ -32,3 +34,247 @@ func (service *keyListService) AddKeys(keyPackageId uuid.UUID, keys []string) er
 	}
 	return streamProvider.AddKeys(keys)
 }
+
+// ImportKeys validates and adds keys in one transaction. Key stream is locked while importing
+// so concurrent imports can't add the same code twice.
+func (service *keyListService) ImportKeys(keyPackageId uuid.UUID, lines []string) (*model.KeyImportReport, error) {
+	keyPackage, err := service.getKeyPackage(keyPackageId)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyPackage.KeyStreamType != model.ListKeyStream {
+		return nil, NewServiceErrorf(http.StatusBadRequest, "Key package `%s` is not key list package", keyPackageId)
+	}
+
+	var pattern *regexp.Regexp
+	if keyPackage.CodePattern != "" {
+		pattern, err = regexp.Compile(keyPackage.CodePattern)
+		if err != nil {
+			return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Compile code pattern"))
+		}
+	}
+
+	report := &model.KeyImportReport{
+		Accepted:   []model.KeyImportLine{},
+		Duplicates: []model.KeyImportLine{},
+		Rejected:   []model.KeyImportLine{},
+	}
+
+	var candidates []model.KeyImportLine
+	seen := make(map[string]int)
+	for i, line := range lines {
+		code := strings.TrimSpace(line)
+		if code == "" {
+			continue
+		}
+
+		item := model.KeyImportLine{Line: i + 1, Code: code}
+		if pattern != nil && !pattern.MatchString(code) {
+			item.Reason = "Code doesn't match pattern of key package"
+			report.Rejected = append(report.Rejected, item)
+			continue
+		}
+
+		if first, ok := seen[code]; ok {
+			item.Reason = fmt.Sprintf("Duplicate of line %d", first)
+			report.Duplicates = append(report.Duplicates, item)
+			continue
+		}
+
+		seen[code] = item.Line
+		candidates = append(candidates, item)
+	}
+
+	transaction := service.db.DB().Begin()
+
+	err = transaction.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", keyPackage.KeyStreamID).First(&model.KeyStream{}).Error
+	if err != nil {
+		transaction.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Lock key stream"))
+	}
+
+	existing := make(map[string]bool)
+	for start := 0; start < len(candidates); start += keyInsertBatchSize {
+		end := start + keyInsertBatchSize
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+
+		codes := make([]string, 0, end-start)
+		for _, item := range candidates[start:end] {
+			codes = append(codes, item.Code)
+		}
+
+		var found []string
+		err := transaction.Model(model.Key{}).
+			Where("key_stream_id = ? AND activation_code in (?)", keyPackage.KeyStreamID, codes).
+			Pluck("activation_code", &found).Error
+		if err != nil {
+			transaction.Rollback()
+			return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Get existing keys"))
+		}
+
+		for _, code := range found {
+			existing[code] = true
+		}
+	}
+
+	var codes []string
+	for _, item := range candidates {
+		if existing[item.Code] {
+			item.Reason = "Key already exists in key package"
+			report.Duplicates = append(report.Duplicates, item)
+			continue
+		}
+
+		codes = append(codes, item.Code)
+		report.Accepted = append(report.Accepted, item)
+	}
+
+	if err := insertKeys(transaction, keyPackage.KeyStreamID, codes); err != nil {
+		transaction.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Insert keys"))
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	sort.Slice(report.Duplicates, func(i, j int) bool {
+		return report.Duplicates[i].Line < report.Duplicates[j].Line
+	})
+
+	return report, nil
+}
+
+func (service *keyListService) List(keyPackageId uuid.UUID, state model.KeyState, offset, limit int) (total int, keys []model.Key, err error) {
+	if offset < 0 || limit < 0 {
+		return 0, nil, NewServiceError(http.StatusBadRequest, "Offset and limit must not be negative")
+	}
+
+	keyPackage, err := service.getKeyPackage(keyPackageId)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	query := service.db.DB().Model(model.Key{}).Where("key_stream_id = ?", keyPackage.KeyStreamID)
+	switch state {
+	case model.KeyStateRedeemed:
+		query = query.Where("redeem_time IS NOT NULL")
+	case model.KeyStateUnredeemed:
+		query = query.Where("redeem_time IS NULL AND revoke_time IS NULL")
+	case model.KeyStateRevoked:
+		query = query.Where("revoke_time IS NOT NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return 0, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Count keys"))
+	}
+
+	query = query.Order("created_at").Offset(offset)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&keys).Error; err != nil {
+		return 0, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Get keys"))
+	}
+
+	return total, keys, nil
+}
+
+func (service *keyListService) Revoke(keyPackageId uuid.UUID, userId string, codes []string, reason string) (int, error) {
+	if len(codes) == 0 {
+		return 0, NewServiceError(http.StatusBadRequest, "Codes are empty")
+	}
+
+	if strings.TrimSpace(reason) == "" {
+		return 0, NewServiceError(http.StatusBadRequest, "Reason is empty")
+	}
+
+	keyPackage, err := service.getKeyPackage(keyPackageId)
+	if err != nil {
+		return 0, err
+	}
+
+	if keyPackage.KeyStreamType != model.ListKeyStream {
+		return 0, NewServiceErrorf(http.StatusBadRequest, "Key package `%s` is not key list package", keyPackageId)
+	}
+
+	transaction := service.db.DB().Begin()
+
+	// Lock keys so they can't be redeemed while being revoked
+	var keys []model.Key
+	err = transaction.
+		Set("gorm:query_option", "FOR UPDATE").
+		Model(model.Key{}).
+		Where("key_stream_id = ? AND activation_code in (?)", keyPackage.KeyStreamID, codes).
+		Find(&keys).Error
+	if err != nil {
+		transaction.Rollback()
+		return 0, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Get keys"))
+	}
+
+	found := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		found[key.ActivationCode] = true
+	}
+	var missing []string
+	for _, code := range codes {
+		if !found[code] {
+			missing = append(missing, code)
+		}
+	}
+	if len(missing) > 0 {
+		transaction.Rollback()
+		return 0, NewServiceErrorf(http.StatusNotFound, "Keys `%s` not found in key package", strings.Join(missing, ", "))
+	}
+
+	t := time.Now().UTC()
+	ids := make([]uuid.UUID, 0, len(keys))
+	for _, key := range keys {
+		if key.RevokeTime != nil {
+			continue
+		}
+
+		revocation := model.KeyRevocation{
+			KeyID:  key.ID,
+			UserID: userId,
+			Reason: reason,
+		}
+		revocation.ID = uuid.NewV4()
+		if err := transaction.Create(&revocation).Error; err != nil {
+			transaction.Rollback()
+			return 0, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Create key revocation"))
+		}
+		ids = append(ids, key.ID)
+	}
+
+	if len(ids) > 0 {
+		err = transaction.Model(model.Key{}).Where("id in (?)", ids).UpdateColumn("revoke_time", t).Error
+		if err != nil {
+			transaction.Rollback()
+			return 0, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Revoke keys"))
+		}
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return 0, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return len(ids), nil
+}
+
+func (service *keyListService) getKeyPackage(keyPackageId uuid.UUID) (*model.KeyPackage, error) {
+	keyPackage := model.KeyPackage{}
+	err := service.db.DB().Model(model.KeyPackage{}).Where("id = ?", keyPackageId).First(&keyPackage).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, NewServiceErrorf(http.StatusNotFound, "Key Package with id `%s` not found", keyPackageId)
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return &keyPackage, nil
+}
@@ -105,3 +105,118 @@ func (suite *KeyListServiceTestSuite) TestAddKeys() {
 	shouldBe.NotNil(suite.service.AddKeys(suite.wrongKeyPackage, keys))
 	shouldBe.NotNil(suite.service.AddKeys(uuid.NewV4(), keys))
 }
+
+func (suite *KeyListServiceTestSuite) TestList() {
+	shouldBe := require.New(suite.T())
+	shouldBe.Nil(suite.service.AddKeys(suite.rightKeyPackage, []string{"FIRST", "SECOND", "THIRD"}))
+
+	total, keys, err := suite.service.List(suite.rightKeyPackage, model.KeyStateAll, 0, 2)
+	shouldBe.Nil(err)
+	shouldBe.Equal(3, total)
+	shouldBe.Len(keys, 2)
+
+	total, keys, err = suite.service.List(suite.rightKeyPackage, model.KeyStateAll, 0, 0)
+	shouldBe.Nil(err)
+	shouldBe.Equal(3, total)
+	shouldBe.Len(keys, 3)
+
+	keyPackage := model.KeyPackage{}
+	shouldBe.Nil(suite.db.DB().Where("id = ?", suite.rightKeyPackage).First(&keyPackage).Error)
+	provider, err := NewKeyListProvider(keyPackage.KeyStreamID, suite.db)
+	shouldBe.Nil(err)
+	_, err = provider.Redeem()
+	shouldBe.Nil(err)
+
+	total, keys, err = suite.service.List(suite.rightKeyPackage, model.KeyStateRedeemed, 0, 10)
+	shouldBe.Nil(err)
+	shouldBe.Equal(1, total)
+	shouldBe.NotNil(keys[0].RedeemTime)
+
+	total, _, err = suite.service.List(suite.rightKeyPackage, model.KeyStateUnredeemed, 0, 10)
+	shouldBe.Nil(err)
+	shouldBe.Equal(2, total)
+
+	_, _, err = suite.service.List(suite.rightKeyPackage, model.KeyStateAll, -1, 10)
+	shouldBe.NotNil(err)
+
+	_, _, err = suite.service.List(uuid.NewV4(), model.KeyStateAll, 0, 10)
+	shouldBe.NotNil(err)
+}
+
+func (suite *KeyListServiceTestSuite) TestRevoke() {
+	shouldBe := require.New(suite.T())
+	shouldBe.Nil(suite.service.AddKeys(suite.rightKeyPackage, []string{"FIRST", "SECOND", "THIRD"}))
+
+	_, err := suite.service.Revoke(suite.rightKeyPackage, "user", []string{"FIRST"}, "")
+	shouldBe.NotNil(err)
+
+	_, err = suite.service.Revoke(suite.rightKeyPackage, "user", []string{"FIRST", "UNKNOWN"}, "Leaked")
+	shouldBe.NotNil(err)
+
+	_, err = suite.service.Revoke(suite.wrongKeyPackage, "user", []string{"FIRST"}, "Leaked")
+	shouldBe.NotNil(err)
+
+	revoked, err := suite.service.Revoke(suite.rightKeyPackage, "user", []string{"FIRST", "SECOND"}, "Leaked")
+	shouldBe.Nil(err)
+	shouldBe.Equal(2, revoked)
+
+	// already revoked keys are skipped
+	revoked, err = suite.service.Revoke(suite.rightKeyPackage, "user", []string{"FIRST"}, "Leaked again")
+	shouldBe.Nil(err)
+	shouldBe.Equal(0, revoked)
+
+	total, keys, err := suite.service.List(suite.rightKeyPackage, model.KeyStateRevoked, 0, 10)
+	shouldBe.Nil(err)
+	shouldBe.Equal(2, total)
+	shouldBe.NotNil(keys[0].RevokeTime)
+
+	var revocations []model.KeyRevocation
+	shouldBe.Nil(suite.db.DB().Find(&revocations).Error)
+	shouldBe.Len(revocations, 2)
+	shouldBe.Equal("user", revocations[0].UserID)
+	shouldBe.Equal("Leaked", revocations[0].Reason)
+
+	// revoked keys are never redeemed
+	keyPackage := model.KeyPackage{}
+	shouldBe.Nil(suite.db.DB().Where("id = ?", suite.rightKeyPackage).First(&keyPackage).Error)
+	provider, err := NewKeyListProvider(keyPackage.KeyStreamID, suite.db)
+	shouldBe.Nil(err)
+	key, err := provider.Redeem()
+	shouldBe.Nil(err)
+	shouldBe.Equal("THIRD", key.ActivationCode)
+	_, err = provider.Redeem()
+	shouldBe.NotNil(err)
+}
+
+func (suite *KeyListServiceTestSuite) TestImportKeys() {
+	shouldBe := require.New(suite.T())
+	shouldBe.Nil(suite.service.AddKeys(suite.rightKeyPackage, []string{"EXISTING"}))
+
+	report, err := suite.service.ImportKeys(suite.rightKeyPackage, []string{"FIRST", "", "  SECOND ", "FIRST", "EXISTING", "third"})
+	shouldBe.Nil(err)
+	shouldBe.Len(report.Accepted, 3)
+	shouldBe.Equal(3, report.Accepted[1].Line)
+	shouldBe.Equal("SECOND", report.Accepted[1].Code)
+	shouldBe.Len(report.Duplicates, 2)
+	shouldBe.Equal(4, report.Duplicates[0].Line)
+	shouldBe.Equal(5, report.Duplicates[1].Line)
+	shouldBe.Len(report.Rejected, 0)
+
+	total, _, err := suite.service.List(suite.rightKeyPackage, model.KeyStateAll, 0, 0)
+	shouldBe.Nil(err)
+	shouldBe.Equal(4, total)
+
+	shouldBe.Nil(suite.db.DB().Model(model.KeyPackage{}).Where("id = ?", suite.rightKeyPackage).UpdateColumn("code_pattern", "^[A-Z]{4,}$").Error)
+	report, err = suite.service.ImportKeys(suite.rightKeyPackage, []string{"FOURTH", "five", "SECOND"})
+	shouldBe.Nil(err)
+	shouldBe.Len(report.Accepted, 1)
+	shouldBe.Len(report.Duplicates, 1)
+	shouldBe.Len(report.Rejected, 1)
+	shouldBe.Equal(2, report.Rejected[0].Line)
+
+	_, err = suite.service.ImportKeys(suite.wrongKeyPackage, []string{"FIRST"})
+	shouldBe.NotNil(err)
+
+	_, err = suite.service.ImportKeys(uuid.NewV4(), []string{"FIRST"})
+	shouldBe.NotNil(err)
+}
@@ -1,20 +1,28 @@
 package orm
 
 import (
+	"fmt"
 	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
 	"github.com/satori/go.uuid"
+	"go.uber.org/zap"
 	"net/http"
 	"qilin-api/pkg/model"
 	"qilin-api/pkg/orm/utils"
+	"regexp"
+	"time"
 )
 
 type keyPackageService struct {
 	db *Database
 	keyStreamService model.KeyStreamService
+	notificationService model.NotificationService
 }
 
-func NewKeyPackageService(db *Database, keyStreamService model.KeyStreamService) model.KeyPackageService {
-	return &keyPackageService{db: db, keyStreamService: keyStreamService}
+var inventoryPeriods = []string{"hour", "day", "week", "month"}
+
+func NewKeyPackageService(db *Database, keyStreamService model.KeyStreamService, notificationService model.NotificationService) model.KeyPackageService {
+	return &keyPackageService{db: db, keyStreamService: keyStreamService, notificationService: notificationService}
 }
 
 func (service *keyPackageService) Get(keyPackageId uuid.UUID) (*model.KeyPackage, error) {
@@ -43,27 +51,37 @@ func (service *keyPackageService) Create(packageId uuid.UUID, name string, provi
 		return nil, NewServiceError(http.StatusUnprocessableEntity, "Name must be not null")
 	}
 
+	streamId, err := service.keyStreamService.Create(providerType)
+	if err != nil {
+		return nil, err
+	}
+
 	keyPackage := &model.KeyPackage{
-		Name:          name,
-		KeyStreamType: providerType,
-		PackageID:     packageId,
+		Name:             name,
+		KeyStreamID:      streamId,
+		KeyStreamType:    providerType,
+		PackageID:        packageId,
+		ActivationPolicy: model.ActivationPolicyPerpetual,
 	}
 	keyPackage.ID = uuid.NewV4()
 
-	err := service.db.DB().Model(model.KeyPackage{}).Create(keyPackage).Error
+	err = service.db.DB().Model(model.KeyPackage{}).Create(keyPackage).Error
 	if err != nil {
 		return nil, NewServiceError(http.StatusInternalServerError, err)
 	}
 
-	_, err = service.keyStreamService.Create(providerType)
-	if err != nil {
-		return nil, err
-	}
-
 	return keyPackage, nil
 }
 
-func (service *keyPackageService) Update(keyPackageId uuid.UUID, name string) (*model.KeyPackage, error) {
+func (service *keyPackageService) Update(keyPackageId uuid.UUID, name string, lowStockThreshold int, codePattern string) (*model.KeyPackage, error) {
+	if lowStockThreshold < 0 {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, "Low stock threshold must be not negative")
+	}
+
+	if _, err := regexp.Compile(codePattern); err != nil {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, errors.Wrap(err, "Invalid code pattern"))
+	}
+
 	keyPackage := &model.KeyPackage{}
 
 	err := service.db.DB().Model(model.KeyPackage{}).Where("id = ?", keyPackageId).First(keyPackage).Error
@@ -75,6 +93,8 @@ func (service *keyPackageService) Update(keyPackageId uuid.UUID, name string) (*
 	}
 
 	keyPackage.Name = name
+	keyPackage.LowStockThreshold = lowStockThreshold
+	keyPackage.CodePattern = codePattern
 	err = service.db.DB().Save(keyPackage).Error
 	if err != nil {
 		return nil, NewServiceError(http.StatusInternalServerError, err)
@@ -83,6 +103,52 @@ func (service *keyPackageService) Update(keyPackageId uuid.UUID, name string) (*
 	return keyPackage, nil
 }
 
+func (service *keyPackageService) UpdateCodeFormat(keyPackageId uuid.UUID, format model.KeyCodeFormat) (*model.KeyPackage, error) {
+	if err := format.Validate(); err != nil {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, err)
+	}
+
+	keyPackage, err := service.Get(keyPackageId)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyPackage.KeyStreamType != model.PlatformKeysStream {
+		return nil, NewServiceErrorf(http.StatusBadRequest, "Key package `%s` is not platform key package", keyPackageId)
+	}
+
+	keyPackage.CodeFormat = format
+	err = service.db.DB().Model(keyPackage).UpdateColumn("code_format", format).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return keyPackage, nil
+}
+
+func (service *keyPackageService) UpdateActivationPolicy(keyPackageId uuid.UUID, policy string, licenseDays int) (*model.KeyPackage, error) {
+	if err := model.CheckActivationPolicy(policy, licenseDays); err != nil {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, err)
+	}
+
+	keyPackage, err := service.Get(keyPackageId)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPackage.ActivationPolicy = policy
+	keyPackage.LicenseDays = licenseDays
+	err = service.db.DB().Model(keyPackage).UpdateColumns(map[string]interface{}{
+		"activation_policy": policy,
+		"license_days":      licenseDays,
+	}).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return keyPackage, nil
+}
+
 func (service *keyPackageService) List(packageId uuid.UUID) ([]model.KeyPackage, error) {
 	var keyPackages []model.KeyPackage
 	err := service.db.DB().Model(model.KeyPackage{}).Where("package_id = ?", packageId).Order("created_at desc").Find(&keyPackages).Error
@@ -92,3 +158,137 @@ func (service *keyPackageService) List(packageId uuid.UUID) ([]model.KeyPackage,
 
 	return keyPackages, nil
 }
+
+func (service *keyPackageService) Redeem(keyPackageId uuid.UUID) (*model.Key, error) {
+	keys, err := service.redeem(keyPackageId, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keys[0], nil
+}
+
+func (service *keyPackageService) RedeemList(keyPackageId uuid.UUID, count int) ([]model.Key, error) {
+	return service.redeem(keyPackageId, count)
+}
+
+func (service *keyPackageService) redeem(keyPackageId uuid.UUID, count int) ([]model.Key, error) {
+	keyPackage, err := service.Get(keyPackageId)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := service.keyStreamService.Get(keyPackage.KeyStreamID)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []model.Key
+	if count == 1 {
+		key, err := provider.Redeem()
+		if err != nil {
+			return nil, err
+		}
+		keys = []model.Key{key}
+	} else {
+		keys, err = provider.RedeemList(count)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := service.checkLowStock(keyPackage, len(keys)); err != nil {
+		zap.L().Error("Can't check low stock of key package", zap.String("keyPackageId", keyPackageId.String()), zap.Error(err))
+	}
+
+	return keys, nil
+}
+
+// checkLowStock notifies vendor when count of free keys in key list crosses threshold of key package
+func (service *keyPackageService) checkLowStock(keyPackage *model.KeyPackage, redeemed int) error {
+	if keyPackage.KeyStreamType != model.ListKeyStream || keyPackage.LowStockThreshold == 0 || service.notificationService == nil {
+		return nil
+	}
+
+	available := 0
+	err := service.db.DB().Model(model.Key{}).
+		Where("key_stream_id = ? AND redeem_time IS NULL AND revoke_time IS NULL", keyPackage.KeyStreamID).
+		Count(&available).Error
+	if err != nil {
+		return errors.Wrap(err, "Count free keys")
+	}
+
+	// Notify only once when threshold is crossed
+	if available >= keyPackage.LowStockThreshold || available+redeemed < keyPackage.LowStockThreshold {
+		return nil
+	}
+
+	pkg := model.Package{}
+	err = service.db.DB().Select("id, vendor_id").Where("id = ?", keyPackage.PackageID).First(&pkg).Error
+	if err != nil {
+		return errors.Wrap(err, "Get package of key package")
+	}
+
+	_, err = service.notificationService.SendNotification(&model.Notification{
+		Title:    fmt.Sprintf("Key package `%s` is running out of keys", keyPackage.Name),
+		Message:  fmt.Sprintf("Key package `%s` has %d free keys left. Threshold is %d.", keyPackage.Name, available, keyPackage.LowStockThreshold),
+		VendorID: pkg.VendorID,
+	})
+	return err
+}
+
+func (service *keyPackageService) GetInventory(keyPackageId uuid.UUID, from, to time.Time, period string) (*model.KeyPackageInventory, error) {
+	if !checkInventoryPeriod(period) {
+		return nil, NewServiceErrorf(http.StatusBadRequest, "Unknown period `%s`", period)
+	}
+
+	if to.Before(from) {
+		return nil, NewServiceError(http.StatusBadRequest, "Wrong date range")
+	}
+
+	keyPackage, err := service.Get(keyPackageId)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := &model.KeyPackageInventory{
+		Unlimited:   keyPackage.KeyStreamType == model.PlatformKeysStream,
+		Redemptions: []model.KeyRedemptionStat{},
+	}
+
+	query := service.db.DB().Model(model.Key{}).Where("key_stream_id = ?", keyPackage.KeyStreamID)
+	if err := query.Count(&inventory.Total).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Count keys"))
+	}
+	if err := query.Where("redeem_time IS NOT NULL").Count(&inventory.Redeemed).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Count redeemed keys"))
+	}
+	if err := query.Where("revoke_time IS NOT NULL").Count(&inventory.Revoked).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Count revoked keys"))
+	}
+	if err := query.Where("redeem_time IS NULL AND revoke_time IS NULL").Count(&inventory.Available).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Count free keys"))
+	}
+
+	err = service.db.DB().Raw(`SELECT date_trunc(?, redeem_time) AS date, count(*) AS count
+		FROM keys
+		WHERE key_stream_id = ? AND deleted_at IS NULL AND redeem_time BETWEEN ? AND ?
+		GROUP BY 1
+		ORDER BY 1`, period, keyPackage.KeyStreamID, from, to).
+		Scan(&inventory.Redemptions).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Get redemption statistics"))
+	}
+
+	return inventory, nil
+}
+
+func checkInventoryPeriod(period string) bool {
+	for _, p := range inventoryPeriods {
+		if p == period {
+			return true
+		}
+	}
+
+	return false
+}
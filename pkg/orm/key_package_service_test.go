@@ -8,6 +8,7 @@ import (
 	"qilin-api/pkg/model"
 	qilintest "qilin-api/pkg/test"
 	"testing"
+	"time"
 )
 
 type KeyPackageServiceTestSuite struct {
@@ -24,6 +25,17 @@ func Test_KeyPackageService(t *testing.T) {
 	suite.Run(t, new(KeyPackageServiceTestSuite))
 }
 
+// notificationServiceMock records sent notifications
+type notificationServiceMock struct {
+	model.NotificationService
+	sent []model.Notification
+}
+
+func (s *notificationServiceMock) SendNotification(notification *model.Notification) (*model.Notification, error) {
+	s.sent = append(s.sent, *notification)
+	return notification, nil
+}
+
 func (suite *KeyPackageServiceTestSuite) SetupTest() {
 	shouldBe := require.New(suite.T())
 	config, err := qilintest.LoadTestConfig()
@@ -46,13 +58,11 @@ func (suite *KeyPackageServiceTestSuite) SetupTest() {
 	suite.wrongKeyPackage = uuid.NewV4()
 
 	keyListStreeam := model.KeyStream{
-		Type:         model.ListKeyStream,
+		Type: model.ListKeyStream,
 	}
 	keyListStreeam.ID = uuid.NewV4()
 	shouldBe.Nil(db.DB().Model(model.KeyStream{}).Create(&keyListStreeam).Error)
-	gamePackage := model.Package{
-
-	}
+	gamePackage := model.Package{}
 	gamePackage.ID = uuid.NewV4()
 	shouldBe.Nil(db.DB().Model(model.Package{}).Create(&gamePackage).Error)
 	suite.packageId = gamePackage.ID
@@ -67,7 +77,7 @@ func (suite *KeyPackageServiceTestSuite) SetupTest() {
 	shouldBe.Nil(db.DB().Model(model.KeyPackage{}).Create(&keyPackage).Error)
 
 	platformListStreeam := model.KeyStream{
-		Type:         model.PlatformKeysStream,
+		Type: model.PlatformKeysStream,
 	}
 	platformListStreeam.ID = uuid.NewV4()
 	shouldBe.Nil(db.DB().Model(model.KeyStream{}).Create(&platformListStreeam).Error)
@@ -82,7 +92,7 @@ func (suite *KeyPackageServiceTestSuite) SetupTest() {
 	shouldBe.Nil(db.DB().Model(model.KeyPackage{}).Create(&platformKeyPackage).Error)
 
 	suite.db = db
-	suite.service = NewKeyPackageService(db, NewKeyStreamService(db))
+	suite.service = NewKeyPackageService(db, NewKeyStreamService(db), nil)
 }
 
 func (suite *KeyPackageServiceTestSuite) TearDownTest() {
@@ -110,6 +120,7 @@ func (suite *KeyPackageServiceTestSuite) TestCreate() {
 	keyPackage, err := suite.service.Create(suite.packageId, "Some name", model.ListKeyStream)
 	shouldBe.Nil(err)
 	shouldBe.NotNil(keyPackage)
+	shouldBe.NotEqual(uuid.Nil, keyPackage.KeyStreamID)
 
 	keyPackage, err = suite.service.Create(suite.packageId, "Some name 2", model.ListKeyStream)
 	shouldBe.Nil(err)
@@ -150,11 +161,158 @@ func (suite *KeyPackageServiceTestSuite) TestList() {
 
 func (suite *KeyPackageServiceTestSuite) TestUpdate() {
 	shouldBe := require.New(suite.T())
-	keyPackage, err := suite.service.Update(uuid.NewV4(), "Not found")
+	keyPackage, err := suite.service.Update(uuid.NewV4(), "Not found", 0, "")
 	shouldBe.NotNil(err)
 	shouldBe.Nil(keyPackage)
 
-	keyPackage, err = suite.service.Update(suite.rightKeyPackage, "Another name")
+	keyPackage, err = suite.service.Update(suite.rightKeyPackage, "Another name", 10, "^[A-Z]+$")
 	shouldBe.Nil(err)
 	shouldBe.NotNil(keyPackage)
-}
\ No newline at end of file
+	shouldBe.Equal(10, keyPackage.LowStockThreshold)
+	shouldBe.Equal("^[A-Z]+$", keyPackage.CodePattern)
+
+	keyPackage, err = suite.service.Update(suite.rightKeyPackage, "Another name", -1, "")
+	shouldBe.NotNil(err)
+	shouldBe.Nil(keyPackage)
+
+	keyPackage, err = suite.service.Update(suite.rightKeyPackage, "Another name", 0, "[A-Z")
+	shouldBe.NotNil(err)
+	shouldBe.Nil(keyPackage)
+}
+
+func (suite *KeyPackageServiceTestSuite) TestRedeem() {
+	shouldBe := require.New(suite.T())
+	key, err := suite.service.Redeem(uuid.NewV4())
+	shouldBe.NotNil(err)
+	shouldBe.Nil(key)
+
+	// key list stream is empty
+	key, err = suite.service.Redeem(suite.rightKeyPackage)
+	shouldBe.NotNil(err)
+	shouldBe.Nil(key)
+
+	keyPackage, err := suite.service.Get(suite.rightKeyPackage)
+	shouldBe.Nil(err)
+	provider, err := NewKeyListProvider(keyPackage.KeyStreamID, suite.db)
+	shouldBe.Nil(err)
+	shouldBe.Nil(provider.AddKeys([]string{"FIRST", "SECOND", "THIRD"}))
+
+	key, err = suite.service.Redeem(suite.rightKeyPackage)
+	shouldBe.Nil(err)
+	shouldBe.NotNil(key)
+	shouldBe.NotNil(key.RedeemTime)
+
+	keys, err := suite.service.RedeemList(suite.rightKeyPackage, 3)
+	shouldBe.NotNil(err)
+
+	keys, err = suite.service.RedeemList(suite.rightKeyPackage, 2)
+	shouldBe.Nil(err)
+	shouldBe.Len(keys, 2)
+	shouldBe.NotEqual(keys[0].ActivationCode, keys[1].ActivationCode)
+
+	keys, err = suite.service.RedeemList(suite.wrongKeyPackage, 5)
+	shouldBe.Nil(err)
+	shouldBe.Len(keys, 5)
+}
+
+func (suite *KeyPackageServiceTestSuite) TestLowStockNotification() {
+	shouldBe := require.New(suite.T())
+
+	gamePackage := model.Package{VendorID: uuid.NewV4()}
+	gamePackage.ID = uuid.NewV4()
+	shouldBe.Nil(suite.db.DB().Create(&gamePackage).Error)
+
+	notifications := &notificationServiceMock{}
+	service := NewKeyPackageService(suite.db, NewKeyStreamService(suite.db), notifications)
+
+	keyPackage, err := service.Create(gamePackage.ID, "Low stock", model.ListKeyStream)
+	shouldBe.Nil(err)
+	_, err = service.Update(keyPackage.ID, keyPackage.Name, 2, "")
+	shouldBe.Nil(err)
+
+	provider, err := NewKeyListProvider(keyPackage.KeyStreamID, suite.db)
+	shouldBe.Nil(err)
+	shouldBe.Nil(provider.AddKeys([]string{"FIRST", "SECOND", "THIRD", "FOURTH", "FIFTH"}))
+
+	_, err = service.RedeemList(keyPackage.ID, 2)
+	shouldBe.Nil(err)
+	_, err = service.Redeem(keyPackage.ID)
+	shouldBe.Nil(err)
+	shouldBe.Empty(notifications.sent, "Stock isn't below threshold yet")
+
+	_, err = service.Redeem(keyPackage.ID)
+	shouldBe.Nil(err)
+	shouldBe.Len(notifications.sent, 1, "Stock is below threshold")
+	shouldBe.Equal(gamePackage.VendorID, notifications.sent[0].VendorID)
+
+	_, err = service.Redeem(keyPackage.ID)
+	shouldBe.Nil(err)
+	shouldBe.Len(notifications.sent, 1, "Vendor is notified once when threshold is crossed")
+}
+
+func (suite *KeyPackageServiceTestSuite) TestGetInventory() {
+	shouldBe := require.New(suite.T())
+	from := time.Now().Add(-time.Hour)
+	to := time.Now().Add(time.Hour)
+
+	inventory, err := suite.service.GetInventory(uuid.NewV4(), from, to, "day")
+	shouldBe.NotNil(err)
+	shouldBe.Nil(inventory)
+
+	inventory, err = suite.service.GetInventory(suite.rightKeyPackage, from, to, "year")
+	shouldBe.NotNil(err)
+	shouldBe.Nil(inventory)
+
+	inventory, err = suite.service.GetInventory(suite.rightKeyPackage, to, from, "day")
+	shouldBe.NotNil(err)
+	shouldBe.Nil(inventory)
+
+	keyPackage, err := suite.service.Get(suite.rightKeyPackage)
+	shouldBe.Nil(err)
+	provider, err := NewKeyListProvider(keyPackage.KeyStreamID, suite.db)
+	shouldBe.Nil(err)
+	shouldBe.Nil(provider.AddKeys([]string{"FIRST", "SECOND", "THIRD"}))
+
+	_, err = suite.service.RedeemList(suite.rightKeyPackage, 2)
+	shouldBe.Nil(err)
+
+	inventory, err = suite.service.GetInventory(suite.rightKeyPackage, from, to, "day")
+	shouldBe.Nil(err)
+	shouldBe.False(inventory.Unlimited)
+	shouldBe.Equal(3, inventory.Total)
+	shouldBe.Equal(2, inventory.Redeemed)
+	shouldBe.Equal(1, inventory.Available)
+	shouldBe.Len(inventory.Redemptions, 1)
+	shouldBe.Equal(2, inventory.Redemptions[0].Count)
+
+	inventory, err = suite.service.GetInventory(suite.wrongKeyPackage, from, to, "hour")
+	shouldBe.Nil(err)
+	shouldBe.True(inventory.Unlimited)
+}
+
+func (suite *KeyPackageServiceTestSuite) TestUpdateCodeFormat() {
+	shouldBe := require.New(suite.T())
+	format := model.KeyCodeFormat{
+		GroupCount:  2,
+		GroupLength: 8,
+		Alphabet:    "ABCDEFGHJKLMNPQRSTUVWXYZ23456789",
+		Prefix:      "GAME-",
+		Checksum:    true,
+	}
+
+	keyPackage, err := suite.service.UpdateCodeFormat(suite.rightKeyPackage, format)
+	shouldBe.NotNil(err)
+	shouldBe.Nil(keyPackage)
+
+	keyPackage, err = suite.service.UpdateCodeFormat(suite.wrongKeyPackage, model.KeyCodeFormat{GroupCount: 1, GroupLength: 1, Alphabet: "AB"})
+	shouldBe.NotNil(err)
+	shouldBe.Nil(keyPackage)
+
+	keyPackage, err = suite.service.UpdateCodeFormat(suite.wrongKeyPackage, format)
+	shouldBe.Nil(err)
+	shouldBe.Equal(format, keyPackage.CodeFormat)
+
+	key, err := suite.service.Redeem(suite.wrongKeyPackage)
+	shouldBe.Nil(err)
+	shouldBe.Regexp(`^GAME-[A-Z2-9]{8}-[A-Z2-9]{9}$`, key.ActivationCode)
+}
@@ -0,0 +1,341 @@
+package orm
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/model"
+	"strings"
+	"time"
+)
+
+// invalidActivationCode is reason of failed validation of activation code
+const invalidActivationCode = "Activation code is invalid"
+
+type licenseService struct {
+	db *Database
+}
+
+func NewLicenseService(db *Database) model.LicenseService {
+	return &licenseService{db: db}
+}
+
+// Activate issues license in transaction with key row locked, so concurrent activations of the same code
+// wait for each other and the second one is rejected as already activated.
+func (service *licenseService) Activate(userId, activationCode, orderId, externalContext string) (*model.License, error) {
+	activationCode = strings.TrimSpace(activationCode)
+	if activationCode == "" {
+		return nil, NewServiceError(http.StatusBadRequest, "Activation code is empty")
+	}
+
+	if userId == "" {
+		return nil, NewServiceError(http.StatusBadRequest, "User is empty")
+	}
+
+	transaction := service.db.DB().Begin()
+
+	key, err := findActivationKey(transaction.Set("gorm:query_option", "FOR UPDATE"), activationCode)
+	if err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+
+	keyPackage := model.KeyPackage{}
+	err = transaction.Where("key_stream_id = ?", key.KeyStreamID).First(&keyPackage).Error
+	if err != nil {
+		transaction.Rollback()
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, NewServiceError(http.StatusNotFound, "Activation code not found")
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	t := time.Now().UTC()
+	updates := map[string]interface{}{"activation_time": t}
+	if key.RedeemTime == nil {
+		// Keys of batches become redeemed on activation
+		updates["redeem_time"] = t
+	}
+	if err := transaction.Model(model.Key{}).Where("id = ?", key.ID).UpdateColumns(updates).Error; err != nil {
+		transaction.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Activate key"))
+	}
+
+	license := &model.License{
+		UserID:           userId,
+		OrderID:          orderId,
+		ExternalContext:  externalContext,
+		KeyID:            &key.ID,
+		ActivationCode:   key.ActivationCode,
+		ActivationPolicy: keyPackage.ActivationPolicy,
+		StartDate:        t,
+		PackageID:        keyPackage.PackageID,
+	}
+	license.ID = uuid.NewV4()
+
+	if license.ActivationPolicy == "" {
+		license.ActivationPolicy = model.ActivationPolicyPerpetual
+	}
+	if license.ActivationPolicy != model.ActivationPolicyPerpetual {
+		endDate := t.AddDate(0, 0, keyPackage.LicenseDays)
+		license.EndDate = &endDate
+	}
+
+	err = transaction.
+		Set("gorm:association_autoupdate", false).
+		Set("gorm:association_autocreate", false).
+		Create(license).Error
+	if err != nil {
+		transaction.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Create license"))
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return license, nil
+}
+
+// Validate reports the same reason for any code which can't be activated, so existence of codes isn't disclosed
+func (service *licenseService) Validate(activationCode string) (*model.KeyValidation, error) {
+	key, err := findActivationKey(service.db.DB(), strings.TrimSpace(activationCode))
+	if err != nil {
+		if serviceErr, ok := err.(*ServiceError); ok && serviceErr.Code != http.StatusInternalServerError {
+			return &model.KeyValidation{Valid: false, Reason: invalidActivationCode}, nil
+		}
+		return nil, err
+	}
+
+	keyPackage := model.KeyPackage{}
+	err = service.db.DB().Where("key_stream_id = ?", key.KeyStreamID).First(&keyPackage).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return &model.KeyValidation{Valid: false, Reason: invalidActivationCode}, nil
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return &model.KeyValidation{Valid: true, PackageID: keyPackage.PackageID}, nil
+}
+
+func (service *licenseService) List(packageId uuid.UUID, userId, orderId string, offset, limit int) (total int, licenses []model.License, err error) {
+	if offset < 0 || limit <= 0 {
+		return 0, nil, NewServiceError(http.StatusBadRequest, "Bad offset or limit")
+	}
+
+	query := service.db.DB().Model(model.License{}).Where("package_id = ?", packageId)
+	if userId != "" {
+		query = query.Where("user_id = ?", userId)
+	}
+	if orderId != "" {
+		query = query.Where("order_id = ?", orderId)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return 0, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Count licenses"))
+	}
+
+	if err := query.Order("created_at desc").Offset(offset).Limit(limit).Find(&licenses).Error; err != nil {
+		return 0, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Get licenses"))
+	}
+
+	return total, licenses, nil
+}
+
+func (service *licenseService) Revoke(packageId, licenseId uuid.UUID, reason string) (*model.License, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, NewServiceError(http.StatusBadRequest, "Reason is empty")
+	}
+
+	license, err := service.get(packageId, licenseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if license.RevokeTime != nil {
+		return nil, NewServiceErrorf(http.StatusBadRequest, "License `%s` is already revoked", licenseId)
+	}
+
+	t := time.Now().UTC()
+	license.RevokeTime = &t
+	license.RevokeReason = reason
+	err = service.db.DB().Model(license).UpdateColumns(map[string]interface{}{
+		"revoke_time":   t,
+		"revoke_reason": reason,
+	}).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Revoke license"))
+	}
+
+	return license, nil
+}
+
+func (service *licenseService) Extend(packageId, licenseId uuid.UUID, endDate time.Time) (*model.License, error) {
+	license, err := service.get(packageId, licenseId)
+	if err != nil {
+		return nil, err
+	}
+
+	if license.ActivationPolicy == model.ActivationPolicyPerpetual || license.EndDate == nil {
+		return nil, NewServiceErrorf(http.StatusBadRequest, "License `%s` is perpetual", licenseId)
+	}
+
+	if license.RevokeTime != nil {
+		return nil, NewServiceErrorf(http.StatusBadRequest, "License `%s` is revoked", licenseId)
+	}
+
+	if !endDate.After(*license.EndDate) {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, "New end date must be after current one")
+	}
+
+	endDate = endDate.UTC()
+	license.EndDate = &endDate
+	if err := service.db.DB().Model(license).UpdateColumn("end_date", endDate).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Extend license"))
+	}
+
+	return license, nil
+}
+
+func (service *licenseService) Renew(packageId, licenseId uuid.UUID) (*model.License, error) {
+	transaction := service.db.DB().Begin()
+
+	license := &model.License{}
+	err := transaction.
+		Set("gorm:query_option", "FOR UPDATE").
+		Where("id = ? AND package_id = ?", licenseId, packageId).
+		First(license).Error
+	if err != nil {
+		transaction.Rollback()
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, NewServiceErrorf(http.StatusNotFound, "License `%s` not found", licenseId)
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	if license.ActivationPolicy != model.ActivationPolicySubscription || license.EndDate == nil {
+		transaction.Rollback()
+		return nil, NewServiceErrorf(http.StatusBadRequest, "License `%s` is not subscription", licenseId)
+	}
+
+	if license.RevokeTime != nil {
+		transaction.Rollback()
+		return nil, NewServiceErrorf(http.StatusBadRequest, "License `%s` is revoked", licenseId)
+	}
+
+	if !time.Now().Before(license.ActiveUntil()) {
+		transaction.Rollback()
+		return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Subscription `%s` is expired and can't be renewed", licenseId)
+	}
+
+	// Period is taken from key package, so renewals follow its current policy
+	keyPackage := model.KeyPackage{}
+	err = transaction.
+		Joins("JOIN keys ON keys.key_stream_id = key_packages.key_stream_id").
+		Where("keys.id = ?", license.KeyID).
+		First(&keyPackage).Error
+	if err != nil {
+		transaction.Rollback()
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, NewServiceErrorf(http.StatusNotFound, "Key package of license `%s` not found", licenseId)
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	if keyPackage.ActivationPolicy != model.ActivationPolicySubscription || keyPackage.LicenseDays <= 0 {
+		transaction.Rollback()
+		return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Key package `%s` doesn't sell subscriptions anymore", keyPackage.ID)
+	}
+
+	endDate := license.EndDate.AddDate(0, 0, keyPackage.LicenseDays).UTC()
+	license.EndDate = &endDate
+	if err := transaction.Model(license).UpdateColumn("end_date", endDate).Error; err != nil {
+		transaction.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Renew license"))
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return license, nil
+}
+
+func (service *licenseService) GetActive(userId string, packageId uuid.UUID) (*model.License, error) {
+	t := time.Now().UTC()
+
+	var licenses []model.License
+	err := service.db.DB().
+		Where("user_id = ? AND package_id = ? AND revoke_time IS NULL", userId, packageId).
+		Order("end_date desc nulls first").
+		Find(&licenses).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Get licenses"))
+	}
+
+	for i := range licenses {
+		if licenses[i].IsActive(t) {
+			return &licenses[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (service *licenseService) get(packageId, licenseId uuid.UUID) (*model.License, error) {
+	license := &model.License{}
+	err := service.db.DB().Where("id = ? AND package_id = ?", licenseId, packageId).First(license).Error
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, NewServiceErrorf(http.StatusNotFound, "License `%s` not found", licenseId)
+		}
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+
+	return license, nil
+}
+
+// findActivationKey returns the only issued key with activation code which is neither activated nor revoked
+func findActivationKey(db *gorm.DB, activationCode string) (*model.Key, error) {
+	var keys []model.Key
+	if err := db.Where("activation_code = ?", activationCode).Find(&keys).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Find key"))
+	}
+
+	var candidates []model.Key
+	found, activated := false, false
+	for _, key := range keys {
+		// Keys not handed out yet (free keys of key list) can't be activated
+		if key.RedeemTime == nil && key.BatchID == nil {
+			continue
+		}
+		found = true
+
+		if key.RevokeTime != nil {
+			continue
+		}
+		if key.ActivationTime != nil {
+			activated = true
+			continue
+		}
+		candidates = append(candidates, key)
+	}
+
+	if !found {
+		return nil, NewServiceError(http.StatusNotFound, "Activation code not found")
+	}
+
+	switch len(candidates) {
+	case 0:
+		if activated {
+			return nil, NewServiceError(http.StatusConflict, "Activation code is already activated")
+		}
+		return nil, NewServiceError(http.StatusBadRequest, "Activation code is revoked")
+	case 1:
+		return &candidates[0], nil
+	default:
+		return nil, NewServiceError(http.StatusConflict, "Activation code is ambiguous")
+	}
+}
@@ -0,0 +1,283 @@
+package orm
+
+import (
+	"fmt"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"qilin-api/pkg/model"
+	qilintest "qilin-api/pkg/test"
+	"sync"
+	"testing"
+	"time"
+)
+
+type LicenseServiceTestSuite struct {
+	suite.Suite
+	db      *Database
+	service model.LicenseService
+
+	packageId     uuid.UUID
+	listPackage   uuid.UUID
+	keyStreamId   uuid.UUID
+	keyPackageSvc model.KeyPackageService
+}
+
+func Test_LicenseService(t *testing.T) {
+	suite.Run(t, new(LicenseServiceTestSuite))
+}
+
+func (suite *LicenseServiceTestSuite) SetupTest() {
+	shouldBe := require.New(suite.T())
+	config, err := qilintest.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+	db, err := NewDatabase(&config.Database)
+	if err != nil {
+		suite.FailNow("Unable to connect to database", "%v", err)
+	}
+
+	if err := db.DropAllTables(); err != nil {
+		assert.FailNow(suite.T(), "Unable to drop tables", err)
+	}
+	if err := db.Init(); err != nil {
+		assert.FailNow(suite.T(), "Unable to init tables", err)
+	}
+
+	suite.packageId = uuid.NewV4()
+	pkg := model.Package{}
+	pkg.ID = suite.packageId
+	shouldBe.Nil(db.DB().Create(&pkg).Error)
+
+	suite.keyPackageSvc = NewKeyPackageService(db, NewKeyStreamService(db), nil)
+	keyPackage, err := suite.keyPackageSvc.Create(suite.packageId, "List", model.ListKeyStream)
+	shouldBe.Nil(err)
+	suite.listPackage = keyPackage.ID
+	suite.keyStreamId = keyPackage.KeyStreamID
+
+	provider, err := NewKeyListProvider(keyPackage.KeyStreamID, db)
+	shouldBe.Nil(err)
+	shouldBe.Nil(provider.AddKeys([]string{"FIRST", "SECOND", "THIRD"}))
+
+	suite.db = db
+	suite.service = NewLicenseService(db)
+}
+
+func (suite *LicenseServiceTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *LicenseServiceTestSuite) TestActivate() {
+	shouldBe := require.New(suite.T())
+
+	// free keys are not handed out yet
+	_, err := suite.service.Activate("user", "FIRST", "order", "")
+	shouldBe.NotNil(err)
+	shouldBe.Equal(http.StatusNotFound, err.(*ServiceError).Code)
+
+	key, err := suite.keyPackageSvc.Redeem(suite.listPackage)
+	shouldBe.Nil(err)
+
+	validation, err := suite.service.Validate(key.ActivationCode)
+	shouldBe.Nil(err)
+	shouldBe.True(validation.Valid)
+	shouldBe.Equal(suite.packageId, validation.PackageID)
+
+	license, err := suite.service.Activate("user", key.ActivationCode, "order", "store")
+	shouldBe.Nil(err)
+	shouldBe.Equal(suite.packageId, license.PackageID)
+	shouldBe.Equal("user", license.UserID)
+	shouldBe.Equal("order", license.OrderID)
+	shouldBe.Equal(key.ID, *license.KeyID)
+	shouldBe.Equal(model.ActivationPolicyPerpetual, license.ActivationPolicy)
+	shouldBe.Nil(license.EndDate)
+
+	_, err = suite.service.Activate("another", key.ActivationCode, "order", "store")
+	shouldBe.NotNil(err)
+	shouldBe.Equal(http.StatusConflict, err.(*ServiceError).Code)
+
+	validation, err = suite.service.Validate(key.ActivationCode)
+	shouldBe.Nil(err)
+	shouldBe.False(validation.Valid)
+
+	unknown, err := suite.service.Validate("UNKNOWN")
+	shouldBe.Nil(err)
+	shouldBe.False(unknown.Valid)
+	shouldBe.Equal(validation.Reason, unknown.Reason, "Reason must not disclose whether code exists")
+
+	_, err = suite.service.Activate("", key.ActivationCode, "order", "store")
+	shouldBe.NotNil(err)
+}
+
+func (suite *LicenseServiceTestSuite) TestActivateRevoked() {
+	shouldBe := require.New(suite.T())
+	key, err := suite.keyPackageSvc.Redeem(suite.listPackage)
+	shouldBe.Nil(err)
+
+	_, err = NewKeyListService(suite.db).Revoke(suite.listPackage, "vendor", []string{key.ActivationCode}, "Leaked")
+	shouldBe.Nil(err)
+
+	_, err = suite.service.Activate("user", key.ActivationCode, "order", "")
+	shouldBe.NotNil(err)
+	shouldBe.Equal(http.StatusBadRequest, err.(*ServiceError).Code)
+}
+
+func (suite *LicenseServiceTestSuite) TestActivateConcurrent() {
+	shouldBe := require.New(suite.T())
+	key, err := suite.keyPackageSvc.Redeem(suite.listPackage)
+	shouldBe.Nil(err)
+
+	const workers = 10
+	var wg sync.WaitGroup
+	errs := make([]error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = suite.service.Activate(fmt.Sprintf("user%d", i), key.ActivationCode, "order", "")
+		}(i)
+	}
+	wg.Wait()
+
+	activated := 0
+	for _, err := range errs {
+		if err == nil {
+			activated++
+			continue
+		}
+		shouldBe.Equal(http.StatusConflict, err.(*ServiceError).Code)
+	}
+	shouldBe.Equal(1, activated)
+
+	count := 0
+	shouldBe.Nil(suite.db.DB().Model(model.License{}).Count(&count).Error)
+	shouldBe.Equal(1, count)
+}
+
+func (suite *LicenseServiceTestSuite) TestManageLicenses() {
+	shouldBe := require.New(suite.T())
+
+	_, err := suite.keyPackageSvc.UpdateActivationPolicy(suite.listPackage, model.ActivationPolicySubscription, 0)
+	shouldBe.NotNil(err)
+	keyPackage, err := suite.keyPackageSvc.UpdateActivationPolicy(suite.listPackage, model.ActivationPolicySubscription, 30)
+	shouldBe.Nil(err)
+	shouldBe.Equal(30, keyPackage.LicenseDays)
+
+	key, err := suite.keyPackageSvc.Redeem(suite.listPackage)
+	shouldBe.Nil(err)
+	license, err := suite.service.Activate("user", key.ActivationCode, "order", "")
+	shouldBe.Nil(err)
+	shouldBe.Equal(model.ActivationPolicySubscription, license.ActivationPolicy)
+	shouldBe.NotNil(license.EndDate)
+	shouldBe.Equal(license.StartDate.AddDate(0, 0, 30), *license.EndDate)
+
+	active, err := suite.service.GetActive("user", suite.packageId)
+	shouldBe.Nil(err)
+	shouldBe.NotNil(active)
+	shouldBe.Equal(license.ID, active.ID)
+
+	active, err = suite.service.GetActive("another", suite.packageId)
+	shouldBe.Nil(err)
+	shouldBe.Nil(active)
+
+	total, licenses, err := suite.service.List(suite.packageId, "user", "", 0, 10)
+	shouldBe.Nil(err)
+	shouldBe.Equal(1, total)
+	shouldBe.Len(licenses, 1)
+
+	total, _, err = suite.service.List(suite.packageId, "", "another order", 0, 10)
+	shouldBe.Nil(err)
+	shouldBe.Equal(0, total)
+
+	_, err = suite.service.Extend(suite.packageId, license.ID, license.StartDate)
+	shouldBe.NotNil(err)
+	_, err = suite.service.Extend(uuid.NewV4(), license.ID, license.EndDate.AddDate(0, 1, 0))
+	shouldBe.NotNil(err)
+	extended, err := suite.service.Extend(suite.packageId, license.ID, license.EndDate.AddDate(0, 1, 0))
+	shouldBe.Nil(err)
+	shouldBe.Equal(license.EndDate.AddDate(0, 1, 0), *extended.EndDate)
+
+	_, err = suite.service.Revoke(suite.packageId, license.ID, "")
+	shouldBe.NotNil(err)
+	revoked, err := suite.service.Revoke(suite.packageId, license.ID, "Chargeback")
+	shouldBe.Nil(err)
+	shouldBe.NotNil(revoked.RevokeTime)
+	_, err = suite.service.Revoke(suite.packageId, license.ID, "Chargeback")
+	shouldBe.NotNil(err)
+
+	active, err = suite.service.GetActive("user", suite.packageId)
+	shouldBe.Nil(err)
+	shouldBe.Nil(active)
+}
+
+func (suite *LicenseServiceTestSuite) TestRenewSubscription() {
+	shouldBe := require.New(suite.T())
+
+	key, err := suite.keyPackageSvc.Redeem(suite.listPackage)
+	shouldBe.Nil(err)
+	license, err := suite.service.Activate("user", key.ActivationCode, "order", "")
+	shouldBe.Nil(err)
+
+	_, err = suite.service.Renew(suite.packageId, license.ID)
+	shouldBe.NotNil(err, "Perpetual license can't be renewed")
+	shouldBe.Equal(http.StatusBadRequest, err.(*ServiceError).Code)
+
+	_, err = suite.keyPackageSvc.UpdateActivationPolicy(suite.listPackage, model.ActivationPolicySubscription, 30)
+	shouldBe.Nil(err)
+	key, err = suite.keyPackageSvc.Redeem(suite.listPackage)
+	shouldBe.Nil(err)
+	license, err = suite.service.Activate("subscriber", key.ActivationCode, "order", "")
+	shouldBe.Nil(err)
+
+	// Payment came late, but within grace period
+	endDate := time.Now().UTC().Add(-time.Hour)
+	shouldBe.Nil(suite.db.DB().Model(license).UpdateColumn("end_date", endDate).Error)
+	active, err := suite.service.GetActive("subscriber", suite.packageId)
+	shouldBe.Nil(err)
+	shouldBe.NotNil(active, "Subscription is active during grace period")
+
+	_, err = suite.service.Renew(uuid.NewV4(), license.ID)
+	shouldBe.NotNil(err)
+	renewed, err := suite.service.Renew(suite.packageId, license.ID)
+	shouldBe.Nil(err)
+	shouldBe.Equal(endDate.AddDate(0, 0, 30).Unix(), renewed.EndDate.Unix(), "Subscription is renewed from end of paid period")
+
+	// Grace period is over
+	endDate = time.Now().UTC().Add(-model.SubscriptionGracePeriod - time.Hour)
+	shouldBe.Nil(suite.db.DB().Model(license).UpdateColumn("end_date", endDate).Error)
+	active, err = suite.service.GetActive("subscriber", suite.packageId)
+	shouldBe.Nil(err)
+	shouldBe.Nil(active)
+	_, err = suite.service.Renew(suite.packageId, license.ID)
+	shouldBe.NotNil(err)
+	shouldBe.Equal(http.StatusUnprocessableEntity, err.(*ServiceError).Code)
+}
+
+func (suite *LicenseServiceTestSuite) TestExpiredLicense() {
+	shouldBe := require.New(suite.T())
+	key, err := suite.keyPackageSvc.Redeem(suite.listPackage)
+	shouldBe.Nil(err)
+	license, err := suite.service.Activate("user", key.ActivationCode, "order", "")
+	shouldBe.Nil(err)
+
+	_, err = suite.service.Extend(suite.packageId, license.ID, license.StartDate.AddDate(1, 0, 0))
+	shouldBe.NotNil(err)
+
+	endDate := license.StartDate.AddDate(0, 0, -1)
+	shouldBe.Nil(suite.db.DB().Model(license).UpdateColumns(map[string]interface{}{
+		"activation_policy": model.ActivationPolicyTimeLimited,
+		"end_date":          endDate,
+	}).Error)
+
+	active, err := suite.service.GetActive("user", suite.packageId)
+	shouldBe.Nil(err)
+	shouldBe.Nil(active)
+}
@@ -93,6 +93,11 @@ func (service *membershipService) Init() error {
 	service.enforcer.LinkRoles(model.SuperAdmin, model.Admin, "vendor")
 	service.enforcer.AddPolicy(rbac.Policy{Role: model.SuperAdmin, Domain: "vendor", ResourceType: model.RolesType, ResourceId: "skip", Action: "any", Effect: "allow"})
 	service.enforcer.AddPolicy(rbac.Policy{Role: model.SuperAdmin, Domain: "vendor", ResourceType: model.AdminDocumentsType, ResourceId: "skip", Action: "any", Effect: "allow"})
+	service.enforcer.AddPolicy(rbac.Policy{Role: model.SuperAdmin, Domain: "vendor", ResourceType: model.AdminCurrencyRatesType, ResourceId: "skip", Action: "any", Effect: "allow"})
+	service.enforcer.AddPolicy(rbac.Policy{Role: model.SuperAdmin, Domain: "vendor", ResourceType: model.AdminTaxRatesType, ResourceId: "skip", Action: "any", Effect: "allow"})
+	service.enforcer.AddPolicy(rbac.Policy{Role: model.SuperAdmin, Domain: "vendor", ResourceType: model.AdminGameReviewsType, ResourceId: "skip", Action: "any", Effect: "allow"})
+	service.enforcer.AddPolicy(rbac.Policy{Role: model.SuperAdmin, Domain: "vendor", ResourceType: model.AdminEventsType, ResourceId: "skip", Action: "any", Effect: "allow"})
+	service.enforcer.AddPolicy(rbac.Policy{Role: model.SuperAdmin, Domain: "vendor", ResourceType: model.AdminCatalogType, ResourceId: "skip", Action: "any", Effect: "allow"})
 
 	return nil
 }
@@ -108,6 +113,11 @@ func (service *membershipService) GetUsers(vendorId uuid.UUID) ([]*model.UserRol
 
 	//Retrieve all users that have membership for vendor
 	roles := []string{model.Admin, model.Manager, model.Support, model.Accountant, model.Store, model.Developer, model.Publisher}
+	customRoles := []string{}
+	if err := service.db.DB().Model(&model.VendorRole{}).Where("vendor_id = ?", vendorId).Pluck("id", &customRoles).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch vendor roles"))
+	}
+	roles = append(roles, customRoles...)
 	namesToSkip := []string{model.Admin, model.Manager, model.Support, model.Accountant, model.Store, model.Developer, model.Publisher, model.SuperAdmin}
 	users := make([]string, 0)
 	for _, role := range roles {
@@ -275,6 +285,10 @@ func (service *membershipService) AddRoleToUserInGame(vendorId uuid.UUID, userId
 		restrict = []string{gameId}
 	}
 
+	if err := service.checkRole(vendorId, role); err != nil {
+		return err
+	}
+
 	owner, err := service.ownerProvider.GetOwnerForVendor(vendorId)
 	if err != nil {
 		return err
@@ -305,6 +319,9 @@ func (service *membershipService) SendInvite(vendorId uuid.UUID, invite model.In
 	}
 
 	for _, role := range invite.Roles {
+		if err := service.checkRole(vendorId, role.Role); err != nil {
+			return nil, err
+		}
 		if role.Resource.Id == "" || role.Resource.Id == "*" {
 			continue
 		}
@@ -409,6 +426,24 @@ func (service *membershipService) AddRoleToUser(userId string, owner string, rol
 	return nil
 }
 
+// checkRole verifies that custom role belongs to vendor, roles of another vendor must not be assigned
+func (service *membershipService) checkRole(vendorId uuid.UUID, role string) error {
+	if !model.IsVendorRole(role) {
+		return nil
+	}
+
+	count := 0
+	err := service.db.DB().Model(&model.VendorRole{}).Where("id = ? and vendor_id = ?", role, vendorId).Count(&count).Error
+	if err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrapf(err, "Get role by id `%s`", role))
+	}
+	if count == 0 {
+		return NewServiceErrorf(http.StatusUnprocessableEntity, "Role `%s` not found", role)
+	}
+
+	return nil
+}
+
 func appendIfMissing(slice []string, users []string, skipNames []string) []string {
 	for _, user := range users {
 		if array_utils.Contains(skipNames, user) || array_utils.Contains(slice, user) {
@@ -463,6 +498,10 @@ func (service *membershipService) AddRoleToUserInResource(vendorId uuid.UUID, us
 		restrict = resourceId
 	}
 
+	if err := service.checkRole(vendorId, role); err != nil {
+		return err
+	}
+
 	owner, err := service.ownerProvider.GetOwnerForVendor(vendorId)
 	if err != nil {
 		return err
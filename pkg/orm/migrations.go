@@ -0,0 +1,138 @@
+package orm
+
+import (
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"qilin-api/pkg/model"
+	"strings"
+	"time"
+)
+
+// keyStateColumns are columns of keys table which are set when key is given to customer or voided
+var keyStateColumns = []string{"redeem_time", "revoke_time", "activation_time", "batch_id"}
+
+// dedupeKeys prepares keys table for unique index on key stream and activation code. Duplicated keys which were
+// never used are removed, the used or the oldest one is kept. Duplicates which were used both can't be removed
+// automatically, they are reported and migration fails until they are resolved manually.
+func dedupeKeys(db *gorm.DB) error {
+	if !db.HasTable(&model.Key{}) || db.Dialect().HasIndex("keys", "key_stream_activation_code") {
+		return nil
+	}
+
+	used := func(alias string) string {
+		conditions := []string{}
+		for _, column := range keyStateColumns {
+			if db.Dialect().HasColumn("keys", column) {
+				conditions = append(conditions, fmt.Sprintf("%s.%s IS NOT NULL", alias, column))
+			}
+		}
+		return "(" + strings.Join(conditions, " OR ") + ")"
+	}
+
+	result := db.Exec(`DELETE FROM keys k USING keys d
+		WHERE k.key_stream_id = d.key_stream_id AND k.activation_code = d.activation_code AND k.id <> d.id
+		AND NOT ` + used("k") + ` AND (` + used("d") + ` OR (d.created_at, d.id) < (k.created_at, k.id))`)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "Remove duplicated keys")
+	}
+	if result.RowsAffected > 0 {
+		zap.L().Warn("Duplicated keys removed", zap.Int64("count", result.RowsAffected))
+	}
+
+	var duplicates []struct {
+		KeyStreamID    string
+		ActivationCode string
+	}
+	err := db.Raw(`SELECT key_stream_id, activation_code FROM keys
+		GROUP BY key_stream_id, activation_code HAVING count(*) > 1
+		ORDER BY key_stream_id, activation_code`).Scan(&duplicates).Error
+	if err != nil {
+		return errors.Wrap(err, "Find duplicated keys")
+	}
+	if len(duplicates) > 0 {
+		codes := make([]string, 0, len(duplicates))
+		for _, duplicate := range duplicates {
+			codes = append(codes, fmt.Sprintf("%s/%s", duplicate.KeyStreamID, duplicate.ActivationCode))
+		}
+		return errors.Errorf("Keys with duplicated activation codes were already used, resolve them before migration: %s", strings.Join(codes, ", "))
+	}
+
+	return nil
+}
+
+// seedCurrencyRates fills empty currency rates storage with bundled table
+func seedCurrencyRates(db *gorm.DB) error {
+	count := 0
+	if err := db.Model(&model.CurrencyRate{}).Count(&count).Error; err != nil {
+		return errors.Wrap(err, "Count currency rates")
+	}
+	if count > 0 {
+		return nil
+	}
+
+	transaction := db.Begin()
+	now := time.Now()
+	for _, rate := range defaultCurrencyRates {
+		rate.UpdatedAt = now
+		if err := transaction.Create(&rate).Error; err != nil {
+			transaction.Rollback()
+			return errors.Wrap(err, "Seed currency rates")
+		}
+	}
+
+	return transaction.Commit().Error
+}
+
+// seedTaxRates fills empty tax rates storage with bundled table
+func seedTaxRates(db *gorm.DB) error {
+	count := 0
+	if err := db.Model(&model.TaxRate{}).Count(&count).Error; err != nil {
+		return errors.Wrap(err, "Count tax rates")
+	}
+	if count > 0 {
+		return nil
+	}
+
+	transaction := db.Begin()
+	now := time.Now()
+	for _, rate := range defaultTaxRates {
+		rate.UpdatedAt = now
+		if err := transaction.Create(&rate).Error; err != nil {
+			transaction.Rollback()
+			return errors.Wrap(err, "Seed tax rates")
+		}
+	}
+
+	return transaction.Commit().Error
+}
+
+// pendingReviewIndex allows only one pending review of game, it backs the check of SendToReview
+const pendingReviewIndex = "idx_game_reviews_pending"
+
+// addPendingReviewIndex creates partial unique index on pending reviews of game. Concurrent submissions could
+// leave several pending reviews before the index, all of them except the latest one are archived.
+func addPendingReviewIndex(db *gorm.DB) error {
+	if db.Dialect().HasIndex("game_reviews", pendingReviewIndex) {
+		return nil
+	}
+
+	pending := []model.ReviewStatus{model.ReviewNew, model.ReviewChecking}
+	result := db.Exec(`UPDATE game_reviews r SET status = ? WHERE r.status IN (?) AND EXISTS (
+		SELECT 1 FROM game_reviews l WHERE l.game_id = r.game_id AND l.status IN (?) AND (l.created_at, l.id) > (r.created_at, r.id))`,
+		model.ReviewArchived, pending, pending)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "Archive duplicated pending reviews")
+	}
+	if result.RowsAffected > 0 {
+		zap.L().Warn("Duplicated pending reviews archived", zap.Int64("count", result.RowsAffected))
+	}
+
+	err := db.Exec(fmt.Sprintf("CREATE UNIQUE INDEX %s ON game_reviews (game_id) WHERE status IN (%d, %d)",
+		pendingReviewIndex, model.ReviewNew, model.ReviewChecking)).Error
+	if err != nil {
+		return errors.Wrap(err, "Create index of pending reviews")
+	}
+	return nil
+}
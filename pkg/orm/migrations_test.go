@@ -0,0 +1,95 @@
+package orm
+
+import (
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"qilin-api/pkg/model"
+	qilintest "qilin-api/pkg/test"
+	"testing"
+	"time"
+)
+
+type MigrationsTestSuite struct {
+	suite.Suite
+	db *Database
+}
+
+func Test_Migrations(t *testing.T) {
+	suite.Run(t, new(MigrationsTestSuite))
+}
+
+func (suite *MigrationsTestSuite) SetupTest() {
+	shouldBe := require.New(suite.T())
+	config, err := qilintest.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+	db, err := NewDatabase(&config.Database)
+	if err != nil {
+		suite.FailNow("Unable to connect to database", "%v", err)
+	}
+
+	shouldBe.Nil(db.DropAllTables())
+	shouldBe.Nil(db.Init())
+	suite.db = db
+}
+
+func (suite *MigrationsTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *MigrationsTestSuite) createKey(streamId uuid.UUID, code string, created time.Time, redeemTime *time.Time) *model.Key {
+	key := &model.Key{KeyStreamID: streamId, ActivationCode: code, RedeemTime: redeemTime}
+	key.ID = uuid.NewV4()
+	key.CreatedAt = created
+	require.Nil(suite.T(), suite.db.DB().Create(key).Error)
+	return key
+}
+
+func (suite *MigrationsTestSuite) TestDedupeKeys() {
+	shouldBe := require.New(suite.T())
+	shouldBe.Nil(suite.db.DB().Exec("DROP INDEX key_stream_activation_code").Error)
+
+	streamId := uuid.NewV4()
+	now := time.Now()
+	oldest := suite.createKey(streamId, "FREE", now.Add(-time.Hour), nil)
+	suite.createKey(streamId, "FREE", now, nil)
+	suite.createKey(streamId, "REDEEMED", now.Add(-time.Hour), nil)
+	redeemed := suite.createKey(streamId, "REDEEMED", now, &now)
+	another := suite.createKey(uuid.NewV4(), "FREE", now, nil)
+
+	shouldBe.Nil(suite.db.Init())
+
+	keys := []model.Key{}
+	shouldBe.Nil(suite.db.DB().Order("activation_code").Find(&keys).Error)
+	shouldBe.Len(keys, 3)
+	ids := []uuid.UUID{keys[0].ID, keys[1].ID, keys[2].ID}
+	shouldBe.Contains(ids, oldest.ID, "The oldest free key is kept")
+	shouldBe.Contains(ids, redeemed.ID, "Redeemed key is kept")
+	shouldBe.Contains(ids, another.ID, "Code of another stream isn't duplicate")
+	shouldBe.True(suite.db.DB().Dialect().HasIndex("keys", "key_stream_activation_code"))
+}
+
+func (suite *MigrationsTestSuite) TestDedupeUsedKeys() {
+	shouldBe := require.New(suite.T())
+	shouldBe.Nil(suite.db.DB().Exec("DROP INDEX key_stream_activation_code").Error)
+
+	streamId := uuid.NewV4()
+	now := time.Now()
+	suite.createKey(streamId, "REDEEMED", now.Add(-time.Hour), &now)
+	suite.createKey(streamId, "REDEEMED", now, &now)
+
+	err := suite.db.Init()
+	shouldBe.NotNil(err, "Used duplicates must be reported")
+	shouldBe.Contains(err.Error(), streamId.String()+"/REDEEMED")
+
+	count := 0
+	shouldBe.Nil(suite.db.DB().Model(model.Key{}).Count(&count).Error)
+	shouldBe.Equal(2, count)
+}
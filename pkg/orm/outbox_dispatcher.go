@@ -0,0 +1,119 @@
+package orm
+
+import (
+	"github.com/gogo/protobuf/proto"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/streadway/amqp"
+	"go.uber.org/zap"
+	"qilin-api/pkg/model"
+	"time"
+)
+
+const outboxBatchSize = 100
+
+// MessageBroker is part of rabbitmq.Broker used for delivery of events
+type MessageBroker interface {
+	Publish(topic string, msg proto.Message, h amqp.Table) error
+}
+
+// OutboxDispatcher delivers pending events from outbox to broker
+type OutboxDispatcher struct {
+	db          *gorm.DB
+	broker      MessageBroker
+	maxAttempts int
+	retryDelay  time.Duration
+}
+
+// outboxMessage is already serialized payload of event, it's passed to broker as is
+type outboxMessage []byte
+
+func (m *outboxMessage) Marshal() ([]byte, error) { return *m, nil }
+func (m *outboxMessage) Reset()                   { *m = nil }
+func (m *outboxMessage) String() string           { return string(*m) }
+func (*outboxMessage) ProtoMessage()              {}
+
+func NewOutboxDispatcher(db *Database, broker MessageBroker, maxAttempts int, retryDelay time.Duration) *OutboxDispatcher {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &OutboxDispatcher{db: db.database, broker: broker, maxAttempts: maxAttempts, retryDelay: retryDelay}
+}
+
+// Dispatch delivers batch of due events and returns number of delivered ones. Events are locked until
+// the end of batch, so several dispatchers can work with the same outbox.
+func (d *OutboxDispatcher) Dispatch(t time.Time) (int, error) {
+	transaction := d.db.Begin()
+
+	events := []model.OutboxEvent{}
+	err := transaction.
+		Set("gorm:query_option", "FOR UPDATE SKIP LOCKED").
+		Where("status = ? and next_attempt_at <= ?", model.OutboxPending, t).
+		Order("created_at").
+		Limit(outboxBatchSize).
+		Find(&events).Error
+	if err != nil {
+		transaction.Rollback()
+		return 0, errors.Wrap(err, "Fetch pending events")
+	}
+
+	delivered := 0
+	for i := range events {
+		event := &events[i]
+		payload := outboxMessage(event.Payload)
+		err := d.broker.Publish(event.Topic, &payload, outboxHeaders(event))
+		if err == nil {
+			event.Deliver(t)
+			delivered++
+		} else {
+			event.Fail(err, t, d.maxAttempts, d.retryDelay)
+			if event.Status == model.OutboxFailed {
+				zap.L().Error("Event is moved to dead letters", zap.String("event", event.ID.String()), zap.String("topic", event.Topic), zap.Error(err))
+			}
+		}
+
+		if err := transaction.Save(event).Error; err != nil {
+			transaction.Rollback()
+			return 0, errors.Wrap(err, "Save event")
+		}
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return 0, errors.Wrap(err, "Commit events")
+	}
+
+	return delivered, nil
+}
+
+// RunOutboxDispatcher delivers events by ticker until stop channel is closed
+func RunOutboxDispatcher(dispatcher *OutboxDispatcher, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case t := <-ticker.C:
+			delivered, err := dispatcher.Dispatch(t.UTC())
+			if err != nil {
+				zap.L().Error("Outbox dispatch failed", zap.Error(err))
+			}
+			if delivered > 0 {
+				zap.L().Info("Outbox events delivered", zap.Int("count", delivered))
+			}
+		}
+	}
+}
+
+// outboxHeaders contains metadata of event, so consumers are able to order and deduplicate events of any encoding
+func outboxHeaders(event *model.OutboxEvent) amqp.Table {
+	return amqp.Table{
+		"event_id":       event.ID.String(),
+		"aggregate_id":   event.AggregateID.String(),
+		"sequence":       event.Sequence,
+		"correlation_id": event.CorrelationID.String(),
+		"schema_version": int32(event.SchemaVersion),
+		"encoding":       event.Encoding,
+	}
+}
@@ -0,0 +1,193 @@
+package orm_test
+
+import (
+	"errors"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"qilin-api/pkg/api/mock"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"qilin-api/pkg/test"
+	"testing"
+	"time"
+)
+
+type outboxDispatcherTestSuite struct {
+	suite.Suite
+	db         *orm.Database
+	broker     *mock.Broker
+	dispatcher *orm.OutboxDispatcher
+	service    model.OutboxService
+}
+
+func Test_OutboxDispatcher(t *testing.T) {
+	suite.Run(t, new(outboxDispatcherTestSuite))
+}
+
+func (suite *outboxDispatcherTestSuite) SetupTest() {
+	config, err := qilin_test.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+
+	db, err := orm.NewDatabase(&config.Database)
+	if err != nil {
+		suite.Fail("Unable to connect to database:", "%v", err)
+	}
+
+	if err := db.DropAllTables(); err != nil {
+		assert.FailNow(suite.T(), "Unable to drop tables", err)
+	}
+	if err := db.Init(); err != nil {
+		assert.FailNow(suite.T(), "Unable to init tables", err)
+	}
+
+	suite.db = db
+	suite.broker = mock.NewBroker()
+	suite.dispatcher = orm.NewOutboxDispatcher(db, suite.broker, 2, time.Second)
+	suite.service = orm.NewOutboxService(db)
+}
+
+func (suite *outboxDispatcherTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *outboxDispatcherTestSuite) enqueue() *model.OutboxEvent {
+	should := require.New(suite.T())
+
+	should.Nil(orm.NewEventBus(suite.db.DB()).PublishGameDelete(uuid.NewV4()))
+
+	events, count, err := suite.service.GetEvents(model.OutboxPending, "game_deleted", 0, 10)
+	should.Nil(err)
+	should.Equal(1, count)
+
+	return &events[0]
+}
+
+func (suite *outboxDispatcherTestSuite) TestDispatch() {
+	should := require.New(suite.T())
+
+	event := suite.enqueue()
+
+	delivered, err := suite.dispatcher.Dispatch(time.Now().UTC())
+	should.Nil(err)
+	should.Equal(1, delivered)
+
+	messages := suite.broker.Messages()
+	should.Len(messages, 1)
+	should.Equal("game_deleted", messages[0].Topic)
+	should.Equal(event.Payload, messages[0].Body)
+	should.Equal(event.ID.String(), messages[0].Headers["event_id"])
+
+	event, err = suite.service.Get(event.ID)
+	should.Nil(err)
+	should.Equal(model.OutboxDelivered, event.Status)
+	should.NotNil(event.DeliveredAt)
+
+	delivered, err = suite.dispatcher.Dispatch(time.Now().UTC())
+	should.Nil(err)
+	should.Equal(0, delivered, "Delivered event must not be sent twice")
+}
+
+func (suite *outboxDispatcherTestSuite) TestRetryAndDeadLetter() {
+	should := require.New(suite.T())
+
+	event := suite.enqueue()
+	suite.broker.Err = errors.New("connection refused")
+
+	now := time.Now().UTC()
+	delivered, err := suite.dispatcher.Dispatch(now)
+	should.Nil(err)
+	should.Equal(0, delivered)
+
+	event, err = suite.service.Get(event.ID)
+	should.Nil(err)
+	should.Equal(model.OutboxPending, event.Status)
+	should.Equal(1, event.Attempts)
+	should.Equal("connection refused", event.LastError)
+	should.True(event.NextAttemptAt.After(now))
+
+	delivered, err = suite.dispatcher.Dispatch(now)
+	should.Nil(err)
+	should.Equal(0, delivered)
+	event, err = suite.service.Get(event.ID)
+	should.Nil(err)
+	should.Equal(1, event.Attempts, "Event must not be retried before delay")
+
+	_, err = suite.dispatcher.Dispatch(now.Add(time.Minute))
+	should.Nil(err)
+	event, err = suite.service.Get(event.ID)
+	should.Nil(err)
+	should.Equal(model.OutboxFailed, event.Status)
+	should.Equal(2, event.Attempts)
+
+	suite.broker.Err = nil
+	delivered, err = suite.dispatcher.Dispatch(now.Add(time.Hour))
+	should.Nil(err)
+	should.Equal(0, delivered, "Dead letter must not be delivered")
+
+	event, err = suite.service.Replay(event.ID)
+	should.Nil(err)
+	should.Equal(model.OutboxPending, event.Status)
+	should.Equal(0, event.Attempts)
+
+	_, err = suite.service.Replay(event.ID)
+	should.NotNil(err)
+	should.Equal(http.StatusConflict, err.(*orm.ServiceError).Code)
+
+	delivered, err = suite.dispatcher.Dispatch(time.Now().UTC().Add(time.Minute))
+	should.Nil(err)
+	should.Equal(1, delivered)
+	should.Len(suite.broker.Messages(), 1)
+}
+
+func (suite *outboxDispatcherTestSuite) TestReplayFailed() {
+	should := require.New(suite.T())
+
+	suite.enqueue()
+	suite.broker.Err = errors.New("connection refused")
+	dispatcher := orm.NewOutboxDispatcher(suite.db, suite.broker, 1, time.Second)
+	_, err := dispatcher.Dispatch(time.Now().UTC())
+	should.Nil(err)
+
+	_, count, err := suite.service.GetEvents(model.OutboxFailed, "", 0, 10)
+	should.Nil(err)
+	should.Equal(1, count)
+
+	replayed, err := suite.service.ReplayFailed("game_changed")
+	should.Nil(err)
+	should.Equal(0, replayed, "Events of other topics must not be replayed")
+
+	replayed, err = suite.service.ReplayFailed("")
+	should.Nil(err)
+	should.Equal(1, replayed)
+
+	_, count, err = suite.service.GetEvents(model.OutboxPending, "", 0, 10)
+	should.Nil(err)
+	should.Equal(1, count)
+}
+
+func (suite *outboxDispatcherTestSuite) TestRolledBackTransaction() {
+	should := require.New(suite.T())
+
+	transaction := suite.db.DB().Begin()
+	should.Nil(orm.NewEventBus(suite.db.DB()).WithTransaction(transaction).PublishGameDelete(uuid.NewV4()))
+	transaction.Rollback()
+
+	_, count, err := suite.service.GetEvents(model.OutboxUndefined, "", 0, 10)
+	should.Nil(err)
+	should.Equal(0, count, "Event of rolled back change must not be saved")
+
+	_, err = suite.service.Get(uuid.NewV4())
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+}
@@ -0,0 +1,86 @@
+package orm
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/model"
+	"time"
+)
+
+type outboxService struct {
+	db *gorm.DB
+}
+
+func NewOutboxService(db *Database) model.OutboxService {
+	return &outboxService{db: db.database}
+}
+
+func (s *outboxService) GetEvents(status model.OutboxStatus, topic string, offset, limit int) ([]model.OutboxEvent, int, error) {
+	query := s.db.Model(&model.OutboxEvent{})
+	if status != model.OutboxUndefined {
+		query = query.Where("status = ?", status)
+	}
+	if topic != "" {
+		query = query.Where("topic = ?", topic)
+	}
+
+	count := 0
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Counting events"))
+	}
+
+	events := []model.OutboxEvent{}
+	if err := query.Order("created_at desc").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
+		return nil, 0, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch events"))
+	}
+
+	return events, count, nil
+}
+
+func (s *outboxService) Get(id uuid.UUID) (*model.OutboxEvent, error) {
+	event := &model.OutboxEvent{}
+	err := s.db.Where("id = ?", id).First(event).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, NewServiceErrorf(http.StatusNotFound, "Event `%s` not found", id)
+	} else if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch event"))
+	}
+	return event, nil
+}
+
+func (s *outboxService) Replay(id uuid.UUID) (*model.OutboxEvent, error) {
+	event, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status != model.OutboxFailed {
+		return nil, NewServiceErrorf(http.StatusConflict, "Event has status `%s`, only failed events can be replayed", event.Status.ToString())
+	}
+
+	event.Replay(time.Now().UTC())
+	if err := s.db.Save(event).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Save event"))
+	}
+
+	return event, nil
+}
+
+func (s *outboxService) ReplayFailed(topic string) (int, error) {
+	query := s.db.Model(&model.OutboxEvent{}).Where("status = ?", model.OutboxFailed)
+	if topic != "" {
+		query = query.Where("topic = ?", topic)
+	}
+
+	res := query.UpdateColumns(map[string]interface{}{
+		"status":          model.OutboxPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now().UTC(),
+	})
+	if res.Error != nil {
+		return 0, NewServiceError(http.StatusInternalServerError, errors.Wrap(res.Error, "Replay failed events"))
+	}
+
+	return int(res.RowsAffected), nil
+}
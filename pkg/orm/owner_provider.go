@@ -73,17 +73,30 @@ func (provider *ownerProvider) GetOwnerForPackage(packageId uuid.UUID) (string,
 
 func (provider *ownerProvider) GetOwnerForBundle(bundleId uuid.UUID) (string, error) {
 	vendor := model.Vendor{}
-	bundle := model.StoreBundle{}
-	err := provider.db.DB().Model(&model.StoreBundle{}).Where("id = ?", bundleId).First(&bundle).Error
-
+	entry := model.BundleEntry{}
+	err := provider.db.DB().Where("entry_id = ?", bundleId).First(&entry).Error
 	if err != nil {
 		if gorm.IsRecordNotFoundError(err) {
 			return "", NewServiceErrorf(http.StatusNotFound, "Bundle `%s` not found", bundleId)
 		}
+		return "", NewServiceError(http.StatusInternalServerError, errors.Wrapf(err, "Get bundle entry"))
+	}
+
+	var bundleModel interface{} = &model.StoreBundle{}
+	if entry.EntryType == model.BundleLootbox {
+		bundleModel = &model.LootboxBundle{}
+	}
+
+	var vendorIds []uuid.UUID
+	err = provider.db.DB().Model(bundleModel).Where("id = ?", bundleId).Pluck("vendor_id", &vendorIds).Error
+	if err != nil {
 		return "", NewServiceError(http.StatusInternalServerError, errors.Wrapf(err, "Get bundle"))
 	}
+	if len(vendorIds) == 0 {
+		return "", NewServiceErrorf(http.StatusNotFound, "Bundle `%s` not found", bundleId)
+	}
 
-	if err := provider.db.DB().Model(&model.Vendor{}).Where("id = ?", bundle.VendorID).First(&vendor).Error; err != nil {
+	if err := provider.db.DB().Model(&model.Vendor{}).Where("id = ?", vendorIds[0]).First(&vendor).Error; err != nil {
 		if gorm.IsRecordNotFoundError(err) {
 			return "", NewServiceErrorf(http.StatusNotFound, "Vendor `%s` not found", bundleId)
 		}
@@ -71,6 +71,11 @@ func createPackage(
 		}
 	}
 
+	err = NewEventBus(transaction).Publish(model.PackageCreated{PackageEventState: model.NewPackageEventState(&newPack), Products: products})
+	if err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish package creation"))
+	}
+
 	return
 }
 
@@ -158,11 +163,12 @@ func (p *packageService) AddProducts(packageId uuid.UUID, prods []uuid.UUID) (re
 	}
 
 	exists := []model.PackageProduct{}
-	err = p.db.Where("package_id = ?", packageId).Find(&exists).Error
+	err = p.db.Where("package_id = ?", packageId).Order("position").Find(&exists).Error
 	if err != nil {
 		return nil, errors.Wrap(err, "Fetch package contents")
 	}
 
+	added := []uuid.UUID{}
 	position := len(exists) + 1
 	transaction := p.db.Begin()
 	defer func() {
@@ -189,6 +195,23 @@ func (p *packageService) AddProducts(packageId uuid.UUID, prods []uuid.UUID) (re
 				transaction.Rollback()
 				return nil, errors.Wrap(err, "Make package product link")
 			}
+			added = append(added, prodId)
+		}
+	}
+	if len(added) > 0 {
+		products := []uuid.UUID{}
+		for _, exist := range exists {
+			products = append(products, exist.ProductID)
+		}
+		err = NewEventBus(transaction).Publish(model.PackageProductsChanged{
+			PackageID: packageId,
+			Added:     added,
+			Removed:   []uuid.UUID{},
+			Products:  append(products, added...),
+		})
+		if err != nil {
+			transaction.Rollback()
+			return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish package products"))
 		}
 	}
 	err = transaction.Commit().Error
@@ -209,7 +232,7 @@ func (p *packageService) RemoveProducts(packageId uuid.UUID, prods []uuid.UUID)
 
 	// 2. Filter for reject unexist's and default products
 	exists := []model.PackageProduct{}
-	err = p.db.Where("package_id = ?", packageId).Find(&exists).Error
+	err = p.db.Where("package_id = ?", packageId).Order("position").Find(&exists).Error
 	if err != nil {
 		return nil, errors.Wrap(err, "Fetch package contents")
 	}
@@ -228,10 +251,41 @@ func (p *packageService) RemoveProducts(packageId uuid.UUID, prods []uuid.UUID)
 
 	// 3. Actual remove products from package (if any)
 	if len(prods) > 0 {
-		err = p.db.Delete(model.PackageProduct{}, "package_id = ? and product_id in (?)", packageId, prods).Error
+		transaction := p.db.Begin()
+		err = transaction.Delete(model.PackageProduct{}, "package_id = ? and product_id in (?)", packageId, prods).Error
 		if err != nil {
+			transaction.Rollback()
 			return nil, errors.Wrap(err, "Delete package products")
 		}
+
+		products := []uuid.UUID{}
+		for _, exist := range exists {
+			removed := false
+			for _, prodID := range prods {
+				if prodID == exist.ProductID {
+					removed = true
+					break
+				}
+			}
+			if !removed {
+				products = append(products, exist.ProductID)
+			}
+		}
+		err = NewEventBus(transaction).Publish(model.PackageProductsChanged{
+			PackageID: packageId,
+			Added:     []uuid.UUID{},
+			Removed:   prods,
+			Products:  products,
+		})
+		if err != nil {
+			transaction.Rollback()
+			return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish package products"))
+		}
+
+		err = transaction.Commit().Error
+		if err != nil {
+			return nil, errors.Wrap(err, "Commit remove products")
+		}
 	} else {
 		return nil, NewServiceError(http.StatusUnprocessableEntity, "No any products for remove")
 	}
@@ -272,7 +326,11 @@ func (p *packageService) Get(packageId uuid.UUID) (result *model.Package, err er
 				}
 				prods = append(prods, game)
 			} else if prod.EntryType == model.ProductDLC {
-				return nil, NewServiceError(http.StatusNotImplemented, "Retrieve DLC is not implemented yet")
+				dlc, err := getDlcProduct(p.db, prod.ProductID)
+				if err != nil {
+					return nil, errors.Wrap(err, "Fetch dlc for package")
+				}
+				prods = append(prods, dlc)
 			}
 		}
 	}
@@ -291,10 +349,15 @@ func (p *packageService) Get(packageId uuid.UUID) (result *model.Package, err er
 func (p *packageService) GetList(
 	userId string,
 	vendorId uuid.UUID,
-	query, sort string,
+	query, sort, country string,
 	offset, limit int,
 	filterFunc model.PackageListingFilter,
 ) (total int, result []model.Package, err error) {
+	if country != "" {
+		if err := checkCountry(country); err != nil {
+			return 0, nil, err
+		}
+	}
 
 	user := model.User{}
 	err = p.db.Select("lang").Where("id = ?", userId).First(&user).Error
@@ -334,12 +397,15 @@ func (p *packageService) GetList(
 		// TODO: Add another kinds for searching
 	}
 
+	listQuery := whereAvailablePackage(p.db.
+		Model(model.Package{}).
+		Where(`vendor_id = ?`, vendorId).
+		Where(strings.Join(conds, " or "), vals...), country)
+
 	if filterFunc != nil {
 		vendorPackages := []model.Package{}
-		err = p.db.
+		err = listQuery.
 			Select("id").
-			Where(`vendor_id = ?`, vendorId).
-			Where(strings.Join(conds, " or "), vals...).
 			Find(&vendorPackages).Error
 		if err != nil {
 			return 0, nil, errors.Wrap(err, "Fetch package ids")
@@ -377,10 +443,7 @@ func (p *packageService) GetList(
 			}
 		}
 	} else {
-		err = p.db.
-			Model(model.Package{}).
-			Where(`vendor_id = ?`, vendorId).
-			Where(strings.Join(conds, " or "), vals...).
+		err = listQuery.
 			Order(orderBy).
 			Limit(limit).
 			Offset(offset).
@@ -388,10 +451,7 @@ func (p *packageService) GetList(
 		if err != nil {
 			return 0, nil, errors.Wrap(err, "Fetch package list")
 		}
-		err = p.db.
-			Model(model.Package{}).
-			Where(`vendor_id = ?`, vendorId).
-			Where(strings.Join(conds, " or "), vals...).
+		err = listQuery.
 			Count(&total).Error
 		if err != nil {
 			return 0, nil, errors.Wrap(err, "Fetch package total")
@@ -420,10 +480,21 @@ func (p *packageService) Update(pkg *model.Package) (*model.Package, error) {
 	pkg.DefaultProductID = exist.DefaultProductID
 	// Products also ignored
 
-	err = p.db.Save(pkg).Error
+	transaction := p.db.Begin()
+	err = transaction.Save(pkg).Error
 	if err != nil {
+		transaction.Rollback()
 		return nil, errors.Wrap(err, "Save package")
 	}
+	err = NewEventBus(transaction).Publish(model.PackageUpdated{PackageEventState: model.NewPackageEventState(pkg)})
+	if err != nil {
+		transaction.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish package update"))
+	}
+	err = transaction.Commit().Error
+	if err != nil {
+		return nil, errors.Wrap(err, "Commit package")
+	}
 	return p.Get(pkg.ID)
 }
 
@@ -438,9 +509,105 @@ func (p *packageService) Remove(packageId uuid.UUID) (err error) {
 		return NewServiceError(http.StatusForbidden, "Package is default")
 	}
 
-	err = p.db.Delete(exist).Error
+	transaction := p.db.Begin()
+	err = transaction.Delete(exist).Error
 	if err != nil {
+		transaction.Rollback()
 		return errors.Wrap(err, "Delete package")
 	}
-	return
+	err = NewEventBus(transaction).Publish(model.PackageRemoved{PackageID: exist.ID, VendorID: exist.VendorID})
+	if err != nil {
+		transaction.Rollback()
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish package removal"))
+	}
+	return transaction.Commit().Error
+}
+
+// getPackages loads packages with their products and prices at once, packages are returned in order of ids
+// and missing packages are skipped
+func getPackages(db *gorm.DB, packageIds []uuid.UUID) ([]model.Package, error) {
+	result := []model.Package{}
+	if len(packageIds) == 0 {
+		return result, nil
+	}
+
+	packages := []model.Package{}
+	if err := db.Where("id in (?)", packageIds).Find(&packages).Error; err != nil {
+		return nil, errors.Wrap(err, "Retrieve packages")
+	}
+
+	prices := []model.Price{}
+	if err := db.Where("base_price_id in (?)", packageIds).Find(&prices).Error; err != nil {
+		return nil, errors.Wrap(err, "Fetch prices for packages")
+	}
+
+	type PackageProductJoin struct {
+		model.PackageProduct
+		EntryType model.ProductType
+	}
+	pkgProds := []PackageProductJoin{}
+	err := db.
+		Table("package_products").
+		Select("package_products.*, products.entry_type").
+		Where("package_id in (?)", packageIds).
+		Joins("left join products on package_products.product_id = products.entry_id").
+		Order("position asc").
+		Find(&pkgProds).
+		Error
+	if err != nil {
+		return nil, errors.Wrap(err, "Fetch packages contents")
+	}
+
+	gameIds := []uuid.UUID{}
+	dlcIds := []uuid.UUID{}
+	for _, prod := range pkgProds {
+		if prod.EntryType == model.ProductGame {
+			gameIds = append(gameIds, prod.ProductID)
+		} else if prod.EntryType == model.ProductDLC {
+			dlcIds = append(dlcIds, prod.ProductID)
+		}
+	}
+	products := map[uuid.UUID]model.Product{}
+	if len(gameIds) > 0 {
+		games := []model.ProductGameImpl{}
+		if err := db.Where("id in (?)", gameIds).Find(&games).Error; err != nil {
+			return nil, errors.Wrap(err, "Fetch games for packages")
+		}
+		for i := range games {
+			products[games[i].Game.ID] = &games[i]
+		}
+	}
+	if len(dlcIds) > 0 {
+		dlcs := []model.Dlc{}
+		if err := db.Where("id in (?)", dlcIds).Find(&dlcs).Error; err != nil {
+			return nil, errors.Wrap(err, "Fetch dlcs for packages")
+		}
+		for i := range dlcs {
+			products[dlcs[i].ID] = &dlcs[i]
+		}
+	}
+
+	byId := map[uuid.UUID]*model.Package{}
+	for i := range packages {
+		packages[i].Products = []model.Product{}
+		packages[i].Prices = []model.Price{}
+		byId[packages[i].ID] = &packages[i]
+	}
+	for _, price := range prices {
+		if pkg, ok := byId[price.BasePriceID]; ok {
+			pkg.Prices = append(pkg.Prices, price)
+		}
+	}
+	for _, prod := range pkgProds {
+		if product, ok := products[prod.ProductID]; ok {
+			byId[prod.PackageID].Products = append(byId[prod.PackageID].Products, product)
+		}
+	}
+
+	for _, id := range packageIds {
+		if pkg, ok := byId[id]; ok {
+			result = append(result, *pkg)
+		}
+	}
+	return result, nil
 }
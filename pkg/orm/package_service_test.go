@@ -5,6 +5,7 @@ import (
 	"github.com/labstack/gommon/random"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"net/http"
 	"qilin-api/pkg/api/mock"
 	"qilin-api/pkg/model"
 	"qilin-api/pkg/model/utils"
@@ -12,6 +13,7 @@ import (
 	"qilin-api/pkg/test"
 	"testing"
 
+	"github.com/lib/pq"
 	"github.com/satori/go.uuid"
 
 	"github.com/stretchr/testify/suite"
@@ -112,18 +114,18 @@ func (suite *packageServiceTestSuite) TestPackages() {
 	should.NotNil(err)
 	should.Nil(pkgEmpty)
 
-	total, list, err := suite.service.GetList(suite.userId, suite.vendorId, "", "-date", 0, 20, nil)
+	total, list, err := suite.service.GetList(suite.userId, suite.vendorId, "", "-date", "", 0, 20, nil)
 	should.Nil(err)
 	should.Equal(3, total)
 	should.Equal(3, len(list)) // includes 2 default game packages
 	should.Equal("Mega package", list[0].Name.EN)
 
-	total, list2, err := suite.service.GetList(suite.userId, suite.vendorId, "", "+name", 1, 1, nil)
+	total, list2, err := suite.service.GetList(suite.userId, suite.vendorId, "", "+name", "", 1, 1, nil)
 	should.Nil(err)
 	should.Equal(1, len(list2))
 	should.Equal("GameB", list2[0].Name.EN)
 
-	total, list3, err := suite.service.GetList(suite.userId, suite.vendorId, "", "-date", 0, 20, func(packageId uuid.UUID) (bool, error) {
+	total, list3, err := suite.service.GetList(suite.userId, suite.vendorId, "", "-date", "", 0, 20, func(packageId uuid.UUID) (bool, error) {
 		return packageId != pkg.ID, nil
 	})
 	should.Nil(err)
@@ -132,7 +134,7 @@ func (suite *packageServiceTestSuite) TestPackages() {
 	should.Equal("GameB", list3[0].Name.EN)
 	should.Equal("GameA", list3[1].Name.EN)
 
-	total, list5, err := suite.service.GetList(suite.userId, suite.vendorId, "", "-date", 1, 20, func(packageId uuid.UUID) (bool, error) {
+	total, list5, err := suite.service.GetList(suite.userId, suite.vendorId, "", "-date", "", 1, 20, func(packageId uuid.UUID) (bool, error) {
 		return packageId != pkg.ID, nil
 	})
 	should.Nil(err)
@@ -140,6 +142,23 @@ func (suite *packageServiceTestSuite) TestPackages() {
 	should.Equal(1, len(list5))
 	should.Equal("GameA", list5[0].Name.EN)
 
+	err = suite.db.DB().Model(&model.Package{}).Where("id = ?", pkg.ID).Update("denied_countries", pq.StringArray{"DE"}).Error
+	should.Nil(err)
+	err = suite.db.DB().Model(&model.Package{}).Where("id = ?", gameB.DefaultPackageID).Update("allowed_countries", pq.StringArray{"FR"}).Error
+	should.Nil(err)
+	total, list6, err := suite.service.GetList(suite.userId, suite.vendorId, "", "-date", "DE", 0, 1, nil)
+	should.Nil(err)
+	should.Equal(1, total, "Packages unavailable in country aren't counted")
+	should.Equal(1, len(list6))
+	should.Equal("GameA", list6[0].Name.EN)
+	total, _, err = suite.service.GetList(suite.userId, suite.vendorId, "", "-date", "FR", 0, 20, nil)
+	should.Nil(err)
+	should.Equal(3, total)
+
+	_, _, err = suite.service.GetList(suite.userId, suite.vendorId, "", "-date", "XX", 0, 20, nil)
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
 	gameC, err := suite.gameService.Create(suite.userId, suite.vendorId, "GameC")
 	should.Nil(err)
 
@@ -6,20 +6,24 @@ import (
 	"github.com/pkg/errors"
 	uuid "github.com/satori/go.uuid"
 	"go.uber.org/zap"
-	"math/rand"
 	"net/http"
 	"qilin-api/pkg/model"
+	"strings"
 	"time"
 )
 
+// codeGenerationAttempts is count of tries to generate unique code, collision means that format is close to exhaustion
+const codeGenerationAttempts = 10
+
 type platformKeyProvider struct {
 	db       *Database
 	streamId uuid.UUID
+	format   model.KeyCodeFormat
 }
 
 func (provider *platformKeyProvider) Redeem() (model.Key, error) {
 	transaction := provider.db.DB().Begin()
-	key, err := redeem(provider.streamId, transaction)
+	key, err := redeem(provider.streamId, provider.format, transaction)
 	if err != nil {
 		transaction.Rollback()
 		return key, err
@@ -28,53 +32,112 @@ func (provider *platformKeyProvider) Redeem() (model.Key, error) {
 	return key, transaction.Commit().Error
 }
 
-func generateCode() string {
-	// Example: AAAA-BBBB-CCCC-DDDD
-	return fmt.Sprintf("%s-%s-%s-%s", RandStringRunes(4), RandStringRunes(4), RandStringRunes(4), RandStringRunes(4))
-}
-
-func redeem(streamId uuid.UUID, transaction *gorm.DB) (model.Key, error) {
+// redeem creates new redeemed key. Uniqueness of code is guaranteed by unique index on stream and activation code.
+func redeem(streamId uuid.UUID, format model.KeyCodeFormat, transaction *gorm.DB) (model.Key, error) {
+	t := time.Now().UTC()
 	key := model.Key{
 		KeyStreamID: streamId,
+		RedeemTime:  &t,
 	}
 	key.ID = uuid.NewV4()
+	key.CreatedAt = t
+	key.UpdatedAt = t
 
-	infiniteLoopIndex := 0
-	for {
-		key.ActivationCode = generateCode()
-		t := time.Now().UTC()
-		key.RedeemTime = &t
-		var keyExist model.Key
-		err := transaction.Model(model.Key{}).Where("key_stream_id = ? AND activation_code = ?",
-			streamId,
-			key.ActivationCode).First(&keyExist).Error
+	for i := 0; i < codeGenerationAttempts; i++ {
+		code, err := generateCode(format)
 		if err != nil {
-			if gorm.IsRecordNotFoundError(err) {
-				break
-			}
-			return key, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Trying to check activation code before exist"))
+			return key, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Generate activation code"))
+		}
+		key.ActivationCode = code
+
+		result := transaction.Exec(`INSERT INTO keys (id, created_at, updated_at, key_stream_id, activation_code, redeem_time)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT (key_stream_id, activation_code) DO NOTHING`,
+			key.ID, key.CreatedAt, key.UpdatedAt, key.KeyStreamID, key.ActivationCode, key.RedeemTime)
+		if result.Error != nil {
+			return key, NewServiceError(http.StatusInternalServerError, errors.Wrap(result.Error, "Create key"))
 		}
 
-		if infiniteLoopIndex >= 100 {
-			return key, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Infinite loop break condition."))
+		if result.RowsAffected == 1 {
+			return key, nil
 		}
-		infiniteLoopIndex++
+
 		zap.L().Info(fmt.Sprintf("KeyStream `%s` already contains activation_code `%s`. Trying again", streamId, key.ActivationCode))
 	}
 
-	err := transaction.Model(model.Key{}).Create(&key).Error
-	if err != nil {
-		return key, err
+	return key, NewServiceErrorf(http.StatusInternalServerError, "Can't generate unique activation code for stream `%s`", streamId)
+}
+
+// issueKeys generates count keys of batch without redeeming them. Codes colliding with existing ones
+// are skipped by unique index and generated again.
+func issueKeys(transaction *gorm.DB, streamId uuid.UUID, format model.KeyCodeFormat, count int, batchId uuid.UUID) ([]model.Key, error) {
+	t := time.Now().UTC()
+	keys := make([]model.Key, 0, count)
+	collisions := 0
+
+	for len(keys) < count {
+		size := count - len(keys)
+		if size > keyInsertBatchSize {
+			size = keyInsertBatchSize
+		}
+
+		generated := make(map[string]model.Key, size)
+		values := make([]string, 0, size)
+		args := make([]interface{}, 0, size*6)
+		for len(generated) < size {
+			code, err := generateCode(format)
+			if err != nil {
+				return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Generate activation code"))
+			}
+			if _, ok := generated[code]; ok {
+				continue
+			}
+
+			key := model.Key{KeyStreamID: streamId, ActivationCode: code, BatchID: &batchId}
+			key.ID = uuid.NewV4()
+			key.CreatedAt = t
+			key.UpdatedAt = t
+			generated[code] = key
+
+			values = append(values, "(?, ?, ?, ?, ?, ?)")
+			args = append(args, key.ID, t, t, streamId, code, batchId)
+		}
+
+		rows, err := transaction.Raw("INSERT INTO keys (id, created_at, updated_at, key_stream_id, activation_code, batch_id) VALUES "+
+			strings.Join(values, ", ")+
+			" ON CONFLICT (key_stream_id, activation_code) DO NOTHING RETURNING activation_code", args...).Rows()
+		if err != nil {
+			return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Create keys"))
+		}
+
+		inserted := 0
+		for rows.Next() {
+			var code string
+			if err := rows.Scan(&code); err != nil {
+				rows.Close()
+				return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Create keys"))
+			}
+			keys = append(keys, generated[code])
+			inserted++
+		}
+		rows.Close()
+
+		if inserted < size {
+			collisions++
+			if collisions >= codeGenerationAttempts {
+				return nil, NewServiceErrorf(http.StatusInternalServerError, "Can't generate unique activation codes for stream `%s`", streamId)
+			}
+		}
 	}
 
-	return key, nil
+	return keys, nil
 }
 
 func (service *platformKeyProvider) RedeemList(count int) ([]model.Key, error) {
 	var keys []model.Key
 	transaction := service.db.DB().Begin()
 	for i := 0; i < count; i++ {
-		key, err := redeem(service.streamId, transaction)
+		key, err := redeem(service.streamId, service.format, transaction)
 		if err != nil {
 			transaction.Rollback()
 			return keys, err
@@ -96,24 +159,19 @@ func NewPlatformKeyProvider(keyStreamId uuid.UUID, database *Database) (model.Ke
 		if gorm.IsRecordNotFoundError(err) {
 			return nil, NewServiceErrorf(http.StatusNotFound, "Key stream with id `%s` not found", keyStreamId)
 		}
-		return nil,  NewServiceError(http.StatusInternalServerError, err)
+		return nil, NewServiceError(http.StatusInternalServerError, err)
 	}
 
 	if keyStream.Type != model.PlatformKeysStream {
 		return nil, NewServiceErrorf(http.StatusBadRequest, "Key stream with id `%s` has wrong type", keyStreamId)
 	}
 
-	return &platformKeyProvider{db: database, streamId: keyStreamId}, nil
-}
-
-var letterRunes = []rune("1234567890ABCDEFGHIJKLMNOPQRSTUVWXYZ")
-
-//RandStringRunes creates random string with specified length
-func RandStringRunes(n int) string {
-	b := make([]rune, n)
-	lenRunes := len(letterRunes)
-	for i := range b {
-		b[i] = letterRunes[rand.Intn(lenRunes)]
+	// Key package defines format of codes, stream without package uses default one
+	keyPackage := model.KeyPackage{}
+	err = database.DB().Select("code_format").Where("key_stream_id = ?", keyStreamId).First(&keyPackage).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return nil, NewServiceError(http.StatusInternalServerError, err)
 	}
-	return string(b)
+
+	return &platformKeyProvider{db: database, streamId: keyStreamId, format: keyPackage.CodeFormat.OrDefault()}, nil
 }
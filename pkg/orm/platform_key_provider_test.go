@@ -1,19 +1,49 @@
 package orm
 
 import (
+	"github.com/stretchr/testify/require"
+	"qilin-api/pkg/model"
+	"regexp"
+	"strings"
 	"testing"
 )
 
 func Test_generateCode(t *testing.T) {
+	shouldBe := require.New(t)
+	format := model.DefaultKeyCodeFormat()
 	codes := make(map[string]int)
-	for i := 0; i < 10000000; i++ {
-		code := generateCode()
+	for i := 0; i < 100000; i++ {
+		code, err := generateCode(format)
+		shouldBe.Nil(err)
 		codes[code]++
 	}
 
-	for _, code := range codes {
-		if code > 1 {
-			t.FailNow()
-		}
+	for _, count := range codes {
+		shouldBe.Equal(1, count)
 	}
-}
\ No newline at end of file
+}
+
+func Test_generateCodeFormat(t *testing.T) {
+	shouldBe := require.New(t)
+	format := model.KeyCodeFormat{
+		GroupCount:  3,
+		GroupLength: 5,
+		Alphabet:    "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+		Exclude:     "01OI",
+		Prefix:      "QLN",
+		Checksum:    true,
+	}
+	shouldBe.Nil(format.Validate())
+
+	pattern := regexp.MustCompile(`^QLN[2-9A-HJ-NP-Z]{5}-[2-9A-HJ-NP-Z]{5}-[2-9A-HJ-NP-Z]{6}$`)
+	for i := 0; i < 1000; i++ {
+		code, err := generateCode(format)
+		shouldBe.Nil(err)
+		shouldBe.Regexp(pattern, code)
+
+		payload := []rune(strings.Replace(strings.TrimPrefix(code, format.Prefix), model.KeyCodeSeparator, "", -1))
+		checksum, err := format.ChecksumRune(payload[:len(payload)-1])
+		shouldBe.Nil(err)
+		shouldBe.Equal(payload[len(payload)-1], checksum)
+	}
+}
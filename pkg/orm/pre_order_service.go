@@ -0,0 +1,315 @@
+package orm
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/utils"
+	"time"
+)
+
+type preOrderService struct {
+	db       *gorm.DB
+	eventBus model.EventBus
+}
+
+func NewPreOrderService(db *Database, eventBus model.EventBus) model.PreOrderService {
+	return &preOrderService{db: db.database, eventBus: eventBus}
+}
+
+func (s *preOrderService) Get(packageId uuid.UUID) (*model.PreOrder, error) {
+	if err := s.checkPackage(packageId); err != nil {
+		return nil, err
+	}
+
+	return s.get(packageId)
+}
+
+func (s *preOrderService) Update(preOrder *model.PreOrder) (*model.PreOrder, error) {
+	exist, err := s.Get(preOrder.PackageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if preOrder.Enabled && !preOrder.DateStart.Before(exist.ReleaseDate) {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, "Pre-order must start before release date")
+	}
+
+	currencies := map[string]bool{}
+	for _, price := range preOrder.Prices {
+		if !utils.IsCurrency(price.Currency) {
+			return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Wrong currency %s", price.Currency)
+		}
+		if currencies[price.Currency] {
+			return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Duplicate price in %s", price.Currency)
+		}
+		if price.Price < 0 {
+			return nil, NewServiceError(http.StatusUnprocessableEntity, "Price must not be negative")
+		}
+		currencies[price.Currency] = true
+	}
+
+	if err := s.checkBonusProducts(preOrder.PackageID, preOrder.BonusProducts); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	preOrder.CreatedAt = exist.CreatedAt
+	preOrder.UpdatedAt = now
+	// Package goes back to pre-order if release is postponed
+	preOrder.ReleasedAt = exist.ReleasedAt
+	if preOrder.ReleasedAt != nil && exist.ReleaseDate.After(now) {
+		preOrder.ReleasedAt = nil
+	}
+
+	transaction := s.db.Begin()
+	if err := transaction.Save(preOrder).Error; err != nil {
+		transaction.Rollback()
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Save pre-order"))
+	}
+	if err := updateLegacyPreOrder(transaction, preOrder.PackageID, preOrder.Enabled && preOrder.ReleasedAt == nil, exist.ReleaseDate); err != nil {
+		transaction.Rollback()
+		return nil, err
+	}
+	if err := transaction.Commit().Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Commit pre-order"))
+	}
+
+	return s.get(preOrder.PackageID)
+}
+
+// ReleaseDue marks released pre-orders with conditional update, so only one instance of service publishes release event
+func (s *preOrderService) ReleaseDue(t time.Time) ([]uuid.UUID, error) {
+	type dueRelease struct {
+		PackageID   uuid.UUID
+		ReleaseDate time.Time
+	}
+	var due []dueRelease
+	err := s.db.
+		Table("package_pre_orders").
+		Select("package_pre_orders.package_id, max(games.release_date) as release_date").
+		Joins("inner join package_products on package_products.package_id = package_pre_orders.package_id").
+		Joins("inner join games on games.id = package_products.product_id").
+		Where("package_pre_orders.enabled = true and package_pre_orders.released_at is null").
+		Group("package_pre_orders.package_id").
+		Having("max(games.release_date) <= ?", t).
+		Scan(&due).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Search due pre-orders"))
+	}
+
+	released := []uuid.UUID{}
+	for _, release := range due {
+		ok, err := s.release(release.PackageID, release.ReleaseDate, t)
+		if err != nil {
+			return released, err
+		}
+		if ok {
+			released = append(released, release.PackageID)
+		}
+	}
+
+	return released, nil
+}
+
+// release switches package to regular pricing, event is saved in the same transaction
+func (s *preOrderService) release(packageId uuid.UUID, releaseDate time.Time, t time.Time) (bool, error) {
+	transaction := s.db.Begin()
+	res := transaction.
+		Model(&model.PreOrder{}).
+		Where("package_id = ? and released_at is null", packageId).
+		UpdateColumn("released_at", t)
+	if res.Error != nil {
+		transaction.Rollback()
+		return false, NewServiceError(http.StatusInternalServerError, errors.Wrap(res.Error, "Release pre-order"))
+	}
+	if res.RowsAffected == 0 {
+		transaction.Rollback()
+		return false, nil
+	}
+
+	if err := updateLegacyPreOrder(transaction, packageId, false, releaseDate); err != nil {
+		transaction.Rollback()
+		return false, err
+	}
+
+	if err := s.eventBus.WithTransaction(transaction).PublishPackageReleased(packageId, releaseDate); err != nil {
+		transaction.Rollback()
+		return false, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish package release"))
+	}
+
+	if err := transaction.Commit().Error; err != nil {
+		return false, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Commit pre-order release"))
+	}
+
+	return true, nil
+}
+
+func (s *preOrderService) get(packageId uuid.UUID) (*model.PreOrder, error) {
+	preOrder := &model.PreOrder{PackageID: packageId}
+	err := s.db.Where("package_id = ?", packageId).First(preOrder).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch pre-order"))
+	}
+	if preOrder.Prices == nil {
+		preOrder.Prices = model.PreOrderPrices{}
+	}
+	if preOrder.BonusProducts == nil {
+		preOrder.BonusProducts = pq.StringArray{}
+	}
+
+	releaseDate, err := getPackageReleaseDate(s.db, packageId)
+	if err != nil {
+		return nil, err
+	}
+	preOrder.ReleaseDate = releaseDate
+
+	return preOrder, nil
+}
+
+func (s *preOrderService) checkPackage(packageId uuid.UUID) error {
+	count := 0
+	if err := s.db.Model(&model.Package{}).Where("id = ?", packageId).Count(&count).Error; err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Search package"))
+	}
+	if count == 0 {
+		return NewServiceError(http.StatusNotFound, "Package not found")
+	}
+	return nil
+}
+
+// checkBonusProducts checks that bonus products are games or DLCs of the same vendor as package
+func (s *preOrderService) checkBonusProducts(packageId uuid.UUID, products []string) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := []uuid.UUID{}
+	for _, product := range products {
+		id, err := uuid.FromString(product)
+		if err != nil {
+			return NewServiceErrorf(http.StatusUnprocessableEntity, "Invalid product id `%s`", product)
+		}
+		ids = append(ids, id)
+	}
+
+	pkg := model.Package{}
+	if err := s.db.Select("id, vendor_id").Where("id = ?", packageId).First(&pkg).Error; err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Search package vendor"))
+	}
+
+	count := 0
+	err := s.db.
+		Model(&model.ProductEntry{}).
+		Joins("left join games on games.id = products.entry_id").
+		Joins("left join dlcs on dlcs.id = products.entry_id").
+		Where("products.entry_id in (?) and coalesce(games.vendor_id, dlcs.vendor_id) = ?", ids, pkg.VendorID).
+		Count(&count).Error
+	if err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Search bonus products"))
+	}
+	if count != len(ids) {
+		return NewServiceError(http.StatusUnprocessableEntity, "Bonus products not found")
+	}
+
+	return nil
+}
+
+// getPackageReleaseDate returns the latest release date of games in package
+func getPackageReleaseDate(db *gorm.DB, packageId uuid.UUID) (time.Time, error) {
+	var result struct {
+		ReleaseDate *time.Time
+	}
+	err := db.
+		Table("package_products").
+		Select("max(games.release_date) as release_date").
+		Joins("inner join games on games.id = package_products.product_id").
+		Where("package_products.package_id = ?", packageId).
+		Scan(&result).Error
+	if err != nil && !gorm.IsRecordNotFoundError(err) {
+		return time.Time{}, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch release date of package"))
+	}
+	if result.ReleaseDate == nil {
+		return time.Time{}, nil
+	}
+	return *result.ReleaseDate, nil
+}
+
+// getPreOrders loads configured pre-orders of packages with their release dates at once,
+// packages without pre-order settings are missing in result
+func getPreOrders(db *gorm.DB, packageIds []uuid.UUID) (map[uuid.UUID]*model.PreOrder, error) {
+	result := map[uuid.UUID]*model.PreOrder{}
+	if len(packageIds) == 0 {
+		return result, nil
+	}
+
+	preOrders := []model.PreOrder{}
+	if err := db.Where("package_id in (?)", packageIds).Find(&preOrders).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch pre-orders"))
+	}
+	if len(preOrders) == 0 {
+		return result, nil
+	}
+
+	var releaseDates []struct {
+		PackageID   uuid.UUID
+		ReleaseDate *time.Time
+	}
+	err := db.
+		Table("package_products").
+		Select("package_products.package_id, max(games.release_date) as release_date").
+		Joins("inner join games on games.id = package_products.product_id").
+		Where("package_products.package_id in (?)", packageIds).
+		Group("package_products.package_id").
+		Scan(&releaseDates).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch release dates of packages"))
+	}
+
+	for i := range preOrders {
+		result[preOrders[i].PackageID] = &preOrders[i]
+	}
+	for _, date := range releaseDates {
+		if preOrder, ok := result[date.PackageID]; ok && date.ReleaseDate != nil {
+			preOrder.ReleaseDate = *date.ReleaseDate
+		}
+	}
+	return result, nil
+}
+
+// updateLegacyPreOrder keeps pre-order blob of package prices in sync for clients of prices API
+func updateLegacyPreOrder(db *gorm.DB, packageId uuid.UUID, enabled bool, releaseDate time.Time) error {
+	err := db.
+		Model(&model.Package{}).
+		Where("id = ?", packageId).
+		UpdateColumn("pre_order", model.JSONB{"Date": releaseDate.Format(time.RFC3339), "Enabled": enabled}).Error
+	if err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Update pre-order of package prices"))
+	}
+	return nil
+}
+
+// RunPreOrderReleaser periodically releases due pre-orders until stop channel is closed
+func RunPreOrderReleaser(service model.PreOrderService, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case t := <-ticker.C:
+			released, err := service.ReleaseDue(t.UTC())
+			if err != nil {
+				zap.L().Error("Pre-order release failed", zap.Error(err))
+			}
+			if len(released) > 0 {
+				zap.L().Info("Pre-orders released", zap.Int("count", len(released)))
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package orm_test
+
+import (
+	"github.com/ProtocolONE/rbac"
+	"github.com/labstack/gommon/random"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"qilin-api/pkg/api/mock"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"qilin-api/pkg/test"
+	"testing"
+	"time"
+)
+
+type preOrderServiceTestSuite struct {
+	suite.Suite
+	db          *orm.Database
+	service     model.PreOrderService
+	packageId   uuid.UUID
+	gameId      uuid.UUID
+	otherGameId uuid.UUID
+}
+
+func Test_PreOrderService(t *testing.T) {
+	suite.Run(t, new(preOrderServiceTestSuite))
+}
+
+func (suite *preOrderServiceTestSuite) SetupTest() {
+	config, err := qilin_test.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+
+	db, err := orm.NewDatabase(&config.Database)
+	if err != nil {
+		suite.Fail("Unable to connect to database:", "%v", err)
+	}
+
+	if err := db.DropAllTables(); err != nil {
+		assert.FailNow(suite.T(), "Unable to drop tables", err)
+	}
+	if err := db.Init(); err != nil {
+		assert.FailNow(suite.T(), "Unable to init tables", err)
+	}
+
+	suite.db = db
+
+	user := model.User{
+		ID:       random.String(8, "0123456789"),
+		Login:    "test@protocol.one",
+		Password: "megapass",
+		Nickname: "Test",
+		Lang:     "ru",
+	}
+	err = db.DB().Create(&user).Error
+	suite.Nil(err, "Unable to create user")
+
+	ownProvider := orm.NewOwnerProvider(suite.db)
+	membershipService := orm.NewMembershipService(suite.db, ownProvider, rbac.NewEnforcer(), mock.NewMailer(), "")
+	vendorService, err := orm.NewVendorService(db, membershipService)
+	suite.Nil(err, "Unable make vendor service")
+	vendor := model.Vendor{
+		ID:              uuid.NewV4(),
+		Name:            "domino",
+		Domain3:         "domino",
+		Email:           "domino@proto.com",
+		HowManyProducts: "+1000",
+		ManagerID:       user.ID,
+	}
+	_, err = vendorService.Create(&vendor)
+	suite.Nil(err, "Must create new vendor")
+
+	gameService, err := orm.NewGameService(db)
+	suite.Nil(err, "Unable make game service")
+	game, err := gameService.Create(user.ID, vendor.ID, "Game")
+	if err != nil {
+		suite.FailNow("Unable to create game", "%v", err)
+	}
+	suite.packageId = game.DefaultPackageID
+	suite.gameId = game.ID
+
+	otherVendor := model.Vendor{
+		ID:              uuid.NewV4(),
+		Name:            "another",
+		Domain3:         "another",
+		Email:           "another@proto.com",
+		HowManyProducts: "+1000",
+		ManagerID:       user.ID,
+	}
+	_, err = vendorService.Create(&otherVendor)
+	suite.Nil(err, "Must create another vendor")
+	otherGame, err := gameService.Create(user.ID, otherVendor.ID, "Another Game")
+	if err != nil {
+		suite.FailNow("Unable to create game", "%v", err)
+	}
+	suite.otherGameId = otherGame.ID
+
+	suite.service = orm.NewPreOrderService(db, mock.NewEventBus())
+}
+
+func (suite *preOrderServiceTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *preOrderServiceTestSuite) setReleaseDate(date time.Time) {
+	err := suite.db.DB().Model(&model.Game{}).Where("id = ?", suite.gameId).UpdateColumn("release_date", date).Error
+	suite.Nil(err, "Unable to update release date")
+}
+
+func (suite *preOrderServiceTestSuite) TestGetAndUpdate() {
+	should := require.New(suite.T())
+
+	release := time.Now().UTC().AddDate(0, 1, 0).Truncate(time.Second)
+	suite.setReleaseDate(release)
+
+	preOrder, err := suite.service.Get(suite.packageId)
+	should.Nil(err)
+	should.False(preOrder.Enabled)
+	should.Equal(model.PackageStateComingSoon, preOrder.State(time.Now().UTC()))
+
+	_, err = suite.service.Get(uuid.NewV4())
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	preOrder, err = suite.service.Update(&model.PreOrder{
+		PackageID:     suite.packageId,
+		Enabled:       true,
+		DateStart:     time.Now().UTC().AddDate(0, 0, -1),
+		Prices:        model.PreOrderPrices{{Currency: "USD", Price: 49.99}},
+		BonusProducts: []string{suite.gameId.String()},
+	})
+	should.Nil(err)
+	should.True(preOrder.Enabled)
+	should.Len(preOrder.Prices, 1)
+	should.Len(preOrder.BonusProducts, 1)
+	should.True(release.Equal(preOrder.ReleaseDate))
+	should.Equal(model.PackageStatePreOrder, preOrder.State(time.Now().UTC()))
+
+	pkg := model.Package{}
+	should.Nil(suite.db.DB().Where("id = ?", suite.packageId).First(&pkg).Error)
+	should.Equal(true, pkg.PreOrder["Enabled"])
+}
+
+func (suite *preOrderServiceTestSuite) TestUpdateValidation() {
+	should := require.New(suite.T())
+
+	release := time.Now().UTC().AddDate(0, 1, 0)
+	suite.setReleaseDate(release)
+
+	invalid := []*model.PreOrder{
+		{PackageID: suite.packageId, Enabled: true, DateStart: release.AddDate(0, 0, 1)},
+		{PackageID: suite.packageId, DateStart: release.AddDate(0, -1, 0), Prices: model.PreOrderPrices{{Currency: "XXX", Price: 1}}},
+		{PackageID: suite.packageId, DateStart: release.AddDate(0, -1, 0), Prices: model.PreOrderPrices{{Currency: "USD", Price: 1}, {Currency: "USD", Price: 2}}},
+		{PackageID: suite.packageId, DateStart: release.AddDate(0, -1, 0), Prices: model.PreOrderPrices{{Currency: "USD", Price: -1}}},
+		{PackageID: suite.packageId, DateStart: release.AddDate(0, -1, 0), BonusProducts: []string{uuid.NewV4().String()}},
+		{PackageID: suite.packageId, DateStart: release.AddDate(0, -1, 0), BonusProducts: []string{suite.otherGameId.String()}},
+	}
+	for _, preOrder := range invalid {
+		_, err := suite.service.Update(preOrder)
+		should.NotNil(err)
+		should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+	}
+}
+
+func (suite *preOrderServiceTestSuite) TestReleaseDue() {
+	should := require.New(suite.T())
+
+	release := time.Now().UTC().AddDate(0, 0, 1)
+	suite.setReleaseDate(release)
+
+	_, err := suite.service.Update(&model.PreOrder{
+		PackageID: suite.packageId,
+		Enabled:   true,
+		DateStart: time.Now().UTC().AddDate(0, 0, -1),
+		Prices:    model.PreOrderPrices{{Currency: "USD", Price: 49.99}},
+	})
+	should.Nil(err)
+
+	released, err := suite.service.ReleaseDue(time.Now().UTC())
+	should.Nil(err)
+	should.Len(released, 0)
+
+	released, err = suite.service.ReleaseDue(release.AddDate(0, 0, 1))
+	should.Nil(err)
+	should.Equal([]uuid.UUID{suite.packageId}, released)
+
+	preOrder, err := suite.service.Get(suite.packageId)
+	should.Nil(err)
+	should.NotNil(preOrder.ReleasedAt)
+	should.Equal(model.PackageStateReleased, preOrder.State(time.Now().UTC()))
+
+	released, err = suite.service.ReleaseDue(release.AddDate(0, 0, 2))
+	should.Nil(err)
+	should.Len(released, 0)
+}
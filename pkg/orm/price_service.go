@@ -3,6 +3,7 @@ package orm
 import (
 	"net/http"
 	"qilin-api/pkg/model"
+	"reflect"
 	"time"
 
 	"github.com/jinzhu/gorm"
@@ -39,7 +40,7 @@ func (p *priceService) GetBase(id uuid.UUID) (*model.BasePrice, error) {
 }
 
 //UpdateBase is method for updating base information about package pricing
-func (p *priceService) UpdateBase(id uuid.UUID, price *model.BasePrice) error {
+func (p *priceService) UpdateBase(userId string, id uuid.UUID, price *model.BasePrice) error {
 
 	domain := &model.BasePrice{ID: id}
 	err := p.db.Select(model.SelectFields(domain)).First(domain).Error
@@ -53,20 +54,44 @@ func (p *priceService) UpdateBase(id uuid.UUID, price *model.BasePrice) error {
 	now := time.Now()
 	price.UpdatedAt = &now
 
-	err = p.db.
+	transaction := p.db.Begin()
+	err = transaction.
 		Set("gorm:association_autoupdate", false).
 		Set("gorm:association_autocreate", false).
 		Save(price).
 		Error
 	if err != nil {
+		transaction.Rollback()
 		return errors.Wrap(err, "save base prices for package")
 	}
 
-	return nil
+	if !reflect.DeepEqual(domain.Common, price.Common) {
+		err = createPriceChange(transaction, &model.PriceChange{
+			PackageID: id,
+			UserID:    userId,
+			Kind:      model.PriceChangeKindBase,
+			OldBase:   domain.Common,
+			NewBase:   price.Common,
+		})
+		if err != nil {
+			transaction.Rollback()
+			return err
+		}
+	}
+
+	if !reflect.DeepEqual(domain.Common, price.Common) || !reflect.DeepEqual(domain.PreOrder, price.PreOrder) {
+		err = NewEventBus(transaction).Publish(model.BasePriceChanged{PackageID: id, Common: price.Common, PreOrder: price.PreOrder})
+		if err != nil {
+			transaction.Rollback()
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "publish base price change"))
+		}
+	}
+
+	return transaction.Commit().Error
 }
 
 //Delete is method for removing price with currency for package
-func (p *priceService) Delete(id uuid.UUID, price *model.Price) error {
+func (p *priceService) Delete(userId string, id uuid.UUID, price *model.Price) error {
 	domain := &model.BasePrice{ID: id}
 
 	count := 0
@@ -87,29 +112,54 @@ func (p *priceService) Delete(id uuid.UUID, price *model.Price) error {
 		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "search prices for package"))
 	}
 
-	found := false
 	for _, v := range prices {
 		if v.Currency == price.Currency {
-			p.db.Delete(&v)
-			found = true
-			break
+			transaction := p.db.Begin()
+			if err := transaction.Delete(&v).Error; err != nil {
+				transaction.Rollback()
+				return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "delete price"))
+			}
+			err := createPriceChange(transaction, &model.PriceChange{
+				PackageID: id,
+				UserID:    userId,
+				Kind:      model.PriceChangeKindPrice,
+				Currency:  v.Currency,
+				OldPrice:  &v.Price,
+				OldVat:    &v.Vat,
+			})
+			if err != nil {
+				transaction.Rollback()
+				return err
+			}
+			if err := NewEventBus(transaction).Publish(model.PriceRemoved{PackageID: id, Currency: v.Currency}); err != nil {
+				transaction.Rollback()
+				return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "publish price removal"))
+			}
+			return transaction.Commit().Error
 		}
 	}
 
-	if found == false {
-		return NewServiceError(http.StatusNotFound, "Price not found")
+	return NewServiceError(http.StatusNotFound, "Price not found")
+}
+
+// Update is method for updating price with currency for package
+func (p *priceService) Update(userId string, id uuid.UUID, price *model.Price) error {
+	transaction := p.db.Begin()
+	if err := updatePrice(transaction, userId, id, price); err != nil {
+		transaction.Rollback()
+		return err
 	}
 
-	return nil
+	return transaction.Commit().Error
 }
 
-// Update is method for updating price with currency for package
-func (p *priceService) Update(id uuid.UUID, price *model.Price) error {
+// updatePrice saves price with currency for package with its change record and event in transaction
+func updatePrice(transaction *gorm.DB, userId string, id uuid.UUID, price *model.Price) error {
 	domain := &model.BasePrice{ID: id}
 	var prices []model.Price
 
 	count := 0
-	if err := p.db.Model(domain).Where("ID = ?", id).Limit(1).Count(&count).Error; err != nil {
+	if err := transaction.Model(domain).Where("ID = ?", id).Limit(1).Count(&count).Error; err != nil {
 		return NewServiceError(http.StatusInternalServerError, "Package search")
 	}
 
@@ -117,7 +167,7 @@ func (p *priceService) Update(id uuid.UUID, price *model.Price) error {
 		return NewServiceError(http.StatusNotFound, "Package not found")
 	}
 
-	err := p.db.
+	err := transaction.
 		Model(domain).
 		Related(&prices, "BasePriceID").
 		Order("created_at").
@@ -128,14 +178,107 @@ func (p *priceService) Update(id uuid.UUID, price *model.Price) error {
 
 	price.BasePriceID = id
 
+	change := &model.PriceChange{
+		PackageID: id,
+		UserID:    userId,
+		Kind:      model.PriceChangeKindPrice,
+		Currency:  price.Currency,
+		NewPrice:  &price.Price,
+		NewVat:    &price.Vat,
+	}
 	for _, v := range prices {
 		if v.Currency == price.Currency {
 			price.ID = v.ID
+			price.CreatedAt = v.CreatedAt
+			oldPrice, oldVat := v.Price, v.Vat
+			change.OldPrice = &oldPrice
+			change.OldVat = &oldVat
 			break
 		}
 	}
+	unchanged := change.OldPrice != nil && *change.OldPrice == price.Price && *change.OldVat == price.Vat
 
-	p.db.Save(price)
+	if err := transaction.Save(price).Error; err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "save price"))
+	}
+	if !unchanged {
+		if err := createPriceChange(transaction, change); err != nil {
+			return err
+		}
+		event := model.PriceChanged{PackageID: id, Currency: price.Currency, Price: price.Price, Vat: price.Vat}
+		if err := NewEventBus(transaction).Publish(event); err != nil {
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "publish price change"))
+		}
+	}
+
+	return nil
+}
 
+// GetHistory is method for retrieving price timeline of package in each currency
+func (p *priceService) GetHistory(id uuid.UUID, currency string, at time.Time) (*model.PriceHistory, error) {
+	base, err := p.GetBase(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []model.PriceChange
+	query := p.db.Where("package_id = ?", id)
+	if currency != "" {
+		query = query.Where("kind = ? and currency = ?", model.PriceChangeKindPrice, currency)
+	}
+	if err := query.Order("created_at").Find(&changes).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "search price history"))
+	}
+
+	history := &model.PriceHistory{
+		PackageID:   id,
+		At:          at,
+		Prices:      []model.PriceTimeline{},
+		BaseChanges: []model.PriceChange{},
+	}
+	timelines := map[string]*model.PriceTimeline{}
+	currencies := []string{}
+	getTimeline := func(currency string) *model.PriceTimeline {
+		timeline, ok := timelines[currency]
+		if !ok {
+			timeline = &model.PriceTimeline{Currency: currency, Changes: []model.PriceChange{}}
+			timelines[currency] = timeline
+			currencies = append(currencies, currency)
+		}
+		return timeline
+	}
+
+	if currency != "" {
+		getTimeline(currency)
+	}
+	for i := range base.Prices {
+		if currency == "" || base.Prices[i].Currency == currency {
+			getTimeline(base.Prices[i].Currency).Current = &base.Prices[i].Price
+		}
+	}
+	for _, change := range changes {
+		if change.Kind == model.PriceChangeKindBase {
+			history.BaseChanges = append(history.BaseChanges, change)
+			continue
+		}
+		timeline := getTimeline(change.Currency)
+		timeline.Changes = append(timeline.Changes, change)
+	}
+
+	for _, currency := range currencies {
+		timeline := timelines[currency]
+		timeline.Lowest = model.LowestPrice(timeline.Changes, timeline.Current, at.Add(-model.LowestPricePeriod), at)
+		history.Prices = append(history.Prices, *timeline)
+	}
+
+	return history, nil
+}
+
+func createPriceChange(db *gorm.DB, change *model.PriceChange) error {
+	change.ID = uuid.NewV4()
+	change.CreatedAt = time.Now().UTC()
+	if err := db.Create(change).Error; err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "save price change"))
+	}
 	return nil
 }
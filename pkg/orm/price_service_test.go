@@ -24,6 +24,7 @@ type PriceServiceTestSuite struct {
 var (
 	gameID    = "029ce039-3333-481a-a831-cde7ff4e50b9"
 	packageID = "029ce039-888a-481a-a831-cde7ff4e50b9"
+	priceUser = "price_user"
 )
 
 func Test_PriceService(t *testing.T) {
@@ -101,7 +102,7 @@ func (suite *PriceServiceTestSuite) TestCreatePriceShouldChangeGameInDB() {
 		UpdatedAt: &updatedAt,
 	}
 
-	err := service.UpdateBase(id, &pkg)
+	err := service.UpdateBase(priceUser, id, &pkg)
 	assert.Nil(suite.T(), err, "Unable to update media for package")
 
 	pkgFromDb, err := service.GetBase(id)
@@ -129,14 +130,14 @@ func (suite *PriceServiceTestSuite) TestPriceServiceShouldReturnError() {
 		assert.Equal(suite.T(), http.StatusNotFound, he.Code)
 	}
 
-	err = service.Update(uuid.NewV4(), &price1)
+	err = service.Update(priceUser, uuid.NewV4(), &price1)
 	assert.NotNil(suite.T(), err)
 	if err != nil {
 		he := err.(*ServiceError)
 		assert.Equal(suite.T(), http.StatusNotFound, he.Code)
 	}
 
-	err = service.Delete(uuid.NewV4(), &price1)
+	err = service.Delete(priceUser, uuid.NewV4(), &price1)
 	assert.NotNil(suite.T(), err)
 	if err != nil {
 		he := err.(*ServiceError)
@@ -161,10 +162,10 @@ func (suite *PriceServiceTestSuite) TestChangePrices() {
 		Vat:      99,
 	}
 
-	err := service.Update(id, &price1)
+	err := service.Update(priceUser, id, &price1)
 	assert.Nil(suite.T(), err, "Unable to update price for package")
 
-	err = service.Update(id, &price2)
+	err = service.Update(priceUser, id, &price2)
 	assert.Nil(suite.T(), err, "Unable to update price for package")
 
 	pkgFromDb, err := service.GetBase(id)
@@ -180,14 +181,56 @@ func (suite *PriceServiceTestSuite) TestChangePrices() {
 	assert.Equal(suite.T(), price2.Price, pkgFromDb.Prices[1].Price, "Incorrect Prices from DB")
 	assert.Equal(suite.T(), price2.Currency, pkgFromDb.Prices[1].Currency, "Incorrect Prices from DB")
 
-	err = service.Delete(id, &price1)
+	err = service.Delete(priceUser, id, &price1)
 	assert.Nil(suite.T(), err, "Unable to delete price: %v", err)
 	pkgFromDb, err = service.GetBase(id)
 	assert.Equal(suite.T(), 1, len(pkgFromDb.Prices), "Incorrect Prices from DB")
 
-	err = service.Delete(id, &price2)
+	err = service.Delete(priceUser, id, &price2)
 	assert.Nil(suite.T(), err, "Unable to delete price: %v", err)
 	pkgFromDb, err = service.GetBase(id)
 	assert.Equal(suite.T(), 0, len(pkgFromDb.Prices), "Incorrect Prices from DB")
 
 }
+
+func (suite *PriceServiceTestSuite) TestPriceHistory() {
+	should := require.New(suite.T())
+	service := NewPriceService(suite.db)
+
+	id, _ := uuid.FromString(packageID)
+
+	should.Nil(service.Update(priceUser, id, &model.Price{Currency: "EUR", Price: 20, Vat: 10}))
+	should.Nil(service.Update(priceUser, id, &model.Price{Currency: "EUR", Price: 20, Vat: 10}))
+	should.Nil(service.Update(priceUser, id, &model.Price{Currency: "EUR", Price: 15, Vat: 10}))
+	should.Nil(service.Update(priceUser, id, &model.Price{Currency: "USD", Price: 25, Vat: 0}))
+	should.Nil(service.Delete(priceUser, id, &model.Price{Currency: "USD"}))
+
+	history, err := service.GetHistory(id, "", time.Now().UTC())
+	should.Nil(err)
+	should.Len(history.Prices, 2)
+
+	eur := history.Prices[0]
+	if eur.Currency != "EUR" {
+		eur = history.Prices[1]
+	}
+	should.Equal("EUR", eur.Currency)
+	should.Len(eur.Changes, 2, "Unchanged price must not be recorded")
+	should.Nil(eur.Changes[0].OldPrice)
+	should.Equal(float32(20), *eur.Changes[0].NewPrice)
+	should.Equal(float32(20), *eur.Changes[1].OldPrice)
+	should.Equal(priceUser, eur.Changes[1].UserID)
+	should.Equal(float32(15), *eur.Current)
+	should.Equal(float32(15), *eur.Lowest)
+
+	history, err = service.GetHistory(id, "USD", time.Now().UTC())
+	should.Nil(err)
+	should.Len(history.Prices, 1)
+	should.Len(history.Prices[0].Changes, 2)
+	should.Nil(history.Prices[0].Changes[1].NewPrice)
+	should.Nil(history.Prices[0].Current)
+	should.Equal(float32(25), *history.Prices[0].Lowest)
+
+	_, err = service.GetHistory(uuid.NewV4(), "", time.Now().UTC())
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*ServiceError).Code)
+}
@@ -0,0 +1,280 @@
+package orm
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/utils"
+	"sort"
+	"time"
+)
+
+type pricingService struct {
+	db             *gorm.DB
+	packageService model.PackageService
+	bundleService  model.BundleService
+}
+
+func NewPricingService(
+	db *Database,
+	packageService model.PackageService,
+	bundleService model.BundleService) model.PricingService {
+	return &pricingService{
+		db:             db.database,
+		packageService: packageService,
+		bundleService:  bundleService,
+	}
+}
+
+func (p *pricingService) GetPackagePrice(packageId uuid.UUID, currency string, at time.Time) (*model.ResolvedPrice, error) {
+	if currency != "" && !utils.IsCurrency(currency) {
+		return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Wrong currency %s", currency)
+	}
+
+	pkg, err := p.packageService.Get(packageId)
+	if err != nil {
+		return nil, err
+	}
+
+	if currency == "" {
+		currency = pkg.GetCurrency()
+	}
+
+	preOrders, err := p.applyPreOrders([]model.Package{*pkg}, at)
+	if err != nil {
+		return nil, err
+	}
+	pkg.Prices = preOrders[0].Prices
+
+	discounts, err := p.getActiveDiscounts([]model.Package{*pkg}, at)
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := model.ResolvePackagePrice(pkg, currency, discounts, at)
+	if !ok {
+		return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Package has no price in %s", currency)
+	}
+	result.PreOrder = preOrders[0].PreOrder
+
+	return result, nil
+}
+
+func (p *pricingService) GetBundlePrice(bundleId uuid.UUID, currency string, at time.Time) (*model.ResolvedPrice, error) {
+	if currency != "" && !utils.IsCurrency(currency) {
+		return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Wrong currency %s", currency)
+	}
+
+	bundle, err := p.bundleService.Get(bundleId)
+	if err != nil {
+		return nil, err
+	}
+
+	if lootbox, ok := bundle.(*model.LootboxBundle); ok {
+		// Lootbox opening has fixed price without discounts
+		if currency != "" && currency != lootbox.Currency {
+			return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Lootbox has no price in %s", currency)
+		}
+		return &model.ResolvedPrice{
+			ID:         lootbox.ID,
+			Currency:   lootbox.Currency,
+			Price:      lootbox.Price,
+			FinalPrice: lootbox.Price,
+			Packages:   []model.ResolvedPrice{},
+			Unpriced:   []uuid.UUID{},
+		}, nil
+	}
+
+	storeBundle, ok := bundle.(*model.StoreBundle)
+	if !ok {
+		return nil, NewServiceError(http.StatusNotImplemented, "Unknown bundle type")
+	}
+
+	preOrders, err := p.applyPreOrders(storeBundle.Packages, at)
+	if err != nil {
+		return nil, err
+	}
+	for i := range storeBundle.Packages {
+		storeBundle.Packages[i].Prices = preOrders[i].Prices
+	}
+
+	discounts, err := p.getActiveDiscounts(storeBundle.Packages, at)
+	if err != nil {
+		return nil, err
+	}
+
+	result := model.ResolveBundlePrice(storeBundle, currency, discounts, at)
+	if len(result.Unpriced) > 0 {
+		return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Bundle packages %v have no price in %s", result.Unpriced, result.Currency)
+	}
+	markPreOrders(result, preOrders)
+
+	return result, nil
+}
+
+func (p *pricingService) GetPackagesPrices(packages []model.Package, currency string, at time.Time) (map[uuid.UUID][]model.ResolvedPrice, error) {
+	if currency != "" && !utils.IsCurrency(currency) {
+		return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Wrong currency %s", currency)
+	}
+
+	preOrders, err := p.applyPreOrders(packages, at)
+	if err != nil {
+		return nil, err
+	}
+
+	discounts, err := p.getActiveDiscounts(packages, at)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[uuid.UUID][]model.ResolvedPrice{}
+	for i := range packages {
+		pkg := packages[i]
+		pkg.Prices = preOrders[i].Prices
+		prices := []model.ResolvedPrice{}
+		for _, cur := range getPriceCurrencies(currency, []model.Package{pkg}) {
+			resolved, ok := model.ResolvePackagePrice(&pkg, cur, discounts, at)
+			if !ok {
+				continue
+			}
+			resolved.PreOrder = preOrders[i].PreOrder
+			prices = append(prices, *resolved)
+		}
+		result[pkg.ID] = prices
+	}
+
+	return result, nil
+}
+
+func (p *pricingService) GetBundlesPrices(bundles []model.StoreBundle, currency string, at time.Time) (map[uuid.UUID][]model.ResolvedPrice, error) {
+	if currency != "" && !utils.IsCurrency(currency) {
+		return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Wrong currency %s", currency)
+	}
+
+	packages := []model.Package{}
+	for i := range bundles {
+		packages = append(packages, bundles[i].Packages...)
+	}
+
+	preOrders, err := p.applyPreOrders(packages, at)
+	if err != nil {
+		return nil, err
+	}
+
+	discounts, err := p.getActiveDiscounts(packages, at)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[uuid.UUID][]model.ResolvedPrice{}
+	next := 0
+	for i := range bundles {
+		bundle := bundles[i]
+		bundle.Packages = make([]model.Package, len(bundles[i].Packages))
+		copy(bundle.Packages, bundles[i].Packages)
+		bundlePreOrders := preOrders[next : next+len(bundle.Packages)]
+		next += len(bundle.Packages)
+		for j := range bundle.Packages {
+			bundle.Packages[j].Prices = bundlePreOrders[j].Prices
+		}
+
+		prices := []model.ResolvedPrice{}
+		for _, cur := range getPriceCurrencies(currency, bundle.Packages) {
+			resolved := model.ResolveBundlePrice(&bundle, cur, discounts, at)
+			if len(resolved.Unpriced) > 0 {
+				continue
+			}
+			markPreOrders(resolved, bundlePreOrders)
+			prices = append(prices, *resolved)
+		}
+		result[bundle.ID] = prices
+	}
+
+	return result, nil
+}
+
+type preOrderPrices struct {
+	ID       uuid.UUID
+	Prices   []model.Price
+	PreOrder bool
+}
+
+// applyPreOrders returns prices of packages at the moment, pre-order prices replace regular ones during pre-order window
+func (p *pricingService) applyPreOrders(packages []model.Package, at time.Time) ([]preOrderPrices, error) {
+	packageIds := []uuid.UUID{}
+	for _, pkg := range packages {
+		packageIds = append(packageIds, pkg.ID)
+	}
+	preOrders, err := getPreOrders(p.db, packageIds)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []preOrderPrices{}
+	for _, pkg := range packages {
+		applied := false
+		if preOrder, ok := preOrders[pkg.ID]; ok {
+			applied = preOrder.Apply(&pkg, at)
+		}
+		result = append(result, preOrderPrices{ID: pkg.ID, Prices: pkg.Prices, PreOrder: applied})
+	}
+	return result, nil
+}
+
+// getActiveDiscounts returns scheduled discounts of games in packages which are active at the moment
+func (p *pricingService) getActiveDiscounts(packages []model.Package, at time.Time) ([]model.Discount, error) {
+	gameIds := []uuid.UUID{}
+	for _, pkg := range packages {
+		for _, pr := range pkg.Products {
+			if pr.GetType() == model.ProductGame {
+				gameIds = append(gameIds, pr.GetID())
+			}
+		}
+	}
+
+	discounts := []model.Discount{}
+	if len(gameIds) == 0 {
+		return discounts, nil
+	}
+
+	err := p.db.
+		Where("game_id in (?) and date_start <= ? and date_end > ?", gameIds, at, at).
+		Find(&discounts).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch active discounts"))
+	}
+
+	return discounts, nil
+}
+
+// markPreOrders flags bundle packages resolved with pre-order prices
+func markPreOrders(price *model.ResolvedPrice, preOrders []preOrderPrices) {
+	for i := range price.Packages {
+		for _, preOrder := range preOrders {
+			if preOrder.ID == price.Packages[i].ID {
+				price.Packages[i].PreOrder = preOrder.PreOrder
+			}
+		}
+	}
+}
+
+// getPriceCurrencies returns requested currency or all currencies of packages prices in alphabetical order
+func getPriceCurrencies(currency string, packages []model.Package) []string {
+	if currency != "" {
+		return []string{currency}
+	}
+	known := map[string]bool{}
+	result := []string{}
+	for _, pkg := range packages {
+		for _, price := range pkg.Prices {
+			if !known[price.Currency] {
+				known[price.Currency] = true
+				result = append(result, price.Currency)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -66,3 +66,16 @@ func (p *ProductService) GetPackages(productId uuid.UUID) (packages []model.Pack
 
 	return
 }
+
+func (p *ProductService) GetDlcGames(dlcIds []uuid.UUID) (games []uuid.UUID, err error) {
+	games = []uuid.UUID{}
+	if len(dlcIds) == 0 {
+		return
+	}
+
+	err = p.db.Model(model.Dlc{}).Where("id in (?)", dlcIds).Pluck("distinct game_id", &games).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "Retrieve games of dlcs")
+	}
+	return
+}
@@ -0,0 +1,373 @@
+package orm
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/model"
+	"time"
+)
+
+type publicationService struct {
+	db *gorm.DB
+}
+
+func NewPublicationService(db *Database) model.PublicationService {
+	return &publicationService{db: db.database}
+}
+
+func (s *publicationService) WithTransaction(tx *gorm.DB) model.PublicationService {
+	return &publicationService{db: tx}
+}
+
+func (s *publicationService) Publish(userId string, gameId uuid.UUID) (*model.GameSnapshot, error) {
+	return s.publish(userId, gameId, nil, nil)
+}
+
+func (s *publicationService) PublishReviewed(userId string, review *model.GameReview) (*model.GameSnapshot, error) {
+	reviewed := review.GetSnapshot()
+	return s.publish(userId, review.GameID, review.RestoredFrom, func(draft *model.GameSnapshot) error {
+		changes, err := model.DiffSnapshots(reviewed, draft)
+		if err != nil {
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Compare game with review"))
+		}
+		if len(changes) > 0 {
+			return NewServiceErrorf(http.StatusConflict, "Game was changed after it was sent to review, changed fields: %s", joinChangePaths(changes))
+		}
+		return nil
+	})
+}
+
+func (s *publicationService) GetList(gameId uuid.UUID) ([]model.GameSnapshot, error) {
+	snapshots := []model.GameSnapshot{}
+	if err := s.db.Where("game_id = ?", gameId).Order("version desc").Find(&snapshots).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch game snapshots"))
+	}
+	return snapshots, nil
+}
+
+func (s *publicationService) Get(gameId uuid.UUID, version int) (*model.GameSnapshot, error) {
+	snapshot := &model.GameSnapshot{}
+	query := s.db.Where("game_id = ?", gameId)
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+	err := query.Order("version desc").First(snapshot).Error
+	if gorm.IsRecordNotFoundError(err) {
+		if version == 0 {
+			return nil, NewServiceError(http.StatusNotFound, "Game is not published")
+		}
+		return nil, NewServiceErrorf(http.StatusNotFound, "Version %d of game not found", version)
+	} else if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch game snapshot"))
+	}
+	return snapshot, nil
+}
+
+func (s *publicationService) Draft(gameId uuid.UUID) (*model.GameSnapshot, error) {
+	_, draft, err := loadDraft(s.db, gameId)
+	return draft, err
+}
+
+func (s *publicationService) Diff(gameId uuid.UUID, version int) ([]model.SnapshotChange, error) {
+	snapshot, err := s.Get(gameId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := s.Draft(gameId)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := model.DiffSnapshots(snapshot, draft)
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Compare game snapshots"))
+	}
+	return changes, nil
+}
+
+// Restore writes vendor data in a transaction, restored data is published after review like any other change
+// of vendor. Packages which were removed after snapshot are skipped, contents of packages are not restored.
+func (s *publicationService) Restore(gameId uuid.UUID, version int) (*model.GameSnapshot, error) {
+	if version <= 0 {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, "Version must be greater than zero")
+	}
+
+	snapshot, err := s.Get(gameId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var draft *model.GameSnapshot
+	err = inTransaction(s.db, func(tx *gorm.DB) error {
+		if err := restoreSnapshot(tx, snapshot); err != nil {
+			return err
+		}
+		_, draft, err = loadDraft(tx, gameId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return draft, nil
+}
+
+// publish saves draft of game as new snapshot, check is called with the draft before it's saved. Game is locked,
+// so concurrent publications of game are made one by one.
+func (s *publicationService) publish(userId string, gameId uuid.UUID, restoredFrom *int, check func(draft *model.GameSnapshot) error) (*model.GameSnapshot, error) {
+	var snapshot *model.GameSnapshot
+	err := inTransaction(s.db, func(tx *gorm.DB) error {
+		if err := lockGame(tx, gameId); err != nil {
+			return err
+		}
+		vendorId, draft, err := loadDraft(tx, gameId)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(draft); err != nil {
+				return err
+			}
+		}
+		draft.CreatorID = userId
+		draft.RestoredFrom = restoredFrom
+
+		var last struct {
+			Version int
+		}
+		err = tx.
+			Table("game_snapshots").
+			Select("coalesce(max(version), 0) as version").
+			Where("game_id = ?", gameId).
+			Scan(&last).Error
+		if err != nil {
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch last version of game"))
+		}
+
+		draft.ID = uuid.NewV4()
+		draft.Version = last.Version + 1
+		draft.CreatedAt = time.Now().UTC()
+		// Unique index on game and version fails concurrent publication of the same version
+		if err := tx.Create(draft).Error; err != nil {
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Save game snapshot"))
+		}
+		if err := tx.Save(model.NewPublishedGame(vendorId, draft)).Error; err != nil {
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Save published game"))
+		}
+		bus := NewEventBus(tx)
+		if err := bus.PublishGameChanges(gameId); err != nil {
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish game changes"))
+		}
+		if err := bus.PublishAchievementsChanges(gameId); err != nil {
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Publish achievements changes"))
+		}
+
+		snapshot = draft
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// lockGame locks row of game until the end of transaction
+func lockGame(tx *gorm.DB, gameId uuid.UUID) error {
+	err := tx.Set("gorm:query_option", "FOR UPDATE").Select("id").Where("id = ?", gameId).First(&model.Game{}).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return NewServiceError(http.StatusNotFound, "Game not found")
+	} else if err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Lock game"))
+	}
+	return nil
+}
+
+// loadDraft builds unsaved snapshot from current vendor data of game
+func loadDraft(db *gorm.DB, gameId uuid.UUID) (uuid.UUID, *model.GameSnapshot, error) {
+	game := model.Game{}
+	err := db.Where("id = ?", gameId).First(&game).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return uuid.Nil, nil, NewServiceError(http.StatusNotFound, "Game not found")
+	} else if err != nil {
+		return uuid.Nil, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch game"))
+	}
+
+	media := model.Media{}
+	if err := db.Where("id = ?", gameId).First(&media).Error; err != nil {
+		return uuid.Nil, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch game media"))
+	}
+
+	tags := []model.GameTag{}
+	if len(game.Tags) > 0 {
+		if err := db.Where("id in (?)", toPgArray(game.Tags)).Order("id").Find(&tags).Error; err != nil {
+			return uuid.Nil, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch game tags"))
+		}
+	}
+
+	genres := []model.GameGenre{}
+	if len(game.GenreAddition) > 0 || game.GenreMain != 0 {
+		filter := append(toPgArray(game.GenreAddition), game.GenreMain)
+		if err := db.Where("id in (?)", filter).Order("id").Find(&genres).Error; err != nil {
+			return uuid.Nil, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch game genres"))
+		}
+	}
+
+	var rating *model.GameRating
+	ratingRow := model.GameRating{}
+	err = db.Where("game_id = ?", gameId).First(&ratingRow).Error
+	if err == nil {
+		rating = &ratingRow
+	} else if !gorm.IsRecordNotFoundError(err) {
+		return uuid.Nil, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch game ratings"))
+	}
+
+	var descr *model.GameDescr
+	descrRow := model.GameDescr{}
+	err = db.Where("game_id = ?", gameId).First(&descrRow).Error
+	if err == nil {
+		descr = &descrRow
+	} else if !gorm.IsRecordNotFoundError(err) {
+		return uuid.Nil, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch game description"))
+	}
+
+	packageIds := []uuid.UUID{}
+	err = db.
+		Model(&model.Package{}).
+		Joins("inner join package_products on package_products.package_id = packages.id").
+		Where("package_products.product_id = ?", gameId).
+		Order("packages.created_at").
+		Pluck("packages.id", &packageIds).Error
+	if err != nil {
+		return uuid.Nil, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Search packages of game"))
+	}
+
+	gamePackages, err := getPackages(db, uniqueIds(packageIds))
+	if err != nil {
+		return uuid.Nil, nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+	packages := model.SnapshotPackages{}
+	for i := range gamePackages {
+		packages = append(packages, model.NewSnapshotPackage(&gamePackages[i]))
+	}
+
+	snapshot := &model.GameSnapshot{
+		GameID:   gameId,
+		Game:     model.NewStoreGame(&game, descr, &media, rating, tags, genres),
+		Packages: packages,
+	}
+	return game.VendorID, snapshot, nil
+}
+
+// restoreSnapshot writes game, description, media, ratings and package settings from snapshot
+func restoreSnapshot(db *gorm.DB, snapshot *model.GameSnapshot) error {
+	doc := snapshot.Game
+
+	genres := pq.Int64Array{}
+	for _, genre := range doc.Genres {
+		if genre.ID != doc.GenreMain {
+			genres = append(genres, genre.ID)
+		}
+	}
+	tags := pq.Int64Array{}
+	for _, tag := range doc.Tags {
+		tags = append(tags, tag.ID)
+	}
+
+	res := db.Model(&model.Game{}).Where("id = ?", snapshot.GameID).UpdateColumns(map[string]interface{}{
+		"title":           doc.Title,
+		"developers":      doc.Developers,
+		"publishers":      doc.Publishers,
+		"release_date":    doc.ReleaseDate,
+		"features_common": pq.StringArray(doc.FeaturesCommon),
+		"features_ctrl":   doc.FeaturesCtrl,
+		"platforms":       doc.Platforms,
+		"requirements":    doc.Requirements,
+		"languages":       doc.Languages,
+		"genre_main":      doc.GenreMain,
+		"genre_addition":  genres,
+		"tags":            tags,
+		"cover_image":     doc.Media.CoverImage,
+		"cover_video":     doc.Media.CoverVideo,
+		"trailers":        doc.Media.Trailers,
+		"screenshots":     doc.Media.Screenshots,
+		"store":           doc.Media.Store,
+		"capsule":         doc.Media.Capsule,
+		"updated_at":      time.Now(),
+	})
+	if res.Error != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(res.Error, "Restore game"))
+	}
+	if res.RowsAffected == 0 {
+		return NewServiceError(http.StatusNotFound, "Game not found")
+	}
+
+	descr := model.GameDescr{
+		Tagline:     doc.Tagline,
+		Description: doc.Description,
+		Reviews:     doc.Reviews,
+		GameSite:    doc.GameSite,
+		Socials:     doc.Socials,
+		GameID:      snapshot.GameID,
+	}
+	res = db.Model(&model.GameDescr{}).Where("game_id = ?", snapshot.GameID).UpdateColumns(map[string]interface{}{
+		"tagline":     descr.Tagline,
+		"description": descr.Description,
+		"reviews":     descr.Reviews,
+		"game_site":   descr.GameSite,
+		"socials":     descr.Socials,
+	})
+	if res.Error == nil && res.RowsAffected == 0 {
+		res = db.Create(&descr)
+	}
+	if res.Error != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(res.Error, "Restore game description"))
+	}
+
+	rating := model.GameRating{
+		PEGI:   doc.Ratings.PEGI,
+		ESRB:   doc.Ratings.ESRB,
+		BBFC:   doc.Ratings.BBFC,
+		USK:    doc.Ratings.USK,
+		CERO:   doc.Ratings.CERO,
+		GameID: snapshot.GameID,
+	}
+	res = db.Model(&model.GameRating{}).Where("game_id = ?", snapshot.GameID).UpdateColumns(map[string]interface{}{
+		"pegi": rating.PEGI,
+		"esrb": rating.ESRB,
+		"bbfc": rating.BBFC,
+		"usk":  rating.USK,
+		"cero": rating.CERO,
+	})
+	if res.Error == nil && res.RowsAffected == 0 {
+		res = db.Create(&rating)
+	}
+	if res.Error != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(res.Error, "Restore game ratings"))
+	}
+
+	for _, pkg := range snapshot.Packages {
+		err := db.Model(&model.Package{}).Where("id = ?", pkg.ID).UpdateColumns(map[string]interface{}{
+			"name":               pkg.Name,
+			"image":              pkg.Image,
+			"image_cover":        pkg.ImageCover,
+			"image_thumb":        pkg.ImageThumb,
+			"is_enabled":         pkg.IsEnabled,
+			"is_upgrade_allowed": pkg.IsUpgradeAllowed,
+			"discount":           pkg.Discount,
+			"discount_buy_opt":   pkg.DiscountBuyOpt,
+			"allowed_countries":  pq.StringArray(pkg.AllowedCountries),
+			"denied_countries":   pq.StringArray(pkg.DeniedCountries),
+			"updated_at":         time.Now(),
+		}).Error
+		if err != nil {
+			return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Restore package"))
+		}
+	}
+
+	return nil
+}
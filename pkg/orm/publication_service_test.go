@@ -0,0 +1,242 @@
+package orm_test
+
+import (
+	"github.com/ProtocolONE/rbac"
+	"github.com/labstack/gommon/random"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"qilin-api/pkg/api/mock"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/model/utils"
+	"qilin-api/pkg/orm"
+	"qilin-api/pkg/test"
+	"testing"
+)
+
+type publicationServiceTestSuite struct {
+	suite.Suite
+	db        *orm.Database
+	service   model.PublicationService
+	userId    string
+	packageId uuid.UUID
+	gameId    uuid.UUID
+}
+
+func Test_PublicationService(t *testing.T) {
+	suite.Run(t, new(publicationServiceTestSuite))
+}
+
+func (suite *publicationServiceTestSuite) SetupTest() {
+	config, err := qilin_test.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+
+	db, err := orm.NewDatabase(&config.Database)
+	if err != nil {
+		suite.Fail("Unable to connect to database:", "%v", err)
+	}
+
+	if err := db.DropAllTables(); err != nil {
+		assert.FailNow(suite.T(), "Unable to drop tables", err)
+	}
+	if err := db.Init(); err != nil {
+		assert.FailNow(suite.T(), "Unable to init tables", err)
+	}
+
+	suite.db = db
+
+	user := model.User{
+		ID:       random.String(8, "0123456789"),
+		Login:    "test@protocol.one",
+		Password: "megapass",
+		Nickname: "Test",
+		Lang:     "ru",
+	}
+	err = db.DB().Create(&user).Error
+	suite.Nil(err, "Unable to create user")
+	suite.userId = user.ID
+
+	ownProvider := orm.NewOwnerProvider(suite.db)
+	membershipService := orm.NewMembershipService(suite.db, ownProvider, rbac.NewEnforcer(), mock.NewMailer(), "")
+	vendorService, err := orm.NewVendorService(db, membershipService)
+	suite.Nil(err, "Unable make vendor service")
+	vendor := model.Vendor{
+		ID:              uuid.NewV4(),
+		Name:            "domino",
+		Domain3:         "domino",
+		Email:           "domino@proto.com",
+		HowManyProducts: "+1000",
+		ManagerID:       user.ID,
+	}
+	_, err = vendorService.Create(&vendor)
+	suite.Nil(err, "Must create new vendor")
+
+	gameService, err := orm.NewGameService(db)
+	suite.Nil(err, "Unable make game service")
+	game, err := gameService.Create(user.ID, vendor.ID, "Game")
+	if err != nil {
+		suite.FailNow("Unable to create game", "%v", err)
+	}
+	suite.packageId = game.DefaultPackageID
+	suite.gameId = game.ID
+
+	suite.service = orm.NewPublicationService(db)
+}
+
+func (suite *publicationServiceTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *publicationServiceTestSuite) setTitle(title string) {
+	err := suite.db.DB().Model(&model.Game{}).Where("id = ?", suite.gameId).UpdateColumn("title", title).Error
+	suite.Nil(err, "Unable to update game")
+}
+
+func (suite *publicationServiceTestSuite) TestPublish() {
+	should := require.New(suite.T())
+
+	_, err := suite.service.Get(suite.gameId, 0)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	suite.setTitle("First")
+	first, err := suite.service.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+	should.Equal(1, first.Version)
+	should.Equal(suite.userId, first.CreatorID)
+	should.Equal("First", first.Game.Title)
+	should.Len(first.Packages, 1)
+	should.Equal(suite.packageId, first.Packages[0].ID)
+	should.Equal([]uuid.UUID{suite.gameId}, first.Packages[0].Games)
+
+	suite.setTitle("Second")
+	second, err := suite.service.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+	should.Equal(2, second.Version)
+
+	snapshots, err := suite.service.GetList(suite.gameId)
+	should.Nil(err)
+	should.Len(snapshots, 2)
+	should.Equal(2, snapshots[0].Version)
+	should.Equal(1, snapshots[1].Version)
+
+	latest, err := suite.service.Get(suite.gameId, 0)
+	should.Nil(err)
+	should.Equal("Second", latest.Game.Title)
+
+	// Earlier snapshot is immutable
+	snapshot, err := suite.service.Get(suite.gameId, 1)
+	should.Nil(err)
+	should.Equal("First", snapshot.Game.Title)
+
+	_, err = suite.service.Get(suite.gameId, 3)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+}
+
+func (suite *publicationServiceTestSuite) TestDiff() {
+	should := require.New(suite.T())
+
+	_, err := suite.service.Diff(suite.gameId, 0)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	suite.setTitle("First")
+	_, err = suite.service.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+
+	changes, err := suite.service.Diff(suite.gameId, 0)
+	should.Nil(err)
+	should.Len(changes, 0)
+
+	suite.setTitle("Second")
+	err = suite.db.DB().Model(&model.Package{}).Where("id = ?", suite.packageId).UpdateColumn("is_enabled", true).Error
+	should.Nil(err)
+
+	changes, err = suite.service.Diff(suite.gameId, 0)
+	should.Nil(err)
+	should.Len(changes, 2)
+	should.Equal("game.title", changes[0].Path)
+	should.Equal("First", changes[0].Old)
+	should.Equal("Second", changes[0].New)
+	should.Equal("packages."+suite.packageId.String()+".isEnabled", changes[1].Path)
+}
+
+func (suite *publicationServiceTestSuite) TestRestore() {
+	should := require.New(suite.T())
+
+	suite.setTitle("First")
+	err := suite.db.DB().Model(&model.GameDescr{}).Create(&model.GameDescr{
+		GameID:      suite.gameId,
+		Tagline:     utils.LocalizedString{EN: "First tagline"},
+		Description: utils.LocalizedString{EN: "First description"},
+	}).Error
+	should.Nil(err)
+	_, err = suite.service.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+
+	suite.setTitle("Second")
+	err = suite.db.DB().Model(&model.GameDescr{}).Where("game_id = ?", suite.gameId).UpdateColumn("tagline", utils.LocalizedString{EN: "Second tagline"}).Error
+	should.Nil(err)
+	err = suite.db.DB().Model(&model.Package{}).Where("id = ?", suite.packageId).UpdateColumn("is_enabled", true).Error
+	should.Nil(err)
+	_, err = suite.service.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+
+	_, err = suite.service.Restore(suite.gameId, 5)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	_, err = suite.service.Restore(suite.gameId, 0)
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
+	draft, err := suite.service.Restore(suite.gameId, 1)
+	should.Nil(err)
+	should.Equal("First", draft.Game.Title)
+	should.Equal("First tagline", draft.Game.Tagline.EN)
+	should.False(draft.Packages[0].IsEnabled)
+
+	snapshots, err := suite.service.GetList(suite.gameId)
+	should.Nil(err)
+	should.Len(snapshots, 2, "Restored data must not be published")
+
+	game := model.Game{}
+	should.Nil(suite.db.DB().Where("id = ?", suite.gameId).First(&game).Error)
+	should.Equal("First", game.Title)
+
+	pkg := model.Package{}
+	should.Nil(suite.db.DB().Where("id = ?", suite.packageId).First(&pkg).Error)
+	should.False(pkg.IsEnabled)
+
+	changes, err := suite.service.Diff(suite.gameId, 1)
+	should.Nil(err)
+	should.Len(changes, 0)
+
+	version := 1
+	review := model.NewGameReview(suite.userId, uuid.NewV4(), draft)
+	review.RestoredFrom = &version
+
+	should.Nil(suite.db.DB().Model(&model.Game{}).Where("id = ?", suite.gameId).UpdateColumn("title", "Changed").Error)
+	_, err = suite.service.PublishReviewed(suite.userId, review)
+	should.NotNil(err)
+	should.Equal(http.StatusConflict, err.(*orm.ServiceError).Code, "Changes made after review must not be published")
+	should.Nil(suite.db.DB().Model(&model.Game{}).Where("id = ?", suite.gameId).UpdateColumn("title", "First").Error)
+
+	snapshot, err := suite.service.PublishReviewed(suite.userId, review)
+	should.Nil(err)
+	should.Equal(3, snapshot.Version)
+	should.NotNil(snapshot.RestoredFrom)
+	should.Equal(1, *snapshot.RestoredFrom)
+	should.Equal("First", snapshot.Game.Title)
+}
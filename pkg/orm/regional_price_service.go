@@ -0,0 +1,157 @@
+package orm
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/utils"
+	"time"
+)
+
+type regionalPriceService struct {
+	db           *gorm.DB
+	priceService model.PriceService
+}
+
+func NewRegionalPriceService(db *Database, priceService model.PriceService) model.RegionalPriceService {
+	return &regionalPriceService{db: db.database, priceService: priceService}
+}
+
+// GetRates returns stored currency rates, storage is seeded with bundled table on database init
+func (s *regionalPriceService) GetRates() ([]model.CurrencyRate, error) {
+	rates := []model.CurrencyRate{}
+	if err := s.db.Order("currency").Find(&rates).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch currency rates"))
+	}
+
+	return rates, nil
+}
+
+func (s *regionalPriceService) UpdateRate(rate *model.CurrencyRate) error {
+	if !utils.IsCurrency(rate.Currency) {
+		return NewServiceErrorf(http.StatusUnprocessableEntity, "Wrong currency %s", rate.Currency)
+	}
+	if rate.Rate <= 0 || rate.PurchasingPower <= 0 {
+		return NewServiceError(http.StatusUnprocessableEntity, "Rate and purchasing power must be greater than zero")
+	}
+	if err := rate.GetRounding().Validate(); err != nil {
+		return NewServiceError(http.StatusUnprocessableEntity, err)
+	}
+
+	rate.UpdatedAt = time.Now()
+	if err := s.db.Save(rate).Error; err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Save currency rate"))
+	}
+
+	return nil
+}
+
+func (s *regionalPriceService) Recommend(packageId uuid.UUID, request *model.RegionalPriceRequest) (string, []model.RegionalPrice, error) {
+	base, prices, err := s.recommend(packageId, request)
+	if err != nil {
+		return "", nil, err
+	}
+	return base.GetCurrency(), prices, nil
+}
+
+// Apply saves suggested prices in one transaction, so either all prices are changed or none. VAT of existing
+// prices is kept.
+func (s *regionalPriceService) Apply(userId string, packageId uuid.UUID, request *model.RegionalPriceRequest) (string, []model.RegionalPrice, error) {
+	base, prices, err := s.recommend(packageId, request)
+	if err != nil {
+		return "", nil, err
+	}
+
+	transaction := s.db.Begin()
+	for _, suggested := range prices {
+		price := model.Price{Currency: suggested.Currency, Price: suggested.Suggested}
+		for _, p := range base.Prices {
+			if p.Currency == suggested.Currency {
+				price.Vat = p.Vat
+				break
+			}
+		}
+		if err := updatePrice(transaction, userId, packageId, &price); err != nil {
+			transaction.Rollback()
+			return "", nil, err
+		}
+	}
+	if err := transaction.Commit().Error; err != nil {
+		return "", nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Commit regional prices"))
+	}
+
+	return base.GetCurrency(), prices, nil
+}
+
+func (s *regionalPriceService) recommend(packageId uuid.UUID, request *model.RegionalPriceRequest) (*model.BasePrice, []model.RegionalPrice, error) {
+	base, err := s.priceService.GetBase(packageId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	currentPrices := map[string]float32{}
+	for _, p := range base.Prices {
+		currentPrices[p.Currency] = p.Price
+	}
+
+	baseCurrency := base.GetCurrency()
+	basePrice := request.BasePrice
+	if basePrice == 0 {
+		basePrice = currentPrices[baseCurrency]
+	}
+	if basePrice <= 0 {
+		return nil, nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Base price in %s is not set", baseCurrency)
+	}
+
+	rates, err := s.GetRates()
+	if err != nil {
+		return nil, nil, err
+	}
+	ratesMap := map[string]*model.CurrencyRate{}
+	for i := range rates {
+		ratesMap[rates[i].Currency] = &rates[i]
+	}
+
+	baseRate, ok := ratesMap[baseCurrency]
+	if !ok {
+		return nil, nil, NewServiceErrorf(http.StatusUnprocessableEntity, "No exchange rate for %s", baseCurrency)
+	}
+
+	currencies := request.Currencies
+	if len(currencies) == 0 {
+		currencies = utils.GetCurrencies()
+	}
+
+	result := []model.RegionalPrice{}
+	for _, currency := range currencies {
+		if !utils.IsCurrency(currency) {
+			return nil, nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Wrong currency %s", currency)
+		}
+		rate, ok := ratesMap[currency]
+		if !ok {
+			return nil, nil, NewServiceErrorf(http.StatusUnprocessableEntity, "No exchange rate for %s", currency)
+		}
+
+		rule := rate.GetRounding()
+		if override, ok := request.Rounding[currency]; ok {
+			if err := override.Validate(); err != nil {
+				return nil, nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Invalid rounding for %s: %s", currency, err.Error())
+			}
+			rule = override
+		}
+
+		price := model.RegionalPrice{Currency: currency, Suggested: basePrice}
+		if currency != baseCurrency {
+			converted := model.ConvertRegionalPrice(float64(basePrice), baseRate, rate, !request.IgnorePurchasingPower)
+			price.Suggested = float32(rule.Round(converted))
+		}
+		if current, ok := currentPrices[currency]; ok {
+			price.Current = &current
+		}
+		result = append(result, price)
+	}
+
+	return base, result, nil
+}
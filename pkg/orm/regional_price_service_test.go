@@ -0,0 +1,174 @@
+package orm_test
+
+import (
+	"github.com/ProtocolONE/rbac"
+	"github.com/labstack/gommon/random"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"qilin-api/pkg/api/mock"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"qilin-api/pkg/test"
+	"testing"
+)
+
+type regionalPriceServiceTestSuite struct {
+	suite.Suite
+	db           *orm.Database
+	service      model.RegionalPriceService
+	priceService model.PriceService
+	packageId    uuid.UUID
+	userId       string
+}
+
+func Test_RegionalPriceService(t *testing.T) {
+	suite.Run(t, new(regionalPriceServiceTestSuite))
+}
+
+func (suite *regionalPriceServiceTestSuite) SetupTest() {
+	config, err := qilin_test.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+
+	db, err := orm.NewDatabase(&config.Database)
+	if err != nil {
+		suite.Fail("Unable to connect to database:", "%v", err)
+	}
+
+	if err := db.DropAllTables(); err != nil {
+		assert.FailNow(suite.T(), "Unable to drop tables", err)
+	}
+	if err := db.Init(); err != nil {
+		assert.FailNow(suite.T(), "Unable to init tables", err)
+	}
+
+	suite.db = db
+
+	user := model.User{
+		ID:       random.String(8, "0123456789"),
+		Login:    "test@protocol.one",
+		Password: "megapass",
+		Nickname: "Test",
+		Lang:     "ru",
+	}
+	err = db.DB().Create(&user).Error
+	suite.Nil(err, "Unable to create user")
+
+	ownProvider := orm.NewOwnerProvider(suite.db)
+	membershipService := orm.NewMembershipService(suite.db, ownProvider, rbac.NewEnforcer(), mock.NewMailer(), "")
+	vendorService, err := orm.NewVendorService(db, membershipService)
+	suite.Nil(err, "Unable make vendor service")
+	vendor := model.Vendor{
+		ID:              uuid.NewV4(),
+		Name:            "domino",
+		Domain3:         "domino",
+		Email:           "domino@proto.com",
+		HowManyProducts: "+1000",
+		ManagerID:       user.ID,
+	}
+	_, err = vendorService.Create(&vendor)
+	suite.Nil(err, "Must create new vendor")
+
+	gameService, err := orm.NewGameService(db)
+	suite.Nil(err, "Unable make game service")
+	game, err := gameService.Create(user.ID, vendor.ID, "Game")
+	if err != nil {
+		suite.FailNow("Unable to create game", "%v", err)
+	}
+	suite.packageId = game.DefaultPackageID
+	suite.userId = user.ID
+
+	suite.priceService = orm.NewPriceService(db)
+	suite.service = orm.NewRegionalPriceService(db, suite.priceService)
+}
+
+func (suite *regionalPriceServiceTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *regionalPriceServiceTestSuite) TestRates() {
+	should := require.New(suite.T())
+
+	rates, err := suite.service.GetRates()
+	should.Nil(err)
+	should.Len(rates, 32)
+
+	err = suite.service.UpdateRate(&model.CurrencyRate{Currency: "EUR", Rate: 0.9, PurchasingPower: 0.9, RoundingStep: 1, RoundingEnding: 0.49})
+	should.Nil(err)
+
+	rates, err = suite.service.GetRates()
+	should.Nil(err)
+	should.Len(rates, 32)
+	for _, rate := range rates {
+		if rate.Currency == "EUR" {
+			should.Equal(0.9, rate.Rate)
+			should.Equal(0.49, rate.RoundingEnding)
+		}
+	}
+
+	err = suite.service.UpdateRate(&model.CurrencyRate{Currency: "XXX", Rate: 1, PurchasingPower: 1, RoundingStep: 1})
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
+	err = suite.service.UpdateRate(&model.CurrencyRate{Currency: "EUR", Rate: 1, PurchasingPower: 1, RoundingStep: 1, RoundingEnding: 1})
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+}
+
+func (suite *regionalPriceServiceTestSuite) TestRecommendAndApply() {
+	should := require.New(suite.T())
+
+	_, _, err := suite.service.Recommend(suite.packageId, &model.RegionalPriceRequest{})
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
+	should.Nil(suite.priceService.Update(suite.userId, suite.packageId, &model.Price{Currency: "EUR", Price: 5, Vat: 20}))
+
+	request := &model.RegionalPriceRequest{
+		BasePrice:             20,
+		Currencies:            []string{"USD", "EUR", "JPY"},
+		IgnorePurchasingPower: true,
+		Rounding:              map[string]model.RoundingRule{"JPY": {Step: 100, Ending: 0}},
+	}
+	baseCurrency, prices, err := suite.service.Recommend(suite.packageId, request)
+	should.Nil(err)
+	should.Equal("USD", baseCurrency)
+	should.Len(prices, 3)
+	should.Equal(float32(20), prices[0].Suggested)
+	should.Nil(prices[0].Current)
+	should.Equal(float32(17.99), prices[1].Suggested)
+	should.Equal(float32(5), *prices[1].Current)
+	should.Equal(float32(2200), prices[2].Suggested)
+
+	base, err := suite.priceService.GetBase(suite.packageId)
+	should.Nil(err)
+	should.Len(base.Prices, 1)
+
+	_, _, err = suite.service.Apply(suite.userId, suite.packageId, request)
+	should.Nil(err)
+
+	base, err = suite.priceService.GetBase(suite.packageId)
+	should.Nil(err)
+	should.Len(base.Prices, 3)
+	for _, p := range base.Prices {
+		if p.Currency == "EUR" {
+			should.Equal(float32(17.99), p.Price)
+			should.Equal(int32(20), p.Vat)
+		}
+	}
+
+	request.Currencies = []string{"XXX"}
+	_, _, err = suite.service.Recommend(suite.packageId, request)
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+}
@@ -0,0 +1,355 @@
+package orm
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"net/http"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/utils"
+	"time"
+)
+
+type storeCatalogService struct {
+	db             *gorm.DB
+	pricingService model.PricingService
+}
+
+func NewStoreCatalogService(db *Database, pricingService model.PricingService) model.StoreCatalogService {
+	return &storeCatalogService{
+		db:             db.database,
+		pricingService: pricingService,
+	}
+}
+
+func (s *storeCatalogService) GetGames(filter model.StoreGameFilter) (total int, games []model.PublishedGame, err error) {
+	query := s.db.Model(&model.PublishedGame{})
+	if filter.Genre != 0 {
+		query = query.Where("? = any(genres)", filter.Genre)
+	}
+	if filter.Tag != 0 {
+		query = query.Where("? = any(tags)", filter.Tag)
+	}
+	if filter.Platform != "" {
+		query = query.Where("? = any(platforms)", filter.Platform)
+	}
+	if filter.Language != "" {
+		query = query.Where("? = any(languages)", filter.Language)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return 0, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Count published games"))
+	}
+
+	games = []model.PublishedGame{}
+	err = query.
+		Order("published_at desc").
+		Offset(filter.Offset).
+		Limit(filter.Limit).
+		Find(&games).Error
+	if err != nil {
+		return 0, nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch published games"))
+	}
+
+	return total, games, nil
+}
+
+func (s *storeCatalogService) GetGame(gameId uuid.UUID) (*model.PublishedGame, error) {
+	published := &model.PublishedGame{}
+	err := s.db.Where("game_id = ?", gameId).First(published).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, NewServiceError(http.StatusNotFound, "Game not found")
+	} else if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch published game"))
+	}
+	return published, nil
+}
+
+func (s *storeCatalogService) GetPackages(gameId uuid.UUID, currency string, at time.Time) ([]model.StoreOffer, error) {
+	if _, err := s.GetGame(gameId); err != nil {
+		return nil, err
+	}
+	if currency != "" && !utils.IsCurrency(currency) {
+		return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Wrong currency %s", currency)
+	}
+
+	snapshots, err := s.getLatestSnapshots([]uuid.UUID{gameId})
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, NewServiceError(http.StatusNotFound, "Game not found")
+	}
+
+	packageGames := map[uuid.UUID][]uuid.UUID{}
+	for _, pkg := range snapshots[0].Packages {
+		if pkg.IsEnabled {
+			packageGames[pkg.ID] = pkg.Games
+		}
+	}
+	byGame, err := s.getSnapshotsByGame(packageGames)
+	if err != nil {
+		return nil, err
+	}
+
+	offers := []model.StoreOffer{}
+	packageIds := []uuid.UUID{}
+	for _, pkg := range snapshots[0].Packages {
+		if !pkg.IsEnabled {
+			continue
+		}
+		if _, published := getPublishedPackages(byGame, map[uuid.UUID][]uuid.UUID{pkg.ID: pkg.Games}); !published {
+			continue
+		}
+
+		offers = append(offers, model.StoreOffer{
+			ID:           pkg.ID,
+			Sku:          pkg.Sku,
+			Name:         pkg.Name,
+			Image:        pkg.Image,
+			Games:        pkg.Games,
+			Packages:     []uuid.UUID{pkg.ID},
+			Restrictions: pkg.GetRestrictions(),
+			Prices:       []model.ResolvedPrice{},
+		})
+		packageIds = append(packageIds, pkg.ID)
+	}
+
+	// Prices of all offers are resolved at once, packages removed after publication stay unpriced
+	packages, err := getPackages(s.db, packageIds)
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+	prices, err := s.pricingService.GetPackagesPrices(packages, currency, at)
+	if err != nil {
+		return nil, err
+	}
+	for i := range offers {
+		if offerPrices, ok := prices[offers[i].ID]; ok {
+			offers[i].Prices = offerPrices
+		}
+	}
+
+	return offers, nil
+}
+
+func (s *storeCatalogService) GetBundles(gameId uuid.UUID, currency string, at time.Time) ([]model.StoreOffer, error) {
+	if _, err := s.GetGame(gameId); err != nil {
+		return nil, err
+	}
+	if currency != "" && !utils.IsCurrency(currency) {
+		return nil, NewServiceErrorf(http.StatusUnprocessableEntity, "Wrong currency %s", currency)
+	}
+
+	bundleIds := []uuid.UUID{}
+	err := s.db.
+		Model(&model.StoreBundle{}).
+		Joins("inner join bundle_packages on bundle_packages.bundle_id = store_bundles.id").
+		Joins("inner join package_products on package_products.package_id = bundle_packages.package_id").
+		Where("package_products.product_id = ? and store_bundles.is_enabled = true", gameId).
+		Order("store_bundles.created_at").
+		Pluck("store_bundles.id", &bundleIds).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Search bundles of game"))
+	}
+
+	bundles, err := s.getStoreBundles(uniqueIds(bundleIds))
+	if err != nil {
+		return nil, err
+	}
+
+	packageGames := map[uuid.UUID][]uuid.UUID{}
+	for i := range bundles {
+		for j := range bundles[i].Packages {
+			packageGames[bundles[i].Packages[j].ID] = getPackageGames(&bundles[i].Packages[j])
+		}
+	}
+	byGame, err := s.getSnapshotsByGame(packageGames)
+	if err != nil {
+		return nil, err
+	}
+
+	offers := []model.StoreOffer{}
+	published := []model.StoreBundle{}
+	for _, storeBundle := range bundles {
+		bundleGames := map[uuid.UUID][]uuid.UUID{}
+		for i := range storeBundle.Packages {
+			bundleGames[storeBundle.Packages[i].ID] = packageGames[storeBundle.Packages[i].ID]
+		}
+		packages, ok := getPublishedPackages(byGame, bundleGames)
+		if !ok {
+			continue
+		}
+
+		offer := model.StoreOffer{
+			ID:           storeBundle.ID,
+			Sku:          storeBundle.Sku,
+			Name:         storeBundle.Name,
+			Games:        []uuid.UUID{},
+			Packages:     []uuid.UUID{},
+			Restrictions: storeBundle.GetRestrictions(),
+			Prices:       []model.ResolvedPrice{},
+		}
+		for i := range storeBundle.Packages {
+			live := &storeBundle.Packages[i]
+			offer.Packages = append(offer.Packages, live.ID)
+			offer.Games = append(offer.Games, bundleGames[live.ID]...)
+			// Packages without games are not published with games, their current settings are used
+			if pkg, published := packages[live.ID]; published {
+				offer.PackageRestrictions = append(offer.PackageRestrictions, pkg.GetRestrictions())
+			} else if live.IsEnabled {
+				offer.PackageRestrictions = append(offer.PackageRestrictions, live.GetRestrictions())
+			} else {
+				ok = false
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		offers = append(offers, offer)
+		published = append(published, storeBundle)
+	}
+
+	prices, err := s.pricingService.GetBundlesPrices(published, currency, at)
+	if err != nil {
+		return nil, err
+	}
+	for i := range offers {
+		if offerPrices, ok := prices[offers[i].ID]; ok {
+			offers[i].Prices = offerPrices
+		}
+	}
+
+	return offers, nil
+}
+
+// getStoreBundles loads store bundles with their packages at once, bundles are returned in order of ids
+func (s *storeCatalogService) getStoreBundles(bundleIds []uuid.UUID) ([]model.StoreBundle, error) {
+	result := []model.StoreBundle{}
+	if len(bundleIds) == 0 {
+		return result, nil
+	}
+
+	bundles := []model.StoreBundle{}
+	if err := s.db.Where("id in (?)", bundleIds).Find(&bundles).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Retrieve store bundles"))
+	}
+
+	bundlePkgs := []model.BundlePackage{}
+	err := s.db.
+		Where("bundle_id in (?)", bundleIds).
+		Order("position asc").
+		Find(&bundlePkgs).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Retrieve bundle packages"))
+	}
+
+	packageIds := []uuid.UUID{}
+	for _, bp := range bundlePkgs {
+		packageIds = append(packageIds, bp.PackageID)
+	}
+	packages, err := getPackages(s.db, uniqueIds(packageIds))
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, err)
+	}
+	packagesById := map[uuid.UUID]*model.Package{}
+	for i := range packages {
+		packagesById[packages[i].ID] = &packages[i]
+	}
+
+	byId := map[uuid.UUID]*model.StoreBundle{}
+	for i := range bundles {
+		bundles[i].Packages = []model.Package{}
+		byId[bundles[i].ID] = &bundles[i]
+	}
+	for _, bp := range bundlePkgs {
+		if pkg, ok := packagesById[bp.PackageID]; ok {
+			byId[bp.BundleID].Packages = append(byId[bp.BundleID].Packages, *pkg)
+		}
+	}
+
+	for _, id := range bundleIds {
+		if bundle, ok := byId[id]; ok {
+			result = append(result, *bundle)
+		}
+	}
+	return result, nil
+}
+
+// getLatestSnapshots returns snapshots of games which are exposed by store catalog
+func (s *storeCatalogService) getLatestSnapshots(gameIds []uuid.UUID) ([]model.GameSnapshot, error) {
+	snapshots := []model.GameSnapshot{}
+	if len(gameIds) == 0 {
+		return snapshots, nil
+	}
+	err := s.db.
+		Joins("inner join store_games on store_games.game_id = game_snapshots.game_id and store_games.version = game_snapshots.version").
+		Where("game_snapshots.game_id in (?)", gameIds).
+		Find(&snapshots).Error
+	if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch published snapshots"))
+	}
+	return snapshots, nil
+}
+
+// getSnapshotsByGame returns published snapshots of all games of packages
+func (s *storeCatalogService) getSnapshotsByGame(packageGames map[uuid.UUID][]uuid.UUID) (map[uuid.UUID]*model.GameSnapshot, error) {
+	gameIds := []uuid.UUID{}
+	for _, games := range packageGames {
+		gameIds = append(gameIds, games...)
+	}
+
+	snapshots, err := s.getLatestSnapshots(uniqueIds(gameIds))
+	if err != nil {
+		return nil, err
+	}
+	byGame := map[uuid.UUID]*model.GameSnapshot{}
+	for i := range snapshots {
+		byGame[snapshots[i].GameID] = &snapshots[i]
+	}
+	return byGame, nil
+}
+
+// getPublishedPackages returns published state of packages. Packages are published if all their games are
+// published and every game snapshot contains the package in enabled state.
+func getPublishedPackages(byGame map[uuid.UUID]*model.GameSnapshot, packageGames map[uuid.UUID][]uuid.UUID) (map[uuid.UUID]model.SnapshotPackage, bool) {
+	result := map[uuid.UUID]model.SnapshotPackage{}
+	for packageId, games := range packageGames {
+		for _, gameId := range games {
+			snapshot, ok := byGame[gameId]
+			if !ok {
+				return nil, false
+			}
+			pkg, ok := snapshot.GetPackage(packageId)
+			if !ok || !pkg.IsEnabled {
+				return nil, false
+			}
+			result[packageId] = *pkg
+		}
+	}
+	return result, true
+}
+
+// uniqueIds removes duplicates keeping order of first occurrence
+func uniqueIds(ids []uuid.UUID) []uuid.UUID {
+	result := []uuid.UUID{}
+	known := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		if !known[id] {
+			known[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func getPackageGames(pkg *model.Package) []uuid.UUID {
+	result := []uuid.UUID{}
+	for _, pr := range pkg.Products {
+		if pr.GetType() == model.ProductGame {
+			result = append(result, pr.GetID())
+		}
+	}
+	return result
+}
@@ -0,0 +1,262 @@
+package orm_test
+
+import (
+	"github.com/ProtocolONE/rbac"
+	"github.com/labstack/gommon/random"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"qilin-api/pkg/api/mock"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/model/game"
+	"qilin-api/pkg/orm"
+	"qilin-api/pkg/test"
+	"testing"
+	"time"
+)
+
+type storeCatalogServiceTestSuite struct {
+	suite.Suite
+	db           *orm.Database
+	service      model.StoreCatalogService
+	publications model.PublicationService
+	priceService model.PriceService
+	userId       string
+	packageId    uuid.UUID
+	gameId       uuid.UUID
+}
+
+func Test_StoreCatalogService(t *testing.T) {
+	suite.Run(t, new(storeCatalogServiceTestSuite))
+}
+
+func (suite *storeCatalogServiceTestSuite) SetupTest() {
+	config, err := qilin_test.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+
+	db, err := orm.NewDatabase(&config.Database)
+	if err != nil {
+		suite.Fail("Unable to connect to database:", "%v", err)
+	}
+
+	if err := db.DropAllTables(); err != nil {
+		assert.FailNow(suite.T(), "Unable to drop tables", err)
+	}
+	if err := db.Init(); err != nil {
+		assert.FailNow(suite.T(), "Unable to init tables", err)
+	}
+
+	suite.db = db
+
+	user := model.User{
+		ID:       random.String(8, "0123456789"),
+		Login:    "test@protocol.one",
+		Password: "megapass",
+		Nickname: "Test",
+		Lang:     "ru",
+	}
+	err = db.DB().Create(&user).Error
+	suite.Nil(err, "Unable to create user")
+	suite.userId = user.ID
+
+	ownProvider := orm.NewOwnerProvider(suite.db)
+	membershipService := orm.NewMembershipService(suite.db, ownProvider, rbac.NewEnforcer(), mock.NewMailer(), "")
+	vendorService, err := orm.NewVendorService(db, membershipService)
+	suite.Nil(err, "Unable make vendor service")
+	vendor := model.Vendor{
+		ID:              uuid.NewV4(),
+		Name:            "domino",
+		Domain3:         "domino",
+		Email:           "domino@proto.com",
+		HowManyProducts: "+1000",
+		ManagerID:       user.ID,
+	}
+	_, err = vendorService.Create(&vendor)
+	suite.Nil(err, "Must create new vendor")
+
+	gameService, err := orm.NewGameService(db)
+	suite.Nil(err, "Unable make game service")
+	g, err := gameService.Create(user.ID, vendor.ID, "Game")
+	if err != nil {
+		suite.FailNow("Unable to create game", "%v", err)
+	}
+	suite.packageId = g.DefaultPackageID
+	suite.gameId = g.ID
+
+	err = db.DB().Model(&model.Game{}).Where("id = ?", g.ID).Updates(map[string]interface{}{
+		"platforms": game.Platforms{Windows: true},
+		"languages": game.GameLangs{EN: game.Langs{Interface: true}},
+	}).Error
+	suite.Nil(err, "Unable to update game")
+
+	packageService, err := orm.NewPackageService(db, gameService)
+	suite.Nil(err, "Unable make package service")
+	bundleService, err := orm.NewBundleService(db, packageService, gameService)
+	suite.Nil(err, "Unable make bundle service")
+	pricingService := orm.NewPricingService(db, packageService, bundleService)
+
+	suite.priceService = orm.NewPriceService(db)
+	suite.publications = orm.NewPublicationService(db)
+	suite.service = orm.NewStoreCatalogService(db, pricingService)
+}
+
+func (suite *storeCatalogServiceTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *storeCatalogServiceTestSuite) TestPublish() {
+	should := require.New(suite.T())
+
+	_, err := suite.service.GetGame(suite.gameId)
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	_, err = suite.publications.Publish(suite.userId, uuid.NewV4())
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	snapshot, err := suite.publications.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+	should.Equal(suite.gameId, snapshot.GameID)
+
+	// Draft changes are not visible until next publication
+	err = suite.db.DB().Model(&model.Game{}).Where("id = ?", suite.gameId).UpdateColumn("title", "Changed").Error
+	should.Nil(err)
+
+	published, err := suite.service.GetGame(suite.gameId)
+	should.Nil(err)
+	should.Equal(1, published.Version)
+	should.NotEqual("Changed", published.Game.Title)
+	should.Equal([]string{model.StorePlatformWindows}, []string(published.Platforms))
+
+	_, err = suite.publications.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+	published, err = suite.service.GetGame(suite.gameId)
+	should.Nil(err)
+	should.Equal(2, published.Version)
+	should.Equal("Changed", published.Game.Title)
+}
+
+func (suite *storeCatalogServiceTestSuite) TestGetGames() {
+	should := require.New(suite.T())
+
+	_, err := suite.publications.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+
+	total, games, err := suite.service.GetGames(model.StoreGameFilter{Limit: 10})
+	should.Nil(err)
+	should.Equal(1, total)
+	should.Len(games, 1)
+
+	total, games, err = suite.service.GetGames(model.StoreGameFilter{Platform: model.StorePlatformWindows, Language: "en", Limit: 10})
+	should.Nil(err)
+	should.Equal(1, total)
+	should.Len(games, 1)
+
+	total, games, err = suite.service.GetGames(model.StoreGameFilter{Platform: model.StorePlatformLinux, Limit: 10})
+	should.Nil(err)
+	should.Equal(0, total)
+	should.Len(games, 0)
+
+	total, _, err = suite.service.GetGames(model.StoreGameFilter{Language: "ru", Limit: 10})
+	should.Nil(err)
+	should.Equal(0, total)
+
+	total, _, err = suite.service.GetGames(model.StoreGameFilter{Genre: 1000, Limit: 10})
+	should.Nil(err)
+	should.Equal(0, total)
+}
+
+func (suite *storeCatalogServiceTestSuite) TestGetPackages() {
+	should := require.New(suite.T())
+
+	_, err := suite.service.GetPackages(suite.gameId, "", time.Now())
+	should.NotNil(err)
+	should.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	_, err = suite.publications.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+
+	offers, err := suite.service.GetPackages(suite.gameId, "", time.Now())
+	should.Nil(err)
+	should.Len(offers, 0, "Disabled package must be hidden")
+
+	err = suite.db.DB().Model(&model.Package{}).Where("id = ?", suite.packageId).UpdateColumn("is_enabled", true).Error
+	should.Nil(err)
+	should.Nil(suite.priceService.Update(suite.userId, suite.packageId, &model.Price{Currency: "USD", Price: 10, Vat: 10}))
+	should.Nil(suite.priceService.Update(suite.userId, suite.packageId, &model.Price{Currency: "EUR", Price: 9, Vat: 10}))
+
+	offers, err = suite.service.GetPackages(suite.gameId, "", time.Now())
+	should.Nil(err)
+	should.Len(offers, 0, "Package enabled after publication must be hidden")
+
+	_, err = suite.publications.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+
+	offers, err = suite.service.GetPackages(suite.gameId, "", time.Now())
+	should.Nil(err)
+	should.Len(offers, 1)
+	should.Equal(suite.packageId, offers[0].ID)
+	should.Equal([]uuid.UUID{suite.gameId}, offers[0].Games)
+	should.Len(offers[0].Prices, 2)
+	should.Equal("EUR", offers[0].Prices[0].Currency)
+	should.Equal("USD", offers[0].Prices[1].Currency)
+
+	offers, err = suite.service.GetPackages(suite.gameId, "EUR", time.Now())
+	should.Nil(err)
+	should.Len(offers, 1)
+	should.Len(offers[0].Prices, 1)
+	should.Equal(float32(9), offers[0].Prices[0].FinalPrice)
+
+	offers, err = suite.service.GetPackages(suite.gameId, "RUB", time.Now())
+	should.Nil(err)
+	should.Len(offers, 1)
+	should.Len(offers[0].Prices, 0)
+
+	_, err = suite.service.GetPackages(suite.gameId, "XXX", time.Now())
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+}
+
+func (suite *storeCatalogServiceTestSuite) TestGetPackagesPreOrder() {
+	should := require.New(suite.T())
+
+	now := time.Now()
+	err := suite.db.DB().Model(&model.Game{}).Where("id = ?", suite.gameId).UpdateColumn("release_date", now.Add(24*time.Hour)).Error
+	should.Nil(err)
+	err = suite.db.DB().Model(&model.Package{}).Where("id = ?", suite.packageId).UpdateColumn("is_enabled", true).Error
+	should.Nil(err)
+	should.Nil(suite.priceService.Update(suite.userId, suite.packageId, &model.Price{Currency: "USD", Price: 10, Vat: 10}))
+	should.Nil(suite.db.DB().Create(&model.PreOrder{
+		PackageID: suite.packageId,
+		Enabled:   true,
+		DateStart: now.Add(-time.Hour),
+		Prices:    model.PreOrderPrices{{Currency: "USD", Price: 5}},
+	}).Error)
+
+	_, err = suite.publications.Publish(suite.userId, suite.gameId)
+	should.Nil(err)
+
+	offers, err := suite.service.GetPackages(suite.gameId, "", now)
+	should.Nil(err)
+	should.Len(offers, 1)
+	should.Len(offers[0].Prices, 1)
+	should.Equal(float32(5), offers[0].Prices[0].FinalPrice, "Pre-order price must be used during pre-order window")
+	should.True(offers[0].Prices[0].PreOrder)
+
+	offers, err = suite.service.GetPackages(suite.gameId, "", now.Add(48*time.Hour))
+	should.Nil(err)
+	should.Len(offers, 1)
+	should.Equal(float32(10), offers[0].Prices[0].FinalPrice, "Regular price must be used after release")
+	should.False(offers[0].Prices[0].PreOrder)
+}
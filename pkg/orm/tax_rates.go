@@ -0,0 +1,54 @@
+package orm
+
+import "qilin-api/pkg/model"
+
+// defaultTaxRates is bundled table used to seed tax rates storage.
+// Rates are standard rates applied to electronically supplied services.
+var defaultTaxRates = []model.TaxRate{
+	{Country: "AT", Name: "VAT", Rate: 20, Inclusive: true},
+	{Country: "BE", Name: "VAT", Rate: 21, Inclusive: true},
+	{Country: "BG", Name: "VAT", Rate: 20, Inclusive: true},
+	{Country: "CY", Name: "VAT", Rate: 19, Inclusive: true},
+	{Country: "CZ", Name: "VAT", Rate: 21, Inclusive: true},
+	{Country: "DE", Name: "VAT", Rate: 19, Inclusive: true},
+	{Country: "DK", Name: "VAT", Rate: 25, Inclusive: true},
+	{Country: "EE", Name: "VAT", Rate: 20, Inclusive: true},
+	{Country: "ES", Name: "VAT", Rate: 21, Inclusive: true},
+	{Country: "FI", Name: "VAT", Rate: 24, Inclusive: true},
+	{Country: "FR", Name: "VAT", Rate: 20, Inclusive: true},
+	{Country: "GB", Name: "VAT", Rate: 20, Inclusive: true},
+	{Country: "GR", Name: "VAT", Rate: 24, Inclusive: true},
+	{Country: "HR", Name: "VAT", Rate: 25, Inclusive: true},
+	{Country: "HU", Name: "VAT", Rate: 27, Inclusive: true},
+	{Country: "IE", Name: "VAT", Rate: 23, Inclusive: true},
+	{Country: "IT", Name: "VAT", Rate: 22, Inclusive: true},
+	{Country: "LT", Name: "VAT", Rate: 21, Inclusive: true},
+	{Country: "LU", Name: "VAT", Rate: 17, Inclusive: true},
+	{Country: "LV", Name: "VAT", Rate: 21, Inclusive: true},
+	{Country: "MT", Name: "VAT", Rate: 18, Inclusive: true},
+	{Country: "NL", Name: "VAT", Rate: 21, Inclusive: true},
+	{Country: "PL", Name: "VAT", Rate: 23, Inclusive: true},
+	{Country: "PT", Name: "VAT", Rate: 23, Inclusive: true},
+	{Country: "RO", Name: "VAT", Rate: 19, Inclusive: true},
+	{Country: "SE", Name: "VAT", Rate: 25, Inclusive: true},
+	{Country: "SI", Name: "VAT", Rate: 22, Inclusive: true},
+	{Country: "SK", Name: "VAT", Rate: 20, Inclusive: true},
+	{Country: "CH", Name: "VAT", Rate: 7.7, Inclusive: true},
+	{Country: "IS", Name: "VAT", Rate: 24, Inclusive: true},
+	{Country: "NO", Name: "VAT", Rate: 25, Inclusive: true},
+	{Country: "RU", Name: "VAT", Rate: 20, Inclusive: true},
+	{Country: "TR", Name: "VAT", Rate: 18, Inclusive: true},
+	{Country: "IL", Name: "VAT", Rate: 17, Inclusive: true},
+	{Country: "ZA", Name: "VAT", Rate: 15, Inclusive: true},
+	{Country: "AU", Name: "GST", Rate: 10, Inclusive: true},
+	{Country: "NZ", Name: "GST", Rate: 15, Inclusive: true},
+	{Country: "SG", Name: "GST", Rate: 7, Inclusive: true},
+	{Country: "IN", Name: "GST", Rate: 18, Inclusive: true},
+	{Country: "JP", Name: "Consumption tax", Rate: 10, Inclusive: true},
+	{Country: "KR", Name: "VAT", Rate: 10, Inclusive: true},
+	{Country: "MX", Name: "VAT", Rate: 16, Inclusive: true},
+	{Country: "MY", Name: "Service tax", Rate: 6, Inclusive: true},
+	{Country: "TH", Name: "VAT", Rate: 7, Inclusive: true},
+	{Country: "CA", Name: "GST", Rate: 5, Inclusive: false},
+	{Country: "US", Name: "Sales tax", Rate: 0, Inclusive: false},
+}
@@ -0,0 +1,81 @@
+package orm
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"net/http"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/utils"
+	"time"
+)
+
+type taxService struct {
+	db *gorm.DB
+}
+
+func NewTaxService(db *Database) model.TaxService {
+	return &taxService{db: db.database}
+}
+
+// GetRates returns stored tax rates, storage is seeded with bundled table on database init
+func (s *taxService) GetRates() ([]model.TaxRate, error) {
+	rates := []model.TaxRate{}
+	if err := s.db.Order("country").Find(&rates).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch tax rates"))
+	}
+
+	return rates, nil
+}
+
+func (s *taxService) UpdateRate(rate *model.TaxRate) error {
+	if !utils.ValidateCountryList([]string{rate.Country}) {
+		return NewServiceErrorf(http.StatusUnprocessableEntity, "Wrong country %s", rate.Country)
+	}
+	if rate.Rate < 0 || rate.Rate >= 100 {
+		return NewServiceError(http.StatusUnprocessableEntity, "Tax rate must be between 0 and 100")
+	}
+	if rate.Name == "" {
+		return NewServiceError(http.StatusUnprocessableEntity, "Tax name is required")
+	}
+
+	rate.UpdatedAt = time.Now()
+	if err := s.db.Save(rate).Error; err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Save tax rate"))
+	}
+
+	return nil
+}
+
+func (s *taxService) ApplyTax(price *model.ResolvedPrice, country string) error {
+	if !utils.ValidateCountryList([]string{country}) {
+		return NewServiceErrorf(http.StatusUnprocessableEntity, "Wrong country %s", country)
+	}
+
+	rates, err := s.GetRates()
+	if err != nil {
+		return err
+	}
+
+	var countryRate *model.TaxRate
+	for i := range rates {
+		if rates[i].Country == country {
+			countryRate = &rates[i]
+			break
+		}
+	}
+
+	apply := func(p *model.ResolvedPrice) {
+		rate := countryRate
+		if rate == nil {
+			rate = &model.TaxRate{Country: country, Name: "VAT", Rate: float64(p.Vat), Inclusive: true}
+		}
+		p.Tax = rate.Calculate(p.FinalPrice)
+	}
+
+	apply(price)
+	for i := range price.Packages {
+		apply(&price.Packages[i])
+	}
+
+	return nil
+}
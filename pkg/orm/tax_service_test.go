@@ -0,0 +1,112 @@
+package orm_test
+
+import (
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"qilin-api/pkg/test"
+	"testing"
+)
+
+type taxServiceTestSuite struct {
+	suite.Suite
+	db      *orm.Database
+	service model.TaxService
+}
+
+func Test_TaxService(t *testing.T) {
+	suite.Run(t, new(taxServiceTestSuite))
+}
+
+func (suite *taxServiceTestSuite) SetupTest() {
+	config, err := qilin_test.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+
+	db, err := orm.NewDatabase(&config.Database)
+	if err != nil {
+		suite.Fail("Unable to connect to database:", "%v", err)
+	}
+
+	if err := db.DropAllTables(); err != nil {
+		assert.FailNow(suite.T(), "Unable to drop tables", err)
+	}
+	if err := db.Init(); err != nil {
+		assert.FailNow(suite.T(), "Unable to init tables", err)
+	}
+
+	suite.db = db
+	suite.service = orm.NewTaxService(db)
+}
+
+func (suite *taxServiceTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *taxServiceTestSuite) TestRates() {
+	should := require.New(suite.T())
+
+	rates, err := suite.service.GetRates()
+	should.Nil(err)
+	should.NotEmpty(rates)
+
+	should.Nil(suite.service.UpdateRate(&model.TaxRate{Country: "DE", Name: "VAT", Rate: 16, Inclusive: true}))
+	should.Nil(suite.service.UpdateRate(&model.TaxRate{Country: "AQ", Name: "VAT", Rate: 0}))
+
+	updated, err := suite.service.GetRates()
+	should.Nil(err)
+	should.Len(updated, len(rates)+1)
+	for _, rate := range updated {
+		switch rate.Country {
+		case "DE":
+			should.Equal(16.0, rate.Rate)
+		case "US", "CA":
+			should.False(rate.Inclusive)
+		}
+	}
+
+	err = suite.service.UpdateRate(&model.TaxRate{Country: "XX1", Name: "VAT", Rate: 10})
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
+	err = suite.service.UpdateRate(&model.TaxRate{Country: "DE", Name: "VAT", Rate: 100})
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+}
+
+func (suite *taxServiceTestSuite) TestApplyTax() {
+	should := require.New(suite.T())
+
+	price := &model.ResolvedPrice{ID: uuid.NewV4(), Currency: "EUR", Price: 20, FinalPrice: 11.9}
+	should.Nil(suite.service.ApplyTax(price, "DE"))
+	should.NotNil(price.Tax)
+	should.True(price.Tax.Inclusive)
+	should.Equal(float32(10), price.Tax.Net)
+	should.Equal(float32(11.9), price.Tax.Gross)
+
+	price = &model.ResolvedPrice{ID: uuid.NewV4(), Currency: "USD", FinalPrice: 10}
+	should.Nil(suite.service.ApplyTax(price, "CA"))
+	should.False(price.Tax.Inclusive)
+	should.Equal(float32(10.5), price.Tax.Gross)
+
+	// VAT of package price is used for country without tax rate
+	price = &model.ResolvedPrice{ID: uuid.NewV4(), Currency: "USD", FinalPrice: 12, Vat: 20}
+	should.Nil(suite.service.ApplyTax(price, "AQ"))
+	should.Equal(20.0, price.Tax.Rate)
+	should.Equal(float32(10), price.Tax.Net)
+
+	err := suite.service.ApplyTax(price, "ZZZ")
+	should.NotNil(err)
+	should.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+}
@@ -0,0 +1,42 @@
+package orm
+
+import (
+	"database/sql"
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"net/http"
+)
+
+// isTransaction checks whether db is bound to transaction
+func isTransaction(db *gorm.DB) bool {
+	_, ok := db.CommonDB().(*sql.Tx)
+	return ok
+}
+
+// inTransaction runs fn in transaction. If db is a transaction already fn joins it and the owner of transaction
+// commits or rolls it back, otherwise new transaction is started and committed when fn succeeds.
+func inTransaction(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	if isTransaction(db) {
+		return fn(db)
+	}
+
+	transaction := db.Begin()
+	if transaction.Error != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(transaction.Error, "Begin transaction"))
+	}
+	if err := fn(transaction); err != nil {
+		transaction.Rollback()
+		return err
+	}
+	if err := transaction.Commit().Error; err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Commit transaction"))
+	}
+	return nil
+}
+
+// isUniqueViolation checks whether statement failed on unique index
+func isUniqueViolation(err error) bool {
+	pqErr, ok := errors.Cause(err).(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
@@ -0,0 +1,220 @@
+package orm
+
+import (
+	"github.com/ProtocolONE/rbac"
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm/utils"
+	"sync"
+	"time"
+)
+
+type vendorRoleService struct {
+	db       *gorm.DB
+	enforcer *rbac.Enforcer
+
+	mutex sync.Mutex
+	// policies are registered in enforcer by the service, they are tracked to remove policies of roles
+	// changed by other instances
+	policies map[rbac.Policy]bool
+}
+
+func NewVendorRoleService(db *Database, enforcer *rbac.Enforcer) model.VendorRoleService {
+	return &vendorRoleService{db: db.database, enforcer: enforcer, policies: map[rbac.Policy]bool{}}
+}
+
+func (s *vendorRoleService) Init() error {
+	return s.Sync()
+}
+
+func (s *vendorRoleService) Sync() error {
+	roles := []model.VendorRole{}
+	if err := s.db.Find(&roles).Error; err != nil {
+		return errors.Wrap(err, "Fetch vendor roles")
+	}
+
+	policies := []rbac.Policy{}
+	for i := range roles {
+		policies = append(policies, roles[i].Policies()...)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := map[rbac.Policy]bool{}
+	for _, policy := range policies {
+		stored[policy] = true
+	}
+	removed := []rbac.Policy{}
+	for policy := range s.policies {
+		if !stored[policy] {
+			removed = append(removed, policy)
+		}
+	}
+	s.replacePolicies(removed, policies)
+
+	return nil
+}
+
+func (s *vendorRoleService) GetList(vendorId uuid.UUID) ([]model.VendorRole, error) {
+	if err := s.checkVendor(vendorId); err != nil {
+		return nil, err
+	}
+
+	roles := []model.VendorRole{}
+	if err := s.db.Where("vendor_id = ?", vendorId).Order("name").Find(&roles).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch vendor roles"))
+	}
+
+	return roles, nil
+}
+
+func (s *vendorRoleService) Get(vendorId uuid.UUID, roleId uuid.UUID) (*model.VendorRole, error) {
+	role := &model.VendorRole{}
+	err := s.db.Where("id = ? and vendor_id = ?", roleId, vendorId).First(role).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, NewServiceErrorf(http.StatusNotFound, "Role `%s` not found", roleId)
+	} else if err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Fetch vendor role"))
+	}
+
+	return role, nil
+}
+
+func (s *vendorRoleService) Create(vendorId uuid.UUID, name string, permissions model.RolePermissions) (*model.VendorRole, error) {
+	if err := s.checkVendor(vendorId); err != nil {
+		return nil, err
+	}
+
+	role, err := model.NewVendorRole(vendorId, name, permissions)
+	if err != nil {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, err)
+	}
+
+	if err := s.checkName(role); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(role).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Create vendor role"))
+	}
+
+	s.mutex.Lock()
+	s.replacePolicies(nil, role.Policies())
+	s.mutex.Unlock()
+
+	return role, nil
+}
+
+func (s *vendorRoleService) Update(vendorId uuid.UUID, roleId uuid.UUID, name string, permissions model.RolePermissions) (*model.VendorRole, error) {
+	role, err := s.Get(vendorId, roleId)
+	if err != nil {
+		return nil, err
+	}
+	oldPolicies := role.Policies()
+
+	if err := role.Change(name, permissions); err != nil {
+		return nil, NewServiceError(http.StatusUnprocessableEntity, err)
+	}
+
+	if err := s.checkName(role); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Save(role).Error; err != nil {
+		return nil, NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Update vendor role"))
+	}
+
+	s.mutex.Lock()
+	s.replacePolicies(oldPolicies, role.Policies())
+	s.mutex.Unlock()
+
+	return role, nil
+}
+
+func (s *vendorRoleService) Delete(vendorId uuid.UUID, roleId uuid.UUID) error {
+	role, err := s.Get(vendorId, roleId)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.Delete(role).Error; err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Delete vendor role"))
+	}
+
+	// Role name is unique for vendor, so all its users are members of the vendor
+	for _, userId := range s.enforcer.GetUsersForRole(role.RbacName(), model.VendorDomain) {
+		for _, restriction := range s.enforcer.GetUserRestrictions(userId) {
+			if restriction.Role == role.RbacName() {
+				s.enforcer.RemoveRestrictionFromUser(userId, restriction)
+			}
+		}
+		s.enforcer.RemoveRole(rbac.Role{User: userId, Role: role.RbacName(), Domain: model.VendorDomain})
+	}
+
+	s.mutex.Lock()
+	s.replacePolicies(role.Policies(), nil)
+	s.mutex.Unlock()
+
+	return nil
+}
+
+// replacePolicies removes and adds policies of roles in enforcer, already existing policies are skipped by
+// enforcer. It must be called under mutex.
+func (s *vendorRoleService) replacePolicies(removed []rbac.Policy, added []rbac.Policy) {
+	for _, policy := range removed {
+		s.enforcer.RemovePolicy(policy)
+		delete(s.policies, policy)
+	}
+	for _, policy := range added {
+		s.enforcer.AddPolicy(policy)
+		s.policies[policy] = true
+	}
+}
+
+func (s *vendorRoleService) checkVendor(vendorId uuid.UUID) error {
+	exist, err := utils.CheckExists(s.db, &model.Vendor{}, vendorId)
+	if err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Check vendor exist"))
+	}
+	if !exist {
+		return NewServiceError(http.StatusNotFound, "Vendor not found")
+	}
+	return nil
+}
+
+func (s *vendorRoleService) checkName(role *model.VendorRole) error {
+	count := 0
+	err := s.db.
+		Model(&model.VendorRole{}).
+		Where("vendor_id = ? and name = ? and id <> ?", role.VendorID, role.Name, role.ID).
+		Count(&count).Error
+	if err != nil {
+		return NewServiceError(http.StatusInternalServerError, errors.Wrap(err, "Check role name"))
+	}
+	if count > 0 {
+		return NewServiceErrorf(http.StatusConflict, "Role with name `%s` already exists", role.Name)
+	}
+	return nil
+}
+
+// RunVendorRoleSync periodically reloads policies of vendor roles until stop channel is closed
+func RunVendorRoleSync(service model.VendorRoleService, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := service.Sync(); err != nil {
+				zap.L().Error("Vendor roles sync failed", zap.Error(err))
+			}
+		}
+	}
+}
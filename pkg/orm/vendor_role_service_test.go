@@ -0,0 +1,184 @@
+package orm_test
+
+import (
+	"github.com/ProtocolONE/rbac"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"net/http"
+	"qilin-api/pkg/api/mock"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"qilin-api/pkg/test"
+	"testing"
+)
+
+type vendorRoleServiceTestSuite struct {
+	suite.Suite
+	db          *orm.Database
+	enforcer    *rbac.Enforcer
+	service     model.VendorRoleService
+	memberships model.MembershipService
+	vendorId    uuid.UUID
+	ownerId     string
+	userId      string
+}
+
+func Test_VendorRoleService(t *testing.T) {
+	suite.Run(t, new(vendorRoleServiceTestSuite))
+}
+
+func (suite *vendorRoleServiceTestSuite) SetupTest() {
+	shouldBe := require.New(suite.T())
+
+	config, err := qilin_test.LoadTestConfig()
+	if err != nil {
+		suite.FailNow("Unable to load config", "%v", err)
+	}
+	db, err := orm.NewDatabase(&config.Database)
+	if err != nil {
+		suite.FailNow("Unable to connect to database", "%v", err)
+	}
+
+	shouldBe.Nil(db.DropAllTables())
+	shouldBe.Nil(db.Init())
+
+	suite.db = db
+	suite.enforcer = rbac.NewEnforcer()
+	suite.service = orm.NewVendorRoleService(db, suite.enforcer)
+	suite.memberships = orm.NewMembershipService(db, orm.NewOwnerProvider(db), suite.enforcer, mock.NewMailer(), "")
+	shouldBe.Nil(suite.memberships.Init())
+
+	suite.ownerId = uuid.NewV4().String()
+	suite.userId = uuid.NewV4().String()
+	shouldBe.Nil(db.DB().Create(&model.User{Email: "owner@example.com", ID: suite.ownerId, FullName: "Owner Test", Login: "owner", Password: "test"}).Error)
+	shouldBe.Nil(db.DB().Create(&model.User{Email: "editor@example.com", ID: suite.userId, FullName: "Editor Test", Login: "editor", Password: "test"}).Error)
+
+	suite.vendorId = uuid.NewV4()
+	shouldBe.Nil(db.DB().Create(&model.Vendor{Name: "Test Vendor", ID: suite.vendorId, Email: "vendor@example.com", Domain3: "somedomain", HowManyProducts: "0", ManagerID: suite.ownerId}).Error)
+}
+
+func (suite *vendorRoleServiceTestSuite) TearDownTest() {
+	if err := suite.db.DropAllTables(); err != nil {
+		panic(err)
+	}
+	if err := suite.db.Close(); err != nil {
+		panic(err)
+	}
+}
+
+func (suite *vendorRoleServiceTestSuite) canWritePackages(userId string) bool {
+	return suite.enforcer.Enforce(rbac.Context{
+		User:          userId,
+		Domain:        model.VendorDomain,
+		Resource:      model.PackageType,
+		ResourceId:    uuid.NewV4().String(),
+		ResourceOwner: suite.ownerId,
+		Action:        "any",
+	})
+}
+
+func (suite *vendorRoleServiceTestSuite) TestCreateAndAssign() {
+	shouldBe := require.New(suite.T())
+
+	role, err := suite.service.Create(suite.vendorId, "Pricing editor", model.RolePermissions{
+		{Resource: model.RoleResourcePackages, Action: model.RoleActionWrite},
+		{Resource: model.RoleResourceGames, Action: model.RoleActionRead},
+	})
+	shouldBe.Nil(err)
+
+	_, err = suite.service.Create(suite.vendorId, "Pricing editor", model.RolePermissions{{Resource: model.RoleResourceGames, Action: model.RoleActionRead}})
+	shouldBe.NotNil(err)
+	shouldBe.Equal(http.StatusConflict, err.(*orm.ServiceError).Code, "Name must be unique for vendor")
+
+	_, err = suite.service.Create(suite.vendorId, "Broken", model.RolePermissions{{Resource: "taxes", Action: model.RoleActionRead}})
+	shouldBe.NotNil(err)
+	shouldBe.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code)
+
+	_, err = suite.service.Create(uuid.NewV4(), "Pricing editor", model.RolePermissions{{Resource: model.RoleResourceGames, Action: model.RoleActionRead}})
+	shouldBe.NotNil(err)
+	shouldBe.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code)
+
+	shouldBe.Nil(suite.memberships.AddRoleToUserInGame(suite.vendorId, suite.userId, "", role.ID.String()))
+	shouldBe.True(suite.canWritePackages(suite.userId))
+
+	users, err := suite.memberships.GetUsers(suite.vendorId)
+	shouldBe.Nil(err)
+	shouldBe.Len(users, 1, "Member with custom role must be listed")
+	shouldBe.Equal(suite.userId, users[0].ID)
+
+	err = suite.memberships.AddRoleToUserInGame(suite.vendorId, suite.userId, "", uuid.NewV4().String())
+	shouldBe.NotNil(err)
+	shouldBe.Equal(http.StatusUnprocessableEntity, err.(*orm.ServiceError).Code, "Unknown role must not be assigned")
+
+	roles, err := suite.service.GetList(suite.vendorId)
+	shouldBe.Nil(err)
+	shouldBe.Len(roles, 1)
+}
+
+func (suite *vendorRoleServiceTestSuite) TestUpdateAndDelete() {
+	shouldBe := require.New(suite.T())
+
+	role, err := suite.service.Create(suite.vendorId, "Pricing editor", model.RolePermissions{
+		{Resource: model.RoleResourcePackages, Action: model.RoleActionWrite},
+	})
+	shouldBe.Nil(err)
+	shouldBe.Nil(suite.memberships.AddRoleToUserInGame(suite.vendorId, suite.userId, "", role.ID.String()))
+	shouldBe.True(suite.canWritePackages(suite.userId))
+
+	role, err = suite.service.Update(suite.vendorId, role.ID, "Pricing viewer", model.RolePermissions{
+		{Resource: model.RoleResourcePackages, Action: model.RoleActionRead},
+	})
+	shouldBe.Nil(err)
+	shouldBe.Equal("Pricing viewer", role.Name)
+	shouldBe.False(suite.canWritePackages(suite.userId), "Updated permissions must be synced to enforcer")
+
+	role, err = suite.service.Get(suite.vendorId, role.ID)
+	shouldBe.Nil(err)
+	shouldBe.Equal(model.RoleActionRead, role.Permissions[0].Action)
+
+	_, err = suite.service.Get(uuid.NewV4(), role.ID)
+	shouldBe.NotNil(err)
+	shouldBe.Equal(http.StatusNotFound, err.(*orm.ServiceError).Code, "Role of another vendor must not be found")
+
+	shouldBe.Nil(suite.service.Delete(suite.vendorId, role.ID))
+	shouldBe.Empty(suite.enforcer.GetUsersForRole(role.RbacName(), model.VendorDomain), "Role must be unassigned")
+	shouldBe.Empty(suite.enforcer.GetUserRestrictions(suite.userId))
+
+	users, err := suite.memberships.GetUsers(suite.vendorId)
+	shouldBe.Nil(err)
+	shouldBe.Len(users, 0)
+}
+
+func (suite *vendorRoleServiceTestSuite) TestSync() {
+	shouldBe := require.New(suite.T())
+
+	role, err := suite.service.Create(suite.vendorId, "Pricing editor", model.RolePermissions{
+		{Resource: model.RoleResourcePackages, Action: model.RoleActionWrite},
+	})
+	shouldBe.Nil(err)
+	shouldBe.Nil(suite.memberships.AddRoleToUserInGame(suite.vendorId, suite.userId, "", role.ID.String()))
+	shouldBe.True(suite.canWritePackages(suite.userId))
+
+	// another instance of service has own enforcer
+	other := orm.NewVendorRoleService(suite.db, rbac.NewEnforcer())
+	shouldBe.Nil(other.Init())
+
+	_, err = other.Update(suite.vendorId, role.ID, "Pricing viewer", model.RolePermissions{
+		{Resource: model.RoleResourcePackages, Action: model.RoleActionRead},
+	})
+	shouldBe.Nil(err)
+	shouldBe.Nil(suite.service.Sync())
+	shouldBe.False(suite.canWritePackages(suite.userId), "Role updated by another instance must be synced")
+
+	_, err = other.Update(suite.vendorId, role.ID, "Pricing editor", model.RolePermissions{
+		{Resource: model.RoleResourcePackages, Action: model.RoleActionWrite},
+	})
+	shouldBe.Nil(err)
+	shouldBe.Nil(suite.service.Sync())
+	shouldBe.True(suite.canWritePackages(suite.userId))
+
+	shouldBe.Nil(other.Delete(suite.vendorId, role.ID))
+	shouldBe.Nil(suite.service.Sync())
+	shouldBe.False(suite.canWritePackages(suite.userId), "Role deleted by another instance must be synced")
+}
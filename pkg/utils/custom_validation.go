@@ -73,6 +73,11 @@ func RegisterCustomValidations(v *validator.Validate) error {
 
 func checkNonAdminRole(fl validator.FieldLevel) bool {
 	role := fl.Field().String()
+	// Custom roles are referenced by id, membership service checks that role belongs to vendor
+	if model.IsVendorRole(role) {
+		return true
+	}
+
 	for _, r := range acceptableRoles {
 		if r == role {
 			return true
@@ -96,3 +101,12 @@ func IsCurrency(cur string) bool {
 	}
 	return false
 }
+
+//GetCurrencies returns list of available currencies
+func GetCurrencies() []string {
+	result := make([]string, 0, len(currencyList))
+	for _, c := range currencyList {
+		result = append(result, string(c))
+	}
+	return result
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"github.com/pkg/errors"
+	"github.com/satori/go.uuid"
+	"go.uber.org/zap"
+	"os"
+	"os/signal"
+	"qilin-api/pkg/model"
+	"qilin-api/pkg/orm"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// resyncCreator is creator of resyncs started from command line
+const resyncCreator = "cli"
+
+// runResync publishes catalog to event bus from command line. Events are saved into outbox and delivered by
+// dispatcher of running server. Interrupted resync is cancelled and may be continued with `-resume` flag.
+func runResync(db *orm.Database, args []string) error {
+	flags := flag.NewFlagSet("resync", flag.ContinueOnError)
+	entities := flags.String("entities", model.ResyncGames, "Comma separated list of entities: games, packages, bundles")
+	vendor := flags.String("vendor", "", "Publish entities of vendor only")
+	since := flags.String("since", "", "Publish entities updated since RFC3339 time only")
+	rate := flags.Int("rate", model.DefaultResyncRate, "Number of entities published per second")
+	resume := flags.String("resume", "", "Id of failed or cancelled resync to continue")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	service := orm.NewCatalogResyncService(db)
+
+	var resync *model.CatalogResync
+	if *resume != "" {
+		id, err := uuid.FromString(*resume)
+		if err != nil {
+			return errors.Wrap(err, "Bad resync id")
+		}
+		if resync, err = service.Resume(id); err != nil {
+			return err
+		}
+	} else {
+		filter := model.ResyncFilter{Entities: strings.Split(*entities, ","), Rate: *rate}
+		if *vendor != "" {
+			vendorId, err := uuid.FromString(*vendor)
+			if err != nil {
+				return errors.Wrap(err, "Bad vendor id")
+			}
+			filter.VendorID = &vendorId
+		}
+		if *since != "" {
+			updatedSince, err := time.Parse(time.RFC3339, *since)
+			if err != nil {
+				return errors.Wrap(err, "Bad since time")
+			}
+			filter.UpdatedSince = &updatedSince
+		}
+
+		var err error
+		if resync, err = service.Create(resyncCreator, filter); err != nil {
+			return err
+		}
+	}
+
+	zap.L().Info("Catalog resync started", zap.String("resync", resync.ID.String()), zap.Strings("entities", resync.Entities))
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	if err := service.Run(resync.ID, stop); err != nil {
+		return err
+	}
+
+	resync, err := service.Get(resync.ID)
+	if err != nil {
+		return err
+	}
+	zap.L().Info(
+		"Catalog resync finished",
+		zap.String("resync", resync.ID.String()),
+		zap.String("status", resync.Status.ToString()),
+		zap.Int("published", resync.Published),
+	)
+
+	return nil
+}